@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/onas/ecommerce-api/config"
@@ -17,6 +19,7 @@ import (
 	"github.com/onas/ecommerce-api/internal/api/inventory"
 	"github.com/onas/ecommerce-api/internal/api/locations"
 	"github.com/onas/ecommerce-api/internal/api/orders"
+	"github.com/onas/ecommerce-api/internal/api/pricelists"
 	"github.com/onas/ecommerce-api/internal/api/products"
 	"github.com/onas/ecommerce-api/internal/api/sections"
 	"github.com/onas/ecommerce-api/internal/api/stats"
@@ -153,23 +156,67 @@ func main() {
 		storefronts.RegisterRoutes(api, sfController)
 
 		// Inventory module (Phase 2)
-		invRepo := inventory.NewRepository(db)
+		availability := inventory.NewAvailabilityProjection(
+			services.NewCache(cfg.Cache),
+			time.Duration(cfg.Cache.AvailabilityTTLSec)*time.Second,
+		)
+		invRepo := inventory.NewRepository(db).WithAvailability(availability)
 		invService := inventory.NewService(db, invRepo)
 		invController := inventory.NewController(invService)
 		inventory.RegisterRoutes(api, invController)
 
+		// Low-stock notifications
+		if cfg.Jobs.LowStockCheckMinutes > 0 {
+			lowStockJob := inventory.NewLowStockJob(
+				invService,
+				services.NewNotifier(cfg.Notifications),
+				time.Duration(cfg.Jobs.LowStockCheckMinutes)*time.Minute,
+				inventory.ReorderParams{LookbackDays: cfg.Jobs.ReorderLookbackDays, CoverDays: cfg.Jobs.ReorderCoverDays},
+			)
+			lowStockJob.Start(context.Background())
+		}
+
+		// Stock hold expiry
+		if cfg.Jobs.HoldExpirySeconds > 0 {
+			inventory.NewHoldExpiryJob(invService, time.Duration(cfg.Jobs.HoldExpirySeconds)*time.Second).Start(context.Background())
+		}
+
 		// Products Phase 2 (V2)
-		productV2Repo := products.NewV2Repository(db)
-		productV2Service := products.NewServiceV2(db, productV2Repo, invRepo)
+		productV2Repo := products.NewV2Repository(db, availability)
+		productV2Service := products.NewServiceV2(db, productV2Repo, invRepo, fileService)
 		productV2Controller := products.NewControllerV2(productV2Service)
 		products.RegisterV2Routes(api, productV2Controller)
 
+		// Best-seller and new-arrival flags
+		if cfg.Jobs.MerchandisingMinutes > 0 {
+			products.NewMerchandisingJob(
+				productV2Service,
+				time.Duration(cfg.Jobs.MerchandisingMinutes)*time.Minute,
+				products.MerchandisingParams{
+					BestSellerWindowDays: cfg.Jobs.BestSellerWindowDays,
+					BestSellerCount:      cfg.Jobs.BestSellerCount,
+					NewArrivalDays:       cfg.Jobs.NewArrivalDays,
+				},
+			).Start(context.Background())
+		}
+
 		// Customers module
 		customerRepo := customers.NewRepository(db)
 		customerService := customers.NewService(customerRepo)
 		customerController := customers.NewController(customerService)
 		customers.RegisterRoutes(api, customerController)
 
+		// Price lists module
+		priceListRepo := pricelists.NewRepository(db)
+		priceListService := pricelists.NewService(db, priceListRepo).WithRevisions(products.RecordPriceScheduleRevision)
+		priceListController := pricelists.NewController(priceListService)
+		pricelists.RegisterRoutes(api, priceListController)
+
+		// Scheduled price changes
+		if cfg.Jobs.PriceScheduleSeconds > 0 {
+			pricelists.NewPriceScheduleJob(priceListService, time.Duration(cfg.Jobs.PriceScheduleSeconds)*time.Second).Start(context.Background())
+		}
+
 		// Location module
 		locationRepo := locations.NewRepository(db)
 		locationService := locations.NewService(locationRepo)
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/onas/ecommerce-api/config"
+	"github.com/onas/ecommerce-api/internal/database"
+	"github.com/onas/ecommerce-api/internal/services"
+)
+
+// Regenerates the thumb, medium, large and WebP derivatives of stored images:
+//
+//	go run ./cmd/images            every image
+//	go run ./cmd/images -missing   images without derivatives
+//	go run ./cmd/images -file 42   one file
+func main() {
+	fileID := flag.Int64("file", 0, "regenerate the derivatives of one file")
+	missingOnly := flag.Bool("missing", false, "only images without derivatives")
+	flag.Parse()
+
+	config.LoadConfig()
+
+	db := database.GetDB()
+	fileService := services.NewFileService(db)
+
+	if *fileID > 0 {
+		file, err := fileService.GetFile(*fileID)
+		if err != nil {
+			log.Fatalf("File %d not found: %v", *fileID, err)
+		}
+		derivatives, err := fileService.GenerateDerivatives(file)
+		if err != nil {
+			log.Fatalf("Failed to generate derivatives of file %d: %v", *fileID, err)
+		}
+		log.Printf("Generated %d derivative(s) of file %d", len(derivatives), *fileID)
+		return
+	}
+
+	log.Println("Regenerating image derivatives...")
+	processed, err := fileService.RegenerateDerivatives(*missingOnly)
+	if err != nil {
+		log.Fatalf("Failed to regenerate image derivatives: %v", err)
+	}
+	log.Printf("Regenerated the derivatives of %d image(s)", processed)
+}
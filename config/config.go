@@ -14,6 +14,10 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Pagination PaginationConfig
+	Notifications NotificationConfig
+	Jobs     JobsConfig
+	Cache    CacheConfig
+	Images   ImageConfig
 }
 
 type ServerConfig struct {
@@ -42,6 +46,44 @@ type PaginationConfig struct {
 	MaxPageSize     int
 }
 
+type NotificationConfig struct {
+	Channel    string // log, email or webhook
+	WebhookURL string
+	SMTPHost   string
+	SMTPPort   string
+	SMTPUser   string
+	SMTPPass   string
+	MailFrom   string
+}
+
+type JobsConfig struct {
+	LowStockCheckMinutes int // 0 disables the job, enable it on one instance only
+	ReorderLookbackDays  int
+	ReorderCoverDays     int
+	HoldExpirySeconds    int // 0 disables the job
+	PriceScheduleSeconds int // 0 disables the job
+	MerchandisingMinutes int // 0 disables the job, it overwrites flags admins set by hand unless pinned
+	BestSellerWindowDays int
+	BestSellerCount      int // Best sellers per store
+	NewArrivalDays       int
+}
+
+type CacheConfig struct {
+	Driver             string // memory or redis
+	RedisAddr          string
+	RedisPassword      string
+	RedisDB            int
+	AvailabilityTTLSec int
+}
+
+type ImageConfig struct {
+	JPEGQuality       int
+	WebPEncoder       string // cwebp binary, WebP derivatives are skipped when it is not found
+	WebPQuality       int
+	DerivativeWorkers int // Images scaled at once in the background
+	MaxPixels         int // Larger images get no derivatives, decoding them would exhaust memory
+}
+
 var AppConfig *Config
 
 // LoadConfig loads configuration from environment variables
@@ -74,6 +116,40 @@ func LoadConfig() *Config {
 			DefaultPageSize: getEnvAsInt("DEFAULT_PAGE_SIZE", 10),
 			MaxPageSize:     getEnvAsInt("MAX_PAGE_SIZE", 100),
 		},
+		Notifications: NotificationConfig{
+			Channel:    getEnv("NOTIFY_CHANNEL", "log"),
+			WebhookURL: getEnv("NOTIFY_WEBHOOK_URL", ""),
+			SMTPHost:   getEnv("SMTP_HOST", ""),
+			SMTPPort:   getEnv("SMTP_PORT", "587"),
+			SMTPUser:   getEnv("SMTP_USER", ""),
+			SMTPPass:   getEnv("SMTP_PASSWORD", ""),
+			MailFrom:   getEnv("MAIL_FROM", ""),
+		},
+		Jobs: JobsConfig{
+			LowStockCheckMinutes: getEnvAsInt("LOW_STOCK_CHECK_MINUTES", 0),
+			ReorderLookbackDays:  getEnvAsInt("REORDER_LOOKBACK_DAYS", 30),
+			ReorderCoverDays:     getEnvAsInt("REORDER_COVER_DAYS", 30),
+			HoldExpirySeconds:    getEnvAsInt("HOLD_EXPIRY_CHECK_SECONDS", 60),
+			PriceScheduleSeconds: getEnvAsInt("PRICE_SCHEDULE_CHECK_SECONDS", 60),
+			MerchandisingMinutes: getEnvAsInt("MERCHANDISING_FLAGS_INTERVAL_MINUTES", 0),
+			BestSellerWindowDays: getEnvAsInt("BEST_SELLER_WINDOW_DAYS", 30),
+			BestSellerCount:      getEnvAsInt("BEST_SELLER_COUNT", 20),
+			NewArrivalDays:       getEnvAsInt("NEW_ARRIVAL_DAYS", 30),
+		},
+		Cache: CacheConfig{
+			Driver:             getEnv("CACHE_DRIVER", "memory"),
+			RedisAddr:          getEnv("REDIS_ADDR", "localhost:6379"),
+			RedisPassword:      getEnv("REDIS_PASSWORD", ""),
+			RedisDB:            getEnvAsInt("REDIS_DB", 0),
+			AvailabilityTTLSec: getEnvAsInt("AVAILABILITY_CACHE_TTL_SECONDS", 300),
+		},
+		Images: ImageConfig{
+			JPEGQuality:       getEnvAsInt("IMAGE_JPEG_QUALITY", 82),
+			WebPEncoder:       getEnv("IMAGE_WEBP_ENCODER", "cwebp"),
+			WebPQuality:       getEnvAsInt("IMAGE_WEBP_QUALITY", 80),
+			DerivativeWorkers: getEnvAsInt("IMAGE_DERIVATIVE_WORKERS", 2),
+			MaxPixels:         getEnvAsInt("IMAGE_MAX_PIXELS", 40000000),
+		},
 	}
 
 	return AppConfig
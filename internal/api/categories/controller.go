@@ -113,3 +113,15 @@ func (c *Controller) ListCategoriesForDropdown(ctx *gin.Context) {
 	res := c.service.ListCategoriesForDropdown()
 	utils.WriteResource(ctx, res)
 }
+
+// StorefrontNavigation returns the section and category tree of a resolved store
+func (c *Controller) StorefrontNavigation(ctx *gin.Context) {
+	sfID, exists := ctx.Get("store_front_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 400, "Store not resolved", nil)
+		return
+	}
+
+	res := c.service.GetNavigation(sfID.(int64), utils.GetRequestLang(ctx))
+	utils.WriteResource(ctx, res)
+}
@@ -7,14 +7,27 @@ import (
 )
 
 type CategoryDTO struct {
-	ID        int64     `json:"id"`
-	SectionID int64     `json:"section_id"`
-	NameAr    string    `json:"name_ar"`
-	NameEn    string    `json:"name_en"`
-	IconPath  string    `json:"icon_path"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                int64     `json:"id"`
+	SectionID         int64     `json:"section_id"`
+	ParentID          *int64    `json:"parent_id"`
+	Position          int       `json:"position"`
+	NameAr            string    `json:"name_ar"`
+	NameEn            string    `json:"name_en"`
+	SlugEn            string    `json:"slug_en"`
+	SlugAr            string    `json:"slug_ar"`
+	MetaTitleEn       string    `json:"meta_title_en"`
+	MetaTitleAr       string    `json:"meta_title_ar"`
+	MetaDescriptionEn string    `json:"meta_description_en"`
+	MetaDescriptionAr string    `json:"meta_description_ar"`
+	IconPath          string    `json:"icon_path"`
+	StoreFrontIDs     []int64   `json:"store_front_ids" gorm:"-"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 func (c *CategoryDTO) GetName() string {
 	return utils.GetLocalizedStringFromContext(c.NameAr, c.NameEn)
-}
\ No newline at end of file
+}
+
+func (c *CategoryDTO) GetSlug() string {
+	return utils.GetLocalizedStringFromContext(c.SlugAr, c.SlugEn)
+}
@@ -0,0 +1,22 @@
+package dtos
+
+// NavigationSectionDTO is a section shown in a store's navigation
+type NavigationSectionDTO struct {
+	ID       int64
+	NameAr   string
+	NameEn   string
+	IconPath string
+}
+
+// NavigationCategoryDTO is a category shown in a store's navigation
+type NavigationCategoryDTO struct {
+	ID        int64
+	SectionID int64
+	ParentID  *int64
+	Position  int
+	NameAr    string
+	NameEn    string
+	SlugAr    string
+	SlugEn    string
+	IconPath  string
+}
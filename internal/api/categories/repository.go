@@ -105,9 +105,84 @@ func (r *Repository) GetCategoryByID(db *gorm.DB, id int64) (*dtos.CategoryDTO,
 	if err != nil {
 		return nil, err
 	}
+
+	category.StoreFrontIDs, err = r.GetStoreFrontIDs(db, id)
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *Repository) GetCategoryModel(db *gorm.DB, id int64) (*models.Category, error) {
+	var category models.Category
+	if err := db.Where("id = ? AND deleted_at IS NULL", id).First(&category).Error; err != nil {
+		return nil, err
+	}
 	return &category, nil
 }
 
+// GetDescendantIDs returns the ids of every category nested under a category, at any depth
+func (r *Repository) GetDescendantIDs(db *gorm.DB, id int64) ([]int64, error) {
+	ids := []int64{}
+	err := db.Raw(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE parent_id = ? AND deleted_at IS NULL
+			UNION
+			SELECT c.id FROM categories c JOIN subtree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM subtree`, id).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MoveToSection moves categories to a section, keeping a subtree within one section
+func (r *Repository) MoveToSection(db *gorm.DB, ids []int64, sectionID int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.Model(&models.Category{}).Where("id IN ?", ids).Update("section_id", sectionID).Error
+}
+
+// IsSlugTaken reports whether a slug is used in either language by another category
+func (r *Repository) IsSlugTaken(db *gorm.DB, slug string, excludeID int64) (bool, error) {
+	var count int64
+	err := db.Model(&models.Category{}).
+		Where("(slug_en = ? OR slug_ar = ?) AND id <> ? AND deleted_at IS NULL", slug, slug, excludeID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *Repository) GetStoreFrontIDs(db *gorm.DB, id int64) ([]int64, error) {
+	ids := []int64{}
+	err := db.Model(&models.CategoryStorefront{}).
+		Where("category_id = ?", id).
+		Order("store_front_id").
+		Pluck("store_front_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ReplaceStoreFronts sets the stores a category is shown in
+func (r *Repository) ReplaceStoreFronts(db *gorm.DB, id int64, storeFrontIDs []int64) error {
+	if err := db.Where("category_id = ?", id).Delete(&models.CategoryStorefront{}).Error; err != nil {
+		return err
+	}
+	for _, sfID := range storeFrontIDs {
+		if err := db.Create(&models.CategoryStorefront{CategoryID: id, StoreFrontID: sfID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *Repository) Create(db *gorm.DB, category *models.Category) error {
 	return db.Create(category).Error
 }
@@ -116,6 +191,13 @@ func (r *Repository) Update(db *gorm.DB, category *models.Category) error {
 	return db.Save(category).Error
 }
 
+// CountChildren counts the categories directly under a category
+func (r *Repository) CountChildren(db *gorm.DB, id int64) (int64, error) {
+	var count int64
+	err := db.Model(&models.Category{}).Where("parent_id = ? AND deleted_at IS NULL", id).Count(&count).Error
+	return count, err
+}
+
 func (r *Repository) Delete(db *gorm.DB, id int64) error {
 	return db.Exec("UPDATE categories SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id).Error
 }
@@ -145,3 +227,37 @@ func (r *Repository) ListCategoriesForDropdown(db *gorm.DB) ([]utils.DropdownIte
 	}
 	return items, nil
 }
+
+// ListNavigation returns the sections and categories shown in a store, categories
+// ordered by position within their parent
+func (r *Repository) ListNavigation(db *gorm.DB, storeFrontID int64) ([]dtos.NavigationSectionDTO, []dtos.NavigationCategoryDTO, error) {
+	var sections []dtos.NavigationSectionDTO
+	err := db.Table("sections").
+		Joins("JOIN section_storefront ss ON ss.section_id = sections.id AND ss.store_front_id = ?", storeFrontID).
+		Joins("LEFT JOIN files AS icon ON icon.id = sections.icon_id").
+		Select("sections.id, sections.name_ar, sections.name_en, icon.file_path AS icon_path").
+		Where("sections.deleted_at IS NULL").
+		Order("sections.id").
+		Scan(&sections).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var categories []dtos.NavigationCategoryDTO
+	err = db.Table("categories").
+		Joins("JOIN category_storefront cs ON cs.category_id = categories.id AND cs.store_front_id = ?", storeFrontID).
+		Joins("LEFT JOIN files AS icon ON icon.id = categories.icon_id").
+		Select(`
+		categories.id, categories.section_id, categories.parent_id, categories.position,
+		categories.name_ar, categories.name_en, categories.slug_ar, categories.slug_en,
+		icon.file_path AS icon_path
+	`).
+		Where("categories.deleted_at IS NULL").
+		Order("categories.position, categories.name_en, categories.id").
+		Scan(&categories).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sections, categories, nil
+}
@@ -1,8 +1,17 @@
 package requests
 
 type CreateCategoryRequest struct {
-	SectionID int64  `json:"section_id" binding:"required"`
-	NameAr    string `json:"name_ar" binding:"required"`
-	NameEn    string `json:"name_en" binding:"required"`
-	IconID    int64  `json:"icon_id" binding:"required"`
+	SectionID         int64   `json:"section_id" binding:"required"`
+	ParentID          *int64  `json:"parent_id"`
+	Position          int     `json:"position" binding:"min=0"`
+	NameAr            string  `json:"name_ar" binding:"required"`
+	NameEn            string  `json:"name_en" binding:"required"`
+	SlugEn            string  `json:"slug_en" binding:"omitempty,max=255"`
+	SlugAr            string  `json:"slug_ar" binding:"omitempty,max=255"`
+	MetaTitleEn       string  `json:"meta_title_en" binding:"omitempty,max=255"`
+	MetaTitleAr       string  `json:"meta_title_ar" binding:"omitempty,max=255"`
+	MetaDescriptionEn string  `json:"meta_description_en"`
+	MetaDescriptionAr string  `json:"meta_description_ar"`
+	IconID            int64   `json:"icon_id" binding:"required"`
+	StoreFrontIDs     []int64 `json:"store_front_ids"` // Stores showing the category, nil keeps them on update
 }
@@ -9,7 +9,10 @@ import (
 type CategoryResponse struct {
 	ID        int64     `json:"id"`
 	SectionID int64     `json:"section_id"`
+	ParentID  *int64    `json:"parent_id"`
+	Position  int       `json:"position"`
 	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
 	IconPath  string    `json:"icon_path"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -18,7 +21,10 @@ func CategoryResponseFromDTO(dto dtos.CategoryDTO) CategoryResponse {
 	return CategoryResponse{
 		ID:        dto.ID,
 		SectionID: dto.SectionID,
+		ParentID:  dto.ParentID,
+		Position:  dto.Position,
 		Name:      dto.GetName(),
+		Slug:      dto.GetSlug(),
 		IconPath:  dto.IconPath,
 		CreatedAt: dto.CreatedAt,
 	}
@@ -0,0 +1,61 @@
+package responses
+
+import (
+	"github.com/onas/ecommerce-api/internal/api/categories/dtos"
+	"github.com/onas/ecommerce-api/internal/utils"
+)
+
+type NavigationSection struct {
+	ID         int64                `json:"id"`
+	Name       string               `json:"name"`
+	IconPath   string               `json:"icon_path"`
+	Categories []NavigationCategory `json:"categories"`
+}
+
+type NavigationCategory struct {
+	ID       int64                `json:"id"`
+	Name     string               `json:"name"`
+	Slug     string               `json:"slug"`
+	IconPath string               `json:"icon_path"`
+	Children []NavigationCategory `json:"children"`
+}
+
+// NavigationFromDTOs builds the section → category tree of a store in a language.
+// Categories come ordered; one whose parent is not shown is left out with its subtree.
+func NavigationFromDTOs(lang string, sections []dtos.NavigationSectionDTO, categories []dtos.NavigationCategoryDTO) []NavigationSection {
+	children := map[int64][]dtos.NavigationCategoryDTO{}
+	roots := map[int64][]dtos.NavigationCategoryDTO{}
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots[c.SectionID] = append(roots[c.SectionID], c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var build func(list []dtos.NavigationCategoryDTO) []NavigationCategory
+	build = func(list []dtos.NavigationCategoryDTO) []NavigationCategory {
+		nodes := make([]NavigationCategory, 0, len(list))
+		for _, c := range list {
+			nodes = append(nodes, NavigationCategory{
+				ID:       c.ID,
+				Name:     utils.SelectLocalizedString(lang, c.NameAr, c.NameEn),
+				Slug:     utils.SelectLocalizedString(lang, c.SlugAr, c.SlugEn),
+				IconPath: c.IconPath,
+				Children: build(children[c.ID]),
+			})
+		}
+		return nodes
+	}
+
+	navigation := make([]NavigationSection, 0, len(sections))
+	for _, s := range sections {
+		navigation = append(navigation, NavigationSection{
+			ID:         s.ID,
+			Name:       utils.SelectLocalizedString(lang, s.NameAr, s.NameEn),
+			IconPath:   s.IconPath,
+			Categories: build(roots[s.ID]),
+		})
+	}
+	return navigation
+}
@@ -0,0 +1,54 @@
+package responses
+
+import (
+	"testing"
+
+	"github.com/onas/ecommerce-api/internal/api/categories/dtos"
+)
+
+func TestNavigationFromDTOs(t *testing.T) {
+	parent := func(id int64) *int64 { return &id }
+	sections := []dtos.NavigationSectionDTO{
+		{ID: 2, NameEn: "Decor", NameAr: "ديكور"},
+		{ID: 1, NameEn: "Furniture", NameAr: "أثاث"},
+	}
+	// Ordered by position, category 5's parent 9 is hidden in this store
+	categories := []dtos.NavigationCategoryDTO{
+		{ID: 3, SectionID: 1, NameEn: "Tables", NameAr: "طاولات", SlugEn: "tables", SlugAr: "طاولات"},
+		{ID: 1, SectionID: 1, NameEn: "Sofas", SlugEn: "sofas"},
+		{ID: 4, SectionID: 1, ParentID: parent(1), NameEn: "Corner sofas", NameAr: "كنب زاوية", SlugEn: "corner-sofas", SlugAr: "كنب-زاوية"},
+		{ID: 2, SectionID: 1, ParentID: parent(1), NameEn: "Sofa beds", SlugEn: "sofa-beds"},
+		{ID: 5, SectionID: 2, ParentID: parent(9), NameEn: "Vases", SlugEn: "vases"},
+		{ID: 6, SectionID: 2, ParentID: parent(5), NameEn: "Glass vases", SlugEn: "glass-vases"},
+	}
+
+	nav := NavigationFromDTOs("ar", sections, categories)
+	if len(nav) != 2 || nav[0].ID != 2 || nav[1].ID != 1 {
+		t.Fatalf("expected sections in the given order, got %+v", nav)
+	}
+	if nav[0].Name != "ديكور" {
+		t.Errorf("expected the Arabic section name, got %q", nav[0].Name)
+	}
+	if len(nav[0].Categories) != 0 {
+		t.Errorf("expected the hidden parent's subtree to be left out, got %+v", nav[0].Categories)
+	}
+
+	furniture := nav[1].Categories
+	if len(furniture) != 2 || furniture[0].ID != 3 || furniture[1].ID != 1 {
+		t.Fatalf("expected roots 3, 1 in order, got %+v", furniture)
+	}
+	if furniture[0].Name != "طاولات" || furniture[0].Slug != "طاولات" {
+		t.Errorf("expected the Arabic name and slug, got %q %q", furniture[0].Name, furniture[0].Slug)
+	}
+	if furniture[1].Name != "Sofas" || furniture[1].Slug != "sofas" {
+		t.Errorf("expected the English fallback without an Arabic name, got %q %q", furniture[1].Name, furniture[1].Slug)
+	}
+	sofas := furniture[1].Children
+	if len(sofas) != 2 || sofas[0].ID != 4 || sofas[1].ID != 2 {
+		t.Fatalf("expected children 4, 2 in order, got %+v", sofas)
+	}
+
+	if en := NavigationFromDTOs("en", sections, categories); en[1].Categories[0].Name != "Tables" {
+		t.Errorf("expected the English name, got %q", en[1].Categories[0].Name)
+	}
+}
@@ -21,4 +21,11 @@ func RegisterRoutes(router *gin.RouterGroup, controller *Controller) {
 
 	// Dropdown endpoint (admin auth, no permission check)
 	adminRoutes.GET("/dropdown", controller.ListCategoriesForDropdown)
+
+	// Storefront public routes (domain-resolved)
+	sfRoutes := router.Group("/storefront")
+	sfRoutes.Use(middleware.StoreFrontResolver())
+	{
+		sfRoutes.GET("/navigation", controller.StorefrontNavigation)
+	}
 }
@@ -1,7 +1,10 @@
 package categories
 
 import (
+	"fmt"
+
 	"github.com/onas/ecommerce-api/internal/api/categories/requests"
+	"github.com/onas/ecommerce-api/internal/api/categories/responses"
 	"github.com/onas/ecommerce-api/internal/models"
 	"github.com/onas/ecommerce-api/internal/utils"
 	"gorm.io/gorm"
@@ -53,14 +56,37 @@ func (s *Service) GetCategoryByID(id int64) utils.IResource {
 }
 
 func (s *Service) CreateCategory(req requests.CreateCategoryRequest) utils.IResource {
+	if res := s.validateParent(req.SectionID, req.ParentID, 0); res != nil {
+		return res
+	}
+
+	slugEn, slugAr, res := s.categorySlugs(req, nil)
+	if res != nil {
+		return res
+	}
+
 	category := &models.Category{
-		SectionID: req.SectionID,
-		NameAr:    req.NameAr,
-		NameEn:    req.NameEn,
-		IconID:    req.IconID,
+		SectionID:         req.SectionID,
+		ParentID:          req.ParentID,
+		Position:          req.Position,
+		NameAr:            req.NameAr,
+		NameEn:            req.NameEn,
+		SlugEn:            slugEn,
+		SlugAr:            slugAr,
+		MetaTitleEn:       req.MetaTitleEn,
+		MetaTitleAr:       req.MetaTitleAr,
+		MetaDescriptionEn: req.MetaDescriptionEn,
+		MetaDescriptionAr: req.MetaDescriptionAr,
+		IconID:            req.IconID,
 	}
 
-	if err := s.repo.Create(s.db, category); err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.Create(tx, category); err != nil {
+			return err
+		}
+		return s.repo.ReplaceStoreFronts(tx, category.ID, uniqueIDs(req.StoreFrontIDs))
+	})
+	if err != nil {
 		return utils.NewInternalErrorResource("failed to create category", err)
 	}
 
@@ -68,32 +94,178 @@ func (s *Service) CreateCategory(req requests.CreateCategoryRequest) utils.IReso
 }
 
 func (s *Service) UpdateCategory(id int64, req requests.CreateCategoryRequest) utils.IResource {
-	category, err := s.repo.GetCategoryByID(s.db, id)
+	category, err := s.repo.GetCategoryModel(s.db, id)
 	if err != nil {
 		return utils.NewInternalErrorResource("category not found", err)
 	}
 
-	updated := &models.Category{
-		ID:        id,
-		SectionID: req.SectionID,
-		NameAr:    req.NameAr,
-		NameEn:    req.NameEn,
-		IconID:    req.IconID,
+	if res := s.validateParent(req.SectionID, req.ParentID, id); res != nil {
+		return res
+	}
+
+	slugEn, slugAr, res := s.categorySlugs(req, category)
+	if res != nil {
+		return res
+	}
+
+	descendantIDs, err := s.repo.GetDescendantIDs(s.db, id)
+	if err != nil {
+		return utils.NewInternalErrorResource("failed to update category", err)
 	}
 
-	if err := s.repo.Update(s.db, updated); err != nil {
+	sectionChanged := category.SectionID != req.SectionID
+	category.SectionID = req.SectionID
+	category.ParentID = req.ParentID
+	category.Position = req.Position
+	category.NameAr = req.NameAr
+	category.NameEn = req.NameEn
+	category.SlugEn = slugEn
+	category.SlugAr = slugAr
+	category.MetaTitleEn = req.MetaTitleEn
+	category.MetaTitleAr = req.MetaTitleAr
+	category.MetaDescriptionEn = req.MetaDescriptionEn
+	category.MetaDescriptionAr = req.MetaDescriptionAr
+	category.IconID = req.IconID
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.Update(tx, category); err != nil {
+			return err
+		}
+		if sectionChanged {
+			if err := s.repo.MoveToSection(tx, descendantIDs, req.SectionID); err != nil {
+				return err
+			}
+		}
+		if req.StoreFrontIDs != nil {
+			return s.repo.ReplaceStoreFronts(tx, id, uniqueIDs(req.StoreFrontIDs))
+		}
+		return nil
+	})
+	if err != nil {
 		return utils.NewInternalErrorResource("failed to update category", err)
 	}
 
 	return utils.NewOKResource("category updated successfully", category)
 }
 
+// validateParent checks that a parent category is in the same section and, for an
+// existing category, is neither the category itself nor nested under it
+func (s *Service) validateParent(sectionID int64, parentID *int64, categoryID int64) utils.IResource {
+	if parentID == nil {
+		return nil
+	}
+
+	parent, err := s.repo.GetCategoryModel(s.db, *parentID)
+	if err != nil {
+		return utils.NewBadRequestResource("parent category not found", nil)
+	}
+	if parent.SectionID != sectionID {
+		return utils.NewBadRequestResource("parent category belongs to another section", nil)
+	}
+	if categoryID == 0 {
+		return nil
+	}
+
+	if parent.ID == categoryID {
+		return utils.NewBadRequestResource("a category cannot be its own parent", nil)
+	}
+	descendantIDs, err := s.repo.GetDescendantIDs(s.db, categoryID)
+	if err != nil {
+		return utils.NewInternalErrorResource("failed to validate parent category", err)
+	}
+	for _, id := range descendantIDs {
+		if id == parent.ID {
+			return utils.NewBadRequestResource("a category cannot be nested under one of its subcategories", nil)
+		}
+	}
+	return nil
+}
+
+// categorySlugs resolves the slugs of a category. Explicit slugs must be free, slugs
+// built from the names get a numeric suffix when taken. An existing category keeps
+// its slugs unless new ones are given.
+func (s *Service) categorySlugs(req requests.CreateCategoryRequest, existing *models.Category) (string, string, utils.IResource) {
+	var excludeID int64
+	var currentEn, currentAr string
+	if existing != nil {
+		excludeID, currentEn, currentAr = existing.ID, existing.SlugEn, existing.SlugAr
+	}
+	taken := func(slug string) (bool, error) {
+		return s.repo.IsSlugTaken(s.db, slug, excludeID)
+	}
+
+	resolve := func(explicit, current, generated string) (string, utils.IResource) {
+		if explicit != "" {
+			if explicit == current {
+				return current, nil
+			}
+			isTaken, err := taken(explicit)
+			if err != nil {
+				return "", utils.NewInternalErrorResource("failed to validate slug", err)
+			}
+			if isTaken {
+				return "", utils.NewBadRequestResource(fmt.Sprintf("slug '%s' already exists", explicit), nil)
+			}
+			return explicit, nil
+		}
+		if current != "" || generated == "" {
+			return current, nil
+		}
+		slug, err := utils.UniqueSlug(generated, taken)
+		if err != nil {
+			return "", utils.NewInternalErrorResource("failed to generate slug", err)
+		}
+		return slug, nil
+	}
+
+	generatedEn := utils.Slugify(req.NameEn)
+	if generatedEn == "" {
+		generatedEn = utils.Slugify(utils.TransliterateArabic(req.NameEn))
+	}
+	slugEn, res := resolve(utils.Slugify(req.SlugEn), currentEn, generatedEn)
+	if res != nil {
+		return "", "", res
+	}
+	if slugEn == "" {
+		return "", "", utils.NewBadRequestResource("could not generate slug from name_en", nil)
+	}
+
+	slugAr, res := resolve(utils.SlugifyArabic(req.SlugAr), currentAr, utils.SlugifyArabic(req.NameAr))
+	if res != nil {
+		return "", "", res
+	}
+	return slugEn, slugAr, nil
+}
+
+// uniqueIDs drops repeated ids, keeping the first occurrence
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// DeleteCategory deletes a category without subcategories, a parent has to be emptied
+// first so no category is left under a deleted one
 func (s *Service) DeleteCategory(id int64) utils.IResource {
 	_, err := s.repo.GetCategoryByID(s.db, id)
 	if err != nil {
 		return utils.NewInternalErrorResource("category not found", err)
 	}
 
+	children, err := s.repo.CountChildren(s.db, id)
+	if err != nil {
+		return utils.NewInternalErrorResource("failed to check subcategories", err)
+	}
+	if children > 0 {
+		return utils.NewBadRequestResource("cannot delete a category that has subcategories, move or delete them first", nil)
+	}
+
 	if err := s.repo.Delete(s.db, id); err != nil {
 		return utils.NewInternalErrorResource("failed to delete category", err)
 	}
@@ -116,3 +288,13 @@ func (s *Service) ListCategoriesForDropdown() utils.IResource {
 	}
 	return utils.NewOKResource("categories fetched successfully", items)
 }
+
+// GetNavigation returns the localized section → category tree of a store
+func (s *Service) GetNavigation(storeFrontID int64, lang string) utils.IResource {
+	sections, categories, err := s.repo.ListNavigation(s.db, storeFrontID)
+	if err != nil {
+		return utils.NewInternalErrorResource("failed to fetch navigation", err)
+	}
+
+	return utils.NewOKResource("Navigation retrieved successfully", responses.NavigationFromDTOs(lang, sections, categories))
+}
@@ -0,0 +1,75 @@
+package categories
+
+import (
+	"testing"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.File{}, &models.Category{}, &models.CategoryStorefront{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	// 1 > 2 > 3 in section 1, 4 alone in section 2
+	parent, child := int64(1), int64(2)
+	db.Create(&[]models.Category{
+		{ID: 1, SectionID: 1, NameEn: "Furniture", SlugEn: "furniture"},
+		{ID: 2, SectionID: 1, ParentID: &parent, NameEn: "Sofas", SlugEn: "sofas"},
+		{ID: 3, SectionID: 1, ParentID: &child, NameEn: "Corner sofas", SlugEn: "corner-sofas"},
+		{ID: 4, SectionID: 2, NameEn: "Lighting", SlugEn: "lighting"},
+	})
+	return db
+}
+
+func TestValidateParent_RejectsCycles(t *testing.T) {
+	service := NewService(NewRepository(), setupTestDB(t))
+	id := func(v int64) *int64 { return &v }
+
+	cases := []struct {
+		name       string
+		categoryID int64
+		parentID   *int64
+		wantStatus int
+	}{
+		{"no parent", 1, nil, 0},
+		{"valid parent", 3, id(1), 0},
+		{"own parent", 1, id(1), 400},
+		{"child as parent", 1, id(2), 400},
+		{"grandchild as parent", 1, id(3), 400},
+		{"other section", 2, id(4), 400},
+		{"missing parent", 2, id(99), 400},
+	}
+	for _, tc := range cases {
+		sectionID := int64(1)
+		res := service.validateParent(sectionID, tc.parentID, tc.categoryID)
+		switch {
+		case tc.wantStatus == 0 && res != nil:
+			t.Errorf("%s: expected no error, got %d: %s", tc.name, res.GetStatusCode(), res.GetMessage())
+		case tc.wantStatus != 0 && (res == nil || res.GetStatusCode() != tc.wantStatus):
+			t.Errorf("%s: expected status %d, got %v", tc.name, tc.wantStatus, res)
+		}
+	}
+}
+
+func TestDeleteCategory_RejectsParents(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(NewRepository(), db)
+
+	if res := service.DeleteCategory(2); res.GetStatusCode() != 400 {
+		t.Fatalf("expected status 400 for a category with subcategories, got %d", res.GetStatusCode())
+	}
+	if res := service.DeleteCategory(3); res.GetStatusCode() != 200 {
+		t.Fatalf("expected status 200 for a leaf category, got %d: %s", res.GetStatusCode(), res.GetMessage())
+	}
+	if res := service.DeleteCategory(2); res.GetStatusCode() != 200 {
+		t.Fatalf("expected status 200 once the subcategory is gone, got %d: %s", res.GetStatusCode(), res.GetMessage())
+	}
+}
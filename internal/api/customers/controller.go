@@ -71,11 +71,12 @@ func (c *Controller) Search(ctx *gin.Context) {
 // Create handles POST /admin/customers
 func (c *Controller) Create(ctx *gin.Context) {
 	var input struct {
-		FirstName    string `json:"first_name" binding:"required"`
-		LastName     string `json:"last_name"`
-		Email        string `json:"email"`
-		Phone        string `json:"phone" binding:"required"`
-		StoreFrontID int64  `json:"store_front_id" binding:"required"`
+		FirstName       string `json:"first_name" binding:"required"`
+		LastName        string `json:"last_name"`
+		Email           string `json:"email"`
+		Phone           string `json:"phone" binding:"required"`
+		StoreFrontID    int64  `json:"store_front_id" binding:"required"`
+		CustomerGroupID *int64 `json:"customer_group_id"` // Optional, selects the group's price lists
 	}
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
@@ -84,11 +85,12 @@ func (c *Controller) Create(ctx *gin.Context) {
 	}
 
 	customer := models.Customer{
-		FirstName:    input.FirstName,
-		LastName:     input.LastName,
-		Email:        input.Email,
-		Phone:        input.Phone,
-		StoreFrontID: input.StoreFrontID,
+		FirstName:       input.FirstName,
+		LastName:        input.LastName,
+		Email:           input.Email,
+		Phone:           input.Phone,
+		StoreFrontID:    input.StoreFrontID,
+		CustomerGroupID: input.CustomerGroupID,
 	}
 
 	if err := c.service.CreateCustomer(&customer); err != nil {
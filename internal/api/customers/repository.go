@@ -105,6 +105,13 @@ func (r *Repository) GetByID(id int64) (*models.Customer, error) {
 	return &customer, nil
 }
 
+// CustomerGroupExists reports whether a customer group exists
+func (r *Repository) CustomerGroupExists(id int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CustomerGroup{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
 // Update modifies an existing customer
 func (r *Repository) Update(customer *models.Customer) error {
 	return r.db.Save(customer).Error
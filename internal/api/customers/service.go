@@ -51,6 +51,9 @@ func (s *Service) CreateCustomer(customer *models.Customer) error {
 	if customer.StoreFrontID <= 0 {
 		return errors.New("store_front_id is required")
 	}
+	if err := s.validateCustomerGroup(customer.CustomerGroupID); err != nil {
+		return err
+	}
 
 	// 2. Check for duplicates by phone
 	existing, err := s.repo.GetByPhone(customer.Phone)
@@ -85,6 +88,9 @@ func (s *Service) UpdateCustomer(id int64, input *models.Customer) error {
 	if input.StoreFrontID <= 0 {
 		return errors.New("store_front_id is required")
 	}
+	if err := s.validateCustomerGroup(input.CustomerGroupID); err != nil {
+		return err
+	}
 
 	// Validate Phone Format
 	matched, _ := regexp.MatchString(`^(010|011|015)\d{8}$`, input.Phone)
@@ -106,7 +112,23 @@ func (s *Service) UpdateCustomer(id int64, input *models.Customer) error {
 	existing.Email = input.Email
 	existing.Phone = input.Phone
 	existing.StoreFrontID = input.StoreFrontID
+	existing.CustomerGroupID = input.CustomerGroupID
 
 	// 5. Save
 	return s.repo.Update(existing)
 }
+
+// validateCustomerGroup checks that an optional customer group exists
+func (s *Service) validateCustomerGroup(groupID *int64) error {
+	if groupID == nil {
+		return nil
+	}
+	exists, err := s.repo.CustomerGroupExists(*groupID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("customer group not found")
+	}
+	return nil
+}
@@ -38,7 +38,7 @@ type FileResponse struct {
 	MimeType        string     `json:"mime_type"`
 	FileType        string     `json:"file_type"`
 	Extension       string     `json:"extension"`
-	UploadedBy      int64      `json:"uploaded_by"`
+	UploadedBy      *int64     `json:"uploaded_by"`
 	UploadedByAdmin *AdminInfo `json:"uploaded_by_admin,omitempty"`
 	IsActive        bool       `json:"is_active"`
 	CreatedAt       string     `json:"created_at"`
@@ -143,7 +143,7 @@ func (c *Controller) GetFile(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"success": true, "message": "File retrieved successfully", "data": fileResponse})
 }
 
-//GetFile by path
+//GetFile by path, ?size=thumb|medium|large and ?format=webp serve an image derivative
 func (c *Controller) GetFileByPath(ctx *gin.Context) {
 	path := ctx.Param("path")
 	if path == "" {
@@ -151,6 +151,16 @@ func (c *Controller) GetFileByPath(ctx *gin.Context) {
 		return
 	}
 
+	// Images without the requested derivative are served as uploaded
+	if size := ctx.Query("size"); size != "" {
+		if derivative, err := c.fileService.GetDerivativeByPath(path, size, ctx.Query("format")); err == nil {
+			if file, err := c.fileService.GetFileByPath(derivative.FilePath); err == nil {
+				ctx.Data(http.StatusOK, derivative.MimeType, file)
+				return
+			}
+		}
+	}
+
 	file, err := c.fileService.GetFileByPath(path)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"success": false, "message": "File not found"})
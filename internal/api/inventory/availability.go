@@ -0,0 +1,254 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/services"
+	"gorm.io/gorm"
+)
+
+// Availability is the cached storefront view of a variant's stock in one store
+type Availability struct {
+	ProductVariantID    int64      `json:"product_variant_id"`
+	StoreFrontID        int64      `json:"store_front_id"`
+	Quantity            int        `json:"quantity"`
+	ReservedQuantity    int        `json:"reserved_quantity"`
+	BackorderPolicy     string     `json:"backorder_policy"`
+	BackorderLimit      *int       `json:"backorder_limit"`
+	BackorderedQuantity int        `json:"backordered_quantity"`
+	AvailableOn         *time.Time `json:"available_on"`
+}
+
+// Inventory returns the projection as an inventory row, so the model's stock rules apply to it
+func (a Availability) Inventory() models.VariantInventory {
+	return models.VariantInventory{
+		ProductVariantID:    a.ProductVariantID,
+		StoreFrontID:        a.StoreFrontID,
+		Quantity:            a.Quantity,
+		ReservedQuantity:    a.ReservedQuantity,
+		BackorderPolicy:     a.BackorderPolicy,
+		BackorderLimit:      a.BackorderLimit,
+		BackorderedQuantity: a.BackorderedQuantity,
+		AvailableOn:         a.AvailableOn,
+	}
+}
+
+// AvailabilityProjection serves variant availability per (variant, store) from a cache,
+// loading misses from variant_inventory in one query. Every inventory write goes through
+// the Repository, which invalidates the affected entries once the write's transaction
+// commits when it was opened with Transaction, and right away otherwise. The same path
+// keeps product_storefront.in_stock current, so storefront listings filter on stock
+// without reading variant_inventory. A nil projection reads straight from the database.
+type AvailabilityProjection struct {
+	cache services.Cache
+	ttl   time.Duration
+}
+
+func NewAvailabilityProjection(cache services.Cache, ttl time.Duration) *AvailabilityProjection {
+	return &AvailabilityProjection{cache: cache, ttl: ttl}
+}
+
+// productInStock is whether a store (product_storefront) can sell its product now: a variant
+// with stock or open backorders, or a bundle whose components can make up at least one
+const productInStock = `EXISTS (
+		SELECT 1 FROM product_variants pv
+		JOIN products p ON p.id = pv.product_id AND p.product_type <> 'bundle'
+		JOIN variant_inventory vi ON vi.product_variant_id = pv.id AND vi.store_front_id = product_storefront.store_front_id
+		WHERE pv.product_id = product_storefront.product_id AND pv.is_active = true AND pv.deleted_at IS NULL
+		AND (vi.quantity - vi.reserved_quantity > 0 OR (vi.backorder_policy IN ('allow_backorder', 'preorder') AND (vi.backorder_limit IS NULL OR vi.backorder_limit > vi.backordered_quantity)))
+	) OR EXISTS (
+		SELECT 1 FROM product_variants pv
+		JOIN products p ON p.id = pv.product_id AND p.product_type = 'bundle'
+		WHERE pv.product_id = product_storefront.product_id AND pv.is_active = true AND pv.deleted_at IS NULL
+		AND EXISTS (SELECT 1 FROM bundle_components bc WHERE bc.bundle_variant_id = pv.id AND bc.quantity > 0)
+		AND NOT EXISTS (
+			SELECT 1 FROM bundle_components bc
+			LEFT JOIN variant_inventory vi ON vi.product_variant_id = bc.component_variant_id AND vi.store_front_id = product_storefront.store_front_id
+			WHERE bc.bundle_variant_id = pv.id AND bc.quantity > 0 AND COALESCE(vi.quantity - vi.reserved_quantity, 0) < bc.quantity
+		)
+	)`
+
+// refreshInStock recomputes in_stock for the store's products that sell the variants,
+// themselves or as bundle components
+func refreshInStock(tx *gorm.DB, storeFrontID int64, variantIDs []int64) error {
+	if len(variantIDs) == 0 {
+		return nil
+	}
+	selling := tx.Model(&models.ProductVariant{}).Select("product_id").Where("id IN ?", variantIDs)
+	bundling := tx.Table("bundle_components bc").
+		Joins("JOIN product_variants pv ON pv.id = bc.bundle_variant_id").
+		Select("pv.product_id").
+		Where("bc.component_variant_id IN ?", variantIDs)
+	return tx.Model(&models.ProductStorefront{}).
+		Where("store_front_id = ? AND (product_id IN (?) OR product_id IN (?))", storeFrontID, selling, bundling).
+		Update("in_stock", gorm.Expr(productInStock)).Error
+}
+
+// RefreshProductStock recomputes in_stock for products in all their stores, for catalog
+// changes that move no stock: store assignments, variants switched off, bundle components
+func RefreshProductStock(tx *gorm.DB, productIDs ...int64) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.ProductStorefront{}).
+		Where("product_id IN ?", productIDs).
+		Update("in_stock", gorm.Expr(productInStock)).Error
+}
+
+func availabilityKey(storeFrontID, variantID int64) string {
+	return fmt.Sprintf("availability:%d:%d", storeFrontID, variantID)
+}
+
+// Get returns the stock of variants in a store keyed by variant ID. Variants
+// without an inventory row are returned empty, which reads as out of stock.
+func (p *AvailabilityProjection) Get(db *gorm.DB, storeFrontID int64, variantIDs []int64) (map[int64]models.VariantInventory, error) {
+	stock := make(map[int64]models.VariantInventory, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return stock, nil
+	}
+
+	missing := variantIDs
+	if p != nil {
+		missing = p.fromCache(storeFrontID, variantIDs, stock)
+	}
+	if len(missing) == 0 {
+		return stock, nil
+	}
+
+	var rows []models.VariantInventory
+	err := db.Where("store_front_id = ? AND product_variant_id IN ?", storeFrontID, missing).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[int64]Availability, len(missing))
+	for _, id := range missing {
+		loaded[id] = Availability{ProductVariantID: id, StoreFrontID: storeFrontID}
+	}
+	for _, inv := range rows {
+		loaded[inv.ProductVariantID] = Availability{
+			ProductVariantID:    inv.ProductVariantID,
+			StoreFrontID:        inv.StoreFrontID,
+			Quantity:            inv.Quantity,
+			ReservedQuantity:    inv.ReservedQuantity,
+			BackorderPolicy:     inv.BackorderPolicy,
+			BackorderLimit:      inv.BackorderLimit,
+			BackorderedQuantity: inv.BackorderedQuantity,
+			AvailableOn:         inv.AvailableOn,
+		}
+	}
+
+	for id, a := range loaded {
+		stock[id] = a.Inventory()
+		if p != nil {
+			p.store(a)
+		}
+	}
+	return stock, nil
+}
+
+// pendingInvalidations collects the variants a transaction wrote, by store
+type pendingInvalidations map[int64][]int64
+
+type pendingInvalidationsKey struct{}
+
+// Transaction runs fn in a transaction and drops the cached availability of the stock it
+// wrote after the commit. Dropping it earlier would let a concurrent read cache the stock
+// from before the transaction for the full TTL. The in-stock flags are refreshed in the
+// transaction once fn is done. Transactions nested in one opened here leave both to the
+// outermost.
+func (p *AvailabilityProjection) Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if _, ok := db.Statement.Context.Value(pendingInvalidationsKey{}).(pendingInvalidations); ok {
+		return db.Transaction(fn)
+	}
+
+	pending := pendingInvalidations{}
+	err := db.WithContext(context.WithValue(db.Statement.Context, pendingInvalidationsKey{}, pending)).Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		for storeFrontID, variantIDs := range pending {
+			if err := refreshInStock(tx, storeFrontID, variantIDs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for storeFrontID, variantIDs := range pending {
+		p.Invalidate(storeFrontID, variantIDs...)
+	}
+	return nil
+}
+
+// InvalidateAfterCommit drops the cached availability of variants in a store once tx
+// commits, or right away when tx was not opened with Transaction, refreshing the in-stock
+// flags of their products with it
+func (p *AvailabilityProjection) InvalidateAfterCommit(tx *gorm.DB, storeFrontID int64, variantIDs ...int64) error {
+	if pending, ok := tx.Statement.Context.Value(pendingInvalidationsKey{}).(pendingInvalidations); ok {
+		pending[storeFrontID] = append(pending[storeFrontID], variantIDs...)
+		return nil
+	}
+	if err := refreshInStock(tx, storeFrontID, variantIDs); err != nil {
+		return err
+	}
+	p.Invalidate(storeFrontID, variantIDs...)
+	return nil
+}
+
+// Invalidate drops the cached availability of variants in a store
+func (p *AvailabilityProjection) Invalidate(storeFrontID int64, variantIDs ...int64) {
+	if p == nil || len(variantIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(variantIDs))
+	for _, id := range variantIDs {
+		keys = append(keys, availabilityKey(storeFrontID, id))
+	}
+	if err := p.cache.Delete(context.Background(), keys...); err != nil {
+		log.Printf("⚠️  Failed to invalidate availability cache: %v", err)
+	}
+}
+
+// fromCache fills stock with cached entries and returns the variant IDs that missed
+func (p *AvailabilityProjection) fromCache(storeFrontID int64, variantIDs []int64, stock map[int64]models.VariantInventory) []int64 {
+	keys := make([]string, 0, len(variantIDs))
+	for _, id := range variantIDs {
+		keys = append(keys, availabilityKey(storeFrontID, id))
+	}
+
+	cached, err := p.cache.GetMany(context.Background(), keys)
+	if err != nil {
+		log.Printf("⚠️  Availability cache read failed: %v", err)
+		return variantIDs
+	}
+
+	var missing []int64
+	for i, id := range variantIDs {
+		var a Availability
+		raw, ok := cached[keys[i]]
+		if !ok || json.Unmarshal(raw, &a) != nil {
+			missing = append(missing, id)
+			continue
+		}
+		stock[id] = a.Inventory()
+	}
+	return missing
+}
+
+func (p *AvailabilityProjection) store(a Availability) {
+	raw, err := json.Marshal(a)
+	if err != nil {
+		return
+	}
+	if err := p.cache.Set(context.Background(), availabilityKey(a.StoreFrontID, a.ProductVariantID), raw, p.ttl); err != nil {
+		log.Printf("⚠️  Availability cache write failed: %v", err)
+	}
+}
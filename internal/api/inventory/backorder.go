@@ -0,0 +1,120 @@
+package inventory
+
+import (
+	"fmt"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+func (s *Service) UpdateBackorderPolicy(variantID, storeFrontID int64, req requests.UpdateBackorderPolicyRequest) utils.IResource {
+	if req.BackorderPolicy == models.BackorderPolicyPreorder && req.AvailableOn == nil {
+		return utils.NewBadRequestResource("available_on is required for preorder", nil)
+	}
+
+	inv, err := s.repo.EnsureInventoryRecord(s.db, variantID, storeFrontID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load inventory", err)
+	}
+
+	updates := map[string]interface{}{
+		"backorder_policy": req.BackorderPolicy,
+		"backorder_limit":  req.BackorderLimit,
+		"available_on":     req.AvailableOn,
+	}
+	if req.BackorderPolicy == models.BackorderPolicyDeny {
+		updates["backorder_limit"] = nil
+		updates["available_on"] = nil
+	}
+	if err := s.repo.UpdateBackorderPolicy(inv, updates); err != nil {
+		return utils.NewInternalErrorResource("Failed to update backorder policy", err)
+	}
+
+	return s.GetVariantInventory(variantID, storeFrontID)
+}
+
+func (s *Service) ListBackorders(storeFrontID int64, pagination *utils.Pagination) utils.IResource {
+	lines, total, err := s.repo.ListBackorderedLines(storeFrontID, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve backorders", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Backorders retrieved successfully", lines, pagination.GetMeta())
+}
+
+// CancelBackorderWithTx gives back backordered units that will no longer be allocated
+func (s *Service) CancelBackorderWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int) error {
+	repo := s.repo.withTx(tx)
+
+	inv, err := repo.GetVariantInventory(variantID, storeFrontID)
+	if err != nil {
+		return err
+	}
+
+	locked, err := repo.LockInventory(tx, inv.ID)
+	if err != nil {
+		return err
+	}
+
+	newBackordered := locked.BackorderedQuantity - quantity
+	if newBackordered < 0 {
+		newBackordered = 0
+	}
+	return repo.SetBackorderedQuantity(tx, locked, newBackordered)
+}
+
+// allocateBackorders reserves newly available stock for waiting order lines, oldest first.
+// locked must be the current, row-locked state of the inventory and is updated in place.
+// Lines of skipOrderID keep waiting, so an order releasing its own stock does not take
+// it back on another of its lines.
+func allocateBackorders(tx *gorm.DB, repo *Repository, locked *models.VariantInventory, performedBy *int64, skipOrderID int64) (int, error) {
+	available := locked.AvailableQuantity()
+	if available <= 0 || locked.BackorderedQuantity <= 0 {
+		return 0, nil
+	}
+
+	items, err := repo.LockBackorderedItems(tx, locked.ProductVariantID, locked.StoreFrontID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load backordered items: %w", err)
+	}
+
+	allocated, waiting := 0, 0
+	for _, item := range items {
+		if item.OrderID == skipOrderID {
+			waiting += item.BackorderedQuantity
+			continue
+		}
+		n := min(item.BackorderedQuantity, available)
+		waiting += item.BackorderedQuantity - n
+		if n == 0 {
+			continue
+		}
+
+		source := OrderMovementSource(item.OrderID)
+		source.PerformedBy = performedBy
+		source.Notes = "Backorder allocated"
+
+		newReserved := locked.ReservedQuantity + n
+		if err := updateStockWithMovement(tx, repo, locked, locked.Quantity, newReserved, models.MovementTypeReserve, source); err != nil {
+			return 0, err
+		}
+		if err := repo.SetItemBackorderedQuantity(tx, item.ID, item.BackorderedQuantity-n); err != nil {
+			return 0, fmt.Errorf("failed to update backordered item: %w", err)
+		}
+
+		locked.ReservedQuantity = newReserved
+		available -= n
+		allocated += n
+	}
+
+	// Recount from the open lines so the counter heals after cancellations
+	locked.BackorderedQuantity = waiting
+	if err := repo.SetBackorderedQuantity(tx, locked, locked.BackorderedQuantity); err != nil {
+		return 0, err
+	}
+
+	return allocated, nil
+}
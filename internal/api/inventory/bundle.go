@@ -0,0 +1,50 @@
+package inventory
+
+import (
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ListBundleComponentsWithTx returns the components of a bundle variant, or
+// nothing when the variant is not a bundle
+func (s *Service) ListBundleComponentsWithTx(tx *gorm.DB, variantID int64) ([]models.BundleComponent, error) {
+	return (&Repository{db: tx}).ListBundleComponents(tx, variantID)
+}
+
+// getBundleInventory reports the stock a bundle can be sold from, derived from its components
+func (s *Service) getBundleInventory(variantID, storeFrontID int64, components []models.BundleComponent) utils.IResource {
+	ids := make([]int64, 0, len(components))
+	for _, c := range components {
+		ids = append(ids, c.ComponentVariantID)
+	}
+	stock, err := s.repo.ListInventoryByVariants(storeFrontID, ids)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve inventory", err)
+	}
+
+	items := make([]map[string]interface{}, 0, len(components))
+	for _, c := range components {
+		inv := stock[c.ComponentVariantID]
+		sku := ""
+		if c.ComponentVariant != nil {
+			sku = c.ComponentVariant.SKU
+		}
+		items = append(items, map[string]interface{}{
+			"product_variant_id": c.ComponentVariantID,
+			"sku":                sku,
+			"quantity":           c.Quantity,
+			"available_quantity": inv.AvailableQuantity(),
+		})
+	}
+
+	available := models.BundleAvailableQuantity(components, stock)
+	return utils.NewOKResource("Inventory retrieved successfully", map[string]interface{}{
+		"product_variant_id": variantID,
+		"store_front_id":     storeFrontID,
+		"is_bundle":          true,
+		"available_quantity": available,
+		"is_sellable":        available > 0,
+		"components":         items,
+	})
+}
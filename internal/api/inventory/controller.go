@@ -1,6 +1,8 @@
 package inventory
 
 import (
+	"fmt"
+	"io"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -116,3 +118,332 @@ func (ctrl *Controller) GetAdjustmentHistory(c *gin.Context) {
 	res := ctrl.service.GetAdjustmentHistory(inventoryID, pagination)
 	utils.WriteResource(c, res)
 }
+
+func (ctrl *Controller) GetMovementHistory(c *gin.Context) {
+	inventoryID, err := strconv.ParseInt(c.Param("inventoryId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid inventory id")
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(c)
+	res := ctrl.service.GetMovementHistory(inventoryID, pagination)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) VerifyLedger(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	res := ctrl.service.RebuildFromLedger(storeFrontID, false, 0)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) RebuildFromLedger(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := c.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.RebuildFromLedger(storeFrontID, true, adminID.(int64))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) UpdateBackorderPolicy(c *gin.Context) {
+	variantID, err := strconv.ParseInt(c.Param("variantId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid variant id")
+		return
+	}
+
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	var req requests.UpdateBackorderPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.UpdateBackorderPolicy(variantID, storeFrontID, req)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ListBackorders(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(c)
+	res := ctrl.service.ListBackorders(storeFrontID, pagination)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ListSerials(c *gin.Context) {
+	variantID, err := strconv.ParseInt(c.Param("variantId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid variant id")
+		return
+	}
+
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	var req requests.SerialFilterRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(c)
+	res := ctrl.service.ListSerials(variantID, storeFrontID, req, pagination)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ListLots(c *gin.Context) {
+	variantID, err := strconv.ParseInt(c.Param("variantId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid variant id")
+		return
+	}
+
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	res := ctrl.service.ListLots(variantID, storeFrontID)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) TraceSerial(c *gin.Context) {
+	res := ctrl.service.TraceSerial(c.Param("serial"))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) TraceLot(c *gin.Context) {
+	res := ctrl.service.TraceLot(c.Param("lotNumber"))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ExportInventory(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	format, err := utils.SpreadsheetFormatFromName(c.DefaultQuery("format", utils.SpreadsheetCSV))
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	var req requests.InventoryFilterRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(c, "invalid filter parameters")
+		return
+	}
+	req.StoreFrontID = storeFrontID
+
+	data, err := ctrl.service.ExportInventory(req, format)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to export inventory", nil)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="inventory-store-%d.%s"`, storeFrontID, format))
+	c.Data(200, utils.SpreadsheetContentType(format), data)
+}
+
+func (ctrl *Controller) ImportInventory(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	var req requests.InventoryImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ValidationErrorResponse(c, "no file uploaded or invalid file")
+		return
+	}
+	format, err := utils.SpreadsheetFormatFromName(fileHeader.Filename)
+	if err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ValidationErrorResponse(c, "failed to open uploaded file")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "failed to read uploaded file")
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := c.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.ImportInventory(storeFrontID, format, data, req, adminID.(int64))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) GetReorderSuggestions(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	var req requests.ReorderSuggestionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	params := ReorderParams{LookbackDays: 30, CoverDays: 30}
+	if req.LookbackDays > 0 {
+		params.LookbackDays = req.LookbackDays
+	}
+	if req.CoverDays > 0 {
+		params.CoverDays = req.CoverDays
+	}
+
+	res := ctrl.service.GetReorderSuggestions(storeFrontID, params)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) CreateHold(c *gin.Context) {
+	var req requests.CreateStockHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := c.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.CreateHold(req, adminID.(int64))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ListHolds(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	var req requests.StockHoldFilterRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(c)
+	res := ctrl.service.ListHolds(storeFrontID, req, pagination)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) GetHold(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	res := ctrl.service.GetHold(storeFrontID, c.Param("reference"))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ExtendHold(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	var req requests.ExtendStockHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.ExtendHold(storeFrontID, c.Param("reference"), req)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ConvertHold(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := c.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.ConvertHold(storeFrontID, c.Param("reference"), adminID.(int64))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ReleaseHold(c *gin.Context) {
+	storeFrontID, err := strconv.ParseInt(c.Param("storeFrontId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid store front id")
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := c.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.ReleaseHold(storeFrontID, c.Param("reference"), adminID.(int64))
+	utils.WriteResource(c, res)
+}
@@ -0,0 +1,367 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// errHoldExpired is returned after an expired hold was released on access
+var errHoldExpired = errors.New("stock hold has expired")
+
+// holdMovementSource builds the ledger source for hold driven movements
+func holdMovementSource(hold *models.StockHold, performedBy *int64, notes string) MovementSource {
+	return MovementSource{
+		Type:        models.MovementSourceHold,
+		ID:          &hold.ID,
+		PerformedBy: performedBy,
+		Notes:       fmt.Sprintf("%s hold %s: %s", hold.Channel, hold.Reference, notes),
+	}
+}
+
+// mergeHoldItems sums the quantities of repeated variants and orders them by
+// variant ID, so holds always lock inventory rows in the same order
+func mergeHoldItems(items []requests.StockHoldItemRequest) []requests.StockHoldItemRequest {
+	byVariant := make(map[int64]int)
+	for _, item := range items {
+		byVariant[item.ProductVariantID] += item.Quantity
+	}
+
+	merged := make([]requests.StockHoldItemRequest, 0, len(byVariant))
+	for id, qty := range byVariant {
+		merged = append(merged, requests.StockHoldItemRequest{ProductVariantID: id, Quantity: qty})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].ProductVariantID < merged[j].ProductVariantID })
+	return merged
+}
+
+// sameHoldItems reports whether a stored hold holds exactly the requested items
+func sameHoldItems(hold *models.StockHold, items []requests.StockHoldItemRequest) bool {
+	held := make([]requests.StockHoldItemRequest, 0, len(hold.Items))
+	for _, item := range hold.Items {
+		held = append(held, requests.StockHoldItemRequest{ProductVariantID: item.ProductVariantID, Quantity: item.Quantity})
+	}
+	return reflect.DeepEqual(mergeHoldItems(held), items)
+}
+
+// holdComponents snapshots the component variants a held bundle reserves, or returns
+// nothing for variants that are not bundles. Tracked components are rejected like tracked
+// variants, their units can only be picked through orders.
+func (s *Service) holdComponents(tx *gorm.DB, variantID int64, quantity int) ([]models.StockHoldItemComponent, error) {
+	components, err := s.ListBundleComponentsWithTx(tx, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make([]models.StockHoldItemComponent, 0, len(components))
+	for _, c := range components {
+		if c.ComponentVariant == nil {
+			return nil, fmt.Errorf("bundle component %d not found", c.ComponentVariantID)
+		}
+		if err := checkHoldTracking(c.ComponentVariant); err != nil {
+			return nil, err
+		}
+		snapshot = append(snapshot, models.StockHoldItemComponent{
+			ProductVariantID:  c.ComponentVariantID,
+			SKU:               c.ComponentVariant.SKU,
+			QuantityPerBundle: c.Quantity,
+			Quantity:          c.Quantity * quantity,
+		})
+	}
+	return snapshot, nil
+}
+
+// checkHoldTracking rejects serial and lot tracked variants
+func checkHoldTracking(variant *models.ProductVariant) error {
+	if variant.TrackingMode != "" && variant.TrackingMode != models.TrackingModeNone {
+		return fmt.Errorf("%s is %s-tracked and can only be sold through orders", variant.SKU, variant.TrackingMode)
+	}
+	return nil
+}
+
+// forEachHeldVariant calls fn for every variant whose stock a hold item reserves:
+// the bundle components recorded when the hold was made, or the item's own variant
+func forEachHeldVariant(item *models.StockHoldItem, fn func(variantID int64, quantity int) error) error {
+	if len(item.Components) == 0 {
+		return fn(item.ProductVariantID, item.Quantity)
+	}
+	for _, c := range item.Components {
+		if err := fn(c.ProductVariantID, c.Quantity); err != nil {
+			return fmt.Errorf("bundle component %s: %w", c.SKU, err)
+		}
+	}
+	return nil
+}
+
+// releaseHoldWithTx gives the held stock back and closes the hold with status
+func (s *Service) releaseHoldWithTx(tx *gorm.DB, hold *models.StockHold, status string, performedBy *int64) error {
+	source := holdMovementSource(hold, performedBy, status)
+	for i := range hold.Items {
+		err := forEachHeldVariant(&hold.Items[i], func(variantID int64, quantity int) error {
+			return s.ReleaseReservedStockWithTx(tx, variantID, hold.StoreFrontID, quantity, source)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to release %s: %w", hold.Items[i].SKU, err)
+		}
+	}
+
+	now := time.Now()
+	if err := s.repo.withTx(tx).UpdateHold(tx, hold.ID, map[string]interface{}{"status": status, "released_at": now}); err != nil {
+		return err
+	}
+	hold.Status = status
+	hold.ReleasedAt = &now
+	return nil
+}
+
+// lockActiveHold locks a hold for a state change. Holds past their TTL are released
+// and errHoldExpired is returned, the caller must still commit the transaction.
+func (s *Service) lockActiveHold(tx *gorm.DB, storeFrontID int64, reference string) (*models.StockHold, error) {
+	hold, err := s.repo.withTx(tx).LockHold(tx, storeFrontID, reference)
+	if err != nil {
+		return nil, err
+	}
+	if !hold.IsActive() {
+		return nil, fmt.Errorf("stock hold is already %s", hold.Status)
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		if err := s.releaseHoldWithTx(tx, hold, models.StockHoldStatusExpired, nil); err != nil {
+			return nil, err
+		}
+		return nil, errHoldExpired
+	}
+	return hold, nil
+}
+
+// CreateHold reserves stock for an external channel. Repeating a request with the same
+// reference returns the existing hold instead of reserving twice.
+func (s *Service) CreateHold(req requests.CreateStockHoldRequest, adminID int64) utils.IResource {
+	items := mergeHoldItems(req.Items)
+
+	if existing, err := s.repo.FindHold(req.StoreFrontID, req.Reference); err == nil {
+		if !sameHoldItems(existing, items) {
+			return utils.NewBadRequestResource("Reference is already used by a different stock hold", nil)
+		}
+		return utils.NewOKResource("Stock hold already exists", existing)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.NewInternalErrorResource("Failed to load stock hold", err)
+	}
+
+	hold := &models.StockHold{
+		StoreFrontID: req.StoreFrontID,
+		Reference:    req.Reference,
+		Channel:      req.Channel,
+		Status:       models.StockHoldStatusActive,
+		ExpiresAt:    time.Now().Add(time.Duration(req.TTLSeconds) * time.Second),
+		CreatedBy:    &adminID,
+	}
+
+	err := s.repo.Transaction(s.db, func(tx *gorm.DB) error {
+		repo := s.repo.withTx(tx)
+
+		ids := make([]int64, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ProductVariantID)
+		}
+		variants, err := repo.FindVariantsByIDs(tx, ids)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			variant, ok := variants[item.ProductVariantID]
+			if !ok {
+				return fmt.Errorf("variant %d not found", item.ProductVariantID)
+			}
+			if err := checkHoldTracking(&variant); err != nil {
+				return err
+			}
+			components, err := s.holdComponents(tx, variant.ID, item.Quantity)
+			if err != nil {
+				return err
+			}
+			hold.Items = append(hold.Items, models.StockHoldItem{
+				ProductVariantID: item.ProductVariantID,
+				SKU:              variant.SKU,
+				Quantity:         item.Quantity,
+				Components:       components,
+			})
+		}
+
+		if err := repo.CreateHold(tx, hold); err != nil {
+			return fmt.Errorf("failed to create stock hold: %w", err)
+		}
+
+		// Holds never backorder
+		source := holdMovementSource(hold, &adminID, "reserved")
+		for i := range hold.Items {
+			err := forEachHeldVariant(&hold.Items[i], func(variantID int64, quantity int) error {
+				return s.ReserveAvailableStockWithTx(tx, variantID, hold.StoreFrontID, quantity, source)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// A concurrent retry may have created the hold first
+		if existing, findErr := s.repo.FindHold(req.StoreFrontID, req.Reference); findErr == nil && sameHoldItems(existing, items) {
+			return utils.NewOKResource("Stock hold already exists", existing)
+		}
+		return utils.NewBadRequestResource("Failed to create stock hold: "+err.Error(), nil)
+	}
+
+	return utils.NewCreatedResource("Stock hold created successfully", hold)
+}
+
+func (s *Service) GetHold(storeFrontID int64, reference string) utils.IResource {
+	hold, err := s.repo.FindHold(storeFrontID, reference)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewNotFoundResource("Stock hold not found", nil)
+		}
+		return utils.NewInternalErrorResource("Failed to load stock hold", err)
+	}
+	return utils.NewOKResource("Stock hold retrieved successfully", hold)
+}
+
+func (s *Service) ListHolds(storeFrontID int64, filter requests.StockHoldFilterRequest, pagination *utils.Pagination) utils.IResource {
+	holds, total, err := s.repo.ListHolds(storeFrontID, filter.Status, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve stock holds", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Stock holds retrieved successfully", holds, pagination.GetMeta())
+}
+
+// ExtendHold pushes the expiry of an active hold back by the requested TTL
+func (s *Service) ExtendHold(storeFrontID int64, reference string, req requests.ExtendStockHoldRequest) utils.IResource {
+	return s.changeHold(storeFrontID, reference, "Stock hold extended successfully", func(tx *gorm.DB, hold *models.StockHold) error {
+		hold.ExpiresAt = hold.ExpiresAt.Add(time.Duration(req.TTLSeconds) * time.Second)
+		return s.repo.withTx(tx).UpdateHold(tx, hold.ID, map[string]interface{}{"expires_at": hold.ExpiresAt})
+	})
+}
+
+// ConvertHold turns the held stock into a sale
+func (s *Service) ConvertHold(storeFrontID int64, reference string, adminID int64) utils.IResource {
+	return s.changeHold(storeFrontID, reference, "Stock hold converted to a sale", func(tx *gorm.DB, hold *models.StockHold) error {
+		source := holdMovementSource(hold, &adminID, "sold")
+		for i := range hold.Items {
+			err := forEachHeldVariant(&hold.Items[i], func(variantID int64, quantity int) error {
+				return s.ConfirmStockDeductionWithTx(tx, variantID, hold.StoreFrontID, quantity, source)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to deduct %s: %w", hold.Items[i].SKU, err)
+			}
+		}
+
+		now := time.Now()
+		hold.Status = models.StockHoldStatusConverted
+		hold.ConvertedAt = &now
+		return s.repo.withTx(tx).UpdateHold(tx, hold.ID, map[string]interface{}{"status": hold.Status, "converted_at": now})
+	})
+}
+
+// ReleaseHold gives the held stock back before the hold expires
+func (s *Service) ReleaseHold(storeFrontID int64, reference string, adminID int64) utils.IResource {
+	return s.changeHold(storeFrontID, reference, "Stock hold released successfully", func(tx *gorm.DB, hold *models.StockHold) error {
+		return s.releaseHoldWithTx(tx, hold, models.StockHoldStatusReleased, &adminID)
+	})
+}
+
+// changeHold applies change to an active hold in a transaction, releasing it instead when it has expired
+func (s *Service) changeHold(storeFrontID int64, reference, message string, change func(tx *gorm.DB, hold *models.StockHold) error) utils.IResource {
+	var hold *models.StockHold
+	expired := false
+
+	err := s.repo.Transaction(s.db, func(tx *gorm.DB) error {
+		var err error
+		hold, err = s.lockActiveHold(tx, storeFrontID, reference)
+		if errors.Is(err, errHoldExpired) {
+			expired = true
+			return nil // Commit the release
+		}
+		if err != nil {
+			return err
+		}
+		return change(tx, hold)
+	})
+	if expired {
+		return utils.NewBadRequestResource(errHoldExpired.Error(), nil)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewNotFoundResource("Stock hold not found", nil)
+		}
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
+
+	return utils.NewOKResource(message, hold)
+}
+
+// ExpireHolds releases active holds whose TTL ran out and returns how many were expired
+func (s *Service) ExpireHolds(now time.Time) (int, error) {
+	holds, err := s.repo.ListExpiredHolds(now, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, h := range holds {
+		err := s.repo.Transaction(s.db, func(tx *gorm.DB) error {
+			_, err := s.lockActiveHold(tx, h.StoreFrontID, h.Reference)
+			if errors.Is(err, errHoldExpired) {
+				expired++
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to expire stock hold %s: %v", h.Reference, err)
+		}
+	}
+	return expired, nil
+}
+
+// HoldExpiryJob runs ExpireHolds on a fixed interval
+type HoldExpiryJob struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewHoldExpiryJob(service *Service, interval time.Duration) *HoldExpiryJob {
+	return &HoldExpiryJob{service: service, interval: interval}
+}
+
+// Start runs the job in the background until ctx is cancelled
+func (j *HoldExpiryJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			expired, err := j.service.ExpireHolds(time.Now())
+			if err != nil {
+				log.Printf("⚠️  Stock hold expiry failed: %v", err)
+			} else if expired > 0 {
+				log.Printf("⏱️  Released %d expired stock hold(s)", expired)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,332 @@
+package inventory
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Import row statuses
+const (
+	importRowOK        = "ok"
+	importRowUnchanged = "unchanged"
+	importRowError     = "error"
+)
+
+var inventoryExportHeader = []string{
+	"sku", "barcode", "product_name", "attribute_value",
+	"quantity", "reserved_quantity", "available_quantity", "low_stock_threshold",
+}
+
+var adjustmentReasons = map[string]bool{
+	"restock": true, "correction": true, "sale": true, "return": true, "transfer": true,
+}
+
+// InventoryImportRow is the validation result for one line of an import file
+type InventoryImportRow struct {
+	Row             int    `json:"row"`
+	SKU             string `json:"sku"`
+	CurrentQuantity int    `json:"current_quantity"`
+	NewQuantity     int    `json:"new_quantity"`
+	Change          int    `json:"change"`
+	Reason          string `json:"reason"`
+	Notes           string `json:"notes"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+
+	variantID int64
+	quantity  *int
+	delta     *int
+}
+
+// InventoryImportReport summarises an import run
+type InventoryImportReport struct {
+	DryRun    bool                 `json:"dry_run"`
+	Applied   bool                 `json:"applied"`
+	Total     int                  `json:"total"`
+	Changed   int                  `json:"changed"`
+	Unchanged int                  `json:"unchanged"`
+	Errors    int                  `json:"errors"`
+	Rows      []InventoryImportRow `json:"rows"`
+}
+
+// ExportInventory renders the inventory matching filter as a CSV or XLSX file
+func (s *Service) ExportInventory(filter requests.InventoryFilterRequest, format string) ([]byte, error) {
+	items, err := s.repo.ListAllInventory(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(items)+1)
+	rows = append(rows, inventoryExportHeader)
+	for _, item := range items {
+		barcode := ""
+		if item.Barcode != nil {
+			barcode = *item.Barcode
+		}
+		rows = append(rows, []string{
+			item.SKU, barcode, item.ProductName, item.AttributeValue,
+			strconv.Itoa(item.Quantity), strconv.Itoa(item.ReservedQuantity),
+			strconv.Itoa(item.AvailableQuantity), strconv.Itoa(item.LowStockThreshold),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := utils.WriteSpreadsheet(&buf, format, rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportInventory validates an inventory file against a store and, unless it is a dry run,
+// applies it with the same semantics as BulkAdjustInventory
+func (s *Service) ImportInventory(storeFrontID int64, format string, data []byte, req requests.InventoryImportRequest, adminID int64) utils.IResource {
+	sheet, err := utils.ReadSpreadsheet(format, data)
+	if err != nil {
+		return utils.NewBadRequestResource("Failed to read import file: "+err.Error(), nil)
+	}
+
+	defaultReason := req.Reason
+	if defaultReason == "" {
+		defaultReason = "correction"
+	}
+	rows, err := parseInventoryImport(sheet, defaultReason, req.Notes)
+	if err != nil {
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
+	if len(rows) == 0 {
+		return utils.NewBadRequestResource("Import file has no rows", nil)
+	}
+
+	if err := s.validateImportRows(storeFrontID, rows); err != nil {
+		return utils.NewInternalErrorResource("Failed to validate import", err)
+	}
+
+	report := buildImportReport(rows)
+	report.DryRun = req.DryRun == nil || *req.DryRun
+	if report.Errors > 0 {
+		if report.DryRun {
+			return utils.NewOKResource("Inventory import validated with errors", report)
+		}
+		return utils.NewBadRequestWithBodyResource("Inventory import has errors, nothing was applied", report, nil)
+	}
+	if report.DryRun {
+		return utils.NewOKResource("Inventory import validated", report)
+	}
+
+	err = s.repo.Transaction(s.db, func(tx *gorm.DB) error {
+		repo := s.repo.withTx(tx)
+		for i := range rows {
+			row := &rows[i]
+			if row.Status != importRowOK {
+				continue
+			}
+
+			inv, err := repo.EnsureInventoryRecord(tx, row.variantID, storeFrontID)
+			if err != nil {
+				return err
+			}
+			locked, err := repo.LockInventory(tx, inv.ID)
+			if err != nil {
+				return err
+			}
+
+			// Recompute against the locked row, stock may have moved since validation
+			row.CurrentQuantity = locked.Quantity
+			row.NewQuantity = importTargetQuantity(*row, locked.Quantity)
+			row.Change = row.NewQuantity - locked.Quantity
+			if row.NewQuantity < 0 {
+				return fmt.Errorf("row %d: adjustment would result in negative stock (current: %d)", row.Row, locked.Quantity)
+			}
+
+			item := requests.BulkInventoryUpdateItem{
+				VariantInventoryID: locked.ID,
+				NewQuantity:        row.NewQuantity,
+				Reason:             row.Reason,
+				Notes:              row.Notes,
+			}
+			if err := applyBulkItem(tx, repo, locked, item, adminID); err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.NewBadRequestResource("Failed to apply inventory import: "+err.Error(), nil)
+	}
+
+	report = buildImportReport(rows)
+	report.Applied = true
+	return utils.NewOKResource("Inventory import applied", report)
+}
+
+// parseInventoryImport reads the header and data rows of an import sheet.
+// Each row needs a sku and either an absolute quantity or a delta.
+func parseInventoryImport(sheet [][]string, defaultReason, defaultNotes string) ([]InventoryImportRow, error) {
+	if len(sheet) == 0 {
+		return nil, fmt.Errorf("import file is empty")
+	}
+
+	cols := make(map[string]int)
+	for i, name := range sheet[0] {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := cols["sku"]; !ok {
+		return nil, fmt.Errorf("import file must have a sku column")
+	}
+	_, hasQty := cols["quantity"]
+	_, hasDelta := cols["delta"]
+	if !hasQty && !hasDelta {
+		return nil, fmt.Errorf("import file must have a quantity or delta column")
+	}
+
+	cell := func(record []string, name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []InventoryImportRow
+	for i, record := range sheet[1:] {
+		row := InventoryImportRow{
+			Row:    i + 2, // 1-based, after the header
+			SKU:    cell(record, "sku"),
+			Reason: strings.ToLower(cell(record, "reason")),
+			Notes:  cell(record, "notes"),
+			Status: importRowOK,
+		}
+		qty, delta := cell(record, "quantity"), cell(record, "delta")
+		if row.SKU == "" && qty == "" && delta == "" {
+			continue // Blank line
+		}
+		if row.Reason == "" {
+			row.Reason = defaultReason
+		}
+		if row.Notes == "" {
+			row.Notes = defaultNotes
+		}
+
+		switch {
+		case row.SKU == "":
+			row.fail("sku is required")
+		case qty != "" && delta != "":
+			row.fail("set either quantity or delta, not both")
+		case qty == "" && delta == "":
+			row.fail("quantity or delta is required")
+		case !adjustmentReasons[row.Reason]:
+			row.fail(fmt.Sprintf("invalid reason %q", row.Reason))
+		case qty != "":
+			n, err := strconv.Atoi(qty)
+			if err != nil || n < 0 {
+				row.fail("quantity must be a whole number of zero or more")
+			} else {
+				row.quantity = &n
+			}
+		default:
+			n, err := strconv.Atoi(strings.TrimPrefix(delta, "+"))
+			if err != nil {
+				row.fail("delta must be a whole number")
+			} else {
+				row.delta = &n
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// validateImportRows resolves SKUs and previews the resulting stock for each row
+func (s *Service) validateImportRows(storeFrontID int64, rows []InventoryImportRow) error {
+	skus := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.SKU != "" {
+			skus = append(skus, row.SKU)
+		}
+	}
+	variants, err := s.repo.FindVariantsBySKU(skus)
+	if err != nil {
+		return err
+	}
+
+	variantIDs := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+	stock, err := s.repo.ListInventoryByVariants(storeFrontID, variantIDs)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		if row.Status == importRowError {
+			continue
+		}
+		if first, dup := seen[row.SKU]; dup {
+			row.fail(fmt.Sprintf("duplicate sku, already listed on row %d", first))
+			continue
+		}
+		seen[row.SKU] = row.Row
+
+		variant, ok := variants[row.SKU]
+		if !ok {
+			row.fail("unknown sku")
+			continue
+		}
+		row.variantID = variant.ID
+		if variant.TrackingMode != "" && variant.TrackingMode != models.TrackingModeNone {
+			row.fail(fmt.Sprintf("%s-tracked variants must be adjusted individually", variant.TrackingMode))
+			continue
+		}
+
+		current := stock[variant.ID].Quantity // Missing rows start at zero
+		row.CurrentQuantity = current
+		row.NewQuantity = importTargetQuantity(*row, current)
+		row.Change = row.NewQuantity - current
+		if row.NewQuantity < 0 {
+			row.fail(fmt.Sprintf("adjustment would result in negative stock (current: %d)", current))
+			continue
+		}
+		if row.Change == 0 {
+			row.Status = importRowUnchanged
+		}
+	}
+	return nil
+}
+
+func importTargetQuantity(row InventoryImportRow, current int) int {
+	if row.quantity != nil {
+		return *row.quantity
+	}
+	return current + *row.delta
+}
+
+func (r *InventoryImportRow) fail(msg string) {
+	r.Status = importRowError
+	r.Error = msg
+}
+
+func buildImportReport(rows []InventoryImportRow) InventoryImportReport {
+	report := InventoryImportReport{Total: len(rows), Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case importRowOK:
+			report.Changed++
+		case importRowUnchanged:
+			report.Unchanged++
+		case importRowError:
+			report.Errors++
+		}
+	}
+	return report
+}
@@ -1,10 +1,14 @@
 package inventory
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/onas/ecommerce-api/internal/api/inventory/requests"
 	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/services"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -18,9 +22,13 @@ func setupTestDB(t *testing.T) *gorm.DB {
 
 	if err := db.AutoMigrate(
 		&models.StoreFront{},
+		&models.Product{},
+		&models.ProductStorefront{},
 		&models.ProductVariant{},
+		&models.BundleComponent{},
 		&models.VariantInventory{},
 		&models.InventoryAdjustment{},
+		&models.InventoryMovement{},
 	); err != nil {
 		t.Fatalf("failed to migrate: %v", err)
 	}
@@ -188,3 +196,391 @@ func TestVariantInventory_IsLowStock(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMovement_RecordsDeltas(t *testing.T) {
+	inv := models.VariantInventory{ID: 3, ProductVariantID: 7, StoreFrontID: 2, Quantity: 10, ReservedQuantity: 4}
+
+	m := NewMovement(&inv, 8, 2, models.MovementTypeSale, OrderMovementSource(42))
+	if m.QuantityChange != -2 || m.ReservedChange != -2 {
+		t.Fatalf("expected changes -2/-2, got %d/%d", m.QuantityChange, m.ReservedChange)
+	}
+	if m.QuantityBefore != 10 || m.QuantityAfter != 8 {
+		t.Fatalf("expected quantity 10 -> 8, got %d -> %d", m.QuantityBefore, m.QuantityAfter)
+	}
+	if m.SourceType != models.MovementSourceOrder || m.SourceID == nil || *m.SourceID != 42 {
+		t.Fatalf("expected order source 42, got %s %v", m.SourceType, m.SourceID)
+	}
+}
+
+func TestLedgerBalance_InSync(t *testing.T) {
+	b := LedgerBalance{Quantity: 10, ReservedQuantity: 2, LedgerQuantity: 10, LedgerReserved: 2}
+	if !b.InSync() {
+		t.Fatal("expected balance to be in sync")
+	}
+
+	b.LedgerReserved = 3
+	if b.InSync() {
+		t.Fatal("expected reserved drift to be reported")
+	}
+}
+
+func TestVariantInventory_Availability(t *testing.T) {
+	limit := 3
+	tests := []struct {
+		name     string
+		inv      models.VariantInventory
+		expected string
+	}{
+		{"in stock", models.VariantInventory{Quantity: 5, ReservedQuantity: 2, BackorderPolicy: models.BackorderPolicyDeny}, models.AvailabilityInStock},
+		{"denied", models.VariantInventory{Quantity: 2, ReservedQuantity: 2, BackorderPolicy: models.BackorderPolicyDeny}, models.AvailabilityOutOfStock},
+		{"backorder uncapped", models.VariantInventory{BackorderPolicy: models.BackorderPolicyAllow}, models.AvailabilityBackorder},
+		{"backorder cap reached", models.VariantInventory{BackorderPolicy: models.BackorderPolicyAllow, BackorderLimit: &limit, BackorderedQuantity: 3}, models.AvailabilityOutOfStock},
+		{"preorder", models.VariantInventory{BackorderPolicy: models.BackorderPolicyPreorder, BackorderLimit: &limit, BackorderedQuantity: 1}, models.AvailabilityPreorder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.inv.Availability(); got != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, got)
+			}
+			if tt.inv.IsSellable() != (tt.expected != models.AvailabilityOutOfStock) {
+				t.Fatalf("IsSellable disagrees with availability %s", tt.expected)
+			}
+		})
+	}
+}
+
+func TestBundleAvailableQuantity(t *testing.T) {
+	components := []models.BundleComponent{
+		{ComponentVariantID: 1, Quantity: 2},
+		{ComponentVariantID: 2, Quantity: 1},
+	}
+
+	tests := []struct {
+		name     string
+		stock    map[int64]models.VariantInventory
+		expected int
+	}{
+		{"limited by pairs", map[int64]models.VariantInventory{1: {Quantity: 7}, 2: {Quantity: 10}}, 3},
+		{"limited by reservations", map[int64]models.VariantInventory{1: {Quantity: 10}, 2: {Quantity: 5, ReservedQuantity: 4}}, 1},
+		{"missing component", map[int64]models.VariantInventory{1: {Quantity: 10}}, 0},
+		{"oversold component", map[int64]models.VariantInventory{1: {Quantity: 1, ReservedQuantity: 3}, 2: {Quantity: 5}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := models.BundleAvailableQuantity(components, tt.stock); got != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+
+	if got := models.BundleAvailableQuantity(nil, map[int64]models.VariantInventory{}); got != 0 {
+		t.Fatalf("expected bundle without components to be unavailable, got %d", got)
+	}
+}
+
+func TestParseInventoryImport(t *testing.T) {
+	sheet := [][]string{
+		{"SKU", "Quantity", "Delta", "Reason", "Notes"},
+		{"SKU-001", "12", "", "", ""},
+		{"SKU-002", "", "-3", "sale", "Shop floor"},
+		{"", "", "", "", ""},
+		{"SKU-003", "4", "2", "", ""},
+		{"SKU-004", "abc", "", "", ""},
+		{"SKU-005", "1", "", "stolen", ""},
+	}
+
+	rows, err := parseInventoryImport(sheet, "restock", "Monthly count")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows (blank line skipped), got %d", len(rows))
+	}
+
+	if rows[0].Status != importRowOK || *rows[0].quantity != 12 || rows[0].Reason != "restock" || rows[0].Notes != "Monthly count" {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Status != importRowOK || *rows[1].delta != -3 || rows[1].Reason != "sale" {
+		t.Fatalf("unexpected delta row: %+v", rows[1])
+	}
+	if importTargetQuantity(rows[1], 10) != 7 {
+		t.Fatalf("expected delta to apply to current stock")
+	}
+	for _, row := range rows[2:] {
+		if row.Status != importRowError {
+			t.Fatalf("expected row %d to fail, got %+v", row.Row, row)
+		}
+	}
+	if rows[2].Row != 5 {
+		t.Fatalf("expected file row numbers to be kept, got %d", rows[2].Row)
+	}
+
+	if _, err := parseInventoryImport([][]string{{"sku", "price"}}, "restock", ""); err == nil {
+		t.Fatal("expected error without quantity or delta column")
+	}
+}
+
+func TestSuggestReorderQuantity(t *testing.T) {
+	tests := []struct {
+		name                                                    string
+		sold, lookback, cover, available, threshold, backorders int
+		expected                                                int
+	}{
+		{"no sales above threshold", 0, 30, 30, 10, 5, 0, 0},
+		{"no sales at threshold", 0, 30, 30, 5, 5, 0, 0},
+		{"covers velocity and threshold", 60, 30, 14, 4, 5, 0, 29},
+		{"includes backorders", 0, 30, 30, 0, 5, 3, 8},
+		{"overstocked", 30, 30, 10, 100, 5, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestReorderQuantity(tt.sold, tt.lookback, tt.cover, tt.available, tt.threshold, tt.backorders)
+			if got != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeSerials(t *testing.T) {
+	serials, err := normalizeSerials([]string{" SN-1 ", "SN-2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(serials) != 2 || serials[0] != "SN-1" || serials[1] != "SN-2" {
+		t.Fatalf("expected trimmed serials, got %v", serials)
+	}
+
+	if _, err := normalizeSerials([]string{"SN-1", "SN-1 "}); err == nil {
+		t.Fatal("expected duplicate serials to be rejected")
+	}
+	if _, err := normalizeSerials([]string{"SN-1", " "}); err == nil {
+		t.Fatal("expected blank serials to be rejected")
+	}
+}
+
+func TestAvailabilityProjection_CachesAndInvalidates(t *testing.T) {
+	db := setupTestDB(t)
+
+	inv := models.VariantInventory{ID: 1, ProductVariantID: 1, StoreFrontID: 1, Quantity: 5, LowStockThreshold: 5}
+	db.Create(&inv)
+
+	projection := NewAvailabilityProjection(services.NewMemoryCache(), time.Minute)
+	repo := NewRepository(db).WithAvailability(projection)
+
+	stock, err := projection.Get(db, 1, []int64{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stock[1].Quantity; got != 5 {
+		t.Errorf("expected quantity 5, got %d", got)
+	}
+	if missing := stock[2]; missing.IsSellable() {
+		t.Error("variant without inventory should not be sellable")
+	}
+
+	// Writes that bypass the repository are not seen until invalidated
+	db.Model(&models.VariantInventory{}).Where("id = ?", inv.ID).Update("quantity", 8)
+	stock, _ = projection.Get(db, 1, []int64{1})
+	if got := stock[1].Quantity; got != 5 {
+		t.Errorf("expected cached quantity 5, got %d", got)
+	}
+
+	if err := repo.UpdateStock(db, &inv, 8, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stock, _ = projection.Get(db, 1, []int64{1})
+	if got := stock[1].Quantity; got != 8 {
+		t.Errorf("expected quantity 8 after write, got %d", got)
+	}
+	if got := stock[1].ReservedQuantity; got != 3 {
+		t.Errorf("expected 3 reserved after write, got %d", got)
+	}
+}
+
+func TestAvailabilityProjection_InvalidatesAfterCommit(t *testing.T) {
+	db := setupTestDB(t)
+
+	inv := models.VariantInventory{ID: 1, ProductVariantID: 1, StoreFrontID: 1, Quantity: 5, LowStockThreshold: 5}
+	db.Create(&inv)
+
+	cache := services.NewMemoryCache()
+	projection := NewAvailabilityProjection(cache, time.Minute)
+	repo := NewRepository(db).WithAvailability(projection)
+	projection.Get(db, 1, []int64{1})
+
+	cached := func() bool {
+		found, _ := cache.GetMany(context.Background(), []string{availabilityKey(1, 1)})
+		return len(found) == 1
+	}
+
+	err := repo.Transaction(db, func(tx *gorm.DB) error {
+		if err := repo.UpdateStock(tx, &inv, 8, 0); err != nil {
+			return err
+		}
+		if !cached() {
+			t.Error("availability invalidated before the commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cached() {
+		t.Error("availability still cached after the commit")
+	}
+
+	projection.Get(db, 1, []int64{1})
+	repo.Transaction(db, func(tx *gorm.DB) error {
+		repo.UpdateStock(tx, &inv, 9, 0)
+		return errors.New("rolled back")
+	})
+	if !cached() {
+		t.Error("availability invalidated by a rolled back transaction")
+	}
+}
+
+func TestAvailabilityProjection_RefreshesInStock(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.Create(&models.Product{ID: 1, NameEn: "Mug", ProductType: models.ProductTypeSimple})
+	db.Create(&models.Product{ID: 2, NameEn: "Gift Box", ProductType: models.ProductTypeBundle})
+	db.Create(&[]models.ProductStorefront{
+		{ProductID: 1, StoreFrontID: 1}, {ProductID: 1, StoreFrontID: 2}, {ProductID: 2, StoreFrontID: 1},
+	})
+	db.Create(&models.ProductVariant{ID: 1, ProductID: 1, SKU: "MUG-1", IsActive: true})
+	db.Create(&models.ProductVariant{ID: 2, ProductID: 2, SKU: "BOX-1", IsActive: true})
+	db.Create(&models.BundleComponent{ID: 1, BundleVariantID: 2, ComponentVariantID: 1, Quantity: 2})
+	inv := models.VariantInventory{ID: 1, ProductVariantID: 1, StoreFrontID: 1, LowStockThreshold: 5}
+	db.Create(&inv)
+
+	repo := NewRepository(db).WithAvailability(NewAvailabilityProjection(services.NewMemoryCache(), time.Minute))
+	inStock := func(productID, storeFrontID int64) bool {
+		var row models.ProductStorefront
+		db.Where("product_id = ? AND store_front_id = ?", productID, storeFrontID).First(&row)
+		return row.InStock
+	}
+
+	err := repo.Transaction(db, func(tx *gorm.DB) error {
+		return repo.UpdateStock(tx, &inv, 1, 0)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inStock(1, 1) {
+		t.Error("expected the product to be in stock in store 1")
+	}
+	if inStock(1, 2) {
+		t.Error("expected the product to stay out of stock in store 2")
+	}
+	if inStock(2, 1) {
+		t.Error("expected the bundle to be out of stock with one component unit")
+	}
+
+	if err := repo.UpdateStock(db, &inv, 2, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inStock(2, 1) {
+		t.Error("expected the bundle to be in stock once its components make one up")
+	}
+}
+
+func TestMergeHoldItems(t *testing.T) {
+	merged := mergeHoldItems([]requests.StockHoldItemRequest{
+		{ProductVariantID: 9, Quantity: 1},
+		{ProductVariantID: 3, Quantity: 2},
+		{ProductVariantID: 9, Quantity: 4},
+	})
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(merged))
+	}
+	if merged[0].ProductVariantID != 3 || merged[0].Quantity != 2 {
+		t.Errorf("unexpected first item %+v", merged[0])
+	}
+	if merged[1].ProductVariantID != 9 || merged[1].Quantity != 5 {
+		t.Errorf("unexpected second item %+v", merged[1])
+	}
+
+	hold := &models.StockHold{Items: []models.StockHoldItem{
+		{ProductVariantID: 9, Quantity: 5},
+		{ProductVariantID: 3, Quantity: 2},
+	}}
+	if !sameHoldItems(hold, merged) {
+		t.Error("expected hold to match the merged items")
+	}
+	hold.Items[0].Quantity = 4
+	if sameHoldItems(hold, merged) {
+		t.Error("expected hold with a different quantity not to match")
+	}
+}
+
+func TestHold_ReleasesRecordedBundleComponents(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.BundleComponent{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	// SQLite only numbers INTEGER keys, the holds are created by the service
+	for _, ddl := range []string{
+		`CREATE TABLE stock_holds (id INTEGER PRIMARY KEY, store_front_id INTEGER, reference TEXT, channel TEXT, status TEXT,
+			expires_at DATETIME, converted_at DATETIME, released_at DATETIME, created_by INTEGER, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE stock_hold_items (id INTEGER PRIMARY KEY, stock_hold_id INTEGER, product_variant_id INTEGER, sku TEXT, quantity INTEGER)`,
+		`CREATE TABLE stock_hold_item_components (id INTEGER PRIMARY KEY, stock_hold_item_id INTEGER, product_variant_id INTEGER,
+			sku TEXT, quantity_per_bundle INTEGER, quantity INTEGER)`,
+	} {
+		if err := db.Exec(ddl).Error; err != nil {
+			t.Fatalf("failed to create hold tables: %v", err)
+		}
+	}
+
+	db.Create(&models.StoreFront{ID: 1, Name: "Test", Slug: "test", Domain: "test.com", Currency: "SAR", DefaultLanguage: "ar", IsActive: true})
+	db.Create(&models.ProductVariant{ID: 1, ProductID: 1, SKU: "BUNDLE", IsActive: true})
+	db.Create(&models.ProductVariant{ID: 2, ProductID: 2, SKU: "PART-A", IsActive: true})
+	db.Create(&models.ProductVariant{ID: 3, ProductID: 3, SKU: "PART-B", IsActive: true, TrackingMode: models.TrackingModeSerial})
+	db.Create(&models.VariantInventory{ID: 1, ProductVariantID: 2, StoreFrontID: 1, Quantity: 10})
+	db.Create(&models.VariantInventory{ID: 2, ProductVariantID: 3, StoreFrontID: 1, Quantity: 10})
+	db.Create(&models.BundleComponent{ID: 1, BundleVariantID: 1, ComponentVariantID: 2, Quantity: 2})
+
+	service := NewService(db, NewRepository(db))
+	req := requests.CreateStockHoldRequest{
+		StoreFrontID: 1,
+		Reference:    "pos-1",
+		Channel:      "pos",
+		TTLSeconds:   600,
+		Items:        []requests.StockHoldItemRequest{{ProductVariantID: 1, Quantity: 3}},
+	}
+	if res := service.CreateHold(req, 1); res.GetStatusCode() != 201 {
+		t.Fatalf("expected status 201, got %d: %s", res.GetStatusCode(), res.GetMessage())
+	}
+
+	reserved := func(variantID int64) int {
+		var inv models.VariantInventory
+		db.Where("product_variant_id = ? AND store_front_id = ?", variantID, 1).First(&inv)
+		return inv.ReservedQuantity
+	}
+	if got := reserved(2); got != 6 {
+		t.Fatalf("expected 6 reserved, got %d", got)
+	}
+
+	// The bundle is rebuilt while the hold is active
+	db.Where("bundle_variant_id = ?", 1).Delete(&models.BundleComponent{})
+	db.Create(&models.BundleComponent{ID: 2, BundleVariantID: 1, ComponentVariantID: 3, Quantity: 1})
+
+	if res := service.ReleaseHold(1, "pos-1", 1); res.GetStatusCode() != 200 {
+		t.Fatalf("expected status 200, got %d: %s", res.GetStatusCode(), res.GetMessage())
+	}
+	if got := reserved(2); got != 0 {
+		t.Errorf("expected the recorded component released, %d still reserved", got)
+	}
+	if got := reserved(3); got != 0 {
+		t.Errorf("expected the new component untouched, got %d reserved", got)
+	}
+
+	// Bundles of tracked components cannot be held
+	req.Reference = "pos-2"
+	if res := service.CreateHold(req, 1); res.GetStatusCode() != 400 {
+		t.Errorf("expected status 400 for a tracked component, got %d", res.GetStatusCode())
+	}
+}
@@ -0,0 +1,140 @@
+package inventory
+
+import (
+	"fmt"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// MovementSource identifies the document responsible for a stock movement
+type MovementSource struct {
+	Type        string
+	ID          *int64
+	PerformedBy *int64
+	Notes       string
+}
+
+// OrderMovementSource builds the ledger source for order driven movements
+func OrderMovementSource(orderID int64) MovementSource {
+	return MovementSource{Type: models.MovementSourceOrder, ID: &orderID}
+}
+
+// NewMovement builds a ledger entry for moving inv to the given quantity and reserved quantity
+func NewMovement(inv *models.VariantInventory, newQty, newReserved int, movementType string, source MovementSource) *models.InventoryMovement {
+	return &models.InventoryMovement{
+		VariantInventoryID: inv.ID,
+		ProductVariantID:   inv.ProductVariantID,
+		StoreFrontID:       inv.StoreFrontID,
+		MovementType:       movementType,
+		SourceType:         source.Type,
+		SourceID:           source.ID,
+		QuantityBefore:     inv.Quantity,
+		QuantityAfter:      newQty,
+		QuantityChange:     newQty - inv.Quantity,
+		ReservedBefore:     inv.ReservedQuantity,
+		ReservedAfter:      newReserved,
+		ReservedChange:     newReserved - inv.ReservedQuantity,
+		PerformedBy:        source.PerformedBy,
+		Notes:              source.Notes,
+	}
+}
+
+// updateStockWithMovement writes the new stock levels of a locked row and appends the matching ledger entry
+func updateStockWithMovement(tx *gorm.DB, repo *Repository, locked *models.VariantInventory, newQty, newReserved int, movementType string, source MovementSource) error {
+	if err := repo.UpdateStock(tx, locked, newQty, newReserved); err != nil {
+		return err
+	}
+	if err := repo.CreateMovement(tx, NewMovement(locked, newQty, newReserved, movementType, source)); err != nil {
+		return fmt.Errorf("failed to record inventory movement: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetMovementHistory(inventoryID int64, pagination *utils.Pagination) utils.IResource {
+	items, total, err := s.repo.ListMovements(inventoryID, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve inventory movements", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Inventory movements retrieved successfully", items, pagination.GetMeta())
+}
+
+// RebuildFromLedger replays the movement ledger of a store and compares it with the stored stock.
+// When apply is true, rows that drifted are reset to the ledger totals.
+func (s *Service) RebuildFromLedger(storeFrontID int64, apply bool, adminID int64) utils.IResource {
+	balances, err := s.repo.ListLedgerBalances(storeFrontID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to read inventory ledger", err)
+	}
+
+	mismatches := make([]LedgerBalance, 0)
+	for _, b := range balances {
+		if !b.InSync() {
+			mismatches = append(mismatches, b)
+		}
+	}
+
+	applied := 0
+	if apply && len(mismatches) > 0 {
+		err = s.repo.Transaction(s.db, func(tx *gorm.DB) error {
+			repo := s.repo.withTx(tx)
+			for _, m := range mismatches {
+				if m.LedgerQuantity < 0 || m.LedgerReserved < 0 {
+					continue // Ledger itself is inconsistent, needs manual review
+				}
+
+				locked, err := repo.LockInventory(tx, m.VariantInventoryID)
+				if err != nil {
+					return err
+				}
+				if err := repo.UpdateStock(tx, locked, m.LedgerQuantity, m.LedgerReserved); err != nil {
+					return err
+				}
+
+				// Rebuild entries document the correction but carry no change,
+				// so the ledger totals stay authoritative
+				movement := NewMovement(locked, m.LedgerQuantity, m.LedgerReserved, models.MovementTypeRebuild, MovementSource{
+					Type:        models.MovementSourceSystem,
+					PerformedBy: &adminID,
+					Notes:       "Stock reset to ledger totals",
+				})
+				movement.QuantityChange = 0
+				movement.ReservedChange = 0
+				if err := repo.CreateMovement(tx, movement); err != nil {
+					return err
+				}
+				applied++
+			}
+			return nil
+		})
+		if err != nil {
+			return utils.NewInternalErrorResource("Failed to rebuild inventory from ledger", err)
+		}
+	}
+
+	return utils.NewOKResource("Inventory ledger verified", map[string]interface{}{
+		"store_front_id": storeFrontID,
+		"checked":        len(balances),
+		"mismatched":     len(mismatches),
+		"applied":        applied,
+		"mismatches":     mismatches,
+	})
+}
+
+// adjustmentMovementSource links an adjustment to its source document, defaulting to the adjustment itself
+func adjustmentMovementSource(adj *models.InventoryAdjustment, sourceType string, sourceID *int64) MovementSource {
+	source := MovementSource{
+		Type:        models.MovementSourceAdjustment,
+		ID:          &adj.ID,
+		PerformedBy: &adj.AdjustedBy,
+		Notes:       adj.Reason,
+	}
+	if sourceType != "" {
+		source.Type = sourceType
+		source.ID = sourceID
+	}
+	return source
+}
@@ -0,0 +1,240 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/services"
+	"github.com/onas/ecommerce-api/internal/utils"
+)
+
+// ReorderSuggestion is a suggested purchase quantity for one variant
+type ReorderSuggestion struct {
+	VariantInventoryID int64    `json:"variant_inventory_id"`
+	ProductVariantID   int64    `json:"product_variant_id"`
+	SKU                string   `json:"sku"`
+	ProductName        string   `json:"product_name"`
+	AvailableQuantity  int      `json:"available_quantity"`
+	LowStockThreshold  int      `json:"low_stock_threshold"`
+	UnitsSold          int      `json:"units_sold"`
+	DailyVelocity      float64  `json:"daily_velocity"`
+	SuggestedQuantity  int      `json:"suggested_quantity"`
+	UnitCost           *float64 `json:"unit_cost"`
+}
+
+// PurchaseList is a draft purchase list for a single supplier
+type PurchaseList struct {
+	SupplierID    *int64              `json:"supplier_id"` // nil for products without a supplier
+	SupplierName  string              `json:"supplier_name"`
+	TotalUnits    int                 `json:"total_units"`
+	EstimatedCost float64             `json:"estimated_cost"`
+	Items         []ReorderSuggestion `json:"items"`
+}
+
+// ReorderParams controls how reorder quantities are computed
+type ReorderParams struct {
+	LookbackDays int // Sales window used for velocity
+	CoverDays    int // Days of sales the reorder should cover
+}
+
+// suggestReorderQuantity returns the units needed to cover coverDays of sales at the
+// recent velocity, on top of the low-stock threshold and outstanding backorders
+func suggestReorderQuantity(unitsSold, lookbackDays, coverDays, available, threshold, backordered int) int {
+	if lookbackDays <= 0 {
+		lookbackDays = 1
+	}
+	velocity := float64(unitsSold) / float64(lookbackDays)
+	target := int(math.Ceil(velocity*float64(coverDays))) + threshold + backordered
+	return max(target-available, 0)
+}
+
+// BuildPurchaseLists computes reorder suggestions for a store grouped by supplier
+func (s *Service) BuildPurchaseLists(storeFrontID int64, params ReorderParams) ([]PurchaseList, error) {
+	since := time.Now().AddDate(0, 0, -params.LookbackDays)
+	candidates, err := s.repo.ListReorderCandidates(storeFrontID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make(map[int64]*PurchaseList) // keyed by supplier, 0 for unassigned
+	for _, c := range candidates {
+		available := c.Quantity - c.ReservedQuantity
+		suggested := suggestReorderQuantity(c.UnitsSold, params.LookbackDays, params.CoverDays, available, c.LowStockThreshold, c.BackorderedQuantity)
+		if suggested == 0 {
+			continue
+		}
+
+		key := int64(0)
+		if c.SupplierID != nil {
+			key = *c.SupplierID
+		}
+		list, ok := lists[key]
+		if !ok {
+			list = &PurchaseList{SupplierID: c.SupplierID, SupplierName: "Unassigned"}
+			if c.SupplierName != nil {
+				list.SupplierName = *c.SupplierName
+			}
+			lists[key] = list
+		}
+
+		list.Items = append(list.Items, ReorderSuggestion{
+			VariantInventoryID: c.VariantInventoryID,
+			ProductVariantID:   c.ProductVariantID,
+			SKU:                c.SKU,
+			ProductName:        c.ProductName,
+			AvailableQuantity:  available,
+			LowStockThreshold:  c.LowStockThreshold,
+			UnitsSold:          c.UnitsSold,
+			DailyVelocity:      math.Round(float64(c.UnitsSold)/float64(max(params.LookbackDays, 1))*100) / 100,
+			SuggestedQuantity:  suggested,
+			UnitCost:           c.CostPrice,
+		})
+		list.TotalUnits += suggested
+		if c.CostPrice != nil {
+			list.EstimatedCost += *c.CostPrice * float64(suggested)
+		}
+	}
+
+	result := make([]PurchaseList, 0, len(lists))
+	for _, list := range lists {
+		result = append(result, *list)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SupplierName < result[j].SupplierName })
+	return result, nil
+}
+
+func (s *Service) GetReorderSuggestions(storeFrontID int64, params ReorderParams) utils.IResource {
+	lists, err := s.BuildPurchaseLists(storeFrontID, params)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to compute reorder suggestions", err)
+	}
+
+	return utils.NewOKResource("Reorder suggestions retrieved successfully", map[string]interface{}{
+		"store_front_id": storeFrontID,
+		"lookback_days":  params.LookbackDays,
+		"cover_days":     params.CoverDays,
+		"purchase_lists": lists,
+	})
+}
+
+// LowStockCheckResult summarises one run of the low-stock check
+type LowStockCheckResult struct {
+	Crossed   []LowStockRow `json:"crossed"`
+	Recovered int64         `json:"recovered"`
+	Notified  int           `json:"notified"`
+}
+
+// CheckLowStock notifies admins with inventory.view about rows that crossed their
+// threshold since the last run, then marks them so they alert only once per crossing
+func (s *Service) CheckLowStock(ctx context.Context, notifier services.Notifier, params ReorderParams) (*LowStockCheckResult, error) {
+	result := &LowStockCheckResult{}
+
+	recovered, err := s.repo.ResetRecoveredLowStock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset recovered stock: %w", err)
+	}
+	result.Recovered = recovered
+
+	crossed, err := s.repo.ListNewLowStock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect low stock: %w", err)
+	}
+	result.Crossed = crossed
+	if len(crossed) == 0 {
+		return result, nil
+	}
+
+	recipients, err := s.repo.ListAdminEmailsWithPermission("inventory.view")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipients: %w", err)
+	}
+
+	// Attach the purchase lists of each affected store
+	purchaseLists := make(map[int64][]PurchaseList)
+	for _, row := range crossed {
+		if _, done := purchaseLists[row.StoreFrontID]; done {
+			continue
+		}
+		lists, err := s.BuildPurchaseLists(row.StoreFrontID, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute reorder suggestions: %w", err)
+		}
+		purchaseLists[row.StoreFrontID] = lists
+	}
+
+	var body strings.Builder
+	body.WriteString("The following items reached their low-stock threshold:\n\n")
+	for _, row := range crossed {
+		fmt.Fprintf(&body, "- [%s] %s (%s): %d available, threshold %d\n", row.StoreFrontName, row.ProductName, row.SKU, row.AvailableQuantity, row.LowStockThreshold)
+	}
+
+	err = notifier.Notify(ctx, services.Notification{
+		Event:      "inventory.low_stock",
+		Subject:    fmt.Sprintf("Low stock: %d item(s) need attention", len(crossed)),
+		Body:       body.String(),
+		Recipients: recipients,
+		Payload: map[string]interface{}{
+			"items":          crossed,
+			"purchase_lists": purchaseLists,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send low stock notification: %w", err)
+	}
+
+	ids := make([]int64, 0, len(crossed))
+	for _, row := range crossed {
+		ids = append(ids, row.VariantInventoryID)
+	}
+	if err := s.repo.MarkLowStockNotified(ids, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to mark notified stock: %w", err)
+	}
+	result.Notified = len(recipients)
+
+	return result, nil
+}
+
+// LowStockJob runs CheckLowStock on a fixed interval
+type LowStockJob struct {
+	service  *Service
+	notifier services.Notifier
+	interval time.Duration
+	params   ReorderParams
+}
+
+func NewLowStockJob(service *Service, notifier services.Notifier, interval time.Duration, params ReorderParams) *LowStockJob {
+	return &LowStockJob{service: service, notifier: notifier, interval: interval, params: params}
+}
+
+// Start runs the job in the background until ctx is cancelled
+func (j *LowStockJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (j *LowStockJob) run(ctx context.Context) {
+	result, err := j.service.CheckLowStock(ctx, j.notifier, j.params)
+	if err != nil {
+		log.Printf("⚠️  Low stock check failed: %v", err)
+		return
+	}
+	if len(result.Crossed) > 0 {
+		log.Printf("📦 Low stock check: %d new alert(s), %d recovered", len(result.Crossed), result.Recovered)
+	}
+}
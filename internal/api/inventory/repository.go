@@ -12,28 +12,87 @@ import (
 
 // VariantInventoryItem is the DTO for listing inventory
 type VariantInventoryItem struct {
-	ID                int64    `json:"id"`
-	ProductVariantID  int64    `json:"product_variant_id"`
-	StoreFrontID      int64    `json:"store_front_id"`
-	Quantity          int      `json:"quantity"`
-	ReservedQuantity  int      `json:"reserved_quantity"`
-	AvailableQuantity int      `json:"available_quantity"`
-	LowStockThreshold int      `json:"low_stock_threshold"`
-	IsLowStock        bool     `json:"is_low_stock"`
-	SKU               string   `json:"sku"`
-	ProductName       string   `json:"product_name"`
-	AttributeValue    string   `json:"attribute_value"`
-	Price             *float64 `json:"price"`
+	ID                  int64      `json:"id"`
+	ProductVariantID    int64      `json:"product_variant_id"`
+	StoreFrontID        int64      `json:"store_front_id"`
+	Quantity            int        `json:"quantity"`
+	ReservedQuantity    int        `json:"reserved_quantity"`
+	AvailableQuantity   int        `json:"available_quantity"`
+	LowStockThreshold   int        `json:"low_stock_threshold"`
+	IsLowStock          bool       `json:"is_low_stock"`
+	BackorderPolicy     string     `json:"backorder_policy"`
+	BackorderLimit      *int       `json:"backorder_limit"`
+	BackorderedQuantity int        `json:"backordered_quantity"`
+	AvailableOn         *time.Time `json:"available_on"`
+	SKU                 string     `json:"sku"`
+	Barcode             *string    `json:"barcode"`
+	ProductName         string     `json:"product_name"`
+	AttributeValue      string     `json:"attribute_value"`
+	Price               *float64   `json:"price"`
+}
+
+// BackorderedLine is an order line still waiting for stock
+type BackorderedLine struct {
+	OrderItemID         int64     `json:"order_item_id"`
+	OrderID             int64     `json:"order_id"`
+	OrderNumber         string    `json:"order_number"`
+	ProductVariantID    int64     `json:"product_variant_id"`
+	SKU                 string    `json:"sku"`
+	Quantity            int       `json:"quantity"`
+	BackorderedQuantity int       `json:"backordered_quantity"`
+	OrderedAt           time.Time `json:"ordered_at"`
+}
+
+// LedgerBalance compares a stored stock level with the one rebuilt from the ledger
+type LedgerBalance struct {
+	VariantInventoryID int64  `json:"variant_inventory_id"`
+	ProductVariantID   int64  `json:"product_variant_id"`
+	StoreFrontID       int64  `json:"store_front_id"`
+	SKU                string `json:"sku"`
+	Quantity           int    `json:"quantity"`
+	ReservedQuantity   int    `json:"reserved_quantity"`
+	LedgerQuantity     int    `json:"ledger_quantity"`
+	LedgerReserved     int    `json:"ledger_reserved"`
+	MovementCount      int64  `json:"movement_count"`
+}
+
+// InSync reports whether the stored stock matches the ledger totals
+func (b LedgerBalance) InSync() bool {
+	return b.Quantity == b.LedgerQuantity && b.ReservedQuantity == b.LedgerReserved
 }
 
 type Repository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	availability *AvailabilityProjection
 }
 
 func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// WithAvailability makes the repository invalidate the availability projection on stock writes
+func (r *Repository) WithAvailability(availability *AvailabilityProjection) *Repository {
+	r.availability = availability
+	return r
+}
+
+// withTx returns a repository bound to tx that keeps invalidating the projection
+func (r *Repository) withTx(tx *gorm.DB) *Repository {
+	return &Repository{db: tx, availability: r.availability}
+}
+
+// Transaction runs fn in a transaction on db, invalidating the availability of the stock
+// it writes once it commits
+func (r *Repository) Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return r.availability.Transaction(db, fn)
+}
+
+// InvalidateAvailability drops the cached availability of variants written outside this
+// repository once tx commits
+func (r *Repository) InvalidateAvailability(tx *gorm.DB, storeFrontID int64, variantIDs ...int64) error {
+	return r.availability.InvalidateAfterCommit(tx, storeFrontID, variantIDs...)
+}
+
 func (r *Repository) GetVariantInventory(variantID, storeFrontID int64) (*models.VariantInventory, error) {
 	var inv models.VariantInventory
 	err := r.db.Where("product_variant_id = ? AND store_front_id = ?", variantID, storeFrontID).First(&inv).Error
@@ -65,26 +124,37 @@ func (r *Repository) EnsureInventoryRecord(tx *gorm.DB, variantID, storeFrontID
 	if err := tx.Create(&inv).Error; err != nil {
 		return nil, err
 	}
+	if err := r.availability.InvalidateAfterCommit(tx, storeFrontID, variantID); err != nil {
+		return nil, err
+	}
 	return &inv, nil
 }
 
-func (r *Repository) AdjustInventory(tx *gorm.DB, inventoryID int64, newQuantity int) error {
-	return tx.Model(&models.VariantInventory{}).
-		Where("id = ?", inventoryID).
+func (r *Repository) AdjustInventory(tx *gorm.DB, inv *models.VariantInventory, newQuantity int) error {
+	err := tx.Model(&models.VariantInventory{}).
+		Where("id = ?", inv.ID).
 		Updates(map[string]interface{}{
 			"quantity":   newQuantity,
 			"updated_at": time.Now(),
 		}).Error
+	if err != nil {
+		return err
+	}
+	return r.availability.InvalidateAfterCommit(tx, inv.StoreFrontID, inv.ProductVariantID)
 }
 
-func (r *Repository) UpdateStock(tx *gorm.DB, inventoryID int64, quantity, reservedQuantity int) error {
-	return tx.Model(&models.VariantInventory{}).
-		Where("id = ?", inventoryID).
+func (r *Repository) UpdateStock(tx *gorm.DB, inv *models.VariantInventory, quantity, reservedQuantity int) error {
+	err := tx.Model(&models.VariantInventory{}).
+		Where("id = ?", inv.ID).
 		Updates(map[string]interface{}{
 			"quantity":          quantity,
 			"reserved_quantity": reservedQuantity,
 			"updated_at":        time.Now(),
 		}).Error
+	if err != nil {
+		return err
+	}
+	return r.availability.InvalidateAfterCommit(tx, inv.StoreFrontID, inv.ProductVariantID)
 }
 
 func (r *Repository) LockInventory(tx *gorm.DB, inventoryID int64) (*models.VariantInventory, error) {
@@ -98,13 +168,145 @@ func (r *Repository) LockInventory(tx *gorm.DB, inventoryID int64) (*models.Vari
 	return &inv, nil
 }
 
+func (r *Repository) UpdateBackorderPolicy(inv *models.VariantInventory, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	if err := r.db.Model(&models.VariantInventory{}).Where("id = ?", inv.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	return r.availability.InvalidateAfterCommit(r.db, inv.StoreFrontID, inv.ProductVariantID)
+}
+
+func (r *Repository) SetBackorderedQuantity(tx *gorm.DB, inv *models.VariantInventory, backordered int) error {
+	err := tx.Model(&models.VariantInventory{}).
+		Where("id = ?", inv.ID).
+		Updates(map[string]interface{}{
+			"backordered_quantity": backordered,
+			"updated_at":           time.Now(),
+		}).Error
+	if err != nil {
+		return err
+	}
+	return r.availability.InvalidateAfterCommit(tx, inv.StoreFrontID, inv.ProductVariantID)
+}
+
+// LockBackorderedItems returns the open order lines waiting for a variant in a store, oldest first
+func (r *Repository) LockBackorderedItems(tx *gorm.DB, variantID, storeFrontID int64) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := tx.Table("order_items oi").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Joins("JOIN order_statuses os ON os.id = o.order_status_id").
+		Where("oi.product_variant_id = ? AND o.store_front_id = ? AND oi.backordered_quantity > 0", variantID, storeFrontID).
+		Where("o.deleted_at IS NULL AND os.slug <> 'cancelled'").
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "oi"}}).
+		Select("oi.*").
+		Order("oi.id ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) SetItemBackorderedQuantity(tx *gorm.DB, orderItemID int64, backordered int) error {
+	return tx.Model(&models.OrderItem{}).Where("id = ?", orderItemID).Update("backordered_quantity", backordered).Error
+}
+
+func (r *Repository) ListBackorderedLines(storeFrontID int64, pagination *utils.Pagination) ([]BackorderedLine, int64, error) {
+	query := r.db.Table("order_items oi").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Joins("JOIN order_statuses os ON os.id = o.order_status_id").
+		Where("o.store_front_id = ? AND oi.backordered_quantity > 0", storeFrontID).
+		Where("o.deleted_at IS NULL AND os.slug <> 'cancelled'")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var lines []BackorderedLine
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Select(`
+			oi.id as order_item_id, oi.order_id, o.order_number, oi.product_variant_id, oi.sku,
+			oi.quantity, oi.backordered_quantity, o.created_at as ordered_at
+		`).
+		Order("oi.id ASC").
+		Offset(offset).Limit(pagination.Limit).
+		Scan(&lines).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return lines, total, nil
+}
+
+// ListBundleComponents returns the components of a bundle variant ordered by
+// variant ID, so rows are always locked in the same order
+func (r *Repository) ListBundleComponents(tx *gorm.DB, bundleVariantID int64) ([]models.BundleComponent, error) {
+	var components []models.BundleComponent
+	err := tx.Preload("ComponentVariant").
+		Where("bundle_variant_id = ?", bundleVariantID).
+		Order("component_variant_id ASC").
+		Find(&components).Error
+	return components, err
+}
+
 func (r *Repository) CreateAdjustment(tx *gorm.DB, adj *models.InventoryAdjustment) error {
 	return tx.Create(adj).Error
 }
 
+func (r *Repository) CreateMovement(tx *gorm.DB, movement *models.InventoryMovement) error {
+	return tx.Create(movement).Error
+}
+
+// inventoryListColumns are the columns selected into VariantInventoryItem
+const inventoryListColumns = `
+			vi.id, vi.product_variant_id, vi.store_front_id,
+			vi.quantity, vi.reserved_quantity, vi.low_stock_threshold,
+			vi.backorder_policy, vi.backorder_limit, vi.backordered_quantity, vi.available_on,
+			(vi.quantity - vi.reserved_quantity) as available_quantity,
+			CASE WHEN (vi.quantity - vi.reserved_quantity) <= vi.low_stock_threshold THEN true ELSE false END as is_low_stock,
+			pv.sku, pv.barcode, p.name_en as product_name, pv.attribute_value, pv.price
+		`
+
 func (r *Repository) ListInventory(filter requests.InventoryFilterRequest, pagination *utils.Pagination) ([]VariantInventoryItem, int64, error) {
 	var total int64
+	query := r.inventoryListQuery(filter)
 
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Select and Paging
+	var items []VariantInventoryItem
+	offset := (pagination.Page - 1) * pagination.Limit
+
+	err := query.Select(inventoryListColumns).
+		Order("(vi.quantity - vi.reserved_quantity) ASC").
+		Offset(offset).Limit(pagination.Limit).
+		Scan(&items).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// ListAllInventory returns every row matching the filter, ordered by SKU, for exports
+func (r *Repository) ListAllInventory(filter requests.InventoryFilterRequest) ([]VariantInventoryItem, error) {
+	var items []VariantInventoryItem
+	err := r.inventoryListQuery(filter).
+		Select(inventoryListColumns).
+		Order("pv.sku ASC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) inventoryListQuery(filter requests.InventoryFilterRequest) *gorm.DB {
 	// Base query
 	query := r.db.Table("variant_inventory vi").
 		Joins("JOIN product_variants pv ON pv.id = vi.product_variant_id").
@@ -138,43 +340,52 @@ func (r *Repository) ListInventory(filter requests.InventoryFilterRequest, pagin
 		query = query.Where("(p.name_en ILIKE ? OR p.name_ar ILIKE ? OR pv.sku ILIKE ?)", search, search, search)
 	}
 
-	// Count total
-	if err := query.Count(&total).Error; err != nil {
+	return query
+}
+
+// FindVariantsBySKU returns variants keyed by SKU
+func (r *Repository) FindVariantsBySKU(skus []string) (map[string]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := r.db.Where("sku IN ?", skus).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+
+	bySKU := make(map[string]models.ProductVariant, len(variants))
+	for _, v := range variants {
+		bySKU[v.SKU] = v
+	}
+	return bySKU, nil
+}
+
+func (r *Repository) ListAdjustmentHistory(inventoryID int64, pagination *utils.Pagination) ([]models.InventoryAdjustment, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.InventoryAdjustment{}).Where("variant_inventory_id = ?", inventoryID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Select and Paging
-	var items []VariantInventoryItem
+	var list []models.InventoryAdjustment
 	offset := (pagination.Page - 1) * pagination.Limit
-
-	err := query.Select(`
-			vi.id, vi.product_variant_id, vi.store_front_id,
-			vi.quantity, vi.reserved_quantity, vi.low_stock_threshold,
-			(vi.quantity - vi.reserved_quantity) as available_quantity,
-			CASE WHEN (vi.quantity - vi.reserved_quantity) <= vi.low_stock_threshold THEN true ELSE false END as is_low_stock,
-			pv.sku, p.name_en as product_name, pv.attribute_value, pv.price
-		`).
-		Order("(vi.quantity - vi.reserved_quantity) ASC").
+	err := r.db.Where("variant_inventory_id = ?", inventoryID).
+		Order("created_at DESC").
 		Offset(offset).Limit(pagination.Limit).
-		Scan(&items).Error
-
+		Find(&list).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	return items, total, nil
+	return list, total, nil
 }
 
-func (r *Repository) ListAdjustmentHistory(inventoryID int64, pagination *utils.Pagination) ([]models.InventoryAdjustment, int64, error) {
+func (r *Repository) ListMovements(inventoryID int64, pagination *utils.Pagination) ([]models.InventoryMovement, int64, error) {
 	var total int64
-	if err := r.db.Model(&models.InventoryAdjustment{}).Where("variant_inventory_id = ?", inventoryID).Count(&total).Error; err != nil {
+	if err := r.db.Model(&models.InventoryMovement{}).Where("variant_inventory_id = ?", inventoryID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	var list []models.InventoryAdjustment
+	var list []models.InventoryMovement
 	offset := (pagination.Page - 1) * pagination.Limit
 	err := r.db.Where("variant_inventory_id = ?", inventoryID).
-		Order("created_at DESC").
+		Order("id DESC").
 		Offset(offset).Limit(pagination.Limit).
 		Find(&list).Error
 	if err != nil {
@@ -184,6 +395,30 @@ func (r *Repository) ListAdjustmentHistory(inventoryID int64, pagination *utils.
 	return list, total, nil
 }
 
+// ListLedgerBalances returns the current stock of every inventory row in a store
+// next to the totals replayed from its movement ledger
+func (r *Repository) ListLedgerBalances(storeFrontID int64) ([]LedgerBalance, error) {
+	var balances []LedgerBalance
+	err := r.db.Table("variant_inventory vi").
+		Joins("JOIN product_variants pv ON pv.id = vi.product_variant_id").
+		Joins("LEFT JOIN inventory_movements im ON im.variant_inventory_id = vi.id").
+		Where("vi.store_front_id = ?", storeFrontID).
+		Select(`
+			vi.id as variant_inventory_id, vi.product_variant_id, vi.store_front_id, pv.sku,
+			vi.quantity, vi.reserved_quantity,
+			COALESCE(SUM(im.quantity_change), 0) as ledger_quantity,
+			COALESCE(SUM(im.reserved_change), 0) as ledger_reserved,
+			COUNT(im.id) as movement_count
+		`).
+		Group("vi.id, vi.product_variant_id, vi.store_front_id, pv.sku, vi.quantity, vi.reserved_quantity").
+		Order("vi.id ASC").
+		Scan(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
 // HasInventoryForProduct checks if any variant of a product has inventory in any store
 func (r *Repository) HasInventoryForProduct(productID int64) (bool, error) {
 	var count int64
@@ -196,3 +431,376 @@ func (r *Repository) HasInventoryForProduct(productID int64) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// ListInventoryByVariants returns the inventory rows of the given variants in a store, keyed by variant
+func (r *Repository) ListInventoryByVariants(storeFrontID int64, variantIDs []int64) (map[int64]models.VariantInventory, error) {
+	var rows []models.VariantInventory
+	if err := r.db.Where("store_front_id = ? AND product_variant_id IN ?", storeFrontID, variantIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	byVariant := make(map[int64]models.VariantInventory, len(rows))
+	for _, inv := range rows {
+		byVariant[inv.ProductVariantID] = inv
+	}
+	return byVariant, nil
+}
+
+// LowStockRow is an inventory row at or below its threshold
+type LowStockRow struct {
+	VariantInventoryID int64  `json:"variant_inventory_id"`
+	ProductVariantID   int64  `json:"product_variant_id"`
+	StoreFrontID       int64  `json:"store_front_id"`
+	StoreFrontName     string `json:"store_front_name"`
+	SKU                string `json:"sku"`
+	ProductName        string `json:"product_name"`
+	AvailableQuantity  int    `json:"available_quantity"`
+	LowStockThreshold  int    `json:"low_stock_threshold"`
+}
+
+// ReorderCandidate carries the stock and recent sales of a variant in a store
+type ReorderCandidate struct {
+	VariantInventoryID  int64    `json:"variant_inventory_id"`
+	ProductVariantID    int64    `json:"product_variant_id"`
+	SKU                 string   `json:"sku"`
+	ProductName         string   `json:"product_name"`
+	SupplierID          *int64   `json:"supplier_id"`
+	SupplierName        *string  `json:"supplier_name"`
+	CostPrice           *float64 `json:"cost_price"`
+	Quantity            int      `json:"quantity"`
+	ReservedQuantity    int      `json:"reserved_quantity"`
+	BackorderedQuantity int      `json:"backordered_quantity"`
+	LowStockThreshold   int      `json:"low_stock_threshold"`
+	UnitsSold           int      `json:"units_sold"`
+}
+
+// ListNewLowStock returns low-stock rows that have not been alerted yet
+func (r *Repository) ListNewLowStock() ([]LowStockRow, error) {
+	var rows []LowStockRow
+	err := r.db.Table("variant_inventory vi").
+		Joins("JOIN product_variants pv ON pv.id = vi.product_variant_id").
+		Joins("JOIN products p ON p.id = pv.product_id").
+		Joins("JOIN store_fronts sf ON sf.id = vi.store_front_id").
+		Where("pv.deleted_at IS NULL AND p.deleted_at IS NULL AND pv.is_active = true").
+		Where("(vi.quantity - vi.reserved_quantity) <= vi.low_stock_threshold AND vi.low_stock_notified_at IS NULL").
+		Select(`
+			vi.id as variant_inventory_id, vi.product_variant_id, vi.store_front_id, sf.name as store_front_name,
+			pv.sku, p.name_en as product_name,
+			(vi.quantity - vi.reserved_quantity) as available_quantity, vi.low_stock_threshold
+		`).
+		Order("vi.store_front_id ASC, (vi.quantity - vi.reserved_quantity) ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *Repository) MarkLowStockNotified(inventoryIDs []int64, at time.Time) error {
+	return r.db.Model(&models.VariantInventory{}).
+		Where("id IN ?", inventoryIDs).
+		Update("low_stock_notified_at", at).Error
+}
+
+// ResetRecoveredLowStock clears the alert marker of rows back above their threshold
+func (r *Repository) ResetRecoveredLowStock() (int64, error) {
+	result := r.db.Model(&models.VariantInventory{}).
+		Where("low_stock_notified_at IS NOT NULL AND (quantity - reserved_quantity) > low_stock_threshold").
+		Update("low_stock_notified_at", nil)
+	return result.RowsAffected, result.Error
+}
+
+// ListAdminEmailsWithPermission returns the emails of active admins whose role grants permission
+func (r *Repository) ListAdminEmailsWithPermission(permission string) ([]string, error) {
+	var emails []string
+	err := r.db.Table("admins a").
+		Joins("JOIN role_permissions rp ON rp.role_id = a.role_id").
+		Joins("JOIN permissions perm ON perm.id = rp.permission_id").
+		Where("perm.name = ? AND a.is_active = true AND a.deleted_at IS NULL", permission).
+		Distinct().
+		Pluck("a.email", &emails).Error
+	if err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
+
+// ListReorderCandidates returns every inventory row of a store with the units sold since the given time
+func (r *Repository) ListReorderCandidates(storeFrontID int64, since time.Time) ([]ReorderCandidate, error) {
+	// Only placed orders count as sales, drafts may never be confirmed
+	sold := r.db.Table("order_items oi").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Joins("JOIN order_statuses os ON os.id = o.order_status_id").
+		Where("o.store_front_id = ? AND o.created_at >= ? AND o.deleted_at IS NULL AND os.slug IN ('confirmed', 'fulfilled', 'completed')", storeFrontID, since).
+		Select("oi.product_variant_id, SUM(oi.quantity) as units").
+		Group("oi.product_variant_id")
+
+	var rows []ReorderCandidate
+	err := r.db.Table("variant_inventory vi").
+		Joins("JOIN product_variants pv ON pv.id = vi.product_variant_id").
+		Joins("JOIN products p ON p.id = pv.product_id").
+		Joins("LEFT JOIN suppliers s ON s.id = p.supplier_id").
+		Joins("LEFT JOIN (?) sold ON sold.product_variant_id = vi.product_variant_id", sold).
+		Where("vi.store_front_id = ? AND pv.deleted_at IS NULL AND p.deleted_at IS NULL AND pv.is_active = true", storeFrontID).
+		Select(`
+			vi.id as variant_inventory_id, vi.product_variant_id, pv.sku, p.name_en as product_name,
+			p.supplier_id, s.company_name as supplier_name, pv.cost_price,
+			vi.quantity, vi.reserved_quantity, vi.backordered_quantity, vi.low_stock_threshold,
+			COALESCE(sold.units, 0) as units_sold
+		`).
+		Order("pv.sku ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ============== Serial & lot tracking ==============
+
+// SerialTrace links a serial number to where it came from and the order it went to
+type SerialTrace struct {
+	ID               int64      `json:"id"`
+	Serial           string     `json:"serial"`
+	Status           string     `json:"status"`
+	ProductVariantID int64      `json:"product_variant_id"`
+	SKU              string     `json:"sku"`
+	ProductName      string     `json:"product_name"`
+	StoreFrontID     int64      `json:"store_front_id"`
+	StoreFrontName   string     `json:"store_front_name"`
+	ReceivedAt       time.Time  `json:"received_at"`
+	SoldAt           *time.Time `json:"sold_at"`
+	OrderItemID      *int64     `json:"order_item_id"`
+	OrderID          *int64     `json:"order_id"`
+	OrderNumber      *string    `json:"order_number"`
+	CustomerName     *string    `json:"customer_name"`
+}
+
+// LotShipment is an order line that was fulfilled from a lot
+type LotShipment struct {
+	StockLotID   int64     `json:"stock_lot_id"`
+	OrderItemID  int64     `json:"order_item_id"`
+	OrderID      int64     `json:"order_id"`
+	OrderNumber  string    `json:"order_number"`
+	CustomerName string    `json:"customer_name"`
+	Quantity     int       `json:"quantity"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (r *Repository) GetTrackingMode(tx *gorm.DB, variantID int64) (string, error) {
+	var variant models.ProductVariant
+	if err := tx.Select("id, tracking_mode").First(&variant, variantID).Error; err != nil {
+		return "", err
+	}
+	if variant.TrackingMode == "" {
+		return models.TrackingModeNone, nil
+	}
+	return variant.TrackingMode, nil
+}
+
+// LockSerials locks the given serial numbers of a variant
+func (r *Repository) LockSerials(tx *gorm.DB, variantID int64, serials []string) ([]models.SerialNumber, error) {
+	var rows []models.SerialNumber
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_variant_id = ? AND serial IN ?", variantID, serials).
+		Order("id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) CreateSerials(tx *gorm.DB, serials []models.SerialNumber) error {
+	return tx.Create(&serials).Error
+}
+
+func (r *Repository) UpdateSerials(tx *gorm.DB, ids []int64, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return tx.Model(&models.SerialNumber{}).Where("id IN ?", ids).Updates(updates).Error
+}
+
+func (r *Repository) ListItemSerials(tx *gorm.DB, orderItemID int64) ([]models.SerialNumber, error) {
+	var rows []models.SerialNumber
+	err := tx.Where("order_item_id = ?", orderItemID).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// LockLot locks a lot by number, returning gorm.ErrRecordNotFound if it does not exist
+func (r *Repository) LockLot(tx *gorm.DB, variantID, storeFrontID int64, lotNumber string) (*models.StockLot, error) {
+	var lot models.StockLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_variant_id = ? AND store_front_id = ? AND lot_number = ?", variantID, storeFrontID, lotNumber).
+		First(&lot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *Repository) LockLotByID(tx *gorm.DB, lotID int64) (*models.StockLot, error) {
+	var lot models.StockLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lot, lotID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *Repository) CreateLot(tx *gorm.DB, lot *models.StockLot) error {
+	return tx.Create(lot).Error
+}
+
+func (r *Repository) UpdateLot(tx *gorm.DB, lotID int64, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return tx.Model(&models.StockLot{}).Where("id = ?", lotID).Updates(updates).Error
+}
+
+func (r *Repository) ListSerials(variantID, storeFrontID int64, status string, pagination *utils.Pagination) ([]models.SerialNumber, int64, error) {
+	query := r.db.Model(&models.SerialNumber{}).
+		Where("product_variant_id = ? AND store_front_id = ?", variantID, storeFrontID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.SerialNumber
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Order("received_at ASC, id ASC").Offset(offset).Limit(pagination.Limit).Find(&rows).Error
+	return rows, total, err
+}
+
+func (r *Repository) ListLots(variantID, storeFrontID int64) ([]models.StockLot, error) {
+	var rows []models.StockLot
+	err := r.db.Where("product_variant_id = ? AND store_front_id = ?", variantID, storeFrontID).
+		Order("received_at ASC, id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// TraceSerial finds every unit with a serial number, with the order it was assigned to
+func (r *Repository) TraceSerial(serial string) ([]SerialTrace, error) {
+	var rows []SerialTrace
+	err := r.db.Table("serial_numbers sn").
+		Joins("JOIN product_variants pv ON pv.id = sn.product_variant_id").
+		Joins("JOIN products p ON p.id = pv.product_id").
+		Joins("JOIN store_fronts sf ON sf.id = sn.store_front_id").
+		Joins("LEFT JOIN order_items oi ON oi.id = sn.order_item_id").
+		Joins("LEFT JOIN orders o ON o.id = oi.order_id").
+		Where("sn.serial = ?", serial).
+		Select(`
+			sn.id, sn.serial, sn.status, sn.product_variant_id, pv.sku,
+			COALESCE(NULLIF(p.name_en, ''), p.name) as product_name,
+			sn.store_front_id, sf.name as store_front_name,
+			sn.received_at, sn.sold_at, sn.order_item_id,
+			o.id as order_id, o.order_number, o.customer_name
+		`).
+		Order("sn.id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *Repository) FindLotsByNumber(lotNumber string) ([]models.StockLot, error) {
+	var rows []models.StockLot
+	err := r.db.Where("lot_number = ?", lotNumber).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// ListLotShipments returns the order lines fulfilled from the given lots
+func (r *Repository) ListLotShipments(lotIDs []int64) ([]LotShipment, error) {
+	var rows []LotShipment
+	if len(lotIDs) == 0 {
+		return rows, nil
+	}
+	err := r.db.Table("order_items oi").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Where("oi.stock_lot_id IN ?", lotIDs).
+		Select("oi.stock_lot_id, oi.id as order_item_id, o.id as order_id, o.order_number, o.customer_name, oi.quantity, o.created_at").
+		Order("o.created_at ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ============== Stock holds ==============
+
+func (r *Repository) FindHold(storeFrontID int64, reference string) (*models.StockHold, error) {
+	var hold models.StockHold
+	err := r.db.Preload("Items.Components").
+		Where("store_front_id = ? AND reference = ?", storeFrontID, reference).
+		First(&hold).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// LockHold loads a hold, its items and their components with the hold row locked for update
+func (r *Repository) LockHold(tx *gorm.DB, storeFrontID int64, reference string) (*models.StockHold, error) {
+	var hold models.StockHold
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("store_front_id = ? AND reference = ?", storeFrontID, reference).
+		First(&hold).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Preload("Components", func(db *gorm.DB) *gorm.DB { return db.Order("product_variant_id ASC") }).
+		Where("stock_hold_id = ?", hold.ID).
+		Order("product_variant_id ASC").
+		Find(&hold.Items).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *Repository) CreateHold(tx *gorm.DB, hold *models.StockHold) error {
+	return tx.Create(hold).Error
+}
+
+func (r *Repository) UpdateHold(tx *gorm.DB, holdID int64, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return tx.Model(&models.StockHold{}).Where("id = ?", holdID).Updates(updates).Error
+}
+
+func (r *Repository) ListHolds(storeFrontID int64, status string, pagination *utils.Pagination) ([]models.StockHold, int64, error) {
+	query := r.db.Model(&models.StockHold{}).Where("store_front_id = ?", storeFrontID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var holds []models.StockHold
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Preload("Items.Components").Order("id DESC").Offset(offset).Limit(pagination.Limit).Find(&holds).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return holds, total, nil
+}
+
+// ListExpiredHolds returns active holds whose TTL ran out before now, oldest first
+func (r *Repository) ListExpiredHolds(now time.Time, limit int) ([]models.StockHold, error) {
+	var holds []models.StockHold
+	err := r.db.Where("status = ? AND expires_at <= ?", models.StockHoldStatusActive, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&holds).Error
+	return holds, err
+}
+
+func (r *Repository) FindVariantsByIDs(tx *gorm.DB, ids []int64) (map[int64]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := tx.Where("id IN ? AND deleted_at IS NULL", ids).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int64]models.ProductVariant, len(variants))
+	for _, v := range variants {
+		result[v.ID] = v
+	}
+	return result, nil
+}
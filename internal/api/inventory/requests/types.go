@@ -1,11 +1,20 @@
 package requests
 
+import "time"
+
 type AdjustInventoryRequest struct {
 	ProductVariantID int64  `json:"product_variant_id" binding:"required"`
 	StoreFrontID     int64  `json:"store_front_id" binding:"required"`
 	Adjustment       int    `json:"adjustment" binding:"required"` // Can be negative
 	Reason           string `json:"reason" binding:"required,oneof=restock correction sale return transfer"`
 	Notes            string `json:"notes"`
+	SourceType       string `json:"source_type" binding:"omitempty,oneof=transfer purchase_order stock_count"`
+	SourceID         *int64 `json:"source_id"`
+
+	// Tracked variants: the serials received or written off, or the lot moved
+	Serials      []string   `json:"serials"`
+	LotNumber    string     `json:"lot_number"`
+	LotExpiresAt *time.Time `json:"lot_expires_at"`
 }
 
 type BulkInventoryUpdateItem struct {
@@ -13,6 +22,8 @@ type BulkInventoryUpdateItem struct {
 	NewQuantity        int    `json:"new_quantity" binding:"required,min=0"`
 	Reason             string `json:"reason" binding:"required,oneof=restock correction sale return transfer"`
 	Notes              string `json:"notes"`
+	SourceType         string `json:"source_type" binding:"omitempty,oneof=transfer purchase_order stock_count"`
+	SourceID           *int64 `json:"source_id"`
 }
 
 type BulkInventoryUpdateRequest struct {
@@ -29,7 +40,44 @@ type InventoryFilterRequest struct {
 	Search       *string `form:"search"`
 }
 
-type ReserveStockRequest struct {
-	VariantID int64 `json:"variant_id" binding:"required"`
-	Quantity  int   `json:"quantity" binding:"required,min=1"`
+type StockHoldItemRequest struct {
+	ProductVariantID int64 `json:"product_variant_id" binding:"required"`
+	Quantity         int   `json:"quantity" binding:"required,min=1"`
+}
+
+type CreateStockHoldRequest struct {
+	StoreFrontID int64                  `json:"store_front_id" binding:"required"`
+	Reference    string                 `json:"reference" binding:"required,max=100"` // Client key, retries return the existing hold
+	Channel      string                 `json:"channel" binding:"required,max=50"`    // e.g. pos, marketplace
+	TTLSeconds   int                    `json:"ttl_seconds" binding:"required,min=30,max=604800"`
+	Items        []StockHoldItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ExtendStockHoldRequest struct {
+	TTLSeconds int `json:"ttl_seconds" binding:"required,min=30,max=604800"` // Added to the current expiry
+}
+
+type StockHoldFilterRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=active converted released expired"`
+}
+
+type UpdateBackorderPolicyRequest struct {
+	BackorderPolicy string     `json:"backorder_policy" binding:"required,oneof=deny allow_backorder preorder"`
+	BackorderLimit  *int       `json:"backorder_limit" binding:"omitempty,min=0"` // Omit for no cap
+	AvailableOn     *time.Time `json:"available_on"`                              // Required for preorder
+}
+
+type InventoryImportRequest struct {
+	DryRun *bool  `form:"dry_run"` // Defaults to true
+	Reason string `form:"reason" binding:"omitempty,oneof=restock correction sale return transfer"`
+	Notes  string `form:"notes"`
+}
+
+type ReorderSuggestionRequest struct {
+	LookbackDays int `form:"lookback_days" binding:"omitempty,min=1,max=365"` // Defaults to 30
+	CoverDays    int `form:"cover_days" binding:"omitempty,min=1,max=365"`    // Defaults to 30
+}
+
+type SerialFilterRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=in_stock allocated sold written_off"`
 }
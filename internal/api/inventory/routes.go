@@ -12,8 +12,32 @@ func RegisterRoutes(router *gin.RouterGroup, controller *Controller) {
 		adminRoutes.POST("/adjust", middleware.RequirePermission("inventory.adjust"), controller.AdjustInventory)
 		adminRoutes.POST("/bulk-update", middleware.RequirePermission("inventory.adjust"), controller.BulkUpdateInventory)
 		adminRoutes.GET("/store/:storeFrontId", middleware.RequirePermission("inventory.adjust"), controller.ListInventoryByStore)
+		adminRoutes.GET("/store/:storeFrontId/export", middleware.RequirePermission("inventory.view"), controller.ExportInventory)
+		adminRoutes.POST("/store/:storeFrontId/import", middleware.RequirePermission("inventory.adjust"), controller.ImportInventory)
 		adminRoutes.GET("/variant/:variantId/store/:storeFrontId", middleware.RequirePermission("inventory.adjust"), controller.GetVariantInventory)
+		adminRoutes.PUT("/variant/:variantId/store/:storeFrontId/backorder-policy", middleware.RequirePermission("inventory.adjust"), controller.UpdateBackorderPolicy)
+		adminRoutes.GET("/variant/:variantId/store/:storeFrontId/serials", middleware.RequirePermission("inventory.view"), controller.ListSerials)
+		adminRoutes.GET("/variant/:variantId/store/:storeFrontId/lots", middleware.RequirePermission("inventory.view"), controller.ListLots)
+		adminRoutes.GET("/backorders/:storeFrontId", middleware.RequirePermission("inventory.view"), controller.ListBackorders)
 		adminRoutes.GET("/low-stock/:storeFrontId", middleware.RequirePermission("inventory.adjust"), controller.GetLowStockAlerts)
+		adminRoutes.GET("/reorder-suggestions/:storeFrontId", middleware.RequirePermission("inventory.view"), controller.GetReorderSuggestions)
 		adminRoutes.GET("/:inventoryId/history", middleware.RequirePermission("inventory.adjust"), controller.GetAdjustmentHistory)
+		adminRoutes.GET("/:inventoryId/movements", middleware.RequirePermission("inventory.view"), controller.GetMovementHistory)
+
+		// Stock holds for external channels (POS, marketplaces)
+		adminRoutes.POST("/holds", middleware.RequirePermission("inventory.reserve"), controller.CreateHold)
+		adminRoutes.GET("/holds/:storeFrontId", middleware.RequirePermission("inventory.view"), controller.ListHolds)
+		adminRoutes.GET("/holds/:storeFrontId/:reference", middleware.RequirePermission("inventory.reserve"), controller.GetHold)
+		adminRoutes.POST("/holds/:storeFrontId/:reference/extend", middleware.RequirePermission("inventory.reserve"), controller.ExtendHold)
+		adminRoutes.POST("/holds/:storeFrontId/:reference/convert", middleware.RequirePermission("inventory.reserve"), controller.ConvertHold)
+		adminRoutes.POST("/holds/:storeFrontId/:reference/release", middleware.RequirePermission("inventory.reserve"), controller.ReleaseHold)
+
+		// Serial and lot traceability
+		adminRoutes.GET("/serials/:serial/trace", middleware.RequirePermission("inventory.view"), controller.TraceSerial)
+		adminRoutes.GET("/lots/:lotNumber/trace", middleware.RequirePermission("inventory.view"), controller.TraceLot)
+
+		// Ledger verification and rebuild
+		adminRoutes.GET("/ledger/verify/:storeFrontId", middleware.RequirePermission("inventory.view"), controller.VerifyLedger)
+		adminRoutes.POST("/ledger/rebuild/:storeFrontId", middleware.RequirePermission("inventory.adjust"), controller.RebuildFromLedger)
 	}
 }
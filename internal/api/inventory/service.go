@@ -18,13 +18,20 @@ func NewService(db *gorm.DB, repo *Repository) *Service {
 	return &Service{db: db, repo: repo}
 }
 
+// Transaction runs fn in a transaction whose stock writes reach the storefront
+// availability cache once it commits
+func (s *Service) Transaction(fn func(tx *gorm.DB) error) error {
+	return s.repo.Transaction(s.db, fn)
+}
+
 func (s *Service) AdjustInventory(req requests.AdjustInventoryRequest, adminID int64) utils.IResource {
 	var result *models.VariantInventory
 	var adjustment *models.InventoryAdjustment
+	allocated := 0
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.repo.Transaction(s.db, func(tx *gorm.DB) error {
 		// Ensure inventory record exists
-		invRepo := &Repository{db: tx}
+		invRepo := s.repo.withTx(tx)
 		inv, err := invRepo.EnsureInventoryRecord(tx, req.ProductVariantID, req.StoreFrontID)
 		if err != nil {
 			return fmt.Errorf("failed to ensure inventory record: %w", err)
@@ -42,8 +49,17 @@ func (s *Service) AdjustInventory(req requests.AdjustInventoryRequest, adminID i
 			return fmt.Errorf("adjustment would result in negative stock (current: %d, adjustment: %d)", locked.Quantity, req.Adjustment)
 		}
 
+		// Serial and lot tracked variants move named units
+		mode, err := invRepo.GetTrackingMode(tx, req.ProductVariantID)
+		if err != nil {
+			return fmt.Errorf("failed to load variant: %w", err)
+		}
+		if err := applyTrackedAdjustment(tx, invRepo, mode, req); err != nil {
+			return err
+		}
+
 		// Update quantity
-		if err := invRepo.AdjustInventory(tx, locked.ID, newQty); err != nil {
+		if err := invRepo.AdjustInventory(tx, locked, newQty); err != nil {
 			return fmt.Errorf("failed to update inventory: %w", err)
 		}
 
@@ -61,8 +77,23 @@ func (s *Service) AdjustInventory(req requests.AdjustInventoryRequest, adminID i
 			return fmt.Errorf("failed to create adjustment record: %w", err)
 		}
 
-		adjustment = adj
+		// Ledger entry
+		source := adjustmentMovementSource(adj, req.SourceType, req.SourceID)
+		if err := invRepo.CreateMovement(tx, NewMovement(locked, newQty, locked.ReservedQuantity, models.MovementTypeAdjustment, source)); err != nil {
+			return fmt.Errorf("failed to record inventory movement: %w", err)
+		}
+
+		// Incoming stock goes to waiting backorders first
 		locked.Quantity = newQty
+		if req.Adjustment > 0 {
+			n, err := allocateBackorders(tx, invRepo, locked, &adminID, 0)
+			if err != nil {
+				return err
+			}
+			allocated = n
+		}
+
+		adjustment = adj
 		result = locked
 
 		return nil
@@ -73,14 +104,17 @@ func (s *Service) AdjustInventory(req requests.AdjustInventoryRequest, adminID i
 	}
 
 	return utils.NewOKResource("Inventory adjusted successfully", map[string]interface{}{
-		"id":                  result.ID,
-		"product_variant_id":  result.ProductVariantID,
-		"store_front_id":      result.StoreFrontID,
-		"quantity":            result.Quantity,
-		"reserved_quantity":   result.ReservedQuantity,
-		"available_quantity":  result.AvailableQuantity(),
-		"low_stock_threshold": result.LowStockThreshold,
-		"is_low_stock":        result.IsLowStock(),
+		"id":                   result.ID,
+		"product_variant_id":   result.ProductVariantID,
+		"store_front_id":       result.StoreFrontID,
+		"quantity":             result.Quantity,
+		"reserved_quantity":    result.ReservedQuantity,
+		"available_quantity":   result.AvailableQuantity(),
+		"low_stock_threshold":  result.LowStockThreshold,
+		"is_low_stock":         result.IsLowStock(),
+		"backorder_policy":     result.BackorderPolicy,
+		"backordered_quantity": result.BackorderedQuantity,
+		"allocated_backorders": allocated,
 		"adjustment": map[string]interface{}{
 			"previous_quantity": adjustment.PreviousQuantity,
 			"new_quantity":      adjustment.NewQuantity,
@@ -91,20 +125,33 @@ func (s *Service) AdjustInventory(req requests.AdjustInventoryRequest, adminID i
 }
 
 func (s *Service) GetVariantInventory(variantID, storeFrontID int64) utils.IResource {
+	components, err := s.repo.ListBundleComponents(s.db, variantID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve inventory", err)
+	}
+	if len(components) > 0 {
+		return s.getBundleInventory(variantID, storeFrontID, components)
+	}
+
 	inv, err := s.repo.GetVariantInventory(variantID, storeFrontID)
 	if err != nil {
 		return utils.NewNotFoundResource("Inventory not found", nil)
 	}
 
 	return utils.NewOKResource("Inventory retrieved successfully", map[string]interface{}{
-		"id":                  inv.ID,
-		"product_variant_id":  inv.ProductVariantID,
-		"store_front_id":      inv.StoreFrontID,
-		"quantity":            inv.Quantity,
-		"reserved_quantity":   inv.ReservedQuantity,
-		"available_quantity":  inv.AvailableQuantity(),
-		"low_stock_threshold": inv.LowStockThreshold,
-		"is_low_stock":        inv.IsLowStock(),
+		"id":                   inv.ID,
+		"product_variant_id":   inv.ProductVariantID,
+		"store_front_id":       inv.StoreFrontID,
+		"quantity":             inv.Quantity,
+		"reserved_quantity":    inv.ReservedQuantity,
+		"available_quantity":   inv.AvailableQuantity(),
+		"low_stock_threshold":  inv.LowStockThreshold,
+		"is_low_stock":         inv.IsLowStock(),
+		"backorder_policy":     inv.BackorderPolicy,
+		"backorder_limit":      inv.BackorderLimit,
+		"backordered_quantity": inv.BackorderedQuantity,
+		"available_on":         inv.AvailableOn,
+		"is_sellable":          inv.IsSellable(),
 	})
 }
 
@@ -119,8 +166,8 @@ func (s *Service) ListInventory(filter requests.InventoryFilterRequest, paginati
 }
 
 func (s *Service) BulkAdjustInventory(req requests.BulkInventoryUpdateRequest, adminID int64) utils.IResource {
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		repo := &Repository{db: tx}
+	err := s.repo.Transaction(s.db, func(tx *gorm.DB) error {
+		repo := s.repo.withTx(tx)
 
 		for _, item := range req.Items {
 			// Lock inventory
@@ -129,33 +176,7 @@ func (s *Service) BulkAdjustInventory(req requests.BulkInventoryUpdateRequest, a
 				return err
 			}
 
-			// Validate
-			if item.NewQuantity < 0 {
-				return fmt.Errorf("negative quantity not allowed for inventory ID %d", item.VariantInventoryID)
-			}
-
-			// Calculate adjustment amount (New - Old)
-			adjustmentAmount := item.NewQuantity - locked.Quantity
-			if adjustmentAmount == 0 {
-				continue // No change
-			}
-
-			// Update
-			if err := repo.AdjustInventory(tx, locked.ID, item.NewQuantity); err != nil {
-				return err
-			}
-
-			// Audit
-			adj := &models.InventoryAdjustment{
-				VariantInventoryID: locked.ID,
-				AdjustedBy:         adminID,
-				PreviousQuantity:   locked.Quantity,
-				NewQuantity:        item.NewQuantity,
-				AdjustmentAmount:   adjustmentAmount,
-				Reason:             item.Reason,
-				Notes:              item.Notes,
-			}
-			if err := repo.CreateAdjustment(tx, adj); err != nil {
+			if err := applyBulkItem(tx, repo, locked, item, adminID); err != nil {
 				return err
 			}
 		}
@@ -169,16 +190,61 @@ func (s *Service) BulkAdjustInventory(req requests.BulkInventoryUpdateRequest, a
 	return utils.NewOKResource("Bulk inventory update successful", nil)
 }
 
-func (s *Service) ReserveStock(req requests.ReserveStockRequest) utils.IResource {
-	// Note: StoreFrontID is needed for reservation. Assuming StoreFront context or default for now.
-	// However, the current requirements don't strictly define Order context yet.
-	// For API completeness per requirements, I'll assume we pass generic reserve logic.
-	// But Wait, ReserveStock needs to know WHICH StoreFront.
-	// I will omit this for now as "Stock Reservation Logic (For Future Orders)" is a requirement
-	// but strictly depends on Order logic which I noted is out of scope.
-	// I will implement a generic placeholder that respects the requirement "Cannot reserve more than AvailableQuantity".
+// applyBulkItem sets a locked inventory row to the item's new quantity with
+// its audit record and ledger entry, then allocates any waiting backorders
+func applyBulkItem(tx *gorm.DB, repo *Repository, locked *models.VariantInventory, item requests.BulkInventoryUpdateItem, adminID int64) error {
+	// Validate
+	if item.NewQuantity < 0 {
+		return fmt.Errorf("negative quantity not allowed for inventory ID %d", item.VariantInventoryID)
+	}
 
-	return utils.NewOKResource("Stock reserved (Placeholder)", nil)
+	// Calculate adjustment amount (New - Old)
+	adjustmentAmount := item.NewQuantity - locked.Quantity
+	if adjustmentAmount == 0 {
+		return nil // No change
+	}
+
+	// Tracked variants need their serials or lot, which a bulk row cannot carry
+	mode, err := repo.GetTrackingMode(tx, locked.ProductVariantID)
+	if err != nil {
+		return err
+	}
+	if mode != models.TrackingModeNone {
+		return fmt.Errorf("variant %d is %s-tracked and must be adjusted individually", locked.ProductVariantID, mode)
+	}
+
+	// Update
+	if err := repo.AdjustInventory(tx, locked, item.NewQuantity); err != nil {
+		return err
+	}
+
+	// Audit
+	adj := &models.InventoryAdjustment{
+		VariantInventoryID: locked.ID,
+		AdjustedBy:         adminID,
+		PreviousQuantity:   locked.Quantity,
+		NewQuantity:        item.NewQuantity,
+		AdjustmentAmount:   adjustmentAmount,
+		Reason:             item.Reason,
+		Notes:              item.Notes,
+	}
+	if err := repo.CreateAdjustment(tx, adj); err != nil {
+		return err
+	}
+
+	// Ledger
+	source := adjustmentMovementSource(adj, item.SourceType, item.SourceID)
+	if err := repo.CreateMovement(tx, NewMovement(locked, item.NewQuantity, locked.ReservedQuantity, models.MovementTypeAdjustment, source)); err != nil {
+		return err
+	}
+
+	if adjustmentAmount > 0 {
+		locked.Quantity = item.NewQuantity
+		if _, err := allocateBackorders(tx, repo, locked, &adminID, 0); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *Service) GetAdjustmentHistory(inventoryID int64, pagination *utils.Pagination) utils.IResource {
@@ -191,35 +257,115 @@ func (s *Service) GetAdjustmentHistory(inventoryID int64, pagination *utils.Pagi
 	return utils.NewPaginatedOKResource("Adjustment history retrieved successfully", items, pagination.GetMeta())
 }
 
-// ReserveStockWithTx reserves stock for an order within a transaction
-func (s *Service) ReserveStockWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int) error {
-	repo := &Repository{db: tx}
+// ReserveStockWithTx reserves stock for an order within a transaction.
+// When the variant allows backorders, the part that cannot be reserved is
+// backordered instead and its quantity is returned. Bundles reserve their
+// components and are never backordered.
+func (s *Service) ReserveStockWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int, source MovementSource) (int, error) {
+	components, err := s.ListBundleComponentsWithTx(tx, variantID)
+	if err != nil {
+		return 0, err
+	}
+	if len(components) > 0 {
+		for _, c := range components {
+			if err := s.ReserveAvailableStockWithTx(tx, c.ComponentVariantID, storeFrontID, quantity*c.Quantity, source); err != nil {
+				return 0, fmt.Errorf("bundle component %d: %w", c.ComponentVariantID, err)
+			}
+		}
+		return 0, nil
+	}
 
-	inv, err := repo.EnsureInventoryRecord(tx, variantID, storeFrontID)
+	return s.reserveVariantStock(tx, variantID, storeFrontID, quantity, true, source)
+}
+
+// ReserveAvailableStockWithTx reserves stock without ever backordering, as bundle
+// components need
+func (s *Service) ReserveAvailableStockWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int, source MovementSource) error {
+	_, err := s.reserveVariantStock(tx, variantID, storeFrontID, quantity, false, source)
+	return err
+}
+
+// ConfirmStockDeductionWithTx confirms stock deduction (moves from reserved to deducted)
+func (s *Service) ConfirmStockDeductionWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int, source MovementSource) error {
+	components, err := s.ListBundleComponentsWithTx(tx, variantID)
 	if err != nil {
 		return err
 	}
+	if len(components) > 0 {
+		for _, c := range components {
+			if err := s.confirmVariantStock(tx, c.ComponentVariantID, storeFrontID, quantity*c.Quantity, source); err != nil {
+				return fmt.Errorf("bundle component %d: %w", c.ComponentVariantID, err)
+			}
+		}
+		return nil
+	}
 
-	locked, err := repo.LockInventory(tx, inv.ID)
+	return s.confirmVariantStock(tx, variantID, storeFrontID, quantity, source)
+}
+
+// ReleaseReservedStockWithTx releases reserved stock (cancels reservation)
+func (s *Service) ReleaseReservedStockWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int, source MovementSource) error {
+	components, err := s.ListBundleComponentsWithTx(tx, variantID)
 	if err != nil {
 		return err
 	}
+	if len(components) > 0 {
+		for _, c := range components {
+			if err := s.releaseVariantStock(tx, c.ComponentVariantID, storeFrontID, quantity*c.Quantity, source); err != nil {
+				return fmt.Errorf("bundle component %d: %w", c.ComponentVariantID, err)
+			}
+		}
+		return nil
+	}
+
+	return s.releaseVariantStock(tx, variantID, storeFrontID, quantity, source)
+}
+
+func (s *Service) reserveVariantStock(tx *gorm.DB, variantID, storeFrontID int64, quantity int, allowBackorder bool, source MovementSource) (int, error) {
+	repo := s.repo.withTx(tx)
 
-	if locked.AvailableQuantity() < quantity {
-		return fmt.Errorf("insufficient stock for variant %d: requested %d, available %d", variantID, quantity, locked.AvailableQuantity())
+	inv, err := repo.EnsureInventoryRecord(tx, variantID, storeFrontID)
+	if err != nil {
+		return 0, err
 	}
 
-	newReserved := locked.ReservedQuantity + quantity
-	if err := repo.UpdateStock(tx, locked.ID, locked.Quantity, newReserved); err != nil {
-		return err
+	locked, err := repo.LockInventory(tx, inv.ID)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	available := max(locked.AvailableQuantity(), 0)
+	backordered := 0
+	if available < quantity {
+		shortfall := quantity - available
+		capacity := locked.BackorderCapacity()
+		if !allowBackorder || !locked.AllowsBackorder() {
+			return 0, fmt.Errorf("insufficient stock for variant %d: requested %d, available %d", variantID, quantity, locked.AvailableQuantity())
+		}
+		if capacity >= 0 && shortfall > capacity {
+			return 0, fmt.Errorf("backorder limit reached for variant %d: requested %d, available %d, backorder capacity %d", variantID, quantity, available, capacity)
+		}
+		backordered = shortfall
+	}
+
+	if backordered > 0 {
+		if err := repo.SetBackorderedQuantity(tx, locked, locked.BackorderedQuantity+backordered); err != nil {
+			return 0, err
+		}
+	}
+
+	if reserve := quantity - backordered; reserve > 0 {
+		newReserved := locked.ReservedQuantity + reserve
+		if err := updateStockWithMovement(tx, repo, locked, locked.Quantity, newReserved, models.MovementTypeReserve, source); err != nil {
+			return 0, err
+		}
+	}
+
+	return backordered, nil
 }
 
-// ConfirmStockDeductionWithTx confirms stock deduction (moves from reserved to deducted)
-func (s *Service) ConfirmStockDeductionWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int) error {
-	repo := &Repository{db: tx}
+func (s *Service) confirmVariantStock(tx *gorm.DB, variantID, storeFrontID int64, quantity int, source MovementSource) error {
+	repo := s.repo.withTx(tx)
 
 	inv, err := repo.GetVariantInventory(variantID, storeFrontID)
 	if err != nil {
@@ -238,16 +384,11 @@ func (s *Service) ConfirmStockDeductionWithTx(tx *gorm.DB, variantID, storeFront
 		return fmt.Errorf("stock inconsistency: qty %d, reserved %d, deducting %d", locked.Quantity, locked.ReservedQuantity, quantity)
 	}
 
-	if err := repo.UpdateStock(tx, locked.ID, newQty, newReserved); err != nil {
-		return err
-	}
-
-	return nil
+	return updateStockWithMovement(tx, repo, locked, newQty, newReserved, models.MovementTypeSale, source)
 }
 
-// ReleaseReservedStockWithTx releases reserved stock (cancels reservation)
-func (s *Service) ReleaseReservedStockWithTx(tx *gorm.DB, variantID, storeFrontID int64, quantity int) error {
-	repo := &Repository{db: tx}
+func (s *Service) releaseVariantStock(tx *gorm.DB, variantID, storeFrontID int64, quantity int, source MovementSource) error {
+	repo := s.repo.withTx(tx)
 
 	inv, err := repo.GetVariantInventory(variantID, storeFrontID)
 	if err != nil {
@@ -264,9 +405,16 @@ func (s *Service) ReleaseReservedStockWithTx(tx *gorm.DB, variantID, storeFrontI
 		newReserved = 0
 	}
 
-	if err := repo.UpdateStock(tx, locked.ID, locked.Quantity, newReserved); err != nil {
+	if err := updateStockWithMovement(tx, repo, locked, locked.Quantity, newReserved, models.MovementTypeRelease, source); err != nil {
 		return err
 	}
 
-	return nil
+	// Released stock goes to waiting backorders first, of other orders than the releasing one
+	var skipOrderID int64
+	if source.Type == models.MovementSourceOrder && source.ID != nil {
+		skipOrderID = *source.ID
+	}
+	locked.ReservedQuantity = newReserved
+	_, err = allocateBackorders(tx, repo, locked, source.PerformedBy, skipOrderID)
+	return err
 }
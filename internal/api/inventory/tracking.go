@@ -0,0 +1,367 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// normalizeSerials trims serial numbers and rejects blanks and duplicates
+func normalizeSerials(serials []string) ([]string, error) {
+	seen := make(map[string]bool, len(serials))
+	result := make([]string, 0, len(serials))
+	for _, s := range serials {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, fmt.Errorf("serial numbers cannot be blank")
+		}
+		if seen[s] {
+			return nil, fmt.Errorf("serial %s is listed more than once", s)
+		}
+		seen[s] = true
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// applyTrackedAdjustment records the serials or lot moved by an adjustment of a
+// tracked variant. Incoming stock is received under new serials or into a lot,
+// outgoing stock writes off in-stock serials or takes units out of a lot.
+func applyTrackedAdjustment(tx *gorm.DB, repo *Repository, mode string, req requests.AdjustInventoryRequest) error {
+	switch mode {
+	case models.TrackingModeSerial:
+		return adjustSerials(tx, repo, req)
+	case models.TrackingModeLot:
+		return adjustLot(tx, repo, req)
+	default:
+		if len(req.Serials) > 0 || req.LotNumber != "" {
+			return fmt.Errorf("variant %d is not serial or lot tracked", req.ProductVariantID)
+		}
+		return nil
+	}
+}
+
+func adjustSerials(tx *gorm.DB, repo *Repository, req requests.AdjustInventoryRequest) error {
+	serials, err := normalizeSerials(req.Serials)
+	if err != nil {
+		return err
+	}
+	units := max(req.Adjustment, -req.Adjustment)
+	if len(serials) != units {
+		return fmt.Errorf("serial-tracked variant needs exactly %d serial numbers, got %d", units, len(serials))
+	}
+
+	existing, err := repo.LockSerials(tx, req.ProductVariantID, serials)
+	if err != nil {
+		return err
+	}
+
+	if req.Adjustment > 0 {
+		if len(existing) > 0 {
+			return fmt.Errorf("serial %s is already registered for this variant", existing[0].Serial)
+		}
+		now := time.Now()
+		rows := make([]models.SerialNumber, 0, len(serials))
+		for _, serial := range serials {
+			rows = append(rows, models.SerialNumber{
+				ProductVariantID: req.ProductVariantID,
+				StoreFrontID:     req.StoreFrontID,
+				Serial:           serial,
+				Status:           models.SerialStatusInStock,
+				ReceivedAt:       now,
+			})
+		}
+		return repo.CreateSerials(tx, rows)
+	}
+
+	// Write-off: every serial must be on the shelf of this store
+	found := make(map[string]models.SerialNumber, len(existing))
+	for _, sn := range existing {
+		found[sn.Serial] = sn
+	}
+	ids := make([]int64, 0, len(serials))
+	for _, serial := range serials {
+		sn, ok := found[serial]
+		if !ok {
+			return fmt.Errorf("serial %s not found", serial)
+		}
+		if sn.StoreFrontID != req.StoreFrontID || sn.Status != models.SerialStatusInStock {
+			return fmt.Errorf("serial %s is not in stock in this store (status: %s)", serial, sn.Status)
+		}
+		ids = append(ids, sn.ID)
+	}
+	return repo.UpdateSerials(tx, ids, map[string]interface{}{"status": models.SerialStatusWrittenOff})
+}
+
+func adjustLot(tx *gorm.DB, repo *Repository, req requests.AdjustInventoryRequest) error {
+	lotNumber := strings.TrimSpace(req.LotNumber)
+	if lotNumber == "" {
+		return fmt.Errorf("lot-tracked variant needs a lot_number")
+	}
+
+	lot, err := repo.LockLot(tx, req.ProductVariantID, req.StoreFrontID, lotNumber)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if lot == nil {
+		if req.Adjustment < 0 {
+			return fmt.Errorf("lot %s not found", lotNumber)
+		}
+		return repo.CreateLot(tx, &models.StockLot{
+			ProductVariantID: req.ProductVariantID,
+			StoreFrontID:     req.StoreFrontID,
+			LotNumber:        lotNumber,
+			Quantity:         req.Adjustment,
+			ExpiresAt:        req.LotExpiresAt,
+			ReceivedAt:       time.Now(),
+		})
+	}
+
+	if req.Adjustment < 0 && lot.AvailableQuantity() < -req.Adjustment {
+		return fmt.Errorf("lot %s has only %d unassigned units", lotNumber, lot.AvailableQuantity())
+	}
+	updates := map[string]interface{}{"quantity": lot.Quantity + req.Adjustment}
+	if req.LotExpiresAt != nil {
+		updates["expires_at"] = req.LotExpiresAt
+	}
+	return repo.UpdateLot(tx, lot.ID, updates)
+}
+
+// ============== Order assignment ==============
+
+// AssignSerialsWithTx assigns in-stock serials to an order line, replacing any
+// earlier assignment. One serial is needed per unit.
+func (s *Service) AssignSerialsWithTx(tx *gorm.DB, item *models.OrderItem, storeFrontID int64, serials []string) error {
+	repo := &Repository{db: tx}
+
+	serials, err := normalizeSerials(serials)
+	if err != nil {
+		return err
+	}
+	if len(serials) != item.Quantity {
+		return fmt.Errorf("item %s needs exactly %d serial numbers, got %d", item.SKU, item.Quantity, len(serials))
+	}
+
+	if err := s.ReleaseTrackedUnitsWithTx(tx, item); err != nil {
+		return err
+	}
+
+	rows, err := repo.LockSerials(tx, item.ProductVariantID, serials)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]models.SerialNumber, len(rows))
+	for _, sn := range rows {
+		found[sn.Serial] = sn
+	}
+
+	ids := make([]int64, 0, len(serials))
+	for _, serial := range serials {
+		sn, ok := found[serial]
+		if !ok {
+			return fmt.Errorf("serial %s not found for %s", serial, item.SKU)
+		}
+		if sn.StoreFrontID != storeFrontID || sn.Status != models.SerialStatusInStock {
+			return fmt.Errorf("serial %s is not in stock in this store (status: %s)", serial, sn.Status)
+		}
+		ids = append(ids, sn.ID)
+	}
+
+	return repo.UpdateSerials(tx, ids, map[string]interface{}{
+		"status":        models.SerialStatusAllocated,
+		"order_item_id": item.ID,
+	})
+}
+
+// AssignLotWithTx fulfils a whole order line from a single lot, so units of
+// different lots are never mixed in one line
+func (s *Service) AssignLotWithTx(tx *gorm.DB, item *models.OrderItem, storeFrontID int64, lotNumber string) error {
+	repo := &Repository{db: tx}
+
+	lotNumber = strings.TrimSpace(lotNumber)
+	if lotNumber == "" {
+		return fmt.Errorf("item %s needs a lot_number", item.SKU)
+	}
+
+	if err := s.ReleaseTrackedUnitsWithTx(tx, item); err != nil {
+		return err
+	}
+
+	lot, err := repo.LockLot(tx, item.ProductVariantID, storeFrontID, lotNumber)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("lot %s not found for %s", lotNumber, item.SKU)
+		}
+		return err
+	}
+	if lot.AvailableQuantity() < item.Quantity {
+		return fmt.Errorf("lot %s has only %d unassigned units, item %s needs %d", lotNumber, lot.AvailableQuantity(), item.SKU, item.Quantity)
+	}
+
+	if err := repo.UpdateLot(tx, lot.ID, map[string]interface{}{"allocated_quantity": lot.AllocatedQuantity + item.Quantity}); err != nil {
+		return err
+	}
+
+	item.StockLotID = &lot.ID
+	item.LotNumber = &lot.LotNumber
+	return tx.Model(&models.OrderItem{ID: item.ID}).Updates(map[string]interface{}{"stock_lot_id": lot.ID, "lot_number": lot.LotNumber}).Error
+}
+
+// ReleaseTrackedUnitsWithTx returns the serials or lot units assigned to an order line
+func (s *Service) ReleaseTrackedUnitsWithTx(tx *gorm.DB, item *models.OrderItem) error {
+	repo := &Repository{db: tx}
+
+	serials, err := repo.ListItemSerials(tx, item.ID)
+	if err != nil {
+		return err
+	}
+	ids := make([]int64, 0, len(serials))
+	for _, sn := range serials {
+		if sn.Status == models.SerialStatusAllocated {
+			ids = append(ids, sn.ID)
+		}
+	}
+	if len(ids) > 0 {
+		if err := repo.UpdateSerials(tx, ids, map[string]interface{}{"status": models.SerialStatusInStock, "order_item_id": nil}); err != nil {
+			return err
+		}
+	}
+	item.Serials = nil
+
+	if item.StockLotID == nil {
+		return nil
+	}
+	lot, err := repo.LockLotByID(tx, *item.StockLotID)
+	if err != nil {
+		return err
+	}
+	if err := repo.UpdateLot(tx, lot.ID, map[string]interface{}{"allocated_quantity": max(lot.AllocatedQuantity-item.Quantity, 0)}); err != nil {
+		return err
+	}
+	item.StockLotID = nil
+	item.LotNumber = nil
+	return tx.Model(&models.OrderItem{ID: item.ID}).Updates(map[string]interface{}{"stock_lot_id": nil, "lot_number": nil}).Error
+}
+
+// CheckTrackingAssignedWithTx fails when a tracked order line has no serials or lot yet
+func (s *Service) CheckTrackingAssignedWithTx(tx *gorm.DB, item *models.OrderItem) error {
+	repo := &Repository{db: tx}
+
+	mode, err := repo.GetTrackingMode(tx, item.ProductVariantID)
+	if err != nil {
+		return err
+	}
+
+	switch mode {
+	case models.TrackingModeSerial:
+		serials, err := repo.ListItemSerials(tx, item.ID)
+		if err != nil {
+			return err
+		}
+		if len(serials) != item.Quantity {
+			return fmt.Errorf("item %s needs %d serial numbers assigned, has %d", item.SKU, item.Quantity, len(serials))
+		}
+	case models.TrackingModeLot:
+		if item.StockLotID == nil {
+			return fmt.Errorf("item %s needs a lot assigned", item.SKU)
+		}
+	}
+	return nil
+}
+
+// SellTrackedUnitsWithTx marks the serials or lot units of a delivered order line as sold
+func (s *Service) SellTrackedUnitsWithTx(tx *gorm.DB, item *models.OrderItem) error {
+	repo := &Repository{db: tx}
+
+	serials, err := repo.ListItemSerials(tx, item.ID)
+	if err != nil {
+		return err
+	}
+	ids := make([]int64, 0, len(serials))
+	for _, sn := range serials {
+		if sn.Status == models.SerialStatusAllocated {
+			ids = append(ids, sn.ID)
+		}
+	}
+	if len(ids) > 0 {
+		if err := repo.UpdateSerials(tx, ids, map[string]interface{}{"status": models.SerialStatusSold, "sold_at": time.Now()}); err != nil {
+			return err
+		}
+	}
+
+	if item.StockLotID == nil {
+		return nil
+	}
+	lot, err := repo.LockLotByID(tx, *item.StockLotID)
+	if err != nil {
+		return err
+	}
+	return repo.UpdateLot(tx, lot.ID, map[string]interface{}{
+		"quantity":           max(lot.Quantity-item.Quantity, 0),
+		"allocated_quantity": max(lot.AllocatedQuantity-item.Quantity, 0),
+	})
+}
+
+// ============== Lookups ==============
+
+func (s *Service) ListSerials(variantID, storeFrontID int64, filter requests.SerialFilterRequest, pagination *utils.Pagination) utils.IResource {
+	items, total, err := s.repo.ListSerials(variantID, storeFrontID, filter.Status, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve serial numbers", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Serial numbers retrieved successfully", items, pagination.GetMeta())
+}
+
+func (s *Service) ListLots(variantID, storeFrontID int64) utils.IResource {
+	lots, err := s.repo.ListLots(variantID, storeFrontID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve lots", err)
+	}
+	return utils.NewOKResource("Lots retrieved successfully", lots)
+}
+
+// TraceSerial looks up a serial number and the order it was shipped on
+func (s *Service) TraceSerial(serial string) utils.IResource {
+	rows, err := s.repo.TraceSerial(strings.TrimSpace(serial))
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to trace serial number", err)
+	}
+	if len(rows) == 0 {
+		return utils.NewNotFoundResource("Serial number not found", nil)
+	}
+	return utils.NewOKResource("Serial number traced successfully", rows)
+}
+
+// TraceLot looks up a lot number and the orders fulfilled from it
+func (s *Service) TraceLot(lotNumber string) utils.IResource {
+	lots, err := s.repo.FindLotsByNumber(strings.TrimSpace(lotNumber))
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to trace lot", err)
+	}
+	if len(lots) == 0 {
+		return utils.NewNotFoundResource("Lot not found", nil)
+	}
+
+	ids := make([]int64, 0, len(lots))
+	for _, lot := range lots {
+		ids = append(ids, lot.ID)
+	}
+	shipments, err := s.repo.ListLotShipments(ids)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to trace lot", err)
+	}
+
+	return utils.NewOKResource("Lot traced successfully", map[string]interface{}{
+		"lots":      lots,
+		"shipments": shipments,
+	})
+}
@@ -100,6 +100,29 @@ func (c *Controller) CompleteOrder(ctx *gin.Context) {
 	utils.WriteResource(ctx, res)
 }
 
+func (c *Controller) AssignItemTracking(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid order id")
+		return
+	}
+
+	itemID, err := strconv.ParseInt(ctx.Param("itemId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid item id")
+		return
+	}
+
+	var req requests.AssignItemTrackingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err)
+		return
+	}
+
+	res := c.service.AssignItemTracking(id, itemID, req)
+	utils.WriteResource(ctx, res)
+}
+
 func (c *Controller) ListOrders(ctx *gin.Context) {
 	var filter requests.OrderFilterRequest
 	if err := ctx.ShouldBindQuery(&filter); err != nil {
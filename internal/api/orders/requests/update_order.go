@@ -18,3 +18,9 @@ type OrderItemUpdate struct {
 	Quantity         int   `json:"quantity"`
 	IsRemoved        bool  `json:"is_removed"` // Flag to mark for deletion
 }
+
+// AssignItemTrackingRequest assigns serial numbers or a lot to a tracked order item
+type AssignItemTrackingRequest struct {
+	Serials   []string `json:"serials"`    // Serial-tracked variants, one per unit
+	LotNumber string   `json:"lot_number"` // Lot-tracked variants
+}
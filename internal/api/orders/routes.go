@@ -19,6 +19,7 @@ func RegisterRoutes(router *gin.RouterGroup, controller *Controller) {
 	g.POST("/:id/cancel", middleware.RequirePermission("orders.cancel"), controller.CancelOrder)
 	g.POST("/:id/out-for-delivery", middleware.RequirePermission("orders.edit"), controller.MarkOutForDelivery)
 	g.POST("/:id/complete", middleware.RequirePermission("orders.edit"), controller.CompleteOrder)
+	g.PUT("/:id/items/:itemId/tracking", middleware.RequirePermission("orders.edit"), controller.AssignItemTracking)
 	// Assuming logic handles permission check or reuse "orders.edit" if "orders.complete" doesn't exist.
 	// But best practice is specific permission.
 	// We'll see if we need to add permission to seed. For now let's assume reuse "orders.edit" or check seed.
@@ -7,9 +7,11 @@ import (
 	"github.com/onas/ecommerce-api/internal/api/customers"
 	"github.com/onas/ecommerce-api/internal/api/inventory"
 	"github.com/onas/ecommerce-api/internal/api/orders/requests"
+	"github.com/onas/ecommerce-api/internal/api/pricelists"
 	"github.com/onas/ecommerce-api/internal/models"
 	"github.com/onas/ecommerce-api/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
@@ -42,7 +44,7 @@ func (s *Service) GetOrderSources() ([]models.OrderSource, error) {
 func (s *Service) CreateOrder(req requests.CreateOrderRequest, adminID int64) utils.IResource {
 	var order *models.Order
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.invService.Transaction(func(tx *gorm.DB) error {
 		// 1. Validate StoreFront
 		var storeFront models.StoreFront
 		if err := tx.First(&storeFront, req.StoreFrontID).Error; err != nil {
@@ -158,6 +160,16 @@ func (s *Service) CreateOrder(req requests.CreateOrderRequest, adminID int64) ut
 			}
 		}
 
+		// Price list prices for the order's currency and the customer's group
+		variantIDs := make([]int64, 0, len(req.Items))
+		for _, itemReq := range req.Items {
+			variantIDs = append(variantIDs, itemReq.ProductVariantID)
+		}
+		listPrices, err := s.listPrices(tx, storeFront.ID, currency.Code, customerID, variantIDs)
+		if err != nil {
+			return fmt.Errorf("failed to resolve prices: %w", err)
+		}
+
 		// Prepare order items
 		var orderItems []models.OrderItem
 		var subtotal float64
@@ -179,16 +191,14 @@ func (s *Service) CreateOrder(req requests.CreateOrderRequest, adminID int64) ut
 			if variant.Price != nil {
 				unitPrice = *variant.Price
 			}
+			if listed, ok := listPrices[variant.ID]; ok {
+				unitPrice = listed.Price
+			}
 			costPrice := 0.0
 			if variant.CostPrice != nil {
 				costPrice = *variant.CostPrice
 			}
 
-			// 3. Reserve Inventory
-			if err := s.invService.ReserveStockWithTx(tx, variant.ID, req.StoreFrontID, itemReq.Quantity); err != nil {
-				return fmt.Errorf("inventory reservation failed for SKU %s: %w", variant.SKU, err)
-			}
-
 			// Build Item
 			totalPrice := unitPrice * float64(itemReq.Quantity)
 			subtotal += totalPrice
@@ -200,6 +210,11 @@ func (s *Service) CreateOrder(req requests.CreateOrderRequest, adminID int64) ut
 				nameEn = variant.Product.Name
 			}
 
+			components, err := s.bundleBreakdown(tx, variant.ID, itemReq.Quantity)
+			if err != nil {
+				return err
+			}
+
 			orderItems = append(orderItems, models.OrderItem{
 				ProductID:             variant.ProductID,
 				ProductVariantID:      variant.ID,
@@ -210,6 +225,7 @@ func (s *Service) CreateOrder(req requests.CreateOrderRequest, adminID int64) ut
 				CostPrice:             costPrice,
 				Quantity:              itemReq.Quantity,
 				TotalPrice:            totalPrice,
+				Components:            components,
 			})
 		}
 
@@ -245,6 +261,17 @@ func (s *Service) CreateOrder(req requests.CreateOrderRequest, adminID int64) ut
 			return err
 		}
 
+		// 4.2 Reserve Inventory against the new order
+		source := inventory.OrderMovementSource(newOrder.ID)
+		source.PerformedBy = &adminID
+		for i, item := range orderItems {
+			backordered, err := s.invService.ReserveStockWithTx(tx, item.ProductVariantID, req.StoreFrontID, item.Quantity, source)
+			if err != nil {
+				return fmt.Errorf("inventory reservation failed for SKU %s: %w", item.SKU, err)
+			}
+			orderItems[i].BackorderedQuantity = backordered
+		}
+
 		// 4.5 Create Order Address
 		// 4.5 Create Order Address
 		orderAddress := &models.OrderAddress{
@@ -291,7 +318,7 @@ func (s *Service) CreateOrder(req requests.CreateOrderRequest, adminID int64) ut
 
 // ConfirmOrder confirms stock deduction (Paid -> Confirmed)
 func (s *Service) ConfirmOrder(id int64) utils.IResource {
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.invService.Transaction(func(tx *gorm.DB) error {
 		repoTx := &Repository{db: tx}
 
 		order, err := repoTx.GetOrderByID(id)
@@ -307,9 +334,17 @@ func (s *Service) ConfirmOrder(id int64) utils.IResource {
 			return fmt.Errorf("cannot confirm cancelled order")
 		}
 
+		if err := checkNoBackorders(order); err != nil {
+			return err
+		}
+
 		// Deduct Stock
-		for _, item := range order.Items {
-			if err := s.invService.ConfirmStockDeductionWithTx(tx, item.ProductVariantID, order.StoreFrontID, item.Quantity); err != nil {
+		for i := range order.Items {
+			item := &order.Items[i]
+			err := forEachStockVariant(item, item.Quantity, func(variantID int64, quantity int) error {
+				return s.invService.ConfirmStockDeductionWithTx(tx, variantID, order.StoreFrontID, quantity, inventory.OrderMovementSource(order.ID))
+			})
+			if err != nil {
 				return fmt.Errorf("failed to confirm stock deduction for item %s: %w", item.SKU, err)
 			}
 		}
@@ -335,7 +370,7 @@ func (s *Service) ConfirmOrder(id int64) utils.IResource {
 
 // MarkOutForDelivery sets the fulfillment status to out_for_delivery
 func (s *Service) MarkOutForDelivery(id int64) utils.IResource {
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.invService.Transaction(func(tx *gorm.DB) error {
 		repoTx := &Repository{db: tx}
 
 		order, err := repoTx.GetOrderByID(id)
@@ -352,6 +387,9 @@ func (s *Service) MarkOutForDelivery(id int64) utils.IResource {
 		if order.FulfillmentStatus.Slug == "out_for_delivery" || order.FulfillmentStatus.Slug == "fulfilled" {
 			return fmt.Errorf("order is already %s", order.FulfillmentStatus.NameEn)
 		}
+		if err := s.checkTrackingAssigned(tx, order); err != nil {
+			return err
+		}
 
 		outForDelivery, err := repoTx.GetFulfillmentStatusBySlug("out_for_delivery")
 		if err != nil {
@@ -369,7 +407,7 @@ func (s *Service) MarkOutForDelivery(id int64) utils.IResource {
 
 // CompleteOrder marks an order as completed, paid, and fulfilled
 func (s *Service) CompleteOrder(id int64) utils.IResource {
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.invService.Transaction(func(tx *gorm.DB) error {
 		repoTx := &Repository{db: tx}
 
 		order, err := repoTx.GetOrderByID(id)
@@ -386,6 +424,12 @@ func (s *Service) CompleteOrder(id int64) utils.IResource {
 		if order.OrderStatus.Slug != "confirmed" {
 			return fmt.Errorf("order must be confirmed before completing")
 		}
+		if err := checkNoBackorders(order); err != nil {
+			return err
+		}
+		if err := s.checkTrackingAssigned(tx, order); err != nil {
+			return err
+		}
 
 		// Update Order Status -> Completed
 		completedStatus, err := repoTx.GetOrderStatusBySlug("completed")
@@ -415,10 +459,17 @@ func (s *Service) CompleteOrder(id int64) utils.IResource {
 		}
 
 		// Deduct stock: move from reserved to permanently sold
-		for _, item := range order.Items {
-			if err := s.invService.ConfirmStockDeductionWithTx(tx, item.ProductVariantID, order.StoreFrontID, item.Quantity); err != nil {
+		for i := range order.Items {
+			item := &order.Items[i]
+			err := forEachStockVariant(item, item.Quantity, func(variantID int64, quantity int) error {
+				return s.invService.ConfirmStockDeductionWithTx(tx, variantID, order.StoreFrontID, quantity, inventory.OrderMovementSource(order.ID))
+			})
+			if err != nil {
 				return fmt.Errorf("failed to deduct stock for item %s: %w", item.SKU, err)
 			}
+			if err := s.invService.SellTrackedUnitsWithTx(tx, item); err != nil {
+				return fmt.Errorf("failed to mark serials or lot sold for item %s: %w", item.SKU, err)
+			}
 		}
 
 		return nil
@@ -432,7 +483,7 @@ func (s *Service) CompleteOrder(id int64) utils.IResource {
 
 // CancelOrder releases reserved stock
 func (s *Service) CancelOrder(id int64) utils.IResource {
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.invService.Transaction(func(tx *gorm.DB) error {
 		repoTx := &Repository{db: tx}
 
 		order, err := repoTx.GetOrderByID(id)
@@ -450,10 +501,14 @@ func (s *Service) CancelOrder(id int64) utils.IResource {
 		shouldRelease := order.OrderStatus.Slug == "draft"
 
 		if shouldRelease {
-			for _, item := range order.Items {
-				if err := s.invService.ReleaseReservedStockWithTx(tx, item.ProductVariantID, order.StoreFrontID, item.Quantity); err != nil {
+			for i := range order.Items {
+				item := &order.Items[i]
+				if err := s.releaseItemStock(tx, order, item, item.Quantity); err != nil {
 					return fmt.Errorf("failed to release stock for item %s: %w", item.SKU, err)
 				}
+				if err := s.invService.ReleaseTrackedUnitsWithTx(tx, item); err != nil {
+					return fmt.Errorf("failed to release serials or lot for item %s: %w", item.SKU, err)
+				}
 			}
 		} else if order.OrderStatus.Slug == "confirmed" {
 			return fmt.Errorf("cancellation of confirmed orders requires restocking (not implemented in P1)")
@@ -477,6 +532,110 @@ func (s *Service) CancelOrder(id int64) utils.IResource {
 	return utils.NewOKResource("Order cancelled", nil)
 }
 
+// releaseItemStock gives back quantity units of an order line.
+// Backordered units are cancelled before reserved stock is released.
+func (s *Service) releaseItemStock(tx *gorm.DB, order *models.Order, item *models.OrderItem, quantity int) error {
+	// Allocation may have changed the line's backorder since the order was loaded
+	var current models.OrderItem
+	if err := tx.Select("id, backordered_quantity").First(&current, item.ID).Error; err != nil {
+		return err
+	}
+	item.BackorderedQuantity = current.BackorderedQuantity
+
+	fromBackorder := min(quantity, item.BackorderedQuantity)
+	if fromBackorder > 0 {
+		if err := s.invService.CancelBackorderWithTx(tx, item.ProductVariantID, order.StoreFrontID, fromBackorder); err != nil {
+			return err
+		}
+		// Persist right away so released stock is not allocated back to this line
+		item.BackorderedQuantity -= fromBackorder
+		if err := tx.Model(&models.OrderItem{ID: item.ID}).Update("backordered_quantity", item.BackorderedQuantity).Error; err != nil {
+			return err
+		}
+	}
+
+	if reserved := quantity - fromBackorder; reserved > 0 {
+		return forEachStockVariant(item, reserved, func(variantID int64, units int) error {
+			return s.invService.ReleaseReservedStockWithTx(tx, variantID, order.StoreFrontID, units, inventory.OrderMovementSource(order.ID))
+		})
+	}
+	return nil
+}
+
+// bundleBreakdown snapshots the component variants a bundle line consumes,
+// or returns nothing for variants that are not bundles
+func (s *Service) bundleBreakdown(tx *gorm.DB, variantID int64, quantity int) ([]models.OrderItemComponent, error) {
+	components, err := s.invService.ListBundleComponentsWithTx(tx, variantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundle components: %w", err)
+	}
+
+	breakdown := make([]models.OrderItemComponent, 0, len(components))
+	for _, c := range components {
+		sku := ""
+		if c.ComponentVariant != nil {
+			sku = c.ComponentVariant.SKU
+		}
+		breakdown = append(breakdown, models.OrderItemComponent{
+			ProductVariantID:  c.ComponentVariantID,
+			SKU:               sku,
+			QuantityPerBundle: c.Quantity,
+			Quantity:          c.Quantity * quantity,
+		})
+	}
+	return breakdown, nil
+}
+
+// listPrices resolves the price list prices of variants for an order's store, currency
+// and customer group
+func (s *Service) listPrices(tx *gorm.DB, storeFrontID int64, currencyCode string, customerID *int64, variantIDs []int64) (map[int64]pricelists.VariantPrice, error) {
+	var customerGroupID *int64
+	if customerID != nil {
+		var customer models.Customer
+		if err := tx.Select("id, customer_group_id").First(&customer, *customerID).Error; err != nil {
+			return nil, err
+		}
+		customerGroupID = customer.CustomerGroupID
+	}
+	return pricelists.ResolvePrices(tx, storeFrontID, currencyCode, customerGroupID, variantIDs)
+}
+
+// forEachStockVariant calls fn for every variant whose stock an order line moves:
+// the bundle components recorded at order time, or the line's own variant
+func forEachStockVariant(item *models.OrderItem, quantity int, fn func(variantID int64, quantity int) error) error {
+	if len(item.Components) == 0 {
+		return fn(item.ProductVariantID, quantity)
+	}
+	for _, c := range item.Components {
+		if err := fn(c.ProductVariantID, c.QuantityPerBundle*quantity); err != nil {
+			return fmt.Errorf("bundle component %s: %w", c.SKU, err)
+		}
+	}
+	return nil
+}
+
+// syncItemComponents updates the component breakdown of a line to its current quantity
+func syncItemComponents(tx *gorm.DB, item *models.OrderItem) error {
+	for i := range item.Components {
+		c := &item.Components[i]
+		c.Quantity = c.QuantityPerBundle * item.Quantity
+		if err := tx.Model(c).Update("quantity", c.Quantity).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNoBackorders blocks stock deduction while lines still wait for stock
+func checkNoBackorders(order *models.Order) error {
+	for _, item := range order.Items {
+		if item.BackorderedQuantity > 0 {
+			return fmt.Errorf("item %s has %d units on backorder awaiting stock", item.SKU, item.BackorderedQuantity)
+		}
+	}
+	return nil
+}
+
 func (s *Service) ListOrders(filter requests.OrderFilterRequest, pagination *utils.Pagination) utils.IResource {
 	orders, total, err := s.repo.ListOrders(filter, pagination)
 	if err != nil {
@@ -533,7 +692,7 @@ func (s *Service) GetOrderMeta() utils.IResource {
 func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.IResource {
 	var updatedOrder *models.Order
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.invService.Transaction(func(tx *gorm.DB) error {
 		repoTx := &Repository{db: tx}
 
 		// 1. Fetch Existing Order
@@ -569,6 +728,22 @@ func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.I
 			currentItems[item.ID] = item
 		}
 
+		// Price list prices for new items
+		var newVariantIDs []int64
+		for _, itemReq := range req.Items {
+			if itemReq.ID == 0 && !itemReq.IsRemoved {
+				newVariantIDs = append(newVariantIDs, itemReq.ProductVariantID)
+			}
+		}
+		currencyCode := ""
+		if order.Currency != nil {
+			currencyCode = order.Currency.Code
+		}
+		listPrices, err := s.listPrices(tx, order.StoreFrontID, currencyCode, order.CustomerID, newVariantIDs)
+		if err != nil {
+			return fmt.Errorf("failed to resolve prices: %w", err)
+		}
+
 		var subtotal float64
 		// We will reconstruct the order items list
 		// Strategies:
@@ -590,15 +765,23 @@ func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.I
 				}
 
 				// Reserve Stock
-				if err := s.invService.ReserveStockWithTx(tx, variant.ID, order.StoreFrontID, itemReq.Quantity); err != nil {
+				backordered, err := s.invService.ReserveStockWithTx(tx, variant.ID, order.StoreFrontID, itemReq.Quantity, inventory.OrderMovementSource(order.ID))
+				if err != nil {
 					return fmt.Errorf("stock reservation failed for new item %s: %w", variant.SKU, err)
 				}
+				components, err := s.bundleBreakdown(tx, variant.ID, itemReq.Quantity)
+				if err != nil {
+					return err
+				}
 
 				// Pricing
 				unitPrice := variant.Product.Price
 				if variant.Price != nil {
 					unitPrice = *variant.Price
 				}
+				if listed, ok := listPrices[variant.ID]; ok {
+					unitPrice = listed.Price
+				}
 				costPrice := 0.0
 				if variant.CostPrice != nil {
 					costPrice = *variant.CostPrice
@@ -623,7 +806,9 @@ func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.I
 					UnitPrice:             unitPrice,
 					CostPrice:             costPrice,
 					Quantity:              itemReq.Quantity,
+					BackorderedQuantity:   backordered,
 					TotalPrice:            totalPrice,
+					Components:            components,
 				}
 				if err := tx.Create(&newItem).Error; err != nil {
 					return err
@@ -639,9 +824,15 @@ func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.I
 
 				if itemReq.IsRemoved {
 					// REMOVE: Release Stock -> Delete
-					if err := s.invService.ReleaseReservedStockWithTx(tx, existingItem.ProductVariantID, order.StoreFrontID, existingItem.Quantity); err != nil {
+					if err := s.releaseItemStock(tx, order, &existingItem, existingItem.Quantity); err != nil {
 						return fmt.Errorf("stock release failed for removed item %s: %w", existingItem.SKU, err)
 					}
+					if err := s.invService.ReleaseTrackedUnitsWithTx(tx, &existingItem); err != nil {
+						return err
+					}
+					if err := tx.Where("order_item_id = ?", existingItem.ID).Delete(&models.OrderItemComponent{}).Error; err != nil {
+						return err
+					}
 					if err := tx.Delete(&existingItem).Error; err != nil {
 						return err
 					}
@@ -650,14 +841,32 @@ func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.I
 					// UPDATE: Check Quantity Diff
 					qtyDiff := itemReq.Quantity - existingItem.Quantity
 
+					// Assigned serials or lot no longer match, they are assigned again
+					if qtyDiff != 0 {
+						if err := s.invService.ReleaseTrackedUnitsWithTx(tx, &existingItem); err != nil {
+							return err
+						}
+					}
+
 					if qtyDiff > 0 {
-						// Increase: Reserve more
-						if err := s.invService.ReserveStockWithTx(tx, existingItem.ProductVariantID, order.StoreFrontID, qtyDiff); err != nil {
-							return fmt.Errorf("stock reservation failed for update %s: %w", existingItem.SKU, err)
+						// Increase: Reserve more, bundles against their recorded components
+						if len(existingItem.Components) > 0 {
+							err := forEachStockVariant(&existingItem, qtyDiff, func(variantID int64, quantity int) error {
+								return s.invService.ReserveAvailableStockWithTx(tx, variantID, order.StoreFrontID, quantity, inventory.OrderMovementSource(order.ID))
+							})
+							if err != nil {
+								return fmt.Errorf("stock reservation failed for update %s: %w", existingItem.SKU, err)
+							}
+						} else {
+							backordered, err := s.invService.ReserveStockWithTx(tx, existingItem.ProductVariantID, order.StoreFrontID, qtyDiff, inventory.OrderMovementSource(order.ID))
+							if err != nil {
+								return fmt.Errorf("stock reservation failed for update %s: %w", existingItem.SKU, err)
+							}
+							existingItem.BackorderedQuantity += backordered
 						}
 					} else if qtyDiff < 0 {
 						// Decrease: Release some
-						if err := s.invService.ReleaseReservedStockWithTx(tx, existingItem.ProductVariantID, order.StoreFrontID, -qtyDiff); err != nil {
+						if err := s.releaseItemStock(tx, order, &existingItem, -qtyDiff); err != nil {
 							return fmt.Errorf("stock release failed for update %s: %w", existingItem.SKU, err)
 						}
 					}
@@ -667,7 +876,10 @@ func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.I
 					existingItem.TotalPrice = existingItem.UnitPrice * float64(itemReq.Quantity)
 					// Note: We keep original unit price snapshot even if product price changed, unless policy dictates otherwise.
 
-					if err := tx.Save(&existingItem).Error; err != nil {
+					if err := tx.Omit(clause.Associations).Save(&existingItem).Error; err != nil {
+						return err
+					}
+					if err := syncItemComponents(tx, &existingItem); err != nil {
 						return err
 					}
 					subtotal += existingItem.TotalPrice
@@ -700,7 +912,8 @@ func (s *Service) UpdateOrder(id int64, req requests.UpdateOrderRequest) utils.I
 		order.Subtotal = subtotal
 		order.TotalAmount = subtotal + order.ShippingAmount + order.TaxAmount - order.DiscountAmount
 
-		if err := tx.Save(order).Error; err != nil {
+		// Items were written above, saving them again would restore removed ones
+		if err := tx.Omit(clause.Associations).Save(order).Error; err != nil {
 			return err
 		}
 
@@ -0,0 +1,60 @@
+package orders
+
+import (
+	"testing"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory"
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCancelOrder_BackorderedLinesOfSameVariant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.StoreFront{}, &models.Product{}, &models.ProductStorefront{}, &models.ProductVariant{}, &models.BundleComponent{},
+		&models.VariantInventory{}, &models.InventoryMovement{}, &models.SerialNumber{}, &models.StockLot{},
+		&models.OrderStatus{}, &models.Order{}, &models.OrderItem{}, &models.OrderItemComponent{}, &models.OrderAddress{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	db.Create(&[]models.OrderStatus{{ID: 1, Slug: "draft"}, {ID: 2, Slug: "cancelled"}})
+	db.Create(&models.ProductVariant{ID: 1, ProductID: 1, SKU: "SKU-1", IsActive: true})
+	// Order 1 reserved the last 2 units on one line and backordered 2 on another,
+	// order 2 waits for 1 more
+	db.Create(&models.VariantInventory{ID: 1, ProductVariantID: 1, StoreFrontID: 1, Quantity: 2, ReservedQuantity: 2, BackorderedQuantity: 3, BackorderPolicy: models.BackorderPolicyAllow})
+	db.Create(&[]models.Order{
+		{ID: 1, StoreFrontID: 1, OrderNumber: "ORD-1", OrderStatusID: 1},
+		{ID: 2, StoreFrontID: 1, OrderNumber: "ORD-2", OrderStatusID: 1},
+	})
+	db.Create(&[]models.OrderItem{
+		{ID: 1, OrderID: 1, ProductID: 1, ProductVariantID: 1, SKU: "SKU-1", Quantity: 2},
+		{ID: 2, OrderID: 1, ProductID: 1, ProductVariantID: 1, SKU: "SKU-1", Quantity: 2, BackorderedQuantity: 2},
+		{ID: 3, OrderID: 2, ProductID: 1, ProductVariantID: 1, SKU: "SKU-1", Quantity: 1, BackorderedQuantity: 1},
+	})
+
+	invService := inventory.NewService(db, inventory.NewRepository(db))
+	service := NewService(db, NewRepository(db), invService, nil)
+
+	if res := service.CancelOrder(1); res.GetStatusCode() != 200 {
+		t.Fatalf("expected status 200, got %d: %s", res.GetStatusCode(), res.GetMessage())
+	}
+
+	var inv models.VariantInventory
+	db.First(&inv, 1)
+	if inv.ReservedQuantity != 1 || inv.BackorderedQuantity != 0 {
+		t.Errorf("got reserved %d, backordered %d, want 1 reserved for order 2 and none backordered", inv.ReservedQuantity, inv.BackorderedQuantity)
+	}
+
+	var items []models.OrderItem
+	db.Order("id").Find(&items)
+	for _, item := range items {
+		if item.BackorderedQuantity != 0 {
+			t.Errorf("item %d still has %d backordered", item.ID, item.BackorderedQuantity)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package orders
+
+import (
+	"fmt"
+
+	"github.com/onas/ecommerce-api/internal/api/orders/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// AssignItemTracking assigns the serial numbers or lot an order item ships with
+func (s *Service) AssignItemTracking(orderID, itemID int64, req requests.AssignItemTrackingRequest) utils.IResource {
+	err := s.invService.Transaction(func(tx *gorm.DB) error {
+		repoTx := &Repository{db: tx}
+
+		order, err := repoTx.GetOrderByID(orderID)
+		if err != nil {
+			return err
+		}
+		if order.OrderStatus.Slug == "completed" || order.OrderStatus.Slug == "cancelled" {
+			return fmt.Errorf("cannot assign stock to a %s order", order.OrderStatus.Slug)
+		}
+
+		var item *models.OrderItem
+		for i := range order.Items {
+			if order.Items[i].ID == itemID {
+				item = &order.Items[i]
+			}
+		}
+		if item == nil {
+			return fmt.Errorf("item id %d not found in order", itemID)
+		}
+
+		mode := models.TrackingModeNone
+		if item.ProductVariant != nil && item.ProductVariant.TrackingMode != "" {
+			mode = item.ProductVariant.TrackingMode
+		}
+
+		switch mode {
+		case models.TrackingModeSerial:
+			return s.invService.AssignSerialsWithTx(tx, item, order.StoreFrontID, req.Serials)
+		case models.TrackingModeLot:
+			return s.invService.AssignLotWithTx(tx, item, order.StoreFrontID, req.LotNumber)
+		default:
+			return fmt.Errorf("item %s is not serial or lot tracked", item.SKU)
+		}
+	})
+
+	if err != nil {
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
+
+	fullOrder, _ := s.repo.GetOrderByID(orderID)
+	return utils.NewOKResource("Order item stock assigned", fullOrder)
+}
+
+// checkTrackingAssigned blocks shipping while tracked lines lack serials or a lot
+func (s *Service) checkTrackingAssigned(tx *gorm.DB, order *models.Order) error {
+	for i := range order.Items {
+		if err := s.invService.CheckTrackingAssignedWithTx(tx, &order.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
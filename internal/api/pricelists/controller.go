@@ -0,0 +1,216 @@
+package pricelists
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onas/ecommerce-api/internal/api/pricelists/requests"
+	"github.com/onas/ecommerce-api/internal/utils"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(service *Service) *Controller {
+	return &Controller{service: service}
+}
+
+func (ctrl *Controller) List(c *gin.Context) {
+	var filter requests.PriceListFilterRequest
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(c)
+	res := ctrl.service.List(filter, pagination)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price list id")
+		return
+	}
+
+	res := ctrl.service.GetByID(id)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) Create(c *gin.Context) {
+	var req requests.CreatePriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.Create(req)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price list id")
+		return
+	}
+
+	var req requests.UpdatePriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.Update(id, req)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price list id")
+		return
+	}
+
+	res := ctrl.service.Delete(id)
+	utils.WriteResource(c, res)
+}
+
+// SetItems handles PUT /admin/price-lists/:id/items
+func (ctrl *Controller) SetItems(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price list id")
+		return
+	}
+
+	var req requests.SetPriceListItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.SetItems(id, req)
+	utils.WriteResource(c, res)
+}
+
+// RemoveItem handles DELETE /admin/price-lists/:id/items/:variantId
+func (ctrl *Controller) RemoveItem(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price list id")
+		return
+	}
+	variantID, err := strconv.ParseInt(c.Param("variantId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid variant id")
+		return
+	}
+
+	res := ctrl.service.RemoveItem(id, variantID)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ListCustomerGroups(c *gin.Context) {
+	res := ctrl.service.ListCustomerGroups()
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) CreateCustomerGroup(c *gin.Context) {
+	var req requests.CustomerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.CreateCustomerGroup(req)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) UpdateCustomerGroup(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid customer group id")
+		return
+	}
+
+	var req requests.CustomerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.UpdateCustomerGroup(id, req)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) ListSchedules(c *gin.Context) {
+	var filter requests.PriceScheduleFilterRequest
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(c)
+	res := ctrl.service.ListSchedules(filter, pagination)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) GetSchedule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price schedule id")
+		return
+	}
+
+	res := ctrl.service.GetSchedule(id)
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) CreateSchedule(c *gin.Context) {
+	var req requests.PriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	adminID, exists := c.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.CreateSchedule(req, adminID.(int64))
+	utils.WriteResource(c, res)
+}
+
+func (ctrl *Controller) UpdateSchedule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price schedule id")
+		return
+	}
+
+	var req requests.PriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err.Error())
+		return
+	}
+
+	res := ctrl.service.UpdateSchedule(id, req)
+	utils.WriteResource(c, res)
+}
+
+// CancelSchedule handles POST /admin/price-schedules/:id/cancel
+func (ctrl *Controller) CancelSchedule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "invalid price schedule id")
+		return
+	}
+
+	res := ctrl.service.CancelSchedule(id)
+	utils.WriteResource(c, res)
+}
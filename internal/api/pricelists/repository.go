@@ -0,0 +1,269 @@
+package pricelists
+
+import (
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/pricelists/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ============== Price Lists ==============
+
+func (r *Repository) List(filter requests.PriceListFilterRequest, pagination *utils.Pagination) ([]models.PriceList, int64, error) {
+	var lists []models.PriceList
+	query := r.db.Model(&models.PriceList{})
+	if filter.StoreFrontID != nil {
+		query = query.Where("store_front_id = ?", *filter.StoreFrontID)
+	}
+	if filter.CustomerGroupID != nil {
+		query = query.Where("customer_group_id = ?", *filter.CustomerGroupID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Preload("StoreFront").Preload("CustomerGroup").
+		Order("store_front_id ASC, currency ASC, id ASC").
+		Offset(offset).Limit(pagination.Limit).
+		Find(&lists).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return lists, total, nil
+}
+
+func (r *Repository) GetByID(id int64) (*models.PriceList, error) {
+	var list models.PriceList
+	err := r.db.Preload("StoreFront").Preload("CustomerGroup").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("product_variant_id ASC")
+		}).
+		First(&list, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *Repository) Create(list *models.PriceList) error {
+	return r.db.Create(list).Error
+}
+
+func (r *Repository) Update(list *models.PriceList) error {
+	return r.db.Omit("StoreFront", "CustomerGroup", "Items").Save(list).Error
+}
+
+// Delete removes a price list with its items
+func (r *Repository) Delete(id int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", id).Delete(&models.PriceListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.PriceList{}, id).Error
+	})
+}
+
+// IsScopeTaken reports whether a store already has a list for the currency and customer group
+func (r *Repository) IsScopeTaken(storeFrontID int64, currency string, customerGroupID *int64) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.PriceList{}).Where("store_front_id = ? AND currency = ?", storeFrontID, currency)
+	if customerGroupID != nil {
+		query = query.Where("customer_group_id = ?", *customerGroupID)
+	} else {
+		query = query.Where("customer_group_id IS NULL")
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ============== Price List Items ==============
+
+// UpsertItems adds variant prices to a list, replacing existing prices of the same variants
+func (r *Repository) UpsertItems(items []models.PriceListItem) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "price_list_id"}, {Name: "product_variant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "compare_at_price", "updated_at"}),
+	}).Create(&items).Error
+}
+
+// DeleteItem removes a variant's price from a list, reporting whether it was there
+func (r *Repository) DeleteItem(priceListID, variantID int64) (bool, error) {
+	result := r.db.Where("price_list_id = ? AND product_variant_id = ?", priceListID, variantID).Delete(&models.PriceListItem{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountVariants counts the existing variants among IDs
+func (r *Repository) CountVariants(ids []int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ProductVariant{}).Where("id IN ?", ids).Count(&count).Error
+	return count, err
+}
+
+// ============== Lookups ==============
+
+func (r *Repository) GetStoreFront(id int64) (*models.StoreFront, error) {
+	var sf models.StoreFront
+	if err := r.db.First(&sf, id).Error; err != nil {
+		return nil, err
+	}
+	return &sf, nil
+}
+
+func (r *Repository) CurrencyExists(code string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Currency{}).Where("code = ?", code).Count(&count).Error
+	return count > 0, err
+}
+
+// ============== Customer Groups ==============
+
+func (r *Repository) ListCustomerGroups() ([]models.CustomerGroup, error) {
+	var groups []models.CustomerGroup
+	err := r.db.Order("name ASC").Find(&groups).Error
+	return groups, err
+}
+
+func (r *Repository) GetCustomerGroup(id int64) (*models.CustomerGroup, error) {
+	var group models.CustomerGroup
+	if err := r.db.First(&group, id).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *Repository) CreateCustomerGroup(group *models.CustomerGroup) error {
+	return r.db.Create(group).Error
+}
+
+func (r *Repository) UpdateCustomerGroup(group *models.CustomerGroup) error {
+	return r.db.Save(group).Error
+}
+
+func (r *Repository) IsGroupSlugUnique(slug string, excludeID int64) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.CustomerGroup{}).Where("slug = ?", slug)
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// ============== Price Schedules ==============
+
+func (r *Repository) ListSchedules(filter requests.PriceScheduleFilterRequest, pagination *utils.Pagination) ([]models.PriceSchedule, int64, error) {
+	var schedules []models.PriceSchedule
+	query := r.db.Model(&models.PriceSchedule{})
+	if filter.ProductVariantID != nil {
+		query = query.Where("product_variant_id = ?", *filter.ProductVariantID)
+	}
+	if filter.PriceListID != nil {
+		query = query.Where("price_list_id = ?", *filter.PriceListID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Order("starts_at DESC, id DESC").Offset(offset).Limit(pagination.Limit).Find(&schedules).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return schedules, total, nil
+}
+
+func (r *Repository) GetSchedule(id int64) (*models.PriceSchedule, error) {
+	var schedule models.PriceSchedule
+	if err := r.db.First(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// LockSchedule loads a schedule for update inside a transaction
+func (r *Repository) LockSchedule(tx *gorm.DB, id int64) (*models.PriceSchedule, error) {
+	var schedule models.PriceSchedule
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *Repository) SaveSchedule(schedule *models.PriceSchedule) error {
+	return r.db.Save(schedule).Error
+}
+
+// HasOverlappingSchedule reports whether an open schedule of the same variant and price
+// list overlaps the window
+func (r *Repository) HasOverlappingSchedule(variantID int64, priceListID *int64, startsAt, endsAt time.Time, excludeID int64) (bool, error) {
+	query := r.db.Model(&models.PriceSchedule{}).
+		Where("product_variant_id = ? AND status IN ?", variantID, []string{models.PriceScheduleStatusScheduled, models.PriceScheduleStatusActive}).
+		Where("starts_at < ? AND ends_at > ?", endsAt, startsAt)
+	if priceListID != nil {
+		query = query.Where("price_list_id = ?", *priceListID)
+	} else {
+		query = query.Where("price_list_id IS NULL")
+	}
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListDueSchedules returns the IDs of schedules to start or end at now, oldest first
+func (r *Repository) ListDueSchedules(now time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.Model(&models.PriceSchedule{}).
+		Where("(status = ? AND starts_at <= ?) OR (status = ? AND ends_at <= ?)",
+			models.PriceScheduleStatusScheduled, now, models.PriceScheduleStatusActive, now).
+		Order("starts_at ASC, id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// FindPriceListItem returns a variant's item in a price list
+func (r *Repository) FindPriceListItem(priceListID, variantID int64) (*models.PriceListItem, error) {
+	var item models.PriceListItem
+	err := r.db.Where("price_list_id = ? AND product_variant_id = ?", priceListID, variantID).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *Repository) GetVariant(id int64) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := r.db.First(&variant, id).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
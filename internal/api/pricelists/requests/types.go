@@ -0,0 +1,57 @@
+package requests
+
+import "time"
+
+type PriceListFilterRequest struct {
+	StoreFrontID    *int64 `form:"store_front_id"`
+	CustomerGroupID *int64 `form:"customer_group_id"`
+}
+
+type CreatePriceListRequest struct {
+	Name            string `json:"name" binding:"required"`
+	StoreFrontID    int64  `json:"store_front_id" binding:"required"`
+	Currency        string `json:"currency" binding:"required"`
+	CustomerGroupID *int64 `json:"customer_group_id"` // Optional, limits the list to one customer group
+	IsActive        *bool  `json:"is_active"`
+}
+
+// UpdatePriceListRequest updates a list, its store, currency and group are fixed
+type UpdatePriceListRequest struct {
+	Name     string `json:"name" binding:"required"`
+	IsActive bool   `json:"is_active"`
+}
+
+type PriceListItemRequest struct {
+	ProductVariantID int64    `json:"product_variant_id" binding:"required"`
+	Price            *float64 `json:"price" binding:"required,min=0"`
+	CompareAtPrice   *float64 `json:"compare_at_price" binding:"omitempty,min=0"`
+}
+
+// SetPriceListItemsRequest adds or replaces the prices of variants in a list
+type SetPriceListItemsRequest struct {
+	Items []PriceListItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type CustomerGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Slug        string `json:"slug"` // Derived from the name when empty
+	Description string `json:"description"`
+}
+
+type PriceScheduleFilterRequest struct {
+	ProductVariantID *int64 `form:"product_variant_id"`
+	PriceListID      *int64 `form:"price_list_id"`
+	Status           string `form:"status"`
+}
+
+// PriceScheduleRequest schedules a sale price for a variant, in a price list when
+// price_list_id is set
+type PriceScheduleRequest struct {
+	Name             string    `json:"name" binding:"required"`
+	ProductVariantID int64     `json:"product_variant_id" binding:"required"`
+	PriceListID      *int64    `json:"price_list_id"`
+	SalePrice        *float64  `json:"sale_price" binding:"required,min=0"`
+	CompareAtPrice   *float64  `json:"compare_at_price" binding:"omitempty,min=0"`
+	StartsAt         time.Time `json:"starts_at" binding:"required"`
+	EndsAt           time.Time `json:"ends_at" binding:"required"`
+}
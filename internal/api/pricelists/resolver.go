@@ -0,0 +1,57 @@
+package pricelists
+
+import "gorm.io/gorm"
+
+// VariantPrice is the price list price of a variant in one store and currency
+type VariantPrice struct {
+	PriceListID    int64    `json:"price_list_id"`
+	Price          float64  `json:"price"`
+	CompareAtPrice *float64 `json:"compare_at_price"`
+}
+
+// ResolvePrices returns the price list prices of variants in a store and currency keyed
+// by variant ID. A customer group's list wins over the store's list without a group;
+// variants without a price in an active list are left out and keep their own price.
+func ResolvePrices(db *gorm.DB, storeFrontID int64, currency string, customerGroupID *int64, variantIDs []int64) (map[int64]VariantPrice, error) {
+	prices := make(map[int64]VariantPrice)
+	if len(variantIDs) == 0 || currency == "" {
+		return prices, nil
+	}
+
+	query := db.Table("price_list_items pli").
+		Joins("JOIN price_lists pl ON pl.id = pli.price_list_id").
+		Where("pl.store_front_id = ? AND pl.currency = ? AND pl.is_active = true AND pli.product_variant_id IN ?", storeFrontID, currency, variantIDs)
+	if customerGroupID != nil {
+		query = query.Where("(pl.customer_group_id IS NULL OR pl.customer_group_id = ?)", *customerGroupID)
+	} else {
+		query = query.Where("pl.customer_group_id IS NULL")
+	}
+
+	var rows []struct {
+		PriceListID      int64
+		ProductVariantID int64
+		Price            float64
+		CompareAtPrice   *float64
+		CustomerGroupID  *int64
+	}
+	err := query.
+		Select("pli.price_list_id, pli.product_variant_id, pli.price, pli.compare_at_price, pl.customer_group_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[int64]bool)
+	for _, row := range rows {
+		if grouped[row.ProductVariantID] {
+			continue
+		}
+		prices[row.ProductVariantID] = VariantPrice{
+			PriceListID:    row.PriceListID,
+			Price:          row.Price,
+			CompareAtPrice: row.CompareAtPrice,
+		}
+		grouped[row.ProductVariantID] = row.CustomerGroupID != nil
+	}
+	return prices, nil
+}
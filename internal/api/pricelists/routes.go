@@ -0,0 +1,33 @@
+package pricelists
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/onas/ecommerce-api/internal/middleware"
+)
+
+func RegisterRoutes(router *gin.RouterGroup, controller *Controller) {
+	adminRoutes := router.Group("/admin")
+	adminRoutes.Use(middleware.AuthMiddleware(), middleware.AdminAuthMiddleware())
+	{
+		priceLists := adminRoutes.Group("/price-lists")
+		priceLists.GET("", middleware.RequirePermission("pricing.view"), controller.List)
+		priceLists.GET("/:id", middleware.RequirePermission("pricing.view"), controller.GetByID)
+		priceLists.POST("", middleware.RequirePermission("pricing.manage"), controller.Create)
+		priceLists.PUT("/:id", middleware.RequirePermission("pricing.manage"), controller.Update)
+		priceLists.DELETE("/:id", middleware.RequirePermission("pricing.manage"), controller.Delete)
+		priceLists.PUT("/:id/items", middleware.RequirePermission("pricing.manage"), controller.SetItems)
+		priceLists.DELETE("/:id/items/:variantId", middleware.RequirePermission("pricing.manage"), controller.RemoveItem)
+
+		schedules := adminRoutes.Group("/price-schedules")
+		schedules.GET("", middleware.RequirePermission("pricing.view"), controller.ListSchedules)
+		schedules.GET("/:id", middleware.RequirePermission("pricing.view"), controller.GetSchedule)
+		schedules.POST("", middleware.RequirePermission("pricing.manage"), controller.CreateSchedule)
+		schedules.PUT("/:id", middleware.RequirePermission("pricing.manage"), controller.UpdateSchedule)
+		schedules.POST("/:id/cancel", middleware.RequirePermission("pricing.manage"), controller.CancelSchedule)
+
+		groups := adminRoutes.Group("/customer-groups")
+		groups.GET("", middleware.RequirePermission("pricing.view"), controller.ListCustomerGroups)
+		groups.POST("", middleware.RequirePermission("pricing.manage"), controller.CreateCustomerGroup)
+		groups.PUT("/:id", middleware.RequirePermission("pricing.manage"), controller.UpdateCustomerGroup)
+	}
+}
@@ -0,0 +1,366 @@
+package pricelists
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/pricelists/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *Service) ListSchedules(filter requests.PriceScheduleFilterRequest, pagination *utils.Pagination) utils.IResource {
+	schedules, total, err := s.repo.ListSchedules(filter, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve price schedules", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Price schedules retrieved successfully", schedules, pagination.GetMeta())
+}
+
+func (s *Service) GetSchedule(id int64) utils.IResource {
+	schedule, err := s.repo.GetSchedule(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Price schedule not found", nil)
+	}
+
+	return utils.NewOKResource("Price schedule retrieved successfully", schedule)
+}
+
+// CreateSchedule schedules a sale price. A window that already started is applied right away.
+func (s *Service) CreateSchedule(req requests.PriceScheduleRequest, adminID int64) utils.IResource {
+	if res := s.validateSchedule(req, 0); res != nil {
+		return res
+	}
+
+	schedule := &models.PriceSchedule{
+		Status:    models.PriceScheduleStatusScheduled,
+		CreatedBy: &adminID,
+	}
+	setScheduleFields(schedule, req)
+	if err := s.repo.SaveSchedule(schedule); err != nil {
+		return utils.NewInternalErrorResource("Failed to create price schedule", err)
+	}
+
+	if !schedule.StartsAt.After(time.Now()) {
+		if err := s.processSchedule(schedule.ID, time.Now()); err != nil {
+			return utils.NewInternalErrorResource("Failed to apply price schedule", err)
+		}
+	}
+
+	return utils.NewCreatedResource("Price schedule created successfully", s.reloadSchedule(schedule))
+}
+
+// UpdateSchedule changes a schedule that has not started yet
+func (s *Service) UpdateSchedule(id int64, req requests.PriceScheduleRequest) utils.IResource {
+	schedule, err := s.repo.GetSchedule(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Price schedule not found", nil)
+	}
+	if schedule.Status != models.PriceScheduleStatusScheduled {
+		return utils.NewBadRequestResource(fmt.Sprintf("Cannot update a price schedule that is %s", schedule.Status), nil)
+	}
+	if res := s.validateSchedule(req, id); res != nil {
+		return res
+	}
+
+	setScheduleFields(schedule, req)
+	if err := s.repo.SaveSchedule(schedule); err != nil {
+		return utils.NewInternalErrorResource("Failed to update price schedule", err)
+	}
+
+	if !schedule.StartsAt.After(time.Now()) {
+		if err := s.processSchedule(schedule.ID, time.Now()); err != nil {
+			return utils.NewInternalErrorResource("Failed to apply price schedule", err)
+		}
+	}
+
+	return utils.NewOKResource("Price schedule updated successfully", s.reloadSchedule(schedule))
+}
+
+// CancelSchedule cancels a schedule, an active sale is reverted to the original prices
+func (s *Service) CancelSchedule(id int64) utils.IResource {
+	var schedule *models.PriceSchedule
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		schedule, err = s.repo.LockSchedule(tx, id)
+		if err != nil {
+			return err
+		}
+		if !schedule.IsOpen() {
+			return fmt.Errorf("price schedule is already %s", schedule.Status)
+		}
+
+		if schedule.Status == models.PriceScheduleStatusActive {
+			if _, err := s.revertSchedule(tx, schedule); err != nil {
+				return err
+			}
+			now := time.Now()
+			schedule.EndedAt = &now
+		}
+		schedule.Status = models.PriceScheduleStatusCancelled
+		return tx.Save(schedule).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.NewNotFoundResource("Price schedule not found", nil)
+	}
+	if err != nil {
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
+
+	return utils.NewOKResource("Price schedule cancelled successfully", schedule)
+}
+
+// RunSchedules starts and ends the schedules due at now and returns how many changed
+func (s *Service) RunSchedules(now time.Time) (int, error) {
+	ids, err := s.repo.ListDueSchedules(now, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, id := range ids {
+		if err := s.processSchedule(id, now); err != nil {
+			log.Printf("⚠️  Failed to run price schedule %d: %v", id, err)
+			continue
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// processSchedule moves one schedule along its window: a started schedule applies its
+// sale price, an ended one restores the original prices. A window that passed before
+// the schedule ever started ends without touching prices, and a sale price changed by
+// hand during the window is left in place with the schedule marked overridden.
+func (s *Service) processSchedule(id int64, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		schedule, err := s.repo.LockSchedule(tx, id)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case schedule.Status == models.PriceScheduleStatusScheduled && !schedule.EndsAt.After(now):
+			schedule.Status = models.PriceScheduleStatusEnded
+			schedule.EndedAt = &now
+		case schedule.Status == models.PriceScheduleStatusScheduled && !schedule.StartsAt.After(now):
+			if err := s.applySchedule(tx, schedule); err != nil {
+				return err
+			}
+			schedule.Status = models.PriceScheduleStatusActive
+			schedule.ActivatedAt = &now
+		case schedule.Status == models.PriceScheduleStatusActive && !schedule.EndsAt.After(now):
+			reverted, err := s.revertSchedule(tx, schedule)
+			if err != nil {
+				return err
+			}
+			schedule.Status = models.PriceScheduleStatusEnded
+			if !reverted {
+				schedule.Status = models.PriceScheduleStatusOverridden
+			}
+			schedule.EndedAt = &now
+		default:
+			return nil
+		}
+		return tx.Save(schedule).Error
+	})
+}
+
+// applySchedule records the prices the sale replaces and sets the sale price. Without a
+// compare at price the replaced price is shown as the compare at price.
+func (s *Service) applySchedule(tx *gorm.DB, schedule *models.PriceSchedule) error {
+	if schedule.PriceListID == nil {
+		var variant models.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&variant, schedule.ProductVariantID).Error; err != nil {
+			return err
+		}
+		schedule.OriginalPrice = variant.Price
+		schedule.OriginalCompareAtPrice = variant.CompareAtPrice
+		if err := setVariantPrice(tx, variant.ID, &schedule.SalePrice, saleCompareAt(schedule)); err != nil {
+			return err
+		}
+		return s.recordPriceRevision(tx, variant.ProductID)
+	}
+
+	var item models.PriceListItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("price_list_id = ? AND product_variant_id = ?", *schedule.PriceListID, schedule.ProductVariantID).
+		First(&item).Error
+	switch {
+	case err == nil:
+		original := item.Price
+		schedule.OriginalListed = true
+		schedule.OriginalPrice = &original
+		schedule.OriginalCompareAtPrice = item.CompareAtPrice
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		schedule.OriginalListed = false
+		item = models.PriceListItem{PriceListID: *schedule.PriceListID, ProductVariantID: schedule.ProductVariantID}
+	default:
+		return err
+	}
+
+	item.Price = schedule.SalePrice
+	item.CompareAtPrice = saleCompareAt(schedule)
+	return tx.Save(&item).Error
+}
+
+// revertSchedule restores the prices an active schedule replaced. A price that no longer
+// is the sale price was changed during the sale and is kept, reverted reports false then.
+func (s *Service) revertSchedule(tx *gorm.DB, schedule *models.PriceSchedule) (bool, error) {
+	if schedule.PriceListID == nil {
+		var variant models.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&variant, schedule.ProductVariantID).Error; err != nil {
+			return false, err
+		}
+		if !isSalePrice(variant.Price, schedule) {
+			return false, nil
+		}
+		if err := setVariantPrice(tx, variant.ID, schedule.OriginalPrice, schedule.OriginalCompareAtPrice); err != nil {
+			return false, err
+		}
+		return true, s.recordPriceRevision(tx, variant.ProductID)
+	}
+
+	var item models.PriceListItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("price_list_id = ? AND product_variant_id = ?", *schedule.PriceListID, schedule.ProductVariantID).
+		First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !isSalePrice(&item.Price, schedule) {
+		return false, nil
+	}
+
+	if !schedule.OriginalListed || schedule.OriginalPrice == nil {
+		return true, tx.Delete(&item).Error
+	}
+	return true, tx.Model(&item).Updates(map[string]interface{}{
+		"price":            *schedule.OriginalPrice,
+		"compare_at_price": schedule.OriginalCompareAtPrice,
+		"updated_at":       time.Now(),
+	}).Error
+}
+
+// isSalePrice reports whether price is still the sale price a schedule set
+func isSalePrice(price *float64, schedule *models.PriceSchedule) bool {
+	return price != nil && math.Abs(*price-schedule.SalePrice) < 0.005
+}
+
+// recordPriceRevision records a product revision for a variant price the scheduler
+// changed. Price list prices are not part of product revisions.
+func (s *Service) recordPriceRevision(tx *gorm.DB, productID int64) error {
+	if s.recordRevision == nil {
+		return nil
+	}
+	return s.recordRevision(tx, productID)
+}
+
+func setVariantPrice(tx *gorm.DB, variantID int64, price, compareAtPrice *float64) error {
+	return tx.Model(&models.ProductVariant{}).Where("id = ?", variantID).Updates(map[string]interface{}{
+		"price":            price,
+		"compare_at_price": compareAtPrice,
+		"updated_at":       time.Now(),
+	}).Error
+}
+
+func saleCompareAt(schedule *models.PriceSchedule) *float64 {
+	if schedule.CompareAtPrice != nil {
+		return schedule.CompareAtPrice
+	}
+	return schedule.OriginalPrice
+}
+
+// validateSchedule checks the window, the target and that no open schedule of the
+// same target overlaps it
+func (s *Service) validateSchedule(req requests.PriceScheduleRequest, excludeID int64) utils.IResource {
+	if !req.EndsAt.After(req.StartsAt) {
+		return utils.NewBadRequestResource("ends_at must be after starts_at", nil)
+	}
+	if !req.EndsAt.After(time.Now()) {
+		return utils.NewBadRequestResource("ends_at must be in the future", nil)
+	}
+	if req.CompareAtPrice != nil && *req.CompareAtPrice < *req.SalePrice {
+		return utils.NewBadRequestResource("compare_at_price must not be below sale_price", nil)
+	}
+
+	if _, err := s.repo.GetVariant(req.ProductVariantID); err != nil {
+		return utils.NewBadRequestResource("Variant not found", nil)
+	}
+	if req.PriceListID != nil {
+		if _, err := s.repo.GetByID(*req.PriceListID); err != nil {
+			return utils.NewBadRequestResource("Price list not found", nil)
+		}
+	}
+
+	overlaps, err := s.repo.HasOverlappingSchedule(req.ProductVariantID, req.PriceListID, req.StartsAt, req.EndsAt, excludeID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate price schedule", err)
+	}
+	if overlaps {
+		return utils.NewBadRequestResource("The variant already has a price schedule overlapping this window", nil)
+	}
+	return nil
+}
+
+func setScheduleFields(schedule *models.PriceSchedule, req requests.PriceScheduleRequest) {
+	schedule.Name = req.Name
+	schedule.ProductVariantID = req.ProductVariantID
+	schedule.PriceListID = req.PriceListID
+	schedule.SalePrice = *req.SalePrice
+	schedule.CompareAtPrice = req.CompareAtPrice
+	schedule.StartsAt = req.StartsAt
+	schedule.EndsAt = req.EndsAt
+}
+
+// reloadSchedule returns the stored schedule, falling back to the given one
+func (s *Service) reloadSchedule(schedule *models.PriceSchedule) *models.PriceSchedule {
+	if fresh, err := s.repo.GetSchedule(schedule.ID); err == nil {
+		return fresh
+	}
+	return schedule
+}
+
+// PriceScheduleJob runs RunSchedules on a fixed interval
+type PriceScheduleJob struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewPriceScheduleJob(service *Service, interval time.Duration) *PriceScheduleJob {
+	return &PriceScheduleJob{service: service, interval: interval}
+}
+
+// Start runs the job in the background until ctx is cancelled
+func (j *PriceScheduleJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			changed, err := j.service.RunSchedules(time.Now())
+			if err != nil {
+				log.Printf("⚠️  Price schedule run failed: %v", err)
+			} else if changed > 0 {
+				log.Printf("⏱️  Started or ended %d price schedule(s)", changed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
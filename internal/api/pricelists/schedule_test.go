@@ -0,0 +1,131 @@
+package pricelists
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/pricelists/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(
+		&models.ProductVariant{},
+		&models.PriceList{},
+		&models.PriceListItem{},
+		&models.PriceSchedule{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	return db
+}
+
+func TestRunSchedules_AppliesAndRevertsSalePrice(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewService(db, NewRepository(db))
+
+	price := 100.0
+	db.Create(&models.ProductVariant{ID: 1, ProductID: 1, SKU: "TEE-1", Price: &price, IsActive: true})
+
+	start := time.Now().Add(time.Hour)
+	salePrice := 80.0
+	req := requests.PriceScheduleRequest{
+		Name:             "Weekend sale",
+		ProductVariantID: 1,
+		SalePrice:        &salePrice,
+		StartsAt:         start,
+		EndsAt:           start.Add(48 * time.Hour),
+	}
+	if res := service.CreateSchedule(req, 1); res.GetStatusCode() != 201 {
+		t.Fatalf("expected schedule to be created, got %d: %s", res.GetStatusCode(), res.GetMessage())
+	}
+
+	// Overlapping windows on the same variant are rejected
+	overlap := req
+	overlap.StartsAt = start.Add(24 * time.Hour)
+	overlap.EndsAt = start.Add(72 * time.Hour)
+	if res := service.CreateSchedule(overlap, 1); res.GetStatusCode() != 400 {
+		t.Fatalf("expected overlapping schedule to be rejected, got %d", res.GetStatusCode())
+	}
+
+	if _, err := service.RunSchedules(start.Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var variant models.ProductVariant
+	db.First(&variant, 1)
+	if *variant.Price != 80 || variant.CompareAtPrice == nil || *variant.CompareAtPrice != 100 {
+		t.Fatalf("expected sale price 80 with compare at 100, got %v / %v", *variant.Price, variant.CompareAtPrice)
+	}
+
+	if _, err := service.RunSchedules(start.Add(49 * time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db.First(&variant, 1)
+	if *variant.Price != 100 || variant.CompareAtPrice != nil {
+		t.Fatalf("expected original price to be restored, got %v / %v", *variant.Price, variant.CompareAtPrice)
+	}
+
+	var schedule models.PriceSchedule
+	db.First(&schedule)
+	if schedule.Status != models.PriceScheduleStatusEnded {
+		t.Fatalf("expected schedule to be ended, got %s", schedule.Status)
+	}
+}
+
+func TestRunSchedules_KeepsPriceChangedDuringSale(t *testing.T) {
+	db := setupTestDB(t)
+	var revisions []int64
+	service := NewService(db, NewRepository(db)).WithRevisions(func(tx *gorm.DB, productID int64) error {
+		revisions = append(revisions, productID)
+		return nil
+	})
+
+	price := 100.0
+	db.Create(&models.ProductVariant{ID: 1, ProductID: 7, SKU: "TEE-1", Price: &price, IsActive: true})
+
+	start := time.Now().Add(time.Hour)
+	salePrice := 80.0
+	req := requests.PriceScheduleRequest{
+		Name:             "Weekend sale",
+		ProductVariantID: 1,
+		SalePrice:        &salePrice,
+		StartsAt:         start,
+		EndsAt:           start.Add(48 * time.Hour),
+	}
+	if res := service.CreateSchedule(req, 1); res.GetStatusCode() != 201 {
+		t.Fatalf("expected schedule to be created, got %d: %s", res.GetStatusCode(), res.GetMessage())
+	}
+	if _, err := service.RunSchedules(start.Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// An admin reprices the variant during the sale
+	db.Model(&models.ProductVariant{}).Where("id = ?", 1).Update("price", 90.0)
+
+	if _, err := service.RunSchedules(start.Add(49 * time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var variant models.ProductVariant
+	db.First(&variant, 1)
+	if *variant.Price != 90 {
+		t.Fatalf("expected the admin's price to be kept, got %v", *variant.Price)
+	}
+
+	var schedule models.PriceSchedule
+	db.First(&schedule)
+	if schedule.Status != models.PriceScheduleStatusOverridden {
+		t.Fatalf("expected schedule to be overridden, got %s", schedule.Status)
+	}
+	if len(revisions) != 1 || revisions[0] != 7 {
+		t.Fatalf("expected one revision of product 7 for the applied sale, got %v", revisions)
+	}
+}
@@ -0,0 +1,256 @@
+package pricelists
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onas/ecommerce-api/internal/api/pricelists/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// RevisionRecorder records a revision of a product whose variant prices a schedule
+// changed. Products own the revisions and depend on this package, so it is passed in.
+type RevisionRecorder func(tx *gorm.DB, productID int64) error
+
+type Service struct {
+	db             *gorm.DB
+	repo           *Repository
+	recordRevision RevisionRecorder
+}
+
+func NewService(db *gorm.DB, repo *Repository) *Service {
+	return &Service{db: db, repo: repo}
+}
+
+// WithRevisions records a product revision whenever a schedule changes variant prices
+func (s *Service) WithRevisions(record RevisionRecorder) *Service {
+	s.recordRevision = record
+	return s
+}
+
+// ============== Price Lists ==============
+
+func (s *Service) List(filter requests.PriceListFilterRequest, pagination *utils.Pagination) utils.IResource {
+	lists, total, err := s.repo.List(filter, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve price lists", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Price lists retrieved successfully", lists, pagination.GetMeta())
+}
+
+func (s *Service) GetByID(id int64) utils.IResource {
+	list, err := s.repo.GetByID(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Price list not found", nil)
+	}
+
+	return utils.NewOKResource("Price list retrieved successfully", list)
+}
+
+func (s *Service) Create(req requests.CreatePriceListRequest) utils.IResource {
+	if _, err := s.repo.GetStoreFront(req.StoreFrontID); err != nil {
+		return utils.NewBadRequestResource("Store front not found", nil)
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	exists, err := s.repo.CurrencyExists(currency)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate currency", err)
+	}
+	if !exists {
+		return utils.NewBadRequestResource(fmt.Sprintf("Unknown currency %s", currency), nil)
+	}
+
+	if req.CustomerGroupID != nil {
+		if _, err := s.repo.GetCustomerGroup(*req.CustomerGroupID); err != nil {
+			return utils.NewBadRequestResource("Customer group not found", nil)
+		}
+	}
+
+	// Prices resolve to a single list per store, currency and group
+	taken, err := s.repo.IsScopeTaken(req.StoreFrontID, currency, req.CustomerGroupID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate price list", err)
+	}
+	if taken {
+		return utils.NewBadRequestResource("The store already has a price list for this currency and customer group", nil)
+	}
+
+	list := &models.PriceList{
+		Name:            req.Name,
+		StoreFrontID:    req.StoreFrontID,
+		Currency:        currency,
+		CustomerGroupID: req.CustomerGroupID,
+		IsActive:        true,
+	}
+	if req.IsActive != nil {
+		list.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.Create(list); err != nil {
+		return utils.NewInternalErrorResource("Failed to create price list", err)
+	}
+	if !list.IsActive {
+		// The column default would otherwise turn a false value back on
+		list.IsActive = false
+		if err := s.repo.Update(list); err != nil {
+			return utils.NewInternalErrorResource("Failed to create price list", err)
+		}
+	}
+
+	return utils.NewCreatedResource("Price list created successfully", list)
+}
+
+func (s *Service) Update(id int64, req requests.UpdatePriceListRequest) utils.IResource {
+	list, err := s.repo.GetByID(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Price list not found", nil)
+	}
+
+	list.Name = req.Name
+	list.IsActive = req.IsActive
+	if err := s.repo.Update(list); err != nil {
+		return utils.NewInternalErrorResource("Failed to update price list", err)
+	}
+
+	return utils.NewOKResource("Price list updated successfully", list)
+}
+
+func (s *Service) Delete(id int64) utils.IResource {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return utils.NewNotFoundResource("Price list not found", nil)
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return utils.NewInternalErrorResource("Failed to delete price list", err)
+	}
+
+	return utils.NewNoContentResource()
+}
+
+// ============== Price List Items ==============
+
+// SetItems adds or replaces variant prices in a list
+func (s *Service) SetItems(id int64, req requests.SetPriceListItemsRequest) utils.IResource {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return utils.NewNotFoundResource("Price list not found", nil)
+	}
+
+	seen := make(map[int64]bool, len(req.Items))
+	variantIDs := make([]int64, 0, len(req.Items))
+	items := make([]models.PriceListItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		if seen[item.ProductVariantID] {
+			return utils.NewBadRequestResource(fmt.Sprintf("Variant %d is listed more than once", item.ProductVariantID), nil)
+		}
+		seen[item.ProductVariantID] = true
+
+		if item.CompareAtPrice != nil && *item.CompareAtPrice < *item.Price {
+			return utils.NewBadRequestResource(fmt.Sprintf("Compare at price of variant %d is below its price", item.ProductVariantID), nil)
+		}
+
+		variantIDs = append(variantIDs, item.ProductVariantID)
+		items = append(items, models.PriceListItem{
+			PriceListID:      id,
+			ProductVariantID: item.ProductVariantID,
+			Price:            *item.Price,
+			CompareAtPrice:   item.CompareAtPrice,
+		})
+	}
+
+	count, err := s.repo.CountVariants(variantIDs)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate variants", err)
+	}
+	if count != int64(len(variantIDs)) {
+		return utils.NewBadRequestResource("One or more variants were not found", nil)
+	}
+
+	if err := s.repo.UpsertItems(items); err != nil {
+		return utils.NewInternalErrorResource("Failed to save prices", err)
+	}
+
+	return s.GetByID(id)
+}
+
+// RemoveItem removes a variant's price from a list, the variant falls back to its own price
+func (s *Service) RemoveItem(id, variantID int64) utils.IResource {
+	removed, err := s.repo.DeleteItem(id, variantID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to remove price", err)
+	}
+	if !removed {
+		return utils.NewNotFoundResource("Variant price not found in price list", nil)
+	}
+
+	return utils.NewNoContentResource()
+}
+
+// ============== Customer Groups ==============
+
+func (s *Service) ListCustomerGroups() utils.IResource {
+	groups, err := s.repo.ListCustomerGroups()
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve customer groups", err)
+	}
+
+	return utils.NewOKResource("Customer groups retrieved successfully", groups)
+}
+
+func (s *Service) CreateCustomerGroup(req requests.CustomerGroupRequest) utils.IResource {
+	slug := groupSlug(req)
+	unique, err := s.repo.IsGroupSlugUnique(slug, 0)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate slug", err)
+	}
+	if !unique {
+		return utils.NewBadRequestResource("Slug already in use", nil)
+	}
+
+	group := &models.CustomerGroup{
+		Name:        req.Name,
+		Slug:        slug,
+		Description: req.Description,
+	}
+	if err := s.repo.CreateCustomerGroup(group); err != nil {
+		return utils.NewInternalErrorResource("Failed to create customer group", err)
+	}
+
+	return utils.NewCreatedResource("Customer group created successfully", group)
+}
+
+func (s *Service) UpdateCustomerGroup(id int64, req requests.CustomerGroupRequest) utils.IResource {
+	group, err := s.repo.GetCustomerGroup(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Customer group not found", nil)
+	}
+
+	slug := groupSlug(req)
+	unique, err := s.repo.IsGroupSlugUnique(slug, id)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate slug", err)
+	}
+	if !unique {
+		return utils.NewBadRequestResource("Slug already in use", nil)
+	}
+
+	group.Name = req.Name
+	group.Slug = slug
+	group.Description = req.Description
+	if err := s.repo.UpdateCustomerGroup(group); err != nil {
+		return utils.NewInternalErrorResource("Failed to update customer group", err)
+	}
+
+	return utils.NewOKResource("Customer group updated successfully", group)
+}
+
+func groupSlug(req requests.CustomerGroupRequest) string {
+	if req.Slug != "" {
+		return utils.Slugify(req.Slug)
+	}
+	return utils.Slugify(req.Name)
+}
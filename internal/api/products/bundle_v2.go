@@ -0,0 +1,126 @@
+package products
+
+import (
+	"fmt"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory"
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// BundleComponentInfo is one component of a bundle variant as shown to admins
+type BundleComponentInfo struct {
+	ProductVariantID int64  `json:"product_variant_id"`
+	ProductID        int64  `json:"product_id"`
+	SKU              string `json:"sku"`
+	AttributeValue   string `json:"attribute_value"`
+	Quantity         int    `json:"quantity"`
+}
+
+// getBundleVariant loads a variant of a bundle product
+func (s *ServiceV2) getBundleVariant(productID, variantID int64) (*models.ProductVariant, utils.IResource) {
+	product, err := s.repo.GetProductModelByID(productID)
+	if err != nil {
+		return nil, utils.NewNotFoundResource("Product not found", nil)
+	}
+	if product.ProductType != models.ProductTypeBundle {
+		return nil, utils.NewBadRequestResource("Product is not a bundle", nil)
+	}
+
+	variant, err := s.repo.GetVariantByID(variantID)
+	if err != nil || variant.ProductID != productID {
+		return nil, utils.NewNotFoundResource("Variant not found", nil)
+	}
+	return variant, nil
+}
+
+// GetBundleComponents lists the components of a bundle variant
+func (s *ServiceV2) GetBundleComponents(productID, variantID int64) utils.IResource {
+	if _, res := s.getBundleVariant(productID, variantID); res != nil {
+		return res
+	}
+
+	components, err := s.invRepo.ListBundleComponents(s.db, variantID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve bundle components", err)
+	}
+
+	return utils.NewOKResource("Bundle components retrieved successfully", toBundleComponentInfos(components))
+}
+
+// SetBundleComponents replaces the components of a bundle variant
+func (s *ServiceV2) SetBundleComponents(productID, variantID int64, req requests.SetBundleComponentsRequest) utils.IResource {
+	if _, res := s.getBundleVariant(productID, variantID); res != nil {
+		return res
+	}
+
+	ids := make([]int64, 0, len(req.Components))
+	seen := make(map[int64]bool)
+	for _, c := range req.Components {
+		if c.ProductVariantID == variantID {
+			return utils.NewBadRequestResource("A bundle cannot contain itself", nil)
+		}
+		if seen[c.ProductVariantID] {
+			return utils.NewBadRequestResource(fmt.Sprintf("Duplicate component variant %d", c.ProductVariantID), nil)
+		}
+		seen[c.ProductVariantID] = true
+		ids = append(ids, c.ProductVariantID)
+	}
+
+	var variants []models.ProductVariant
+	if err := s.db.Preload("Product").Where("id IN ?", ids).Find(&variants).Error; err != nil {
+		return utils.NewInternalErrorResource("Failed to validate components", err)
+	}
+	found := make(map[int64]models.ProductVariant, len(variants))
+	for _, v := range variants {
+		found[v.ID] = v
+	}
+
+	components := make([]models.BundleComponent, 0, len(req.Components))
+	for _, c := range req.Components {
+		v, ok := found[c.ProductVariantID]
+		if !ok {
+			return utils.NewBadRequestResource(fmt.Sprintf("Component variant %d not found", c.ProductVariantID), nil)
+		}
+		if v.Product != nil && v.Product.ProductType == models.ProductTypeBundle {
+			return utils.NewBadRequestResource(fmt.Sprintf("Component %s is itself a bundle", v.SKU), nil)
+		}
+		// Bundle lines carry no serials or lots, so their components must be untracked
+		if v.TrackingMode != "" && v.TrackingMode != models.TrackingModeNone {
+			return utils.NewBadRequestResource(fmt.Sprintf("Component %s is %s-tracked and cannot be part of a bundle", v.SKU, v.TrackingMode), nil)
+		}
+		components = append(components, models.BundleComponent{
+			BundleVariantID:    variantID,
+			ComponentVariantID: c.ProductVariantID,
+			Quantity:           c.Quantity,
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := (&V2Repository{db: tx}).ReplaceBundleComponents(tx, variantID, components); err != nil {
+			return err
+		}
+		return inventory.RefreshProductStock(tx, productID)
+	})
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to save bundle components", err)
+	}
+
+	return s.GetBundleComponents(productID, variantID)
+}
+
+func toBundleComponentInfos(components []models.BundleComponent) []BundleComponentInfo {
+	items := make([]BundleComponentInfo, 0, len(components))
+	for _, c := range components {
+		item := BundleComponentInfo{ProductVariantID: c.ComponentVariantID, Quantity: c.Quantity}
+		if c.ComponentVariant != nil {
+			item.ProductID = c.ComponentVariant.ProductID
+			item.SKU = c.ComponentVariant.SKU
+			item.AttributeValue = c.ComponentVariant.AttributeValue
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,891 @@
+package products
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Catalog import row statuses and actions
+const (
+	catalogRowOK     = "ok"
+	catalogRowError  = "error"
+	catalogRowCreate = "create"
+	catalogRowUpdate = "update"
+)
+
+// catalogImportBatch is how many processed rows are reported between progress updates
+const catalogImportBatch = 50
+
+// CatalogImportRow is the result for one variant row of a catalog import file
+type CatalogImportRow struct {
+	Row       int      `json:"row"`
+	Handle    string   `json:"handle"`
+	SKU       string   `json:"sku"`
+	Action    string   `json:"action,omitempty"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	ProductID *int64   `json:"product_id,omitempty"`
+	VariantID *int64   `json:"variant_id,omitempty"`
+
+	cells          map[string]string
+	price          *float64
+	compareAtPrice *float64
+	costPrice      *float64
+	weight         *float64
+	stock          *int
+	isActive       *bool
+	imageURLs      []string
+	imageFileIDs   []int64
+}
+
+// CatalogImportJobDetail is a job with its row report
+type CatalogImportJobDetail struct {
+	models.CatalogImportJob
+	Rows []CatalogImportRow `json:"rows"`
+}
+
+// catalogProduct is the group of rows sharing a product handle
+type catalogProduct struct {
+	handle        string
+	slug          string
+	slugAr        string // Arabic slug of a new product, from name_ar
+	rows          []*CatalogImportRow
+	existing      *models.Product
+	attributes    []models.Attribute // Attributes the variants vary by
+	brandID       *int64
+	categoryID    *int64
+	supplierID    *int64
+	storeFrontIDs []int64
+	variants      map[string]models.ProductVariant // Existing variants by SKU
+}
+
+// value returns the first non-empty cell of column across the group's rows
+func (p *catalogProduct) value(column string) string {
+	for _, row := range p.rows {
+		if v := row.cells[column]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// fail marks every row of the group that has no error of its own
+func (p *catalogProduct) fail(msg string) {
+	for _, row := range p.rows {
+		if row.Status != catalogRowError {
+			row.fail(msg)
+		}
+	}
+}
+
+func (p *catalogProduct) failed() bool {
+	for _, row := range p.rows {
+		if row.Status == catalogRowError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *CatalogImportRow) fail(msg string) {
+	r.Status = catalogRowError
+	r.Error = msg
+}
+
+func (r *CatalogImportRow) warn(msg string) {
+	r.Warnings = append(r.Warnings, msg)
+}
+
+// catalogLookups holds the names and keys referenced by an import file, resolved in bulk
+type catalogLookups struct {
+	brands      map[string]int64 // Lowercased English or Arabic name
+	categories  map[string]int64
+	suppliers   map[string]int64            // Lowercased company name
+	storeFronts map[string]int64            // Slug
+	attributes  map[string]models.Attribute // Lowercased English name
+	files       map[int64]bool
+	variants    map[string]models.ProductVariant // By SKU
+	products    map[int64]*models.Product
+	slugs       map[string]*models.Product
+}
+
+// parseCatalogImport reads an import sheet into rows. Each row is a variant and rows
+// sharing a handle make up one product.
+func parseCatalogImport(sheet [][]string) ([]CatalogImportRow, error) {
+	if len(sheet) == 0 {
+		return nil, fmt.Errorf("import file is empty")
+	}
+
+	cols := make(map[string]int)
+	for i, name := range sheet[0] {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"handle", "sku"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("import file must have a %s column", required)
+		}
+	}
+
+	var rows []CatalogImportRow
+	for i, record := range sheet[1:] {
+		row := CatalogImportRow{Row: i + 2, Status: catalogRowOK, cells: make(map[string]string, len(cols))}
+		blank := true
+		for name, idx := range cols {
+			if idx < len(record) {
+				if v := strings.TrimSpace(record[idx]); v != "" {
+					row.cells[name] = v
+					blank = false
+				}
+			}
+		}
+		if blank {
+			continue
+		}
+		row.Handle = row.cells["handle"]
+		row.SKU = row.cells["sku"]
+		row.parseValues()
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseValues validates the typed columns of a row
+func (r *CatalogImportRow) parseValues() {
+	if r.Handle == "" {
+		r.fail("handle is required")
+		return
+	}
+	if r.SKU == "" {
+		r.fail("sku is required")
+		return
+	}
+
+	var err error
+	for column, dest := range map[string]**float64{
+		"price":            &r.price,
+		"compare_at_price": &r.compareAtPrice,
+		"cost_price":       &r.costPrice,
+		"weight":           &r.weight,
+	} {
+		if *dest, err = parseOptionalAmount(r.cells[column]); err != nil {
+			r.fail(fmt.Sprintf("%s must be a number of zero or more", column))
+			return
+		}
+	}
+
+	if v := r.cells["stock"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			r.fail("stock must be a whole number of zero or more")
+			return
+		}
+		r.stock = &n
+	}
+
+	if v := r.cells["is_active"]; v != "" {
+		b, err := strconv.ParseBool(strings.ToLower(v))
+		if err != nil {
+			r.fail("is_active must be true or false")
+			return
+		}
+		r.isActive = &b
+	}
+
+	r.imageURLs = splitList(r.cells["image_urls"])
+	for _, v := range splitList(r.cells["image_file_ids"]) {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			r.fail(fmt.Sprintf("invalid image file id %q", v))
+			return
+		}
+		r.imageFileIDs = append(r.imageFileIDs, id)
+	}
+}
+
+func parseOptionalAmount(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return nil, fmt.Errorf("invalid amount %q", value)
+	}
+	return &f, nil
+}
+
+// splitList splits a cell listing several values separated by commas, semicolons or spaces
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\n'
+	})
+}
+
+// groupCatalogRows groups valid rows by product handle in file order and rejects repeated SKUs
+func groupCatalogRows(rows []CatalogImportRow) []*catalogProduct {
+	var groups []*catalogProduct
+	byHandle := make(map[string]*catalogProduct)
+	skuRow := make(map[string]int)
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Status == catalogRowError {
+			continue
+		}
+		if first, dup := skuRow[row.SKU]; dup {
+			row.fail(fmt.Sprintf("duplicate sku, already listed on row %d", first))
+			continue
+		}
+		skuRow[row.SKU] = row.Row
+
+		key := strings.ToLower(row.Handle)
+		group, ok := byHandle[key]
+		if !ok {
+			group = &catalogProduct{handle: row.Handle, slug: utils.Slugify(row.Handle)}
+			byHandle[key] = group
+			groups = append(groups, group)
+		}
+		group.rows = append(group.rows, row)
+	}
+	return groups
+}
+
+// loadCatalogLookups resolves every brand, category, supplier, store front, attribute,
+// file and SKU referenced by the groups in a few queries
+func (s *ServiceV2) loadCatalogLookups(groups []*catalogProduct) (*catalogLookups, error) {
+	l := &catalogLookups{
+		brands:      make(map[string]int64),
+		categories:  make(map[string]int64),
+		suppliers:   make(map[string]int64),
+		storeFronts: make(map[string]int64),
+		attributes:  make(map[string]models.Attribute),
+		files:       make(map[int64]bool),
+		variants:    make(map[string]models.ProductVariant),
+		products:    make(map[int64]*models.Product),
+		slugs:       make(map[string]*models.Product),
+	}
+
+	var skus, slugs []string
+	var fileIDs []int64
+	for _, g := range groups {
+		slugs = append(slugs, g.slug)
+		for _, row := range g.rows {
+			skus = append(skus, row.SKU)
+			fileIDs = append(fileIDs, row.imageFileIDs...)
+		}
+	}
+
+	var brands []models.Brand
+	if err := s.db.Find(&brands).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range brands {
+		l.brands[strings.ToLower(b.NameEn)] = b.ID
+		l.brands[strings.ToLower(b.NameAr)] = b.ID
+	}
+
+	var categories []models.Category
+	if err := s.db.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range categories {
+		l.categories[strings.ToLower(c.NameEn)] = c.ID
+		l.categories[strings.ToLower(c.NameAr)] = c.ID
+	}
+
+	var suppliers []models.Supplier
+	if err := s.db.Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	for _, sup := range suppliers {
+		l.suppliers[strings.ToLower(sup.CompanyName)] = sup.ID
+	}
+
+	var stores []models.StoreFront
+	if err := s.db.Find(&stores).Error; err != nil {
+		return nil, err
+	}
+	for _, sf := range stores {
+		l.storeFronts[sf.Slug] = sf.ID
+	}
+
+	var attributes []models.Attribute
+	if err := s.db.Find(&attributes).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range attributes {
+		l.attributes[strings.ToLower(a.NameEn)] = a
+	}
+
+	if len(fileIDs) > 0 {
+		var ids []int64
+		if err := s.db.Model(&models.File{}).Where("id IN ?", fileIDs).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			l.files[id] = true
+		}
+	}
+
+	if len(skus) > 0 {
+		var variants []models.ProductVariant
+		if err := s.db.Where("sku IN ?", skus).Find(&variants).Error; err != nil {
+			return nil, err
+		}
+		productIDs := make([]int64, 0, len(variants))
+		for _, v := range variants {
+			l.variants[v.SKU] = v
+			productIDs = append(productIDs, v.ProductID)
+		}
+
+		if len(productIDs) > 0 {
+			var products []models.Product
+			if err := s.db.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+				return nil, err
+			}
+			for i := range products {
+				l.products[products[i].ID] = &products[i]
+			}
+		}
+	}
+
+	if len(slugs) > 0 {
+		var products []models.Product
+		if err := s.db.Where("slug_en IN ?", slugs).Order("id ASC").Find(&products).Error; err != nil {
+			return nil, err
+		}
+		for i := range products {
+			if _, ok := l.slugs[products[i].SlugEn]; !ok {
+				l.slugs[products[i].SlugEn] = &products[i]
+			}
+		}
+	}
+
+	return l, nil
+}
+
+// validateCatalogGroup resolves the references of a product group and marks rows that cannot be imported
+func (s *ServiceV2) validateCatalogGroup(g *catalogProduct, l *catalogLookups) {
+	if g.slug == "" {
+		g.fail("could not generate a slug from handle")
+		return
+	}
+
+	// Existing SKUs pin the product, otherwise the handle may match one
+	g.variants = make(map[string]models.ProductVariant)
+	for _, row := range g.rows {
+		v, ok := l.variants[row.SKU]
+		if !ok {
+			row.Action = catalogRowCreate
+			continue
+		}
+		row.Action = catalogRowUpdate
+		g.variants[row.SKU] = v
+		product := l.products[v.ProductID]
+		if product == nil {
+			row.fail("sku belongs to a deleted product")
+			continue
+		}
+		if g.existing != nil && g.existing.ID != product.ID {
+			g.fail(fmt.Sprintf("skus of handle %s belong to different products", g.handle))
+			return
+		}
+		g.existing = product
+	}
+	if g.failed() {
+		return
+	}
+	if g.existing == nil {
+		g.existing = l.slugs[g.slug]
+	}
+
+	// Product level references, taken from the first row that sets them
+	var missing []string
+	resolve := func(column string, index map[string]int64, dest **int64) {
+		name := g.value(column)
+		if name == "" {
+			return
+		}
+		id, ok := index[strings.ToLower(name)]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("unknown %s %q", column, name))
+			return
+		}
+		*dest = &id
+	}
+	resolve("brand", l.brands, &g.brandID)
+	resolve("category", l.categories, &g.categoryID)
+	resolve("supplier", l.suppliers, &g.supplierID)
+
+	for _, slug := range splitList(g.value("store_fronts")) {
+		id, ok := l.storeFronts[slug]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("unknown store front %q", slug))
+			continue
+		}
+		g.storeFrontIDs = append(g.storeFrontIDs, id)
+	}
+
+	if name := g.value("attribute_type"); name != "" {
+		attr, ok := l.attributes[strings.ToLower(name)]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("unknown attribute_type %q", name))
+		}
+		g.attributes = []models.Attribute{attr}
+	}
+
+	if len(missing) > 0 {
+		g.fail(strings.Join(missing, "; "))
+		return
+	}
+
+	// Existing products keep their attributes, values of several are given as "S / Red"
+	if g.existing != nil {
+		attrs, err := productAttributes(s.db, g.existing.ID)
+		if err != nil {
+			g.fail("failed to load product attributes")
+			return
+		}
+		if g.attributes != nil && !sameAttributeSet(attrs, g.attributes) {
+			g.fail("attribute_type does not match the attributes of the existing product")
+			return
+		}
+		g.attributes = attrs
+	}
+
+	if g.existing == nil {
+		switch {
+		case g.value("name_en") == "" || g.value("name_ar") == "":
+			g.fail("name_en and name_ar are required for new products")
+			return
+		case len(g.storeFrontIDs) == 0:
+			g.fail("store_fronts is required for new products")
+			return
+		case len(g.attributes) == 0 && len(g.rows) > 1:
+			g.fail("attribute_type is required for products with several variants")
+			return
+		}
+	}
+
+	// Slugs must stay unique in every store the product is listed in
+	excludeID := int64(0)
+	if g.existing != nil {
+		excludeID = g.existing.ID
+	} else {
+		g.slugAr = utils.SlugifyArabic(g.value("name_ar"))
+	}
+	for _, slug := range []string{g.slug, g.slugAr} {
+		if slug == "" {
+			continue
+		}
+		for _, sfID := range g.storeFrontIDs {
+			unique, err := s.repo.IsSlugUniqueForStore(slug, sfID, excludeID)
+			if err != nil {
+				g.fail("failed to validate slug")
+				return
+			}
+			if !unique {
+				g.fail(fmt.Sprintf("slug '%s' already exists in store %d", slug, sfID))
+				return
+			}
+		}
+	}
+
+	for _, row := range g.rows {
+		if row.Status == catalogRowError {
+			continue
+		}
+		attrValue := row.cells["attribute_value"]
+		switch {
+		case len(g.attributes) == 0 && attrValue != "":
+			row.fail("simple product cannot have attribute values, set attribute_type")
+		case len(g.attributes) > 0 && attrValue == "" && row.Action == catalogRowCreate:
+			row.fail("attribute_value is required for products with attributes")
+		case row.Action == catalogRowCreate && row.price == nil:
+			row.fail("price is required for new variants")
+		}
+		for _, id := range row.imageFileIDs {
+			if !l.files[id] {
+				row.fail(fmt.Sprintf("image file %d not found", id))
+				break
+			}
+		}
+		if row.Action == catalogRowUpdate && row.stock != nil {
+			row.warn("stock is only set for new variants, use the inventory import to change stock")
+		}
+		if g.existing != nil && g.existing.ProductType == models.ProductTypeBundle && row.stock != nil {
+			row.warn("bundles take their stock from their components, stock is ignored")
+		}
+	}
+
+	// A product is imported whole or not at all
+	if g.failed() {
+		g.fail(fmt.Sprintf("another row of handle %s has errors", g.handle))
+	}
+}
+
+// applyCatalogGroup writes one validated product group in a transaction
+func (s *ServiceV2) applyCatalogGroup(g *catalogProduct, job *models.CatalogImportJob) error {
+	// Download remote images before the transaction, failures only warn
+	imageIDs := make(map[*CatalogImportRow][]int64)
+	imageConfig := s.files.GetDefaultConfig()
+	imageConfig.AllowedTypes = map[string][]string{"image": imageConfig.AllowedTypes["image"]}
+	for _, row := range g.rows {
+		ids := append([]int64{}, row.imageFileIDs...)
+		for _, u := range row.imageURLs {
+			file, err := s.files.ImportFromURL(u, job.CreatedBy, imageConfig)
+			if err != nil {
+				row.warn(fmt.Sprintf("image %s skipped: %v", u, err))
+				continue
+			}
+			ids = append(ids, file.ID)
+		}
+		imageIDs[row] = ids
+	}
+
+	created := g.existing == nil
+	return s.invRepo.Transaction(s.db, func(tx *gorm.DB) error {
+		repoTx := &V2Repository{db: tx}
+
+		product := g.existing
+		if created {
+			productType := g.value("product_type")
+			if productType != models.ProductTypeBundle {
+				productType = models.ProductTypeSimple
+			}
+			product = &models.Product{
+				NameEn:        g.value("name_en"),
+				NameAr:        g.value("name_ar"),
+				Name:          g.value("name_en"), // backward compat
+				Slug:          g.slug,
+				SlugEn:        g.slug,
+				SlugAr:        g.slugAr,
+				DescriptionEn: g.value("description_en"),
+				DescriptionAr: g.value("description_ar"),
+				Description:   g.value("description_en"), // backward compat
+				BrandID:       g.brandID,
+				CategoryID:    g.categoryID,
+				SupplierID:    g.supplierID,
+				AttributeType: attributeTypeOf(g.attributes),
+				ProductType:   productType,
+				Status:        models.ProductStatusDraft,
+				IsActive:      true,
+			}
+			if err := tx.Create(product).Error; err != nil {
+				return fmt.Errorf("failed to create product: %w", err)
+			}
+			if err := setProductAttributes(tx, product.ID, g.attributes); err != nil {
+				return fmt.Errorf("failed to set product attributes: %w", err)
+			}
+		} else {
+			updates := map[string]interface{}{"updated_at": time.Now()}
+			for column, field := range map[string]string{
+				"name_en": "name_en", "name_ar": "name_ar",
+				"description_en": "description_en", "description_ar": "description_ar",
+			} {
+				if v := g.value(column); v != "" {
+					updates[field] = v
+				}
+			}
+			if g.brandID != nil {
+				updates["brand_id"] = *g.brandID
+			}
+			if g.categoryID != nil {
+				updates["category_id"] = *g.categoryID
+			}
+			if g.supplierID != nil {
+				updates["supplier_id"] = *g.supplierID
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update product: %w", err)
+			}
+		}
+
+		storeFrontIDs := g.storeFrontIDs
+		if len(storeFrontIDs) > 0 {
+			if err := repoTx.AssignProductToStores(tx, product.ID, storeFrontIDs); err != nil {
+				return fmt.Errorf("failed to assign store fronts: %w", err)
+			}
+		} else {
+			var err error
+			if storeFrontIDs, err = repoTx.GetProductStoreFrontIDs(product.ID); err != nil {
+				return err
+			}
+		}
+
+		var fileIDs []int64
+		for _, row := range g.rows {
+			variantID, err := s.upsertCatalogVariant(tx, repoTx, product, g.attributes, storeFrontIDs, g.variants[row.SKU], row)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			row.ProductID = &product.ID
+			row.VariantID = &variantID
+			fileIDs = append(fileIDs, imageIDs[row]...)
+		}
+
+		if err := s.attachCatalogImages(tx, repoTx, product.ID, fileIDs); err != nil {
+			return fmt.Errorf("failed to attach images: %w", err)
+		}
+		if err := inventory.RefreshProductStock(tx, product.ID); err != nil {
+			return err
+		}
+		return recordRevision(tx, product.ID, models.ProductRevisionImport, job.CreatedBy, nil)
+	})
+}
+
+// upsertCatalogVariant creates or updates the variant of a row and returns its ID
+func (s *ServiceV2) upsertCatalogVariant(tx *gorm.DB, repoTx *V2Repository, product *models.Product, attrs []models.Attribute, storeFrontIDs []int64, existing models.ProductVariant, row *CatalogImportRow) (int64, error) {
+	if row.Action == catalogRowUpdate {
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		for column, value := range map[string]*float64{
+			"price": row.price, "compare_at_price": row.compareAtPrice, "cost_price": row.costPrice, "weight": row.weight,
+		} {
+			if value != nil {
+				updates[column] = *value
+			}
+		}
+		if v := row.cells["barcode"]; v != "" {
+			updates["barcode"] = v
+		}
+		var values []models.AttributeValue
+		label := row.cells["attribute_value"]
+		if label != "" && label != existing.AttributeValue {
+			var err error
+			if values, err = assignVariantValues(tx, attrs, &existing, nil, label); err != nil {
+				return 0, err
+			}
+			updates["attribute_value"] = existing.AttributeValue
+		}
+		if row.isActive != nil {
+			updates["is_active"] = *row.isActive
+		}
+		if err := tx.Model(&models.ProductVariant{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+			return 0, err
+		}
+		if values != nil {
+			if err := linkVariantValues(tx, product.ID, existing.ID, values); err != nil {
+				return 0, err
+			}
+		}
+		return existing.ID, nil
+	}
+
+	variant := &models.ProductVariant{
+		ProductID:      product.ID,
+		SKU:            row.SKU,
+		Price:          row.price,
+		CompareAtPrice: row.compareAtPrice,
+		CostPrice:      row.costPrice,
+		Weight:         row.weight,
+		IsActive:       row.isActive == nil || *row.isActive,
+		TrackingMode:   models.TrackingModeNone,
+	}
+	if v := row.cells["barcode"]; v != "" {
+		variant.Barcode = &v
+	}
+	values, err := assignVariantValues(tx, attrs, variant, nil, row.cells["attribute_value"])
+	if err != nil {
+		return 0, err
+	}
+	if err := repoTx.CreateVariantV2(variant); err != nil {
+		return 0, err
+	}
+	if err := linkVariantValues(tx, product.ID, variant.ID, values); err != nil {
+		return 0, err
+	}
+	if err := s.createVariantInventory(tx, product, variant, storeFrontIDs, openingStock(row.stock)); err != nil {
+		return 0, err
+	}
+	return variant.ID, nil
+}
+
+// attachCatalogImages adds the files not yet attached to a product as its images
+func (s *ServiceV2) attachCatalogImages(tx *gorm.DB, repoTx *V2Repository, productID int64, fileIDs []int64) error {
+	if len(fileIDs) == 0 {
+		return nil
+	}
+
+	var attached []int64
+	if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", productID).Pluck("file_id", &attached).Error; err != nil {
+		return err
+	}
+	seen := make(map[int64]bool, len(attached))
+	for _, id := range attached {
+		seen[id] = true
+	}
+
+	var newIDs []int64
+	for _, id := range fileIDs {
+		if !seen[id] {
+			seen[id] = true
+			newIDs = append(newIDs, id)
+		}
+	}
+	if len(newIDs) == 0 {
+		return nil
+	}
+	return repoTx.AddProductImages(tx, productID, newIDs)
+}
+
+// StartCatalogImport validates the file format, records a job and processes it in the background
+func (s *ServiceV2) StartCatalogImport(fileName, format string, data []byte, dryRun bool, adminID int64) utils.IResource {
+	sheet, err := utils.ReadSpreadsheet(format, data)
+	if err != nil {
+		return utils.NewBadRequestResource("Failed to read import file: "+err.Error(), nil)
+	}
+	rows, err := parseCatalogImport(sheet)
+	if err != nil {
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
+	if len(rows) == 0 {
+		return utils.NewBadRequestResource("Import file has no rows", nil)
+	}
+
+	job := &models.CatalogImportJob{
+		FileName:  fileName,
+		DryRun:    dryRun,
+		Status:    models.CatalogImportPending,
+		TotalRows: len(rows),
+		CreatedBy: adminID,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return utils.NewInternalErrorResource("Failed to create import job", err)
+	}
+
+	go s.runCatalogImport(job, rows)
+
+	return utils.NewCreatedResource("Catalog import started", job)
+}
+
+// runCatalogImport validates every product group and, unless it is a dry run, applies the
+// valid ones one product per transaction, reporting progress as it goes
+func (s *ServiceV2) runCatalogImport(job *models.CatalogImportJob, rows []CatalogImportRow) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️  Catalog import %d panicked: %v", job.ID, r)
+			s.finishCatalogImport(job, rows, fmt.Errorf("import stopped unexpectedly"))
+		}
+	}()
+
+	now := time.Now()
+	job.Status = models.CatalogImportRunning
+	job.StartedAt = &now
+	s.db.Model(job).Updates(map[string]interface{}{"status": job.Status, "started_at": now})
+
+	groups := groupCatalogRows(rows)
+	lookups, err := s.loadCatalogLookups(groups)
+	if err != nil {
+		s.finishCatalogImport(job, rows, err)
+		return
+	}
+
+	// Rows rejected while parsing count as processed straight away
+	job.ProcessedRows = len(rows)
+	for _, g := range groups {
+		job.ProcessedRows -= len(g.rows)
+	}
+
+	sinceUpdate := 0
+	for _, g := range groups {
+		s.validateCatalogGroup(g, lookups)
+
+		if !g.failed() && !job.DryRun {
+			if err := s.applyCatalogGroup(g, job); err != nil {
+				g.fail(err.Error())
+			}
+		}
+		if !g.failed() {
+			if g.existing == nil {
+				job.CreatedProducts++
+			} else {
+				job.UpdatedProducts++
+			}
+			for _, row := range g.rows {
+				if row.Action == catalogRowCreate {
+					job.CreatedVariants++
+				} else {
+					job.UpdatedVariants++
+				}
+			}
+		}
+
+		job.ProcessedRows += len(g.rows)
+		sinceUpdate += len(g.rows)
+		if sinceUpdate >= catalogImportBatch {
+			sinceUpdate = 0
+			s.db.Model(job).Update("processed_rows", job.ProcessedRows)
+		}
+	}
+
+	s.finishCatalogImport(job, rows, nil)
+}
+
+// finishCatalogImport stores the final counters and row report of a job
+func (s *ServiceV2) finishCatalogImport(job *models.CatalogImportJob, rows []CatalogImportRow, runErr error) {
+	job.ErrorRows = 0
+	for _, row := range rows {
+		if row.Status == catalogRowError {
+			job.ErrorRows++
+		}
+	}
+
+	report, err := json.Marshal(rows)
+	if err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = models.CatalogImportCompleted
+	if runErr != nil {
+		job.Status = models.CatalogImportFailed
+		job.Error = runErr.Error()
+	}
+	job.Report = string(report)
+
+	err = s.db.Model(job).Updates(map[string]interface{}{
+		"status":           job.Status,
+		"processed_rows":   job.ProcessedRows,
+		"created_products": job.CreatedProducts,
+		"updated_products": job.UpdatedProducts,
+		"created_variants": job.CreatedVariants,
+		"updated_variants": job.UpdatedVariants,
+		"error_rows":       job.ErrorRows,
+		"report":           job.Report,
+		"error":            job.Error,
+		"finished_at":      now,
+	}).Error
+	if err != nil {
+		log.Printf("⚠️  Failed to save catalog import %d: %v", job.ID, err)
+	}
+}
+
+// GetCatalogImport returns a job's progress and, once finished, its row report
+func (s *ServiceV2) GetCatalogImport(jobID int64) utils.IResource {
+	var job models.CatalogImportJob
+	if err := s.db.First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewNotFoundResource("Import job not found", nil)
+		}
+		return utils.NewInternalErrorResource("Failed to load import job", err)
+	}
+
+	detail := CatalogImportJobDetail{CatalogImportJob: job, Rows: []CatalogImportRow{}}
+	if job.Report != "" {
+		if err := json.Unmarshal([]byte(job.Report), &detail.Rows); err != nil {
+			return utils.NewInternalErrorResource("Failed to read import report", err)
+		}
+	}
+	return utils.NewOKResource("Import job retrieved successfully", detail)
+}
@@ -0,0 +1,484 @@
+package products
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ============== Listing Scope ==============
+
+// storefrontScope is the set of products a storefront listing draws from: every published
+// product of a store, or the ones of a collection of the store
+type storefrontScope struct {
+	storeFrontID int64
+	collection   *models.Collection
+	rules        *models.CollectionRules
+}
+
+func (scope storefrontScope) isManual() bool {
+	return scope.collection != nil && scope.collection.Type == models.CollectionTypeManual
+}
+
+// apply narrows a listing (p, ps) to the scope's collection
+func (scope storefrontScope) apply(query *gorm.DB) *gorm.DB {
+	if scope.collection == nil {
+		return query
+	}
+	if scope.isManual() {
+		return query.Joins("JOIN collection_products cp ON cp.product_id = p.id AND cp.collection_id = ?", scope.collection.ID)
+	}
+	return applyCollectionRules(query, scope.rules, time.Now())
+}
+
+// applyCollectionRules narrows a listing (p, ps) to the products matching every set rule
+func applyCollectionRules(query *gorm.DB, rules *models.CollectionRules, now time.Time) *gorm.DB {
+	if rules == nil {
+		return query
+	}
+	if rules.MinPrice != nil {
+		query = query.Where(storefrontMinPrice+" >= ?", *rules.MinPrice)
+	}
+	if rules.MaxPrice != nil {
+		query = query.Where(storefrontMinPrice+" <= ?", *rules.MaxPrice)
+	}
+	if len(rules.BrandIDs) > 0 {
+		query = query.Where("p.brand_id IN ?", rules.BrandIDs)
+	}
+	if len(rules.CategoryIDs) > 0 {
+		query = query.Where("p.category_id IN "+categorySubtree, rules.CategoryIDs)
+	}
+	if len(rules.Tags) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM product_tags pt WHERE pt.product_id = p.id AND pt.tag IN ?)", rules.Tags)
+	}
+	if rules.InStock != nil {
+		if *rules.InStock {
+			query = query.Where(storefrontInStock)
+		} else {
+			query = query.Where("NOT " + storefrontInStock)
+		}
+	}
+	if rules.CreatedAfter != nil {
+		query = query.Where("p.created_at >= ?", *rules.CreatedAfter)
+	}
+	if rules.CreatedWithinDays != nil {
+		query = query.Where("p.created_at >= ?", now.AddDate(0, 0, -*rules.CreatedWithinDays))
+	}
+	return query
+}
+
+// collectionScope builds the listing scope of a collection
+func collectionScope(collection *models.Collection) (storefrontScope, error) {
+	scope := storefrontScope{storeFrontID: collection.StoreFrontID, collection: collection}
+	if collection.Type != models.CollectionTypeAutomatic {
+		return scope, nil
+	}
+
+	scope.rules = &models.CollectionRules{}
+	if collection.Rules != "" {
+		if err := json.Unmarshal([]byte(collection.Rules), scope.rules); err != nil {
+			return scope, fmt.Errorf("invalid rules of collection %d: %w", collection.ID, err)
+		}
+	}
+	return scope, nil
+}
+
+// ============== Collection DTOs ==============
+
+type AdminCollection struct {
+	models.Collection
+	Rules      *models.CollectionRules `json:"rules"`
+	ProductIDs []int64                 `json:"product_ids"`
+}
+
+type StorefrontCollectionInfo struct {
+	ID            int64  `json:"id"`
+	NameEn        string `json:"name_en"`
+	NameAr        string `json:"name_ar"`
+	Slug          string `json:"slug"`
+	DescriptionEn string `json:"description_en"`
+	DescriptionAr string `json:"description_ar"`
+	Type          string `json:"type"`
+}
+
+// ============== Collection Repository ==============
+
+func (r *V2Repository) ListCollections(filter requests.CollectionFilterRequest, pagination *utils.Pagination) ([]models.Collection, int64, error) {
+	query := r.db.Model(&models.Collection{})
+	if filter.StoreFrontID != nil {
+		query = query.Where("store_front_id = ?", *filter.StoreFrontID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Search != "" {
+		search := "%" + strings.ToLower(escapeLike(filter.Search)) + "%"
+		query = query.Where("(LOWER(name_en) LIKE ? OR LOWER(name_ar) LIKE ? OR slug LIKE ?)", search, search, search)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	collections := []models.Collection{}
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(pagination.Limit).Find(&collections).Error
+	return collections, total, err
+}
+
+func (r *V2Repository) GetCollection(id int64) (*models.Collection, error) {
+	var collection models.Collection
+	if err := r.db.First(&collection, id).Error; err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// GetStorefrontCollection finds an active collection of a store by slug
+func (r *V2Repository) GetStorefrontCollection(storeFrontID int64, slug string) (*models.Collection, error) {
+	var collection models.Collection
+	err := r.db.Where("store_front_id = ? AND slug = ? AND is_active = true", storeFrontID, slug).First(&collection).Error
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *V2Repository) IsCollectionSlugTaken(storeFrontID int64, slug string, excludeID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Collection{}).
+		Where("store_front_id = ? AND slug = ? AND id <> ?", storeFrontID, slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *V2Repository) StoreFrontExists(storeFrontID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.StoreFront{}).Where("id = ?", storeFrontID).Count(&count).Error
+	return count > 0, err
+}
+
+// CollectionProductIDs returns the products of a manual collection in display order
+func (r *V2Repository) CollectionProductIDs(collectionID int64) ([]int64, error) {
+	ids := []int64{}
+	err := r.db.Model(&models.CollectionProduct{}).
+		Where("collection_id = ?", collectionID).
+		Order("position ASC, id ASC").
+		Pluck("product_id", &ids).Error
+	return ids, err
+}
+
+// StoreProductIDs returns which of the products exist and are assigned to a store
+func (r *V2Repository) StoreProductIDs(storeFrontID int64, productIDs []int64) (map[int64]bool, error) {
+	found := make(map[int64]bool, len(productIDs))
+	if len(productIDs) == 0 {
+		return found, nil
+	}
+
+	var ids []int64
+	err := r.db.Table("products p").
+		Joins("JOIN product_storefront ps ON ps.product_id = p.id AND ps.store_front_id = ?", storeFrontID).
+		Where("p.id IN ? AND p.deleted_at IS NULL", productIDs).
+		Pluck("p.id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		found[id] = true
+	}
+	return found, nil
+}
+
+// ReplaceCollectionProducts sets the products of a manual collection, positioned in order
+func (r *V2Repository) ReplaceCollectionProducts(tx *gorm.DB, collectionID int64, productIDs []int64) error {
+	if err := tx.Where("collection_id = ?", collectionID).Delete(&models.CollectionProduct{}).Error; err != nil {
+		return err
+	}
+	for i, productID := range productIDs {
+		item := models.CollectionProduct{CollectionID: collectionID, ProductID: productID, Position: i}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ============== Collection Service ==============
+
+func (s *ServiceV2) ListCollections(filter requests.CollectionFilterRequest, pagination *utils.Pagination) utils.IResource {
+	collections, total, err := s.repo.ListCollections(filter, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve collections", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Collections retrieved successfully", collections, pagination.GetMeta())
+}
+
+func (s *ServiceV2) GetCollection(id int64) utils.IResource {
+	collection, err := s.repo.GetCollection(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Collection not found", nil)
+	}
+
+	detail, err := s.adminCollection(collection)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve collection", err)
+	}
+	return utils.NewOKResource("Collection retrieved successfully", detail)
+}
+
+func (s *ServiceV2) CreateCollection(req requests.CollectionRequest) utils.IResource {
+	collection := &models.Collection{IsActive: true}
+	return s.saveCollection(collection, req, true)
+}
+
+func (s *ServiceV2) UpdateCollection(id int64, req requests.CollectionRequest) utils.IResource {
+	collection, err := s.repo.GetCollection(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Collection not found", nil)
+	}
+	return s.saveCollection(collection, req, false)
+}
+
+// saveCollection validates a collection request and writes it with its products. Manual
+// collections list products of their store, automatic ones need at least one rule.
+func (s *ServiceV2) saveCollection(collection *models.Collection, req requests.CollectionRequest, create bool) utils.IResource {
+	exists, err := s.repo.StoreFrontExists(req.StoreFrontID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate store front", err)
+	}
+	if !exists {
+		return utils.NewBadRequestResource("Store front not found", nil)
+	}
+
+	slug, res := s.collectionSlug(req, collection.ID)
+	if res != nil {
+		return res
+	}
+
+	rules := ""
+	productIDs := req.ProductIDs
+	if req.Type == models.CollectionTypeAutomatic {
+		encoded, res := encodeCollectionRules(req.Rules)
+		if res != nil {
+			return res
+		}
+		rules = encoded
+		productIDs = []int64{}
+	} else if productIDs != nil || (!create && collection.StoreFrontID != req.StoreFrontID) {
+		if productIDs == nil {
+			// Kept products must be sold by the collection's new store
+			if productIDs, err = s.repo.CollectionProductIDs(collection.ID); err != nil {
+				return utils.NewInternalErrorResource("Failed to load collection products", err)
+			}
+		}
+		if res := s.validateCollectionProducts(req.StoreFrontID, productIDs); res != nil {
+			return res
+		}
+	}
+
+	collection.StoreFrontID = req.StoreFrontID
+	collection.NameEn = req.NameEn
+	collection.NameAr = req.NameAr
+	collection.Slug = slug
+	collection.DescriptionEn = req.DescriptionEn
+	collection.DescriptionAr = req.DescriptionAr
+	collection.Type = req.Type
+	collection.Rules = rules
+	if req.IsActive != nil {
+		collection.IsActive = *req.IsActive
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(collection).Error; err != nil {
+			return err
+		}
+		if productIDs != nil {
+			return s.repo.ReplaceCollectionProducts(tx, collection.ID, productIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to save collection", err)
+	}
+
+	detail, err := s.adminCollection(collection)
+	if err != nil {
+		return utils.NewInternalErrorResource("Collection saved but failed to retrieve", err)
+	}
+	if create {
+		return utils.NewCreatedResource("Collection created successfully", detail)
+	}
+	return utils.NewOKResource("Collection updated successfully", detail)
+}
+
+// collectionSlug resolves a collection's slug. An explicit slug must be free in the
+// store, one built from the name gets a numeric suffix when taken.
+func (s *ServiceV2) collectionSlug(req requests.CollectionRequest, collectionID int64) (string, utils.IResource) {
+	taken := func(slug string) (bool, error) {
+		return s.repo.IsCollectionSlugTaken(req.StoreFrontID, slug, collectionID)
+	}
+
+	if req.Slug != "" {
+		slug := utils.Slugify(req.Slug)
+		if slug == "" {
+			return "", utils.NewBadRequestResource("Invalid slug", nil)
+		}
+		isTaken, err := taken(slug)
+		if err != nil {
+			return "", utils.NewInternalErrorResource("Failed to validate slug", err)
+		}
+		if isTaken {
+			return "", utils.NewBadRequestResource(fmt.Sprintf("Slug '%s' already exists in store %d", slug, req.StoreFrontID), nil)
+		}
+		return slug, nil
+	}
+
+	base, _ := productSlugs(req.NameEn, "", "", "", false)
+	if base == "" {
+		return "", utils.NewBadRequestResource("Could not generate slug from name_en", nil)
+	}
+	slug, err := utils.UniqueSlug(base, taken)
+	if err != nil {
+		return "", utils.NewInternalErrorResource("Failed to generate slug", err)
+	}
+	return slug, nil
+}
+
+// encodeCollectionRules validates the rules of an automatic collection and encodes them
+func encodeCollectionRules(req *requests.CollectionRulesRequest) (string, utils.IResource) {
+	if req == nil {
+		return "", utils.NewBadRequestResource("Automatic collections need rules", nil)
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return "", utils.NewBadRequestResource("min_price must not be greater than max_price", nil)
+	}
+
+	rules := models.CollectionRules{
+		MinPrice:          req.MinPrice,
+		MaxPrice:          req.MaxPrice,
+		BrandIDs:          uniqueInt64s(req.BrandIDs),
+		CategoryIDs:       uniqueInt64s(req.CategoryIDs),
+		Tags:              normalizeTags(req.Tags),
+		InStock:           req.InStock,
+		CreatedAfter:      req.CreatedAfter,
+		CreatedWithinDays: req.CreatedWithinDays,
+	}
+	if rules.MinPrice == nil && rules.MaxPrice == nil && len(rules.BrandIDs) == 0 && len(rules.CategoryIDs) == 0 &&
+		len(rules.Tags) == 0 && rules.InStock == nil && rules.CreatedAfter == nil && rules.CreatedWithinDays == nil {
+		return "", utils.NewBadRequestResource("Automatic collections need at least one rule", nil)
+	}
+
+	encoded, err := json.Marshal(rules)
+	if err != nil {
+		return "", utils.NewInternalErrorResource("Failed to encode collection rules", err)
+	}
+	return string(encoded), nil
+}
+
+// validateCollectionProducts checks that the products are listed once and sold by the store
+func (s *ServiceV2) validateCollectionProducts(storeFrontID int64, productIDs []int64) utils.IResource {
+	seen := make(map[int64]bool, len(productIDs))
+	for _, id := range productIDs {
+		if seen[id] {
+			return utils.NewBadRequestResource(fmt.Sprintf("Product %d is listed more than once", id), nil)
+		}
+		seen[id] = true
+	}
+
+	found, err := s.repo.StoreProductIDs(storeFrontID, productIDs)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to validate products", err)
+	}
+	for _, id := range productIDs {
+		if !found[id] {
+			return utils.NewBadRequestResource(fmt.Sprintf("Product %d not found in store %d", id, storeFrontID), nil)
+		}
+	}
+	return nil
+}
+
+// adminCollection adds the decoded rules and the manual products to a collection
+func (s *ServiceV2) adminCollection(collection *models.Collection) (*AdminCollection, error) {
+	scope, err := collectionScope(collection)
+	if err != nil {
+		return nil, err
+	}
+	productIDs, err := s.repo.CollectionProductIDs(collection.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminCollection{Collection: *collection, Rules: scope.rules, ProductIDs: productIDs}, nil
+}
+
+func (s *ServiceV2) DeleteCollection(id int64) utils.IResource {
+	if _, err := s.repo.GetCollection(id); err != nil {
+		return utils.NewNotFoundResource("Collection not found", nil)
+	}
+	if err := s.db.Delete(&models.Collection{}, id).Error; err != nil {
+		return utils.NewInternalErrorResource("Failed to delete collection", err)
+	}
+	return utils.NewOKResource("Collection deleted successfully", nil)
+}
+
+// ListCollectionProducts previews a collection as its store shows it
+func (s *ServiceV2) ListCollectionProducts(id int64, pagination *utils.Pagination) utils.IResource {
+	collection, err := s.repo.GetCollection(id)
+	if err != nil {
+		return utils.NewNotFoundResource("Collection not found", nil)
+	}
+	return s.listCollection(collection, requests.StorefrontProductFilterRequest{}, pagination)
+}
+
+// StorefrontGetCollection lists the products of an active collection with facets, like
+// the store's product listing
+func (s *ServiceV2) StorefrontGetCollection(storeFrontID int64, slug string, filter requests.StorefrontProductFilterRequest, pagination *utils.Pagination) utils.IResource {
+	collection, err := s.repo.GetStorefrontCollection(storeFrontID, slug)
+	if err != nil {
+		return utils.NewNotFoundResource("Collection not found", nil)
+	}
+	return s.listCollection(collection, filter, pagination)
+}
+
+func (s *ServiceV2) listCollection(collection *models.Collection, filter requests.StorefrontProductFilterRequest, pagination *utils.Pagination) utils.IResource {
+	scope, err := collectionScope(collection)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve collection", err)
+	}
+
+	items, meta, res := s.listStorefront(scope, filter, pagination)
+	if res != nil {
+		return res
+	}
+	meta["collection"] = StorefrontCollectionInfo{
+		ID:            collection.ID,
+		NameEn:        collection.NameEn,
+		NameAr:        collection.NameAr,
+		Slug:          collection.Slug,
+		DescriptionEn: collection.DescriptionEn,
+		DescriptionAr: collection.DescriptionAr,
+		Type:          collection.Type,
+	}
+	return utils.NewPaginatedOKResource("Collection retrieved successfully", items, meta)
+}
+
+// uniqueInt64s drops repeated ids, keeping the first occurrence
+func uniqueInt64s(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -5,12 +5,19 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/onas/ecommerce-api/internal/api/inventory"
+	"github.com/onas/ecommerce-api/internal/api/pricelists"
 	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
 	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 type fakeService struct {
@@ -135,3 +142,775 @@ func TestPublicGetProduct_NotFound(t *testing.T) {
 		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+func TestParseCatalogImport_GroupsRowsByHandle(t *testing.T) {
+	sheet := [][]string{
+		{"Handle", "SKU", "Price", "Stock", "Attribute_Value"},
+		{"Basic Tee", "TEE-S", "10", "5", "S"},
+		{"basic tee", "TEE-M", "10", "", "M"},
+		{"", "", "", "", ""},
+		{"Mug", "MUG-1", "abc", "", ""},
+		{"Cap", "TEE-S", "8", "", ""},
+	}
+
+	rows, err := parseCatalogImport(sheet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected blank rows to be skipped, got %d rows", len(rows))
+	}
+	if rows[0].stock == nil || *rows[0].stock != 5 || rows[0].Row != 2 {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+	if rows[2].Status != catalogRowError || rows[2].Row != 5 {
+		t.Fatalf("expected invalid price on row 5 to fail, got %+v", rows[2])
+	}
+
+	groups := groupCatalogRows(rows)
+	if len(groups) != 1 || groups[0].slug != "basic-tee" || len(groups[0].rows) != 2 {
+		t.Fatalf("expected one basic-tee group with two rows, got %+v", groups)
+	}
+	if rows[3].Status != catalogRowError {
+		t.Fatalf("expected duplicate sku to fail, got %+v", rows[3])
+	}
+
+	if _, err := parseCatalogImport([][]string{{"handle", "price"}}); err == nil {
+		t.Fatal("expected missing sku column to fail")
+	}
+}
+
+func TestVariantCombinations_RendersSKUs(t *testing.T) {
+	attrs := []matrixAttribute{
+		{
+			attribute: models.Attribute{ID: 1, NameEn: "Size"},
+			values:    []models.AttributeValue{{ID: 10, ValueEn: "S"}, {ID: 11, ValueEn: "XL"}},
+		},
+		{
+			attribute: models.Attribute{ID: 2, NameEn: "Color"},
+			values:    []models.AttributeValue{{ID: 20, ValueEn: "Navy Blue"}, {ID: 21, ValueAr: "أحمر"}},
+		},
+	}
+	product := &models.Product{ID: 7, Slug: "basic-tee"}
+
+	combos := variantCombinations(attrs)
+	if len(combos) != 4 {
+		t.Fatalf("expected 4 combinations, got %d", len(combos))
+	}
+	if got := combinationLabel(combos[0]); got != "S / Navy Blue" {
+		t.Fatalf("unexpected label %q", got)
+	}
+	if got := renderVariantSKU("", product, 1, attrs, combos[0]); got != "BASIC-TEE-S-NAVY-BLUE" {
+		t.Fatalf("unexpected default sku %q", got)
+	}
+	if got := renderVariantSKU("{slug}-{color}-{size}-{n}", product, 4, attrs, combos[3]); got != "BASIC-TEE-21-XL-4" {
+		t.Fatalf("unexpected templated sku %q", got)
+	}
+	if combinationKey([]int64{21, 11}) != combinationKey([]int64{11, 21}) {
+		t.Fatal("expected combination key to ignore order")
+	}
+}
+
+func TestAssignVariantValues_UniqueAcrossCombinations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Attribute{},
+		&models.AttributeValue{},
+		&models.ProductVariant{},
+		&models.ProductAttributeValue{},
+		&models.ProductVariantAttributeValue{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	attrs := []models.Attribute{{ID: 1, NameEn: "Size", NameAr: "المقاس"}, {ID: 2, NameEn: "Color", NameAr: "اللون"}}
+	db.Create(&attrs)
+	db.Create(&[]models.AttributeValue{
+		{ID: 10, AttributeID: 1, ValueEn: "S", ValueAr: "S", IsActive: true},
+		{ID: 20, AttributeID: 2, ValueEn: "Red", ValueAr: "أحمر", IsActive: true},
+	})
+
+	first := models.ProductVariant{ID: 1, ProductID: 1, SKU: "TEE-S-RED"}
+	values, err := assignVariantValues(db, attrs, &first, []int64{20, 10}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.AttributeValue != "S / Red" {
+		t.Fatalf("expected values in attribute order, got %q", first.AttributeValue)
+	}
+	db.Create(&first)
+	if err := linkVariantValues(db, 1, first.ID, values); err != nil {
+		t.Fatalf("failed to link values: %v", err)
+	}
+
+	second := models.ProductVariant{ID: 2, ProductID: 1, SKU: "TEE-S-RED-2"}
+	if _, err := assignVariantValues(db, attrs, &second, []int64{10, 20}, ""); err == nil {
+		t.Fatal("expected duplicate combination to be rejected")
+	}
+	if _, err := assignVariantValues(db, attrs, &second, []int64{10}, ""); err == nil {
+		t.Fatal("expected a missing color value to be rejected")
+	}
+
+	// Free-text values are matched case-insensitively or added to the attribute
+	if _, err := assignVariantValues(db, attrs, &second, nil, "s / Blue"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.AttributeValue != "S / Blue" {
+		t.Fatalf("unexpected label %q", second.AttributeValue)
+	}
+}
+
+func TestStorefrontPrices_PreferCustomerGroupList(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.StoreFront{}, &models.PriceList{}, &models.PriceListItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	groupID := int64(7)
+	db.Create(&models.StoreFront{ID: 1, Name: "Egypt", Slug: "eg", Domain: "eg.test", Currency: "EGP"})
+	db.Create(&[]models.PriceList{
+		{ID: 1, Name: "Retail EGP", StoreFrontID: 1, Currency: "EGP", IsActive: true},
+		{ID: 2, Name: "Wholesale EGP", StoreFrontID: 1, Currency: "EGP", CustomerGroupID: &groupID, IsActive: true},
+		{ID: 3, Name: "Retail SAR", StoreFrontID: 1, Currency: "SAR", IsActive: true},
+	})
+	compareAt := 600.0
+	db.Create(&[]models.PriceListItem{
+		{ID: 1, PriceListID: 1, ProductVariantID: 10, Price: 500, CompareAtPrice: &compareAt},
+		{ID: 2, PriceListID: 2, ProductVariantID: 10, Price: 400},
+		{ID: 3, PriceListID: 3, ProductVariantID: 11, Price: 50},
+	})
+
+	repo := &V2Repository{db: db}
+	prices, err := repo.storefrontPrices(1, []int64{10, 11})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	basePrice, baseCompareAt := 40.0, 45.0
+	variants := []StorefrontVariant{
+		{ID: 10, Price: &basePrice, CompareAtPrice: &baseCompareAt},
+		{ID: 11, Price: &basePrice, CompareAtPrice: &baseCompareAt},
+	}
+	applyVariantPrices(variants, prices)
+	if *variants[0].Price != 500 || variants[0].CompareAtPrice == nil || *variants[0].CompareAtPrice != 600 {
+		t.Fatalf("expected the store's EGP list price, got %+v", variants[0])
+	}
+	if *variants[1].Price != 40 || *variants[1].CompareAtPrice != 45 {
+		t.Fatalf("expected a list in another currency to be ignored, got %+v", variants[1])
+	}
+
+	grouped, err := pricelists.ResolvePrices(db, 1, "EGP", &groupID, []int64{10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if grouped[10].Price != 400 || grouped[10].CompareAtPrice != nil {
+		t.Fatalf("expected the customer group's list to win, got %+v", grouped[10])
+	}
+}
+
+func TestDiffSnapshots_ListsChangedFields(t *testing.T) {
+	price, newPrice := 10.0, 12.5
+	prev := &ProductSnapshot{
+		Product:       ProductSnapshotFields{NameEn: "Shirt", Slug: "shirt"},
+		StoreFrontIDs: []int64{1},
+		Variants:      []VariantSnapshot{{ID: 7, SKU: "SH-1", Price: &price}},
+	}
+	next := &ProductSnapshot{
+		Product:       ProductSnapshotFields{NameEn: "Linen Shirt", Slug: "shirt"},
+		StoreFrontIDs: []int64{1},
+		Variants:      []VariantSnapshot{{ID: 7, SKU: "SH-1", Price: &newPrice}},
+	}
+
+	changes := diffSnapshots(prev, next)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Field != "product.name_en" || changes[0].From != "Shirt" || changes[0].To != "Linen Shirt" {
+		t.Errorf("unexpected name change %+v", changes[0])
+	}
+	if changes[1].Field != "variants[7].price" || changes[1].From != 10.0 || changes[1].To != 12.5 {
+		t.Errorf("unexpected price change %+v", changes[1])
+	}
+
+	if changes := diffSnapshots(next, next); len(changes) != 0 {
+		t.Errorf("expected no changes between equal snapshots, got %+v", changes)
+	}
+}
+
+func TestRenderDuplicateSKU(t *testing.T) {
+	if got := renderDuplicateSKU("", "blue-sofa-2", "SOFA-S", 3); got != "BLUE-SOFA-2-3" {
+		t.Errorf("default pattern gave %q", got)
+	}
+	if got := renderDuplicateSKU("{sku}-LINEN", "linen-sofa", "SOFA-S", 1); got != "SOFA-S-LINEN" {
+		t.Errorf("sku pattern gave %q", got)
+	}
+}
+
+func TestProductSlugs_PerLanguage(t *testing.T) {
+	en, ar := productSlugs("Blue Sofa", "كنبة زرقاء", "", "", false)
+	if en != "blue-sofa" || ar != "كنبة-زرقاء" {
+		t.Errorf("got %q and %q", en, ar)
+	}
+
+	en, ar = productSlugs("كنبة", "كنبة زرقاء", "", "", true)
+	if en != "knba" || ar != "knba-zrqa" {
+		t.Errorf("transliterated got %q and %q", en, ar)
+	}
+
+	if en, _ = productSlugs("Blue Sofa", "", "Navy Couch", "", false); en != "navy-couch" {
+		t.Errorf("explicit slug got %q", en)
+	}
+}
+
+func TestPriceRanges_RoundedWidths(t *testing.T) {
+	ranges := priceRanges([]float64{1, 12, 48, 99}, 5)
+	want := []PriceRangeFacet{{Min: 0, Max: 20, Count: 2}, {Min: 40, Max: 60, Count: 1}, {Min: 80, Max: 100, Count: 1}}
+	if len(ranges) != len(want) {
+		t.Fatalf("got %+v", ranges)
+	}
+	for i := range want {
+		if ranges[i] != want[i] {
+			t.Errorf("range %d: got %+v, want %+v", i, ranges[i], want[i])
+		}
+	}
+
+	if ranges := priceRanges([]float64{25, 25}, 5); len(ranges) != 1 || ranges[0].Count != 2 {
+		t.Errorf("single price got %+v", ranges)
+	}
+	if ranges := priceRanges(nil, 5); len(ranges) != 0 {
+		t.Errorf("no prices got %+v", ranges)
+	}
+}
+
+func TestCollectionScope_MatchesRules(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Product{}, &models.ProductStorefront{}, &models.ProductTag{}, &models.CollectionProduct{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	brandID := int64(3)
+	old := time.Now().AddDate(0, 0, -60)
+	db.Create(&[]models.Product{
+		{ID: 1, NameEn: "Dates Box", Slug: "dates-box", SlugEn: "dates-box", BrandID: &brandID, Status: models.ProductStatusActive, IsPublished: true},
+		{ID: 2, NameEn: "Lantern", Slug: "lantern", SlugEn: "lantern", BrandID: &brandID, Status: models.ProductStatusActive, IsPublished: true, CreatedAt: old},
+		{ID: 3, NameEn: "Prayer Mat", Slug: "prayer-mat", SlugEn: "prayer-mat", Status: models.ProductStatusActive, IsPublished: true},
+		{ID: 4, NameEn: "Draft Dates", Slug: "draft-dates", SlugEn: "draft-dates", BrandID: &brandID, Status: models.ProductStatusDraft},
+	})
+	db.Create(&[]models.ProductStorefront{{ProductID: 1, StoreFrontID: 1}, {ProductID: 2, StoreFrontID: 1}, {ProductID: 3, StoreFrontID: 1}, {ProductID: 4, StoreFrontID: 1}})
+	db.Create(&[]models.ProductTag{{ID: 1, ProductID: 1, Tag: "ramadan"}, {ID: 2, ProductID: 2, Tag: "ramadan"}, {ID: 3, ProductID: 4, Tag: "ramadan"}})
+
+	repo := &V2Repository{db: db}
+	list := func(collection *models.Collection) []int64 {
+		scope, err := collectionScope(collection)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var ids []int64
+		if err := repo.storefrontListing(scope, requests.StorefrontProductFilterRequest{}, nil).Clauses(storefrontOrder(scope, "", "")).Pluck("p.id", &ids).Error; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return ids
+	}
+
+	automatic := &models.Collection{ID: 1, StoreFrontID: 1, Type: models.CollectionTypeAutomatic, Rules: `{"tags":["ramadan"],"brand_ids":[3],"created_within_days":30}`}
+	if ids := list(automatic); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("automatic collection got %v, want [1]", ids)
+	}
+
+	db.Create(&[]models.CollectionProduct{{ID: 1, CollectionID: 2, ProductID: 3, Position: 0}, {ID: 2, CollectionID: 2, ProductID: 1, Position: 1}})
+	manual := &models.Collection{ID: 2, StoreFrontID: 1, Type: models.CollectionTypeManual}
+	if ids := list(manual); len(ids) != 2 || ids[0] != 3 || ids[1] != 1 {
+		t.Errorf("manual collection got %v, want [3 1]", ids)
+	}
+}
+
+func TestRefreshMerchandisingFlags_RespectsPins(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Product{}, &models.ProductStorefront{}, &models.Order{}, &models.OrderItem{}, &models.OrderStatus{},
+		&models.ProductSEO{}, &models.ProductTag{}, &models.ProductVariant{}, &models.ProductRevision{},
+	); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -90)
+	db.Create(&[]models.Product{
+		{ID: 1, NameEn: "Dates Box", Slug: "dates-box", CreatedAt: old, IsNew: true},
+		{ID: 2, NameEn: "Lantern", Slug: "lantern", CreatedAt: old, IsBestSeller: true},
+		{ID: 3, NameEn: "Prayer Mat", Slug: "prayer-mat", CreatedAt: old, IsNew: true, IsNewPinned: true},
+		{ID: 4, NameEn: "Oud", Slug: "oud", CreatedAt: now, IsBestSeller: true, IsBestSellerPinned: true},
+		{ID: 5, NameEn: "Incense", Slug: "incense", CreatedAt: now}, // Recent but not marked new by the admin
+	})
+	db.Create(&[]models.ProductStorefront{{ProductID: 1, StoreFrontID: 1}, {ProductID: 2, StoreFrontID: 1}, {ProductID: 3, StoreFrontID: 1}, {ProductID: 4, StoreFrontID: 1}, {ProductID: 5, StoreFrontID: 1}})
+	db.Create(&[]models.OrderStatus{{ID: 1, Slug: "completed"}, {ID: 2, Slug: "cancelled"}})
+	db.Create(&[]models.Order{
+		{ID: 1, OrderNumber: "ORD-1", StoreFrontID: 1, OrderStatusID: 1, CreatedAt: now},
+		{ID: 2, OrderNumber: "ORD-2", StoreFrontID: 1, OrderStatusID: 2, CreatedAt: now},
+		{ID: 3, OrderNumber: "ORD-3", StoreFrontID: 1, OrderStatusID: 1, CreatedAt: old},
+	})
+	db.Create(&[]models.OrderItem{
+		{ID: 1, OrderID: 1, ProductID: 1, ProductVariantID: 1, Quantity: 5},
+		{ID: 2, OrderID: 1, ProductID: 3, ProductVariantID: 3, Quantity: 2},
+		{ID: 3, OrderID: 2, ProductID: 2, ProductVariantID: 2, Quantity: 50},
+		{ID: 4, OrderID: 3, ProductID: 2, ProductVariantID: 2, Quantity: 50},
+	})
+
+	service := &ServiceV2{db: db}
+	if _, err := service.RefreshMerchandisingFlags(now, MerchandisingParams{BestSellerWindowDays: 30, BestSellerCount: 1, NewArrivalDays: 30}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var products []models.Product
+	db.Order("id").Find(&products)
+	want := []struct{ isBestSeller, isNew bool }{{true, false}, {false, false}, {false, true}, {true, false}, {false, false}}
+	for i, p := range products {
+		if p.IsBestSeller != want[i].isBestSeller || p.IsNew != want[i].isNew {
+			t.Errorf("product %d got best seller %v new %v, want %v %v", p.ID, p.IsBestSeller, p.IsNew, want[i].isBestSeller, want[i].isNew)
+		}
+	}
+
+	var storeBestSellers []int64
+	db.Model(&models.ProductStorefront{}).Where("is_best_seller").Order("product_id").Pluck("product_id", &storeBestSellers)
+	if len(storeBestSellers) != 2 || storeBestSellers[0] != 1 || storeBestSellers[1] != 4 {
+		t.Errorf("store best sellers got %v, want [1 4]", storeBestSellers)
+	}
+
+	// Products 1 and 2 changed, revisions are recorded without an admin
+	var revisions []models.ProductRevision
+	db.Order("product_id").Find(&revisions)
+	if len(revisions) != 2 || revisions[0].ProductID != 1 || revisions[1].ProductID != 2 {
+		t.Fatalf("expected revisions of products 1 and 2, got %+v", revisions)
+	}
+	for _, rev := range revisions {
+		if rev.Action != models.ProductRevisionMerchandising || rev.AdminID != nil {
+			t.Errorf("unexpected revision %+v", rev)
+		}
+	}
+}
+
+func TestCoPurchasedProductIDs_RanksByOrders(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Product{}, &models.ProductStorefront{}, &models.Order{}, &models.OrderItem{}, &models.OrderStatus{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	db.Create(&[]models.Product{
+		{ID: 1, NameEn: "Coffee Pot", Slug: "coffee-pot", Status: models.ProductStatusActive, IsPublished: true},
+		{ID: 2, NameEn: "Cups", Slug: "cups", Status: models.ProductStatusActive, IsPublished: true},
+		{ID: 3, NameEn: "Cardamom", Slug: "cardamom", Status: models.ProductStatusActive, IsPublished: true},
+		{ID: 4, NameEn: "Old Tray", Slug: "old-tray", Status: models.ProductStatusDraft},
+	})
+	db.Create(&[]models.ProductStorefront{{ProductID: 1, StoreFrontID: 1}, {ProductID: 2, StoreFrontID: 1}, {ProductID: 3, StoreFrontID: 1}, {ProductID: 4, StoreFrontID: 1}})
+	db.Create(&[]models.OrderStatus{{ID: 1, Slug: "completed"}, {ID: 2, Slug: "cancelled"}})
+	db.Create(&[]models.Order{
+		{ID: 1, OrderNumber: "ORD-1", StoreFrontID: 1, OrderStatusID: 1},
+		{ID: 2, OrderNumber: "ORD-2", StoreFrontID: 1, OrderStatusID: 1},
+		{ID: 3, OrderNumber: "ORD-3", StoreFrontID: 1, OrderStatusID: 2},
+	})
+	db.Create(&[]models.OrderItem{
+		{ID: 1, OrderID: 1, ProductID: 1, ProductVariantID: 1, Quantity: 1},
+		{ID: 2, OrderID: 1, ProductID: 3, ProductVariantID: 3, Quantity: 1},
+		{ID: 3, OrderID: 1, ProductID: 4, ProductVariantID: 4, Quantity: 1},
+		{ID: 4, OrderID: 2, ProductID: 1, ProductVariantID: 1, Quantity: 1},
+		{ID: 5, OrderID: 2, ProductID: 3, ProductVariantID: 3, Quantity: 1},
+		{ID: 6, OrderID: 2, ProductID: 2, ProductVariantID: 2, Quantity: 1},
+		{ID: 7, OrderID: 3, ProductID: 1, ProductVariantID: 1, Quantity: 1},
+		{ID: 8, OrderID: 3, ProductID: 2, ProductVariantID: 2, Quantity: 1},
+	})
+
+	ids, err := (&V2Repository{db: db}).CoPurchasedProductIDs(1, 1, 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 3 || ids[1] != 2 {
+		t.Errorf("got %v, want [3 2]", ids)
+	}
+}
+
+func TestProductRatingSummary_ApprovedOnly(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ProductReview{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	db.Create(&[]models.ProductReview{
+		{ID: 1, ProductID: 1, StoreFrontID: 1, CustomerID: 1, OrderID: 1, Rating: 5, Status: models.ReviewStatusApproved},
+		{ID: 2, ProductID: 1, StoreFrontID: 1, CustomerID: 2, OrderID: 2, Rating: 4, Status: models.ReviewStatusApproved},
+		{ID: 3, ProductID: 1, StoreFrontID: 1, CustomerID: 3, OrderID: 3, Rating: 4, Status: models.ReviewStatusApproved},
+		{ID: 4, ProductID: 1, StoreFrontID: 1, CustomerID: 4, OrderID: 4, Rating: 1, Status: models.ReviewStatusPending},
+		{ID: 5, ProductID: 1, StoreFrontID: 1, CustomerID: 5, OrderID: 5, Rating: 1, Status: models.ReviewStatusRejected},
+		{ID: 6, ProductID: 1, StoreFrontID: 2, CustomerID: 6, OrderID: 6, Rating: 1, Status: models.ReviewStatusApproved},
+	})
+
+	summary, err := (&V2Repository{db: db}).ProductRatingSummary(1, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Count != 3 || summary.Average != 4.3 || summary.Stars[4] != 2 || summary.Stars[1] != 0 {
+		t.Errorf("got %+v, want 3 reviews averaging 4.3", summary)
+	}
+
+	if name := reviewerName("Sara", "Ahmed"); name != "Sara A." {
+		t.Errorf("reviewer name got %q, want %q", name, "Sara A.")
+	}
+}
+
+func TestRenderSitemap_HreflangAlternates(t *testing.T) {
+	storeFront := models.StoreFront{Domain: "shop.example.com", DefaultLanguage: "ar"}
+	pages := []sitemapPage{{Paths: map[string]string{"en": "/products/dates-box", "ar": "/products/" + url.PathEscape("علبة-تمر")}}}
+
+	data, err := renderSitemap(storeFront, pages)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body := string(data)
+	for _, want := range []string{
+		`<loc>https://shop.example.com/en/products/dates-box</loc>`,
+		`<xhtml:link rel="alternate" hreflang="en" href="https://shop.example.com/en/products/dates-box"></xhtml:link>`,
+		`hreflang="x-default" href="https://shop.example.com/ar/products/%D8%B9%D9%84%D8%A8%D8%A9-%D8%AA%D9%85%D8%B1"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("sitemap missing %s\n%s", want, body)
+		}
+	}
+	if n := strings.Count(body, "<url>"); n != 2 {
+		t.Errorf("got %d urls, want one per language", n)
+	}
+}
+
+func TestGetProductImages_Srcset(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.File{}, &models.FileDerivative{}, &models.ProductImage{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	db.Create(&models.File{ID: 1, OriginalName: "box.jpg", FileName: "box_1.jpg", FilePath: "image/box_1.jpg", FileSize: 4096, MimeType: "image/jpeg", FileType: "image", Extension: ".jpg", IsActive: true})
+	db.Create(&[]models.FileDerivative{
+		{FileID: 1, Size: models.ImageSizeThumb, Format: models.ImageFormatJPEG, FilePath: "image/box_1_thumb.jpg", MimeType: "image/jpeg", Width: 200, Height: 150, FileSize: 512},
+		{FileID: 1, Size: models.ImageSizeMedium, Format: models.ImageFormatJPEG, FilePath: "image/box_1_medium.jpg", MimeType: "image/jpeg", Width: 600, Height: 450, FileSize: 1024},
+		{FileID: 1, Size: models.ImageSizeMedium, Format: models.ImageFormatWebP, FilePath: "image/box_1_medium.webp", MimeType: "image/webp", Width: 600, Height: 450, FileSize: 768},
+	})
+	db.Create(&models.ProductImage{ProductID: 1, FileID: 1, IsCover: true})
+
+	images, err := (&V2Repository{db: db}).GetProductImages(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("got %d images, want 1", len(images))
+	}
+	srcset := images[0].Srcset
+	if len(srcset) != 2 {
+		t.Errorf("got sizes %v, want thumb and medium", srcset)
+	}
+	if got := srcset[models.ImageSizeMedium]; got != (ImageSource{URL: "image/box_1_medium.jpg", WebP: "image/box_1_medium.webp", Width: 600, Height: 450}) {
+		t.Errorf("medium got %+v", got)
+	}
+	if got := srcset[models.ImageSizeThumb]; got.URL != "image/box_1_thumb.jpg" || got.WebP != "" {
+		t.Errorf("thumb got %+v", got)
+	}
+}
+
+func TestHasVariantStock(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ProductVariant{}, &models.VariantInventory{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	db.Create(&[]models.ProductVariant{{ID: 1, ProductID: 1, SKU: "A"}, {ID: 2, ProductID: 2, SKU: "B"}})
+	db.Create(&[]models.VariantInventory{
+		{ID: 1, ProductVariantID: 1, StoreFrontID: 1},
+		{ID: 2, ProductVariantID: 2, StoreFrontID: 1, BackorderedQuantity: 1},
+	})
+
+	repo := &V2Repository{db: db}
+	if has, err := repo.HasVariantStock(1); err != nil || has {
+		t.Errorf("product 1 got %v, %v, want no stock", has, err)
+	}
+	if has, err := repo.HasVariantStock(2); err != nil || !has {
+		t.Errorf("product 2 got %v, %v, want backordered stock", has, err)
+	}
+}
+
+func TestCreateMissingVariantInventory(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.VariantInventory{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	db.Create(&models.VariantInventory{ID: 1, ProductVariantID: 1, StoreFrontID: 1, Quantity: 4})
+
+	created, err := (&V2Repository{db: db}).CreateMissingVariantInventory([]int64{1, 2}, []int64{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 3 {
+		t.Fatalf("expected 3 rows added, got %d", len(created))
+	}
+	for _, inv := range created {
+		if inv.ProductVariantID == 1 && inv.StoreFrontID == 1 {
+			t.Error("expected the existing row to be kept")
+		}
+		if inv.Quantity != 0 {
+			t.Errorf("expected empty stock, got %d", inv.Quantity)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	cases := map[string]string{
+		"sofa":     "sofa",
+		"100%":     `100\%`,
+		"a_b":      `a\_b`,
+		`c:\dir`:   `c:\\dir`,
+		`%_\`:      `\%\_\\`,
+		"كنبة 50%": `كنبة 50\%`,
+	}
+	for in, want := range cases {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchProducts_BindsTermToEveryPlaceholder(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+
+	var rows []map[string]interface{}
+	stmt := searchProducts(db.Table("products p"), "blue sofa").Clauses(listOrder("blue sofa")).Find(&rows).Statement
+	sql := stmt.SQL.String()
+	if !strings.Contains(sql, "JOIN product_search_documents psd ON psd.product_id = p.id") {
+		t.Errorf("expected the search documents join, got %s", sql)
+	}
+	if want := strings.Count(sql, "?"); len(stmt.Vars) != want || want != 6 {
+		t.Fatalf("expected 6 bound terms, got %d vars for %d placeholders", len(stmt.Vars), want)
+	}
+	for _, v := range stmt.Vars {
+		if v != "blue sofa" {
+			t.Errorf("unexpected bound value %v", v)
+		}
+	}
+}
+
+func TestSearchSuggest_ShortTermSuggestsNothing(t *testing.T) {
+	service := &ServiceV2{} // Short terms return before any query
+	for _, q := range []string{"", " a ", "ك"} {
+		res := service.SearchSuggest(1, requests.SearchSuggestRequest{Q: q})
+		if res.GetStatusCode() != http.StatusOK {
+			t.Fatalf("expected status 200 for %q, got %d", q, res.GetStatusCode())
+		}
+		suggestion, ok := res.GetData().(SearchSuggestion)
+		if !ok {
+			t.Fatalf("unexpected data %T", res.GetData())
+		}
+		if len(suggestion.Products) != 0 || suggestion.Brands == nil || suggestion.Categories == nil {
+			t.Errorf("expected empty suggestion lists for %q, got %+v", q, suggestion)
+		}
+	}
+}
+
+func TestGroupAttributeValues_ReportsUnknownIDs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.AttributeValue{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	db.Create(&[]models.AttributeValue{
+		{ID: 1, AttributeID: 1, ValueEn: "Red", ValueAr: "أحمر"},
+		{ID: 2, AttributeID: 2, ValueEn: "Large", ValueAr: "كبير"},
+		{ID: 3, AttributeID: 1, ValueEn: "Blue", ValueAr: "أزرق"},
+	})
+	db.Delete(&models.AttributeValue{}, 3)
+
+	groups, unknown, err := (&V2Repository{db: db}).groupAttributeValues([]int64{1, 2, 3, 9, 9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 || len(groups[1]) != 1 || len(groups[2]) != 1 {
+		t.Errorf("unexpected groups %v", groups)
+	}
+	if len(unknown) != 2 || unknown[0] != 3 || unknown[1] != 9 {
+		t.Errorf("expected deleted 3 and missing 9 to be unknown, got %v", unknown)
+	}
+}
+
+// migrateWithRowIDs creates the tables with integer keys, which sqlite numbers on insert
+// where it leaves bigint keys empty
+func migrateWithRowIDs(t *testing.T, db *gorm.DB, dst ...interface{}) {
+	t.Helper()
+	if err := db.AutoMigrate(dst...); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	for _, model := range dst {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("failed to parse model: %v", err)
+		}
+		var ddl string
+		db.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", stmt.Schema.Table).Scan(&ddl)
+		if !strings.Contains(ddl, "`id` bigint") {
+			continue
+		}
+		db.Exec("DROP TABLE " + stmt.Schema.Table)
+		if err := db.Exec(strings.Replace(ddl, "`id` bigint", "`id` integer", 1)).Error; err != nil {
+			t.Fatalf("failed to recreate %s: %v", stmt.Schema.Table, err)
+		}
+	}
+}
+
+func TestAdminDuplicateProduct_LeavesBarcodeOff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	migrateWithRowIDs(t, db,
+		&models.Product{}, &models.ProductStorefront{}, &models.ProductSEO{}, &models.ProductTag{},
+		&models.ProductImage{}, &models.Attribute{}, &models.AttributeValue{}, &models.ProductAttribute{}, &models.ProductAttributeValue{},
+		&models.ProductRelation{}, &models.ProductVariant{}, &models.ProductVariantAttributeValue{},
+		&models.ProductVariantAddOn{}, &models.BundleComponent{}, &models.VariantInventory{},
+		&models.ProductRevision{},
+	)
+	db.Exec("CREATE UNIQUE INDEX ux_product_variants_barcode ON product_variants (barcode)")
+
+	barcode := "5012345678900"
+	db.Create(&models.Product{ID: 1, NameEn: "Mug", Slug: "mug", SlugEn: "mug", Status: models.ProductStatusActive, IsActive: true})
+	db.Create(&models.ProductStorefront{ProductID: 1, StoreFrontID: 1})
+	db.Create(&models.ProductVariant{ID: 1, ProductID: 1, SKU: "MUG-1", Barcode: &barcode, IsActive: true})
+	db.Create(&models.VariantInventory{ProductVariantID: 1, StoreFrontID: 1, Quantity: 3, LowStockThreshold: 2})
+
+	ctrl := NewControllerV2(NewServiceV2(db, NewV2Repository(db, nil), inventory.NewRepository(db), nil))
+	router := gin.New()
+	router.POST("/products/:id/duplicate", func(ctx *gin.Context) {
+		ctx.Set("entity_id", int64(7))
+	}, ctrl.AdminDuplicateProduct)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/products/1/duplicate", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var copied models.ProductVariant
+	if err := db.Where("product_id <> ?", 1).First(&copied).Error; err != nil {
+		t.Fatalf("expected the variant to be copied: %v", err)
+	}
+	if copied.Barcode != nil {
+		t.Errorf("expected the copy to have no barcode, got %q", *copied.Barcode)
+	}
+	if copied.SKU == "MUG-1" {
+		t.Error("expected the copy to get a new SKU")
+	}
+
+	var stock models.VariantInventory
+	if err := db.Where("product_variant_id = ?", copied.ID).First(&stock).Error; err != nil {
+		t.Fatalf("expected the copy to get inventory: %v", err)
+	}
+	if stock.Quantity != 0 || stock.LowStockThreshold != 2 {
+		t.Errorf("expected empty stock with the source threshold, got %d and %d", stock.Quantity, stock.LowStockThreshold)
+	}
+}
+
+func TestBundleComponents_RejectTracking(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Product{}, &models.ProductVariant{}, &models.BundleComponent{}, &models.VariantInventory{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	db.Create(&models.Product{ID: 1, NameEn: "Gift Box", ProductType: models.ProductTypeBundle})
+	db.Create(&models.Product{ID: 2, NameEn: "Phone", ProductType: models.ProductTypeSimple})
+	db.Create(&models.ProductVariant{ID: 1, ProductID: 1, SKU: "BOX-1"})
+	db.Create(&models.ProductVariant{ID: 2, ProductID: 2, SKU: "PHONE-1", TrackingMode: models.TrackingModeSerial})
+	db.Create(&models.ProductVariant{ID: 3, ProductID: 2, SKU: "PHONE-2", TrackingMode: models.TrackingModeNone})
+	db.Create(&models.BundleComponent{ID: 1, BundleVariantID: 1, ComponentVariantID: 3, Quantity: 1})
+
+	service := NewServiceV2(db, NewV2Repository(db, nil), nil, nil)
+
+	res := service.SetBundleComponents(1, 1, requests.SetBundleComponentsRequest{
+		Components: []requests.BundleComponentRequest{{ProductVariantID: 2, Quantity: 1}},
+	})
+	if res.GetStatusCode() != http.StatusBadRequest {
+		t.Errorf("expected a serial-tracked component to be rejected, got %d", res.GetStatusCode())
+	}
+
+	component := models.ProductVariant{ID: 3, SKU: "PHONE-2", TrackingMode: models.TrackingModeNone}
+	if err := service.applyTrackingMode(db, &component, models.TrackingModeLot); err == nil {
+		t.Error("expected a bundle component to stay untracked")
+	}
+	if component.TrackingMode != models.TrackingModeNone {
+		t.Errorf("expected tracking mode to be unchanged, got %s", component.TrackingMode)
+	}
+}
+
+func TestRecordSlugRedirects_KeepsOtherStores(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ProductStorefront{}, &models.ProductSlugRedirect{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	db.Create(&[]models.ProductStorefront{
+		{ProductID: 1, StoreFrontID: 1},
+		{ProductID: 2, StoreFrontID: 1},
+		{ProductID: 3, StoreFrontID: 2},
+	})
+	db.Create(&[]models.ProductSlugRedirect{
+		{ID: 1, ProductID: 2, Language: models.SlugLanguageEn, Slug: "classic-mug"},
+		{ID: 2, ProductID: 3, Language: models.SlugLanguageEn, Slug: "classic-mug"},
+	})
+
+	renamed := &models.Product{ID: 1, SlugEn: "classic-mug"}
+	if err := recordSlugRedirects(db, renamed, []int64{1}, "mug", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var redirects []models.ProductSlugRedirect
+	db.Order("product_id ASC").Find(&redirects)
+	if len(redirects) != 2 {
+		t.Fatalf("expected 2 redirects, got %d", len(redirects))
+	}
+	if redirects[0].ProductID != 1 || redirects[0].Slug != "mug" {
+		t.Errorf("expected the old slug to redirect to the renamed product, got %+v", redirects[0])
+	}
+	if redirects[1].ProductID != 3 {
+		t.Errorf("expected the other store's redirect to be kept, got %+v", redirects[1])
+	}
+}
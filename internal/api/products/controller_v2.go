@@ -1,11 +1,16 @@
 package products
 
 import (
+	"errors"
+	"io"
+	"mime/multipart"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
 	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
 )
 
 // ControllerV2 handles Phase 2 product endpoints
@@ -25,7 +30,14 @@ func (ctrl *ControllerV2) AdminCreateProductV2(ctx *gin.Context) {
 		return
 	}
 
-	res := ctrl.service.CreateProductV2(req)
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.CreateProductV2(req, adminID.(int64))
 	utils.WriteResource(ctx, res)
 }
 
@@ -43,7 +55,14 @@ func (ctrl *ControllerV2) AdminUpdateProductV2(ctx *gin.Context) {
 		return
 	}
 
-	res := ctrl.service.UpdateProductV2(id, req)
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.UpdateProductV2(id, req, adminID.(int64))
 	utils.WriteResource(ctx, res)
 }
 
@@ -61,7 +80,14 @@ func (ctrl *ControllerV2) AdminUpdateProductStatus(ctx *gin.Context) {
 		return
 	}
 
-	res := ctrl.service.UpdateProductStatus(id, req)
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.UpdateProductStatus(id, req, adminID.(int64))
 	utils.WriteResource(ctx, res)
 }
 
@@ -79,7 +105,14 @@ func (ctrl *ControllerV2) AdminUpdateProductSEO(ctx *gin.Context) {
 		return
 	}
 
-	res := ctrl.service.UpdateProductSEO(id, req)
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.UpdateProductSEO(id, req, adminID.(int64))
 	utils.WriteResource(ctx, res)
 }
 
@@ -134,7 +167,14 @@ func (ctrl *ControllerV2) AdminCreateVariantV2(ctx *gin.Context) {
 		return
 	}
 
-	res := ctrl.service.CreateVariantV2(productID, req)
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.CreateVariantV2(productID, req, adminID.(int64))
 	utils.WriteResource(ctx, res)
 }
 
@@ -158,7 +198,111 @@ func (ctrl *ControllerV2) AdminUpdateVariantV2(ctx *gin.Context) {
 		return
 	}
 
-	res := ctrl.service.UpdateVariantV2(productID, variantID, req)
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.UpdateVariantV2(productID, variantID, req, adminID.(int64))
+	utils.WriteResource(ctx, res)
+}
+
+// AdminGenerateVariants creates variants for every combination of the selected attribute values
+func (ctrl *ControllerV2) AdminGenerateVariants(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	var req requests.GenerateVariantsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.GenerateVariants(productID, req, adminID.(int64))
+	utils.WriteResource(ctx, res)
+}
+
+// AdminGetBundleComponents lists the components of a bundle variant
+func (ctrl *ControllerV2) AdminGetBundleComponents(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	variantID, err := strconv.ParseInt(ctx.Param("variantId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid variant id")
+		return
+	}
+
+	res := ctrl.service.GetBundleComponents(productID, variantID)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminSetBundleComponents replaces the components of a bundle variant
+func (ctrl *ControllerV2) AdminSetBundleComponents(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	variantID, err := strconv.ParseInt(ctx.Param("variantId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid variant id")
+		return
+	}
+
+	var req requests.SetBundleComponentsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	res := ctrl.service.SetBundleComponents(productID, variantID, req)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminGetProductRelations lists the related products, cross-sells, upsells and accessories of a product
+func (ctrl *ControllerV2) AdminGetProductRelations(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	res := ctrl.service.GetProductRelations(productID)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminSetProductRelations replaces the relations of a product
+func (ctrl *ControllerV2) AdminSetProductRelations(ctx *gin.Context) {
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	var req requests.SetProductRelationsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	res := ctrl.service.SetProductRelations(productID, req)
 	utils.WriteResource(ctx, res)
 }
 
@@ -216,7 +360,146 @@ func (ctrl *ControllerV2) AdminSetCoverImage(ctx *gin.Context) {
 	utils.WriteResource(ctx, res)
 }
 
-// StorefrontListProducts lists products for a resolved store
+// AdminDuplicateProduct copies a product into a new draft
+func (ctrl *ControllerV2) AdminDuplicateProduct(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	// The body is optional, every field has a default
+	var req requests.DuplicateProductRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(ctx, err.Error())
+			return
+		}
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.DuplicateProduct(id, req, adminID.(int64))
+	utils.WriteResource(ctx, res)
+}
+
+// AdminListProductRevisions lists a product's revisions with their changes
+func (ctrl *ControllerV2) AdminListProductRevisions(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(ctx)
+	res := ctrl.service.ListProductRevisions(id, pagination)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminGetProductRevision returns a revision with its full snapshot
+func (ctrl *ControllerV2) AdminGetProductRevision(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	revisionID, err := strconv.ParseInt(ctx.Param("revisionId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid revision id")
+		return
+	}
+
+	res := ctrl.service.GetProductRevision(id, revisionID)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminRestoreProductRevision rolls a product back to a revision
+func (ctrl *ControllerV2) AdminRestoreProductRevision(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid product id")
+		return
+	}
+
+	revisionID, err := strconv.ParseInt(ctx.Param("revisionId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid revision id")
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.RestoreProductRevision(id, revisionID, adminID.(int64))
+	utils.WriteResource(ctx, res)
+}
+
+// AdminImportCatalog starts a background import of products and variants from a CSV or XLSX file
+func (ctrl *ControllerV2) AdminImportCatalog(ctx *gin.Context) {
+	var req requests.CatalogImportRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "no file uploaded or invalid file")
+		return
+	}
+	format, err := utils.SpreadsheetFormatFromName(fileHeader.Filename)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "failed to open uploaded file")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "failed to read uploaded file")
+		return
+	}
+
+	// Get admin ID from auth context
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	dryRun := req.DryRun == nil || *req.DryRun
+	res := ctrl.service.StartCatalogImport(fileHeader.Filename, format, data, dryRun, adminID.(int64))
+	utils.WriteResource(ctx, res)
+}
+
+// AdminGetCatalogImport returns the progress and row report of a catalog import
+func (ctrl *ControllerV2) AdminGetCatalogImport(ctx *gin.Context) {
+	jobID, err := strconv.ParseInt(ctx.Param("jobId"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid import job id")
+		return
+	}
+
+	res := ctrl.service.GetCatalogImport(jobID)
+	utils.WriteResource(ctx, res)
+}
+
+// StorefrontListProducts lists, filters and sorts products for a resolved store with facet counts
 func (ctrl *ControllerV2) StorefrontListProducts(ctx *gin.Context) {
 	sfID, exists := ctx.Get("store_front_id")
 	if !exists {
@@ -224,8 +507,11 @@ func (ctrl *ControllerV2) StorefrontListProducts(ctx *gin.Context) {
 		return
 	}
 
-	var filter requests.ProductFilterRequest
-	_ = ctx.ShouldBindQuery(&filter)
+	var filter requests.StorefrontProductFilterRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
 
 	pagination := utils.ParsePaginationParams(ctx)
 	res := ctrl.service.StorefrontListProducts(sfID.(int64), filter, pagination)
@@ -267,3 +553,269 @@ func (ctrl *ControllerV2) StorefrontGetStructuredData(ctx *gin.Context) {
 	res := ctrl.service.GetProductStructuredData(sfID.(int64), slug)
 	utils.WriteResource(ctx, res)
 }
+
+// StorefrontSearchSuggest returns autocomplete suggestions for a search term
+func (ctrl *ControllerV2) StorefrontSearchSuggest(ctx *gin.Context) {
+	sfID, exists := ctx.Get("store_front_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 400, "Store not resolved", nil)
+		return
+	}
+
+	var req requests.SearchSuggestRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	res := ctrl.service.SearchSuggest(sfID.(int64), req)
+	utils.WriteResource(ctx, res)
+}
+
+// ============== Collections ==============
+
+// AdminListCollections lists collections
+func (ctrl *ControllerV2) AdminListCollections(ctx *gin.Context) {
+	var filter requests.CollectionFilterRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(ctx)
+	res := ctrl.service.ListCollections(filter, pagination)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminGetCollection gets a collection with its rules or products
+func (ctrl *ControllerV2) AdminGetCollection(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid collection id")
+		return
+	}
+
+	res := ctrl.service.GetCollection(id)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminCreateCollection creates a manual or automatic collection
+func (ctrl *ControllerV2) AdminCreateCollection(ctx *gin.Context) {
+	var req requests.CollectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	res := ctrl.service.CreateCollection(req)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminUpdateCollection updates a collection
+func (ctrl *ControllerV2) AdminUpdateCollection(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid collection id")
+		return
+	}
+
+	var req requests.CollectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	res := ctrl.service.UpdateCollection(id, req)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminDeleteCollection deletes a collection
+func (ctrl *ControllerV2) AdminDeleteCollection(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid collection id")
+		return
+	}
+
+	res := ctrl.service.DeleteCollection(id)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminListCollectionProducts previews the products a collection shows in its store
+func (ctrl *ControllerV2) AdminListCollectionProducts(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid collection id")
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(ctx)
+	res := ctrl.service.ListCollectionProducts(id, pagination)
+	utils.WriteResource(ctx, res)
+}
+
+// StorefrontGetCollection lists, filters and sorts a collection's products for a resolved store
+func (ctrl *ControllerV2) StorefrontGetCollection(ctx *gin.Context) {
+	sfID, exists := ctx.Get("store_front_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 400, "Store not resolved", nil)
+		return
+	}
+
+	var filter requests.StorefrontProductFilterRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(ctx)
+	res := ctrl.service.StorefrontGetCollection(sfID.(int64), ctx.Param("slug"), filter, pagination)
+	utils.WriteResource(ctx, res)
+}
+
+// StorefrontListReviews lists the approved reviews of a product with its rating
+func (ctrl *ControllerV2) StorefrontListReviews(ctx *gin.Context) {
+	sfID, exists := ctx.Get("store_front_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 400, "Store not resolved", nil)
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(ctx)
+	res := ctrl.service.StorefrontListReviews(sfID.(int64), ctx.Param("slug"), pagination)
+	utils.WriteResource(ctx, res)
+}
+
+// StorefrontSubmitReview submits the signed in customer's review of a product they bought
+func (ctrl *ControllerV2) StorefrontSubmitReview(ctx *gin.Context) {
+	sfID, exists := ctx.Get("store_front_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 400, "Store not resolved", nil)
+		return
+	}
+
+	userID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	var req requests.SubmitReviewRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	var images []*multipart.FileHeader
+	if form, err := ctx.MultipartForm(); err == nil {
+		images = form.File["images"]
+	}
+
+	res := ctrl.service.SubmitReview(sfID.(int64), userID.(int64), ctx.Param("slug"), req, images)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminListReviews lists reviews for moderation
+func (ctrl *ControllerV2) AdminListReviews(ctx *gin.Context) {
+	var filter requests.ReviewFilterRequest
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	pagination := utils.ParsePaginationParams(ctx)
+	res := ctrl.service.ListReviews(filter, pagination)
+	utils.WriteResource(ctx, res)
+}
+
+// AdminApproveReview approves a review
+func (ctrl *ControllerV2) AdminApproveReview(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid review id")
+		return
+	}
+
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.ApproveReview(id, adminID.(int64))
+	utils.WriteResource(ctx, res)
+}
+
+// AdminRejectReview rejects a review
+func (ctrl *ControllerV2) AdminRejectReview(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationErrorResponse(ctx, "invalid review id")
+		return
+	}
+
+	var req requests.RejectReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	adminID, exists := ctx.Get("entity_id")
+	if !exists {
+		utils.ErrorResponse(ctx, 401, "Unauthorized", nil)
+		return
+	}
+
+	res := ctrl.service.RejectReview(id, adminID.(int64), req)
+	utils.WriteResource(ctx, res)
+}
+
+// StorefrontSitemap serves the store's XML sitemap, or one file of its sitemap index
+func (ctrl *ControllerV2) StorefrontSitemap(ctx *gin.Context) {
+	storeFront, exists := ctx.Get("store_front")
+	if !exists {
+		utils.ErrorResponse(ctx, 400, "Store not resolved", nil)
+		return
+	}
+
+	var req requests.SitemapRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	data, err := ctrl.service.StorefrontSitemap(storeFront.(models.StoreFront), req.Page)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(ctx, 404, "Sitemap not found", nil)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(ctx, 500, "Failed to build sitemap", nil)
+		return
+	}
+
+	ctx.Data(200, "application/xml; charset=utf-8", data)
+}
+
+// StorefrontProductFeed serves the store's product catalog feed for Google Merchant or Meta
+func (ctrl *ControllerV2) StorefrontProductFeed(ctx *gin.Context) {
+	storeFront, exists := ctx.Get("store_front")
+	if !exists {
+		utils.ErrorResponse(ctx, 400, "Store not resolved", nil)
+		return
+	}
+
+	var req requests.ProductFeedRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(ctx, err.Error())
+		return
+	}
+
+	data, contentType, err := ctrl.service.StorefrontProductFeed(storeFront.(models.StoreFront), req)
+	if err != nil {
+		utils.ErrorResponse(ctx, 500, "Failed to build product feed", nil)
+		return
+	}
+
+	ctx.Data(200, contentType, data)
+}
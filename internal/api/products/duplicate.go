@@ -0,0 +1,309 @@
+package products
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory"
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+const defaultDuplicateSKUPattern = "{slug}-{n}"
+
+// renderDuplicateSKU fills a duplicate SKU pattern for the n-th variant
+func renderDuplicateSKU(pattern, slug, sourceSKU string, n int) string {
+	if pattern == "" {
+		pattern = defaultDuplicateSKUPattern
+	}
+	return strings.NewReplacer(
+		"{sku}", sourceSKU,
+		"{slug}", strings.ToUpper(slug),
+		"{n}", strconv.Itoa(n),
+	).Replace(pattern)
+}
+
+// DuplicateProduct copies a product into a new draft: its details, SEO, images, attributes,
+// tags, storefront assignments and variants with their attribute values, add-ons and bundle
+// components. Stock is not copied, the copy's variants start at zero in every store.
+func (s *ServiceV2) DuplicateProduct(productID int64, req requests.DuplicateProductRequest, adminID int64) utils.IResource {
+	source, err := s.repo.GetProductModelByID(productID)
+	if err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	nameEn := req.NameEn
+	if nameEn == "" {
+		nameEn = source.NameEn + " (Copy)"
+	}
+	nameAr := req.NameAr
+	if nameAr == "" && source.NameAr != "" {
+		nameAr = source.NameAr + " (نسخة)"
+	}
+
+	baseEn, baseAr := productSlugs(nameEn, nameAr, "", "", false)
+	if baseEn == "" {
+		return utils.NewBadRequestResource("Could not generate slug from name_en", nil)
+	}
+	slug, err := utils.UniqueSlug(baseEn, s.repo.IsSlugTaken)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to generate slug", err)
+	}
+	slugAr := ""
+	if baseAr != "" {
+		if slugAr, err = utils.UniqueSlug(baseAr, s.repo.IsSlugTaken); err != nil {
+			return utils.NewInternalErrorResource("Failed to generate slug", err)
+		}
+	}
+
+	var variants []models.ProductVariant
+	if err := s.db.Where("product_id = ?", productID).Order("id ASC").Find(&variants).Error; err != nil {
+		return utils.NewInternalErrorResource("Failed to load variants", err)
+	}
+
+	// Every copied variant needs a new SKU that is free in the catalog
+	skus := make(map[int64]string, len(variants))
+	batchSKUs := make(map[string]bool, len(variants))
+	for i, v := range variants {
+		sku := renderDuplicateSKU(req.SKUPattern, slug, v.SKU, i+1)
+		if sku == "" || batchSKUs[sku] {
+			return utils.NewBadRequestResource("sku_pattern does not give a unique SKU for every variant", nil)
+		}
+		unique, err := s.repo.IsSKUUnique(sku, 0)
+		if err != nil {
+			return utils.NewInternalErrorResource("Failed to validate SKU", err)
+		}
+		if !unique {
+			return utils.NewBadRequestResource(fmt.Sprintf("SKU '%s' already exists", sku), nil)
+		}
+		batchSKUs[sku] = true
+		skus[v.ID] = sku
+	}
+
+	sfIDs, err := s.repo.GetProductStoreFrontIDs(productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product store fronts", err)
+	}
+	attrs, err := productAttributes(s.db, productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product attributes", err)
+	}
+	tags, err := productTags(s.db, productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product tags", err)
+	}
+
+	var copyID int64
+	err = s.invRepo.Transaction(s.db, func(tx *gorm.DB) error {
+		repoTx := &V2Repository{db: tx}
+
+		product := &models.Product{
+			NameEn:             nameEn,
+			NameAr:             nameAr,
+			Name:               nameEn, // backward compat
+			Slug:               slug,
+			SlugEn:             slug,
+			SlugAr:             slugAr,
+			DescriptionEn:      source.DescriptionEn,
+			DescriptionAr:      source.DescriptionAr,
+			Description:        source.DescriptionEn, // backward compat
+			Price:              source.Price,
+			BrandID:            source.BrandID,
+			CategoryID:         source.CategoryID,
+			SupplierID:         source.SupplierID,
+			IsInternalSupplier: source.IsInternalSupplier,
+			AttributeType:      source.AttributeType,
+			ProductType:        source.ProductType,
+			Status:             models.ProductStatusDraft,
+			IsPublished:        false,
+			IsFeatured:         source.IsFeatured,
+			IsNew:              source.IsNew,
+			IsActive:           true,
+		}
+		if err := repoTx.CreateProductV2(product); err != nil {
+			return err
+		}
+		copyID = product.ID
+
+		if err := repoTx.AssignProductToStores(tx, copyID, sfIDs); err != nil {
+			return err
+		}
+		if err := setProductTags(tx, copyID, tags); err != nil {
+			return fmt.Errorf("failed to copy tags: %w", err)
+		}
+		if err := duplicateSEO(tx, repoTx, source, product); err != nil {
+			return fmt.Errorf("failed to copy SEO: %w", err)
+		}
+		if err := duplicateImages(tx, productID, copyID); err != nil {
+			return fmt.Errorf("failed to copy images: %w", err)
+		}
+		if err := duplicateAttributes(tx, productID, copyID, attrs); err != nil {
+			return fmt.Errorf("failed to copy attributes: %w", err)
+		}
+		if err := duplicateRelations(tx, productID, copyID); err != nil {
+			return fmt.Errorf("failed to copy relations: %w", err)
+		}
+
+		for _, v := range variants {
+			if err := s.duplicateVariant(tx, repoTx, product, v, skus[v.ID], sfIDs); err != nil {
+				return fmt.Errorf("failed to copy variant %s: %w", v.SKU, err)
+			}
+		}
+
+		// Bundle copies get no inventory rows, their stock follows the components
+		if err := inventory.RefreshProductStock(tx, copyID); err != nil {
+			return err
+		}
+		return recordRevision(tx, copyID, models.ProductRevisionCreate, adminID, nil)
+	})
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to duplicate product", err)
+	}
+
+	detail, err := s.repo.GetAdminProductV2ByID(copyID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Product duplicated but failed to retrieve", err)
+	}
+
+	return utils.NewCreatedResource("Product duplicated successfully", detail)
+}
+
+// duplicateSEO copies the SEO of a product, pointing a canonical URL at the copy's slug
+func duplicateSEO(tx *gorm.DB, repoTx *V2Repository, source, product *models.Product) error {
+	var seo models.ProductSEO
+	err := tx.Where("product_id = ?", source.ID).First(&seo).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return repoTx.UpsertProductSEO(tx, product.ID, requests.ProductSEORequest{
+		MetaTitleEn:       seo.MetaTitleEn,
+		MetaTitleAr:       seo.MetaTitleAr,
+		MetaDescriptionEn: seo.MetaDescriptionEn,
+		MetaDescriptionAr: seo.MetaDescriptionAr,
+		MetaKeywords:      seo.MetaKeywords,
+		CanonicalURL:      strings.ReplaceAll(seo.CanonicalURL, "/"+source.SlugEn, "/"+product.SlugEn),
+		OgTitle:           seo.OgTitle,
+		OgDescription:     seo.OgDescription,
+		OgImage:           seo.OgImage,
+	})
+}
+
+// duplicateImages links the copy to the same files, keeping order and cover
+func duplicateImages(tx *gorm.DB, sourceID, copyID int64) error {
+	var images []models.ProductImage
+	if err := tx.Where("product_id = ?", sourceID).Order("position ASC, id ASC").Find(&images).Error; err != nil {
+		return err
+	}
+	for _, img := range images {
+		copied := models.ProductImage{ProductID: copyID, FileID: img.FileID, Position: img.Position, IsCover: img.IsCover}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duplicateAttributes gives the copy the same attributes and allowed values
+func duplicateAttributes(tx *gorm.DB, sourceID, copyID int64, attrs []models.Attribute) error {
+	if err := setProductAttributes(tx, copyID, attrs); err != nil {
+		return err
+	}
+
+	var valueIDs []int64
+	if err := tx.Model(&models.ProductAttributeValue{}).Where("product_id = ?", sourceID).Pluck("attribute_value_id", &valueIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range valueIDs {
+		if err := tx.Create(&models.ProductAttributeValue{ProductID: copyID, AttributeValueID: id}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duplicateVariant copies a variant under a new SKU with its attribute values, add-ons,
+// bundle components and empty inventory in the product's stores. Barcodes identify one
+// variant, so the copy has none.
+func (s *ServiceV2) duplicateVariant(tx *gorm.DB, repoTx *V2Repository, product *models.Product, source models.ProductVariant, sku string, sfIDs []int64) error {
+	variant := &models.ProductVariant{
+		ProductID:      product.ID,
+		SKU:            sku,
+		AttributeValue: source.AttributeValue,
+		Price:          source.Price,
+		CompareAtPrice: source.CompareAtPrice,
+		CostPrice:      source.CostPrice,
+		Weight:         source.Weight,
+		Length:         source.Length,
+		Width:          source.Width,
+		Height:         source.Height,
+		IsActive:       source.IsActive,
+		TrackingMode:   source.TrackingMode,
+	}
+	if err := repoTx.CreateVariantV2(variant); err != nil {
+		return err
+	}
+	if !source.IsActive {
+		// The column default would otherwise turn a false value back on
+		if err := tx.Model(variant).Update("is_active", false).Error; err != nil {
+			return err
+		}
+	}
+
+	var valueIDs []int64
+	if err := tx.Model(&models.ProductVariantAttributeValue{}).Where("product_variant_id = ?", source.ID).Pluck("attribute_value_id", &valueIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range valueIDs {
+		if err := tx.Create(&models.ProductVariantAttributeValue{ProductVariantID: variant.ID, AttributeValueID: id}).Error; err != nil {
+			return err
+		}
+	}
+
+	var addOns []models.ProductVariantAddOn
+	if err := tx.Where("product_variant_id = ?", source.ID).Order("id ASC").Find(&addOns).Error; err != nil {
+		return err
+	}
+	for _, a := range addOns {
+		if err := tx.Create(&models.ProductVariantAddOn{ProductVariantID: variant.ID, AddOnProductID: a.AddOnProductID}).Error; err != nil {
+			return err
+		}
+	}
+
+	if product.ProductType == models.ProductTypeBundle {
+		var components []models.BundleComponent
+		if err := tx.Where("bundle_variant_id = ?", source.ID).Order("id ASC").Find(&components).Error; err != nil {
+			return err
+		}
+		copied := make([]models.BundleComponent, 0, len(components))
+		for _, c := range components {
+			copied = append(copied, models.BundleComponent{BundleVariantID: variant.ID, ComponentVariantID: c.ComponentVariantID, Quantity: c.Quantity})
+		}
+		return repoTx.ReplaceBundleComponents(tx, variant.ID, copied)
+	}
+
+	// The copy starts without stock but keeps each store's threshold and backorder policy
+	var sourceStock []models.VariantInventory
+	if err := tx.Where("product_variant_id = ?", source.ID).Find(&sourceStock).Error; err != nil {
+		return err
+	}
+	byStore := make(map[int64]models.VariantInventory, len(sourceStock))
+	for _, inv := range sourceStock {
+		byStore[inv.StoreFrontID] = inv
+	}
+
+	return s.createVariantInventory(tx, product, variant, sfIDs, func(inv *models.VariantInventory) {
+		if src, ok := byStore[inv.StoreFrontID]; ok {
+			inv.LowStockThreshold = src.LowStockThreshold
+			inv.BackorderPolicy = src.BackorderPolicy
+			inv.BackorderLimit = src.BackorderLimit
+			inv.AvailableOn = src.AvailableOn
+		}
+	})
+}
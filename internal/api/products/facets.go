@@ -0,0 +1,389 @@
+package products
+
+import (
+	"math"
+	"sort"
+
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Storefront listing sort options
+const (
+	sortRelevance   = "relevance"
+	sortNewest      = "newest"
+	sortPriceAsc    = "price_asc"
+	sortPriceDesc   = "price_desc"
+	sortBestSelling = "best_selling"
+	sortFeatured    = "featured"
+)
+
+const maxPriceRanges = 5
+
+// storefrontMinPrice is the lowest price a store shows for a listed product (p, ps)
+const storefrontMinPrice = `(SELECT MIN(COALESCE(pli.price, pv.price)) FROM ` + storefrontVariantPrices + ` WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL)`
+
+// storefrontInStock matches listed products (p, ps) the store can sell now. The flag is kept
+// current by the inventory availability projection, storefront reads don't touch stock rows.
+const storefrontInStock = `ps.in_stock`
+
+// storefrontUnitsSold counts the units of a listed product (p, ps) sold by the store
+const storefrontUnitsSold = `(SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL
+	JOIN order_statuses os ON os.id = o.order_status_id
+	WHERE oi.product_id = p.id AND o.store_front_id = ps.store_front_id AND os.slug NOT IN ('draft', 'cancelled', 'returned', 'refunded'))`
+
+type StorefrontFacets struct {
+	Brands       []FacetOption     `json:"brands"`
+	Categories   []FacetOption     `json:"categories"`
+	PriceRanges  []PriceRangeFacet `json:"price_ranges"`
+	Attributes   []AttributeFacet  `json:"attributes"`
+	Availability AvailabilityFacet `json:"availability"`
+}
+
+type FacetOption struct {
+	ID     int64  `json:"id"`
+	NameEn string `json:"name_en"`
+	NameAr string `json:"name_ar"`
+	Count  int64  `json:"count"`
+}
+
+// PriceRangeFacet counts the products whose lowest price is at least Min and below Max
+type PriceRangeFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
+}
+
+type AttributeFacet struct {
+	ID     int64                 `json:"id"`
+	NameEn string                `json:"name_en"`
+	NameAr string                `json:"name_ar"`
+	Values []AttributeValueFacet `json:"values"`
+}
+
+type AttributeValueFacet struct {
+	ID      int64  `json:"id"`
+	ValueEn string `json:"value_en"`
+	ValueAr string `json:"value_ar"`
+	Count   int64  `json:"count"`
+}
+
+type AvailabilityFacet struct {
+	InStock    int64 `json:"in_stock"`
+	OutOfStock int64 `json:"out_of_stock"`
+}
+
+// attributeValueCount is one row of the attribute facet queries
+type attributeValueCount struct {
+	AttributeID     int64
+	AttributeNameEn string
+	AttributeNameAr string
+	ID              int64
+	ValueEn         string
+	ValueAr         string
+	Count           int64
+}
+
+// groupAttributeValues groups selected attribute values by their attribute. Selected IDs
+// that are not a current attribute value are returned as unknown.
+func (r *V2Repository) groupAttributeValues(valueIDs []int64) (map[int64][]int64, []int64, error) {
+	groups := map[int64][]int64{}
+	if len(valueIDs) == 0 {
+		return groups, nil, nil
+	}
+
+	var values []struct {
+		ID          int64
+		AttributeID int64
+	}
+	err := r.db.Table("attribute_values").
+		Where("id IN ? AND deleted_at IS NULL", valueIDs).
+		Select("id, attribute_id").
+		Scan(&values).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[int64]bool, len(values))
+	for _, v := range values {
+		groups[v.AttributeID] = append(groups[v.AttributeID], v.ID)
+		found[v.ID] = true
+	}
+	var unknown []int64
+	for _, id := range valueIDs {
+		if !found[id] {
+			unknown = append(unknown, id)
+			found[id] = true // Report repeated IDs once
+		}
+	}
+	return groups, unknown, nil
+}
+
+// storefrontListing selects the published products of a store or collection (p, ps) matching a filter.
+// A product matches the selected attribute values when one of its variants has, for every
+// attribute, one of the values selected for it.
+func (r *V2Repository) storefrontListing(scope storefrontScope, filter requests.StorefrontProductFilterRequest, attributeValues map[int64][]int64) *gorm.DB {
+	query := r.db.Table("products p").
+		Joins("JOIN product_storefront ps ON ps.product_id = p.id").
+		Where("ps.store_front_id = ? AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", scope.storeFrontID)
+	query = scope.apply(query)
+
+	if filter.CategoryID != nil {
+		query = query.Where("p.category_id IN "+categorySubtree, []int64{*filter.CategoryID})
+	}
+	if filter.BrandID != nil {
+		query = query.Where("p.brand_id = ?", *filter.BrandID)
+	}
+	if filter.Search != "" {
+		query = searchProducts(query, filter.Search)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where(storefrontMinPrice+" >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where(storefrontMinPrice+" <= ?", *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			query = query.Where(storefrontInStock)
+		} else {
+			query = query.Where("NOT " + storefrontInStock)
+		}
+	}
+
+	if len(attributeValues) > 0 {
+		attributeIDs := make([]int64, 0, len(attributeValues))
+		for id := range attributeValues {
+			attributeIDs = append(attributeIDs, id)
+		}
+		sort.Slice(attributeIDs, func(i, j int) bool { return attributeIDs[i] < attributeIDs[j] })
+
+		sql := "EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL"
+		args := make([]interface{}, 0, len(attributeIDs))
+		for _, id := range attributeIDs {
+			sql += " AND EXISTS (SELECT 1 FROM product_variant_attribute_values pvav WHERE pvav.product_variant_id = pv.id AND pvav.attribute_value_id IN ?)"
+			args = append(args, attributeValues[id])
+		}
+		query = query.Where(sql+")", args...)
+	}
+
+	return query
+}
+
+// storefrontOrder orders a storefront listing. Without a sort, search results are ranked,
+// manual collections keep their order and other listings show the newest products first.
+func storefrontOrder(scope storefrontScope, sortBy, search string) clause.OrderBy {
+	order := func(sql string) clause.OrderBy {
+		return clause.OrderBy{Expression: clause.Expr{SQL: sql + ", p.created_at DESC", WithoutParentheses: true}}
+	}
+
+	switch sortBy {
+	case sortNewest:
+		return listOrder("")
+	case sortPriceAsc:
+		return order("min_price ASC NULLS LAST")
+	case sortPriceDesc:
+		return order("min_price DESC NULLS LAST")
+	case sortBestSelling:
+		return order(storefrontUnitsSold + " DESC")
+	case sortFeatured:
+		return order("p.is_featured DESC")
+	default:
+		if search == "" && scope.isManual() {
+			return order("cp.position ASC")
+		}
+		return listOrder(search)
+	}
+}
+
+// StorefrontFacets counts the products of a listing by brand, category, price range,
+// attribute value and availability. Every facet applies all filters but its own, so
+// its counts show what picking another of its options would give.
+func (r *V2Repository) StorefrontFacets(scope storefrontScope, filter requests.StorefrontProductFilterRequest, attributeValues map[int64][]int64) (*StorefrontFacets, error) {
+	facets := &StorefrontFacets{}
+
+	withoutBrand := filter
+	withoutBrand.BrandID = nil
+	brands := []FacetOption{}
+	err := r.storefrontListing(scope, withoutBrand, attributeValues).
+		Joins("JOIN brands b ON b.id = p.brand_id AND b.deleted_at IS NULL").
+		Select("b.id, b.name_en, b.name_ar, COUNT(*) as count").
+		Group("b.id, b.name_en, b.name_ar").
+		Order("count DESC, b.name_en ASC").
+		Scan(&brands).Error
+	if err != nil {
+		return nil, err
+	}
+	facets.Brands = brands
+
+	withoutCategory := filter
+	withoutCategory.CategoryID = nil
+	categories := []FacetOption{}
+	err = r.storefrontListing(scope, withoutCategory, attributeValues).
+		Joins("JOIN categories c ON c.id = p.category_id AND c.deleted_at IS NULL").
+		Select("c.id, c.name_en, c.name_ar, COUNT(*) as count").
+		Group("c.id, c.name_en, c.name_ar").
+		Order("count DESC, c.name_en ASC").
+		Scan(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	facets.Categories = categories
+
+	withoutPrice := filter
+	withoutPrice.MinPrice, withoutPrice.MaxPrice = nil, nil
+	var prices []float64
+	err = r.storefrontListing(scope, withoutPrice, attributeValues).
+		Where(storefrontMinPrice + " IS NOT NULL").
+		Select(storefrontMinPrice + " as price").
+		Scan(&prices).Error
+	if err != nil {
+		return nil, err
+	}
+	facets.PriceRanges = priceRanges(prices, maxPriceRanges)
+
+	if facets.Attributes, err = r.attributeFacets(scope, filter, attributeValues); err != nil {
+		return nil, err
+	}
+
+	withoutStock := filter
+	withoutStock.InStock = nil
+	var stock struct {
+		Total   int64
+		InStock int64
+	}
+	err = r.storefrontListing(scope, withoutStock, attributeValues).
+		Select("COUNT(*) as total, COALESCE(SUM(CASE WHEN " + storefrontInStock + " THEN 1 ELSE 0 END), 0) as in_stock").
+		Scan(&stock).Error
+	if err != nil {
+		return nil, err
+	}
+	facets.Availability = AvailabilityFacet{InStock: stock.InStock, OutOfStock: stock.Total - stock.InStock}
+
+	return facets, nil
+}
+
+// attributeFacets counts products by the attribute values of their active variants. The
+// values of a selected attribute are counted without the selection on that attribute.
+func (r *V2Repository) attributeFacets(scope storefrontScope, filter requests.StorefrontProductFilterRequest, attributeValues map[int64][]int64) ([]AttributeFacet, error) {
+	count := func(values map[int64][]int64, narrow func(*gorm.DB) *gorm.DB) ([]attributeValueCount, error) {
+		var rows []attributeValueCount
+		query := r.storefrontListing(scope, filter, values).
+			Joins("JOIN product_variants fv ON fv.product_id = p.id AND fv.is_active = true AND fv.deleted_at IS NULL").
+			Joins("JOIN product_variant_attribute_values fpv ON fpv.product_variant_id = fv.id").
+			Joins("JOIN attribute_values av ON av.id = fpv.attribute_value_id AND av.is_active = true AND av.deleted_at IS NULL").
+			Joins("JOIN attributes a ON a.id = av.attribute_id AND a.deleted_at IS NULL")
+		err := narrow(query).
+			Select("a.id as attribute_id, a.name_en as attribute_name_en, a.name_ar as attribute_name_ar, av.id, av.value_en, av.value_ar, COUNT(DISTINCT p.id) as count").
+			Group("a.id, a.name_en, a.name_ar, av.id, av.value_en, av.value_ar, av.sort_order").
+			Order("a.id ASC, av.sort_order ASC, av.id ASC").
+			Scan(&rows).Error
+		return rows, err
+	}
+
+	selected := make([]int64, 0, len(attributeValues))
+	for id := range attributeValues {
+		selected = append(selected, id)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i] < selected[j] })
+
+	rows, err := count(attributeValues, func(q *gorm.DB) *gorm.DB {
+		if len(selected) == 0 {
+			return q
+		}
+		return q.Where("av.attribute_id NOT IN ?", selected)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attributeID := range selected {
+		others := make(map[int64][]int64, len(attributeValues)-1)
+		for id, values := range attributeValues {
+			if id != attributeID {
+				others[id] = values
+			}
+		}
+		selectedRows, err := count(others, func(q *gorm.DB) *gorm.DB {
+			return q.Where("av.attribute_id = ?", attributeID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, selectedRows...)
+	}
+
+	facets := []AttributeFacet{}
+	index := map[int64]int{}
+	for _, row := range rows {
+		i, ok := index[row.AttributeID]
+		if !ok {
+			i = len(facets)
+			index[row.AttributeID] = i
+			facets = append(facets, AttributeFacet{ID: row.AttributeID, NameEn: row.AttributeNameEn, NameAr: row.AttributeNameAr})
+		}
+		facets[i].Values = append(facets[i].Values, AttributeValueFacet{ID: row.ID, ValueEn: row.ValueEn, ValueAr: row.ValueAr, Count: row.Count})
+	}
+	sort.SliceStable(facets, func(i, j int) bool { return facets[i].ID < facets[j].ID })
+
+	return facets, nil
+}
+
+// priceRanges spreads prices over at most maxRanges ranges of a rounded width (1, 2 or 5
+// times a power of ten), leaving out empty ranges
+func priceRanges(prices []float64, maxRanges int) []PriceRangeFacet {
+	ranges := []PriceRangeFacet{}
+	if len(prices) == 0 {
+		return ranges
+	}
+
+	low, high := prices[0], prices[0]
+	for _, p := range prices {
+		low = math.Min(low, p)
+		high = math.Max(high, p)
+	}
+	if low == high {
+		return append(ranges, PriceRangeFacet{Min: low, Max: high, Count: int64(len(prices))})
+	}
+
+	step := niceStep((high - low) / float64(maxRanges))
+	start := math.Floor(low/step) * step
+	// Rounding the width up can still leave one range too many after flooring the start
+	for math.Floor((high-start)/step)+1 > float64(maxRanges) {
+		step = niceStep(step * 1.5)
+		start = math.Floor(low/step) * step
+	}
+
+	counts := map[int]int64{}
+	for _, p := range prices {
+		counts[int((p-start)/step)]++
+	}
+	for i := 0; start+float64(i)*step <= high; i++ {
+		if counts[i] == 0 {
+			continue
+		}
+		ranges = append(ranges, PriceRangeFacet{
+			Min:   start + float64(i)*step,
+			Max:   start + float64(i+1)*step,
+			Count: counts[i],
+		})
+	}
+	return ranges
+}
+
+// niceStep rounds a width up to 1, 2 or 5 times a power of ten
+func niceStep(raw float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch fraction := raw / magnitude; {
+	case fraction <= 1:
+		return magnitude
+	case fraction <= 2:
+		return 2 * magnitude
+	case fraction <= 5:
+		return 5 * magnitude
+	default:
+		return 10 * magnitude
+	}
+}
@@ -0,0 +1,358 @@
+package products
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+)
+
+// Product feed formats
+const (
+	FeedFormatXML = "xml" // Google Merchant RSS, also read by Meta catalogs
+	FeedFormatCSV = "csv" // Meta catalog columns
+)
+
+// feedBatchSize is the products loaded per query while building a feed
+const feedBatchSize = 500
+
+// feedMaxImages caps the additional images of a feed item
+const feedMaxImages = 10
+
+// FeedItem is one variant of a product in a storefront catalog feed
+type FeedItem struct {
+	ID              string // SKU
+	ItemGroupID     string // Product, shared by its variants
+	Title           string
+	Description     string
+	Link            string
+	ImageLink       string
+	AdditionalLinks []string
+	Availability    string // in_stock, out_of_stock, preorder or backorder
+	AvailableOn     string // Preorder date, ISO 8601
+	Price           string // Regular price and currency, "10.00 SAR"
+	SalePrice       string // Shown price when it is below the regular price
+	Brand           string
+	GTIN            string // Barcode
+}
+
+// feedProduct is an active published product of a store with what its feed items need
+type feedProduct struct {
+	ID            int64
+	NameEn        string
+	NameAr        string
+	DescriptionEn string
+	DescriptionAr string
+	SlugEn        string
+	SlugAr        string
+	ProductType   string
+	BrandName     *string
+}
+
+// StorefrontProductFeed renders the store's active published products as a Google
+// Merchant / Meta catalog feed, one item per active variant with a price
+func (s *ServiceV2) StorefrontProductFeed(storeFront models.StoreFront, req requests.ProductFeedRequest) ([]byte, string, error) {
+	lang := req.Lang
+	if lang == "" {
+		lang = storeFront.DefaultLanguage
+	}
+
+	items, err := s.repo.FeedItems(storeFront, lang)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if req.Format == FeedFormatCSV {
+		var buf bytes.Buffer
+		if err := utils.WriteSpreadsheet(&buf, utils.SpreadsheetCSV, feedCSVRows(items)); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), utils.SpreadsheetContentType(utils.SpreadsheetCSV), nil
+	}
+
+	body, err := renderFeedXML(storeFront, lang, items)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, "application/xml; charset=utf-8", nil
+}
+
+type feedRSS struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	G       string      `xml:"xmlns:g,attr"`
+	Channel feedChannel `xml:"channel"`
+}
+
+type feedChannel struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description"`
+	Items       []feedRSSItem `xml:"item"`
+}
+
+type feedRSSItem struct {
+	ID               string   `xml:"g:id"`
+	ItemGroupID      string   `xml:"g:item_group_id"`
+	Title            string   `xml:"title"`
+	Description      string   `xml:"description"`
+	Link             string   `xml:"link"`
+	ImageLink        string   `xml:"g:image_link,omitempty"`
+	AdditionalLinks  []string `xml:"g:additional_image_link"`
+	Availability     string   `xml:"g:availability"`
+	AvailabilityDate string   `xml:"g:availability_date,omitempty"`
+	Price            string   `xml:"g:price"`
+	SalePrice        string   `xml:"g:sale_price,omitempty"`
+	Brand            string   `xml:"g:brand,omitempty"`
+	GTIN             string   `xml:"g:gtin,omitempty"`
+	Condition        string   `xml:"g:condition"`
+	IdentifierExists string   `xml:"g:identifier_exists,omitempty"`
+}
+
+func renderFeedXML(storeFront models.StoreFront, lang string, items []FeedItem) ([]byte, error) {
+	rss := feedRSS{
+		Version: "2.0",
+		G:       "http://base.google.com/ns/1.0",
+		Channel: feedChannel{
+			Title:       storeFront.Name,
+			Link:        storefrontPageURL(storeFront.Domain, lang, ""),
+			Description: fmt.Sprintf("%s product catalog", storeFront.Name),
+			Items:       make([]feedRSSItem, 0, len(items)),
+		},
+	}
+	for _, item := range items {
+		rssItem := feedRSSItem{
+			ID:               item.ID,
+			ItemGroupID:      item.ItemGroupID,
+			Title:            item.Title,
+			Description:      item.Description,
+			Link:             item.Link,
+			ImageLink:        item.ImageLink,
+			AdditionalLinks:  item.AdditionalLinks,
+			Availability:     item.Availability,
+			AvailabilityDate: item.AvailableOn,
+			Price:            item.Price,
+			SalePrice:        item.SalePrice,
+			Brand:            item.Brand,
+			GTIN:             item.GTIN,
+			Condition:        "new",
+		}
+		if item.GTIN == "" && item.Brand == "" {
+			rssItem.IdentifierExists = "no"
+		}
+		rss.Channel.Items = append(rss.Channel.Items, rssItem)
+	}
+	return marshalXML(rss)
+}
+
+func feedCSVRows(items []FeedItem) [][]string {
+	rows := [][]string{{
+		"id", "item_group_id", "title", "description", "availability", "availability_date", "condition",
+		"price", "sale_price", "link", "image_link", "additional_image_link", "brand", "gtin",
+	}}
+	for _, item := range items {
+		rows = append(rows, []string{
+			item.ID, item.ItemGroupID, item.Title, item.Description, item.Availability, item.AvailableOn, "new",
+			item.Price, item.SalePrice, item.Link, item.ImageLink, strings.Join(item.AdditionalLinks, ","), item.Brand, item.GTIN,
+		})
+	}
+	return rows
+}
+
+// FeedItems builds the feed items of the store's active published products, with the
+// store's prices and availability
+func (r *V2Repository) FeedItems(storeFront models.StoreFront, lang string) ([]FeedItem, error) {
+	items := []FeedItem{}
+	var lastID int64
+	for {
+		var products []feedProduct
+		err := r.db.Table("products p").
+			Joins("JOIN product_storefront ps ON ps.product_id = p.id").
+			Joins("LEFT JOIN brands b ON b.id = p.brand_id").
+			Where("ps.store_front_id = ? AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL AND p.id > ?", storeFront.ID, lastID).
+			Select("p.id, p.name_en, p.name_ar, p.description_en, p.description_ar, p.slug_en, p.slug_ar, p.product_type, b.name_en AS brand_name").
+			Order("p.id").
+			Limit(feedBatchSize).
+			Scan(&products).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(products) == 0 {
+			return items, nil
+		}
+		lastID = products[len(products)-1].ID
+
+		batch, err := r.feedBatch(storeFront, lang, products)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+	}
+}
+
+func (r *V2Repository) feedBatch(storeFront models.StoreFront, lang string, products []feedProduct) ([]FeedItem, error) {
+	productIDs := make([]int64, 0, len(products))
+	bundles := make(map[int64]bool)
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+		if p.ProductType == models.ProductTypeBundle {
+			bundles[p.ID] = true
+		}
+	}
+
+	var variants []models.ProductVariant
+	if err := r.db.Where("product_id IN ? AND is_active = true AND deleted_at IS NULL", productIDs).Order("id ASC").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	shown, err := r.shownVariants(storeFront.ID, variants, bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []models.ProductImage
+	if err := r.db.Where("product_id IN ?", productIDs).Preload("File").Order("is_cover DESC, position ASC, id ASC").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	imageLinks := make(map[int64][]string)
+	for _, img := range images {
+		if img.File != nil {
+			imageLinks[img.ProductID] = append(imageLinks[img.ProductID], fmt.Sprintf("https://%s/api/files/path/%s", storeFront.Domain, img.File.FilePath))
+		}
+	}
+
+	byProduct := make(map[int64]feedProduct, len(products))
+	for _, p := range products {
+		byProduct[p.ID] = p
+	}
+
+	items := make([]FeedItem, 0, len(variants))
+	for _, v := range variants {
+		sv, ok := shown[v.ID]
+		if !ok || sv.Price == nil {
+			continue
+		}
+		p := byProduct[v.ProductID]
+
+		slug := p.SlugEn
+		if lang == "ar" && p.SlugAr != "" {
+			slug = p.SlugAr
+		}
+		title := utils.SelectLocalizedString(lang, p.NameAr, p.NameEn)
+		if v.AttributeValue != "" {
+			title += " - " + v.AttributeValue
+		}
+
+		item := FeedItem{
+			ID:           v.SKU,
+			ItemGroupID:  fmt.Sprintf("%d", p.ID),
+			Title:        truncateRunes(title, 150),
+			Description:  truncateRunes(utils.SelectLocalizedString(lang, p.DescriptionAr, p.DescriptionEn), 5000),
+			Link:         storefrontPageURL(storeFront.Domain, lang, "/products/"+url.PathEscape(slug)),
+			Availability: sv.Availability,
+			Price:        feedPrice(*sv.Price, storeFront.Currency),
+		}
+		if item.Description == "" {
+			item.Description = title
+		}
+		if sv.AvailableOn != nil {
+			item.AvailableOn = sv.AvailableOn.Format("2006-01-02T15:04:05Z07:00")
+		}
+		if sv.CompareAtPrice != nil && *sv.CompareAtPrice > *sv.Price {
+			item.Price = feedPrice(*sv.CompareAtPrice, storeFront.Currency)
+			item.SalePrice = feedPrice(*sv.Price, storeFront.Currency)
+		}
+		if p.BrandName != nil {
+			item.Brand = *p.BrandName
+		}
+		if v.Barcode != nil {
+			item.GTIN = strings.TrimSpace(*v.Barcode)
+		}
+		if links := imageLinks[p.ID]; len(links) > 0 {
+			item.ImageLink = links[0]
+			item.AdditionalLinks = links[1:]
+			if len(item.AdditionalLinks) > feedMaxImages {
+				item.AdditionalLinks = item.AdditionalLinks[:feedMaxImages]
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// shownVariants returns the variants as the store shows them, with its prices and
+// availability, keyed by variant. Variants of bundles are in stock when one can be assembled.
+func (r *V2Repository) shownVariants(storeFrontID int64, variants []models.ProductVariant, bundles map[int64]bool) (map[int64]StorefrontVariant, error) {
+	var bundleVariants, simpleVariants []models.ProductVariant
+	variantIDs := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+		if bundles[v.ProductID] {
+			bundleVariants = append(bundleVariants, v)
+		} else {
+			simpleVariants = append(simpleVariants, v)
+		}
+	}
+
+	var shown []StorefrontVariant
+	if len(bundleVariants) > 0 {
+		var err error
+		if shown, err = r.storefrontBundleVariants(storeFrontID, bundleVariants); err != nil {
+			return nil, err
+		}
+	}
+
+	simpleIDs := make([]int64, 0, len(simpleVariants))
+	for _, v := range simpleVariants {
+		simpleIDs = append(simpleIDs, v.ID)
+	}
+	stock, err := r.availability.Get(r.db, storeFrontID, simpleIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range simpleVariants {
+		inv := stock[v.ID]
+		sv := StorefrontVariant{
+			ID:             v.ID,
+			SKU:            v.SKU,
+			AttributeValue: v.AttributeValue,
+			Price:          v.Price,
+			CompareAtPrice: v.CompareAtPrice,
+			InStock:        inv.IsSellable(),
+			Availability:   inv.Availability(),
+		}
+		if sv.Availability == models.AvailabilityPreorder {
+			sv.AvailableOn = inv.AvailableOn
+		}
+		shown = append(shown, sv)
+	}
+
+	prices, err := r.storefrontPrices(storeFrontID, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+	applyVariantPrices(shown, prices)
+
+	result := make(map[int64]StorefrontVariant, len(shown))
+	for _, sv := range shown {
+		result[sv.ID] = sv
+	}
+	return result, nil
+}
+
+// truncateRunes shortens s to maxLen characters, keeping Arabic text valid
+func truncateRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen])
+}
+
+func feedPrice(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
@@ -0,0 +1,175 @@
+package products
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// MerchandisingParams controls how the best-seller and new-arrival flags are computed
+type MerchandisingParams struct {
+	BestSellerWindowDays int // Completed orders counted for best sellers
+	BestSellerCount      int // Best sellers per store
+	NewArrivalDays       int // Days a product stays new after it is created
+}
+
+// MerchandisingResult counts what a refresh changed
+type MerchandisingResult struct {
+	Stores      int `json:"stores"`
+	BestSellers int `json:"best_sellers"` // Computed store best sellers
+	Products    int `json:"products"`     // Products whose is_best_seller or is_new changed
+}
+
+// storeBestSeller is the store flag of a product (ps) as the product level flag
+const storeBestSeller = `EXISTS (SELECT 1 FROM product_storefront ps
+	WHERE ps.product_id = products.id AND ps.is_best_seller)`
+
+// RefreshMerchandisingFlags recomputes the best sellers of every store from the units sold
+// by its completed orders in the window, and expires is_new once a product is older than
+// NewArrivalDays. is_new is never set, and pinned products keep the flags an admin set.
+// Every product whose flags changed gets a revision without an admin.
+func (s *ServiceV2) RefreshMerchandisingFlags(now time.Time, params MerchandisingParams) (MerchandisingResult, error) {
+	var result MerchandisingResult
+	since := now.AddDate(0, 0, -params.BestSellerWindowDays)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var storeFrontIDs []int64
+		if err := tx.Model(&models.ProductStorefront{}).Distinct("store_front_id").Pluck("store_front_id", &storeFrontIDs).Error; err != nil {
+			return err
+		}
+		result.Stores = len(storeFrontIDs)
+
+		unpinned := tx.Model(&models.Product{}).Select("id").Where("NOT is_best_seller_pinned")
+		pinned := tx.Model(&models.Product{}).Select("id").Where("is_best_seller_pinned")
+
+		for _, sfID := range storeFrontIDs {
+			bestSellerIDs, err := storeBestSellers(tx, sfID, since, params.BestSellerCount)
+			if err != nil {
+				return err
+			}
+			result.BestSellers += len(bestSellerIDs)
+
+			if err := tx.Model(&models.ProductStorefront{}).
+				Where("store_front_id = ? AND is_best_seller AND product_id IN (?)", sfID, unpinned).
+				Update("is_best_seller", false).Error; err != nil {
+				return err
+			}
+			if len(bestSellerIDs) > 0 {
+				if err := tx.Model(&models.ProductStorefront{}).
+					Where("store_front_id = ? AND product_id IN ? AND product_id IN (?)", sfID, bestSellerIDs, unpinned).
+					Update("is_best_seller", true).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Pinned products show the admin's flag in every store
+		if err := tx.Model(&models.ProductStorefront{}).
+			Where("product_id IN (?)", pinned).
+			Update("is_best_seller", gorm.Expr("(SELECT p.is_best_seller FROM products p WHERE p.id = product_storefront.product_id)")).Error; err != nil {
+			return err
+		}
+
+		var bestSellerIDs []int64
+		if err := tx.Model(&models.Product{}).
+			Where("NOT is_best_seller_pinned AND is_best_seller <> "+storeBestSeller).
+			Pluck("id", &bestSellerIDs).Error; err != nil {
+			return err
+		}
+		if len(bestSellerIDs) > 0 {
+			if err := tx.Model(&models.Product{}).
+				Where("id IN ?", bestSellerIDs).
+				UpdateColumn("is_best_seller", gorm.Expr(storeBestSeller)).Error; err != nil {
+				return err
+			}
+		}
+
+		var expiredIDs []int64
+		newSince := now.AddDate(0, 0, -params.NewArrivalDays)
+		if err := tx.Model(&models.Product{}).
+			Where("NOT is_new_pinned AND is_new AND created_at < ?", newSince).
+			Pluck("id", &expiredIDs).Error; err != nil {
+			return err
+		}
+		if len(expiredIDs) > 0 {
+			if err := tx.Model(&models.Product{}).Where("id IN ?", expiredIDs).UpdateColumn("is_new", false).Error; err != nil {
+				return err
+			}
+		}
+
+		changed := uniqueInt64s(append(bestSellerIDs, expiredIDs...))
+		for _, id := range changed {
+			if err := recordRevision(tx, id, models.ProductRevisionMerchandising, 0, nil); err != nil {
+				return err
+			}
+		}
+
+		result.Products = len(changed)
+		return nil
+	})
+	return result, err
+}
+
+// storeBestSellers returns the store's products with the most units sold by completed
+// orders since the given time, best first
+func storeBestSellers(tx *gorm.DB, storeFrontID int64, since time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	if limit <= 0 {
+		return ids, nil
+	}
+	err := tx.Table("order_items oi").
+		Select("oi.product_id").
+		Joins("JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL").
+		Joins("JOIN order_statuses os ON os.id = o.order_status_id AND os.slug = 'completed'").
+		Joins("JOIN product_storefront ps ON ps.product_id = oi.product_id AND ps.store_front_id = o.store_front_id").
+		Where("o.store_front_id = ? AND o.created_at >= ?", storeFrontID, since).
+		Group("oi.product_id").
+		Order("SUM(oi.quantity) DESC, oi.product_id").
+		Limit(limit).
+		Pluck("oi.product_id", &ids).Error
+	return ids, err
+}
+
+// pinStoreBestSeller copies a pinned product's is_best_seller to its stores
+func pinStoreBestSeller(tx *gorm.DB, productID int64, isBestSeller bool) error {
+	return tx.Model(&models.ProductStorefront{}).
+		Where("product_id = ?", productID).
+		Update("is_best_seller", isBestSeller).Error
+}
+
+// MerchandisingJob runs RefreshMerchandisingFlags on a fixed interval
+type MerchandisingJob struct {
+	service  *ServiceV2
+	interval time.Duration
+	params   MerchandisingParams
+}
+
+func NewMerchandisingJob(service *ServiceV2, interval time.Duration, params MerchandisingParams) *MerchandisingJob {
+	return &MerchandisingJob{service: service, interval: interval, params: params}
+}
+
+// Start runs the job in the background until ctx is cancelled
+func (j *MerchandisingJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			result, err := j.service.RefreshMerchandisingFlags(time.Now(), j.params)
+			if err != nil {
+				log.Printf("⚠️  Merchandising flags refresh failed: %v", err)
+			} else if result.Products > 0 {
+				log.Printf("🏷️  Merchandising flags: %d best seller(s) across %d store(s), %d product(s) changed", result.BestSellers, result.Stores, result.Products)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,324 @@
+package products
+
+import (
+	"fmt"
+
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// coPurchaseLimit caps the co-purchased products shown when a product has no relations
+const coPurchaseLimit = 8
+
+// Relation sources of a storefront product
+const (
+	relationSourceManual    = "manual"
+	relationSourceAutomatic = "automatic" // Co-purchased products as cross-sells
+)
+
+// RelatedProductInfo is a related product in the admin
+type RelatedProductInfo struct {
+	ID          int64  `json:"id"`
+	NameEn      string `json:"name_en"`
+	NameAr      string `json:"name_ar"`
+	Slug        string `json:"slug"`
+	Status      string `json:"status"`
+	IsPublished bool   `json:"is_published"`
+}
+
+// AdminProductRelations are the relations of a product by type, in display order
+type AdminProductRelations struct {
+	Related     []RelatedProductInfo `json:"related"`
+	CrossSells  []RelatedProductInfo `json:"cross_sells"`
+	Upsells     []RelatedProductInfo `json:"upsells"`
+	Accessories []RelatedProductInfo `json:"accessories"`
+}
+
+// StorefrontRelations are the products a store shows with a product. Without manual
+// relations the products most often bought in the same orders are shown as cross-sells.
+type StorefrontRelations struct {
+	Source      string                  `json:"source"` // manual or automatic
+	Related     []StorefrontProductItem `json:"related"`
+	CrossSells  []StorefrontProductItem `json:"cross_sells"`
+	Upsells     []StorefrontProductItem `json:"upsells"`
+	Accessories []StorefrontProductItem `json:"accessories"`
+}
+
+// relationTypes pairs each relation type with its list in a request
+func relationTypes(req requests.SetProductRelationsRequest) []struct {
+	relationType string
+	productIDs   []int64
+} {
+	return []struct {
+		relationType string
+		productIDs   []int64
+	}{
+		{models.ProductRelationRelated, req.Related},
+		{models.ProductRelationCrossSell, req.CrossSells},
+		{models.ProductRelationUpsell, req.Upsells},
+		{models.ProductRelationAccessory, req.Accessories},
+	}
+}
+
+// GetProductRelations lists the manual relations of a product
+func (s *ServiceV2) GetProductRelations(productID int64) utils.IResource {
+	if _, err := s.repo.GetProductModelByID(productID); err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	relations, err := s.repo.ListProductRelations(productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve product relations", err)
+	}
+
+	return utils.NewOKResource("Product relations retrieved successfully", relations)
+}
+
+// SetProductRelations replaces the manual relations of a product
+func (s *ServiceV2) SetProductRelations(productID int64, req requests.SetProductRelationsRequest) utils.IResource {
+	if _, err := s.repo.GetProductModelByID(productID); err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	var relations []models.ProductRelation
+	var ids []int64
+	for _, t := range relationTypes(req) {
+		seen := make(map[int64]bool)
+		for i, id := range t.productIDs {
+			if id == productID {
+				return utils.NewBadRequestResource("A product cannot be related to itself", nil)
+			}
+			if seen[id] {
+				return utils.NewBadRequestResource(fmt.Sprintf("Duplicate %s product %d", t.relationType, id), nil)
+			}
+			seen[id] = true
+			ids = append(ids, id)
+			relations = append(relations, models.ProductRelation{
+				ProductID:        productID,
+				RelatedProductID: id,
+				Type:             t.relationType,
+				Position:         i,
+			})
+		}
+	}
+
+	if missing, err := s.repo.MissingProductIDs(uniqueInt64s(ids)); err != nil {
+		return utils.NewInternalErrorResource("Failed to validate related products", err)
+	} else if len(missing) > 0 {
+		return utils.NewBadRequestResource(fmt.Sprintf("Product %d not found", missing[0]), nil)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return (&V2Repository{db: tx}).ReplaceProductRelations(tx, productID, relations)
+	})
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to update product relations", err)
+	}
+
+	return s.GetProductRelations(productID)
+}
+
+// storefrontRelations returns the products a store shows with a product
+func (s *ServiceV2) storefrontRelations(storeFrontID, productID int64) (*StorefrontRelations, error) {
+	var relations []models.ProductRelation
+	if err := s.db.Where("product_id = ?", productID).Order("type, position, id").Find(&relations).Error; err != nil {
+		return nil, err
+	}
+
+	result := &StorefrontRelations{
+		Source:      relationSourceManual,
+		Related:     []StorefrontProductItem{},
+		CrossSells:  []StorefrontProductItem{},
+		Upsells:     []StorefrontProductItem{},
+		Accessories: []StorefrontProductItem{},
+	}
+	if len(relations) == 0 {
+		ids, err := s.repo.CoPurchasedProductIDs(storeFrontID, productID, coPurchaseLimit)
+		if err != nil {
+			return nil, err
+		}
+		items, err := s.repo.StorefrontProductItems(storeFrontID, ids)
+		if err != nil {
+			return nil, err
+		}
+		result.Source = relationSourceAutomatic
+		result.CrossSells = items
+		return result, nil
+	}
+
+	ids := make([]int64, 0, len(relations))
+	for _, rel := range relations {
+		ids = append(ids, rel.RelatedProductID)
+	}
+	items, err := s.repo.StorefrontProductItems(storeFrontID, uniqueInt64s(ids))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]StorefrontProductItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	// Relations to products the store does not list are left out
+	for _, rel := range relations {
+		item, ok := byID[rel.RelatedProductID]
+		if !ok {
+			continue
+		}
+		switch rel.Type {
+		case models.ProductRelationRelated:
+			result.Related = append(result.Related, item)
+		case models.ProductRelationCrossSell:
+			result.CrossSells = append(result.CrossSells, item)
+		case models.ProductRelationUpsell:
+			result.Upsells = append(result.Upsells, item)
+		case models.ProductRelationAccessory:
+			result.Accessories = append(result.Accessories, item)
+		}
+	}
+	return result, nil
+}
+
+// ListProductRelations returns the manual relations of a product by type
+func (r *V2Repository) ListProductRelations(productID int64) (*AdminProductRelations, error) {
+	var rows []struct {
+		RelatedProductInfo
+		Type string
+	}
+	err := r.db.Table("product_relations pr").
+		Joins("JOIN products p ON p.id = pr.related_product_id AND p.deleted_at IS NULL").
+		Where("pr.product_id = ?", productID).
+		Select("pr.type, p.id, p.name_en, p.name_ar, p.slug, p.status, p.is_published").
+		Order("pr.position, pr.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	relations := &AdminProductRelations{
+		Related:     []RelatedProductInfo{},
+		CrossSells:  []RelatedProductInfo{},
+		Upsells:     []RelatedProductInfo{},
+		Accessories: []RelatedProductInfo{},
+	}
+	for _, row := range rows {
+		switch row.Type {
+		case models.ProductRelationRelated:
+			relations.Related = append(relations.Related, row.RelatedProductInfo)
+		case models.ProductRelationCrossSell:
+			relations.CrossSells = append(relations.CrossSells, row.RelatedProductInfo)
+		case models.ProductRelationUpsell:
+			relations.Upsells = append(relations.Upsells, row.RelatedProductInfo)
+		case models.ProductRelationAccessory:
+			relations.Accessories = append(relations.Accessories, row.RelatedProductInfo)
+		}
+	}
+	return relations, nil
+}
+
+// ReplaceProductRelations swaps the manual relations of a product for the given ones
+func (r *V2Repository) ReplaceProductRelations(tx *gorm.DB, productID int64, relations []models.ProductRelation) error {
+	if err := tx.Where("product_id = ?", productID).Delete(&models.ProductRelation{}).Error; err != nil {
+		return err
+	}
+	if len(relations) == 0 {
+		return nil
+	}
+	return tx.Create(&relations).Error
+}
+
+// MissingProductIDs returns the given product IDs that do not exist
+func (r *V2Repository) MissingProductIDs(ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var found []int64
+	if err := r.db.Model(&models.Product{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	exists := make(map[int64]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+	var missing []int64
+	for _, id := range ids {
+		if !exists[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// CoPurchasedProductIDs returns the store's listed products found most often in the same
+// orders as the given product, most frequent first
+func (r *V2Repository) CoPurchasedProductIDs(storeFrontID, productID int64, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.Table("order_items oi").
+		Select("other.product_id").
+		Joins("JOIN order_items other ON other.order_id = oi.order_id AND other.product_id <> oi.product_id").
+		Joins("JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL").
+		Joins("JOIN order_statuses os ON os.id = o.order_status_id AND os.slug NOT IN ('draft', 'cancelled', 'returned', 'refunded')").
+		Joins("JOIN product_storefront ps ON ps.product_id = other.product_id AND ps.store_front_id = o.store_front_id").
+		Joins("JOIN products p ON p.id = other.product_id AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL").
+		Where("oi.product_id = ? AND o.store_front_id = ?", productID, storeFrontID).
+		Group("other.product_id").
+		Order("COUNT(DISTINCT oi.order_id) DESC, other.product_id").
+		Limit(limit).
+		Pluck("other.product_id", &ids).Error
+	return ids, err
+}
+
+// StorefrontProductItems returns the cards of the given products the store lists, in the
+// order of ids
+func (r *V2Repository) StorefrontProductItems(storeFrontID int64, ids []int64) ([]StorefrontProductItem, error) {
+	items := []StorefrontProductItem{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	var rows []StorefrontProductItem
+	err := r.storefrontListing(storefrontScope{storeFrontID: storeFrontID}, requests.StorefrontProductFilterRequest{}, nil).
+		Where("p.id IN ?", ids).
+		Joins("LEFT JOIN brands b ON b.id = p.brand_id").
+		Joins("LEFT JOIN categories c ON c.id = p.category_id").
+		Select(storefrontItemColumns).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.applyListAvailability(storeFrontID, rows); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]StorefrontProductItem, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// duplicateRelations copies the manual relations of a product to its copy
+func duplicateRelations(tx *gorm.DB, sourceID, copyID int64) error {
+	var relations []models.ProductRelation
+	if err := tx.Where("product_id = ?", sourceID).Order("id ASC").Find(&relations).Error; err != nil {
+		return err
+	}
+	for _, rel := range relations {
+		if err := tx.Create(&models.ProductRelation{
+			ProductID:        copyID,
+			RelatedProductID: rel.RelatedProductID,
+			Type:             rel.Type,
+			Position:         rel.Position,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
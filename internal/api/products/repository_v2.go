@@ -2,22 +2,25 @@ package products
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
+	"github.com/onas/ecommerce-api/internal/api/inventory"
+	"github.com/onas/ecommerce-api/internal/api/pricelists"
 	"github.com/onas/ecommerce-api/internal/api/products/requests"
 	"github.com/onas/ecommerce-api/internal/models"
 	"github.com/onas/ecommerce-api/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // V2Repository handles V2 product operations using GORM
 type V2Repository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	availability *inventory.AvailabilityProjection // Storefront stock reads
 }
 
-func NewV2Repository(db *gorm.DB) *V2Repository {
-	return &V2Repository{db: db}
+func NewV2Repository(db *gorm.DB, availability *inventory.AvailabilityProjection) *V2Repository {
+	return &V2Repository{db: db, availability: availability}
 }
 
 // ============== DTOs ==============
@@ -27,10 +30,13 @@ type AdminProductV2ListItem struct {
 	NameEn        string    `json:"name_en"`
 	NameAr        string    `json:"name_ar"`
 	Slug          string    `json:"slug"`
+	SlugEn        string    `json:"slug_en"`
+	SlugAr        string    `json:"slug_ar"`
 	Status        string    `json:"status"`
 	IsPublished   bool      `json:"is_published"`
 	IsFeatured    bool      `json:"is_featured"`
 	AttributeType *string   `json:"attribute_type"`
+	ProductType   string    `json:"product_type"`
 	BrandName     *string   `json:"brand_name"`
 	CategoryName  *string   `json:"category_name"`
 	VariantCount  int64     `json:"variant_count"`
@@ -41,37 +47,53 @@ type AdminProductV2ListItem struct {
 }
 
 type AdminProductV2Detail struct {
-	ID                 int64              `json:"id"`
-	NameEn             string             `json:"name_en"`
-	NameAr             string             `json:"name_ar"`
-	Slug               string             `json:"slug"`
-	DescriptionEn      string             `json:"description_en"`
-	DescriptionAr      string             `json:"description_ar"`
-	Status             string             `json:"status"`
-	IsPublished        bool               `json:"is_published"`
-	IsFeatured         bool               `json:"is_featured"`
-	IsNew              bool               `json:"is_new"`
-	IsBestSeller       bool               `json:"is_best_seller"`
-	IsInternalSupplier bool               `json:"is_internal_supplier"`
-	AttributeType      *string            `json:"attribute_type"`
-	Brand              *BrandInfo         `json:"brand"`
-	Category           *CategoryInfo      `json:"category"`
-	Supplier           *SupplierInfo      `json:"supplier"`
-	StoreFronts        []StoreFrontInfo   `json:"store_fronts"`
-	Variants           []AdminVariantV2   `json:"variants"`
-	Images             []ProductImageInfo `json:"images"`
-	SEO                *models.ProductSEO `json:"seo"`
-	CreatedAt          time.Time          `json:"created_at"`
-	UpdatedAt          time.Time          `json:"updated_at"`
-	Name               string             `json:"name"`
+	ID                 int64                   `json:"id"`
+	NameEn             string                  `json:"name_en"`
+	NameAr             string                  `json:"name_ar"`
+	Slug               string                  `json:"slug"`
+	SlugEn             string                  `json:"slug_en"`
+	SlugAr             string                  `json:"slug_ar"`
+	DescriptionEn      string                  `json:"description_en"`
+	DescriptionAr      string                  `json:"description_ar"`
+	Status             string                  `json:"status"`
+	IsPublished        bool                    `json:"is_published"`
+	IsFeatured         bool                    `json:"is_featured"`
+	IsNew              bool                    `json:"is_new"`
+	IsBestSeller       bool                    `json:"is_best_seller"`
+	IsNewPinned        bool                    `json:"is_new_pinned"`
+	IsBestSellerPinned bool                    `json:"is_best_seller_pinned"`
+	IsInternalSupplier bool                    `json:"is_internal_supplier"`
+	AttributeType      *string                 `json:"attribute_type"`
+	Attributes         []AdminProductAttribute `json:"attributes"`
+	ProductType        string                  `json:"product_type"`
+	Brand              *BrandInfo              `json:"brand"`
+	Category           *CategoryInfo           `json:"category"`
+	Supplier           *SupplierInfo           `json:"supplier"`
+	StoreFronts        []StoreFrontInfo        `json:"store_fronts"`
+	Tags               []string                `json:"tags"`
+	Variants           []AdminVariantV2        `json:"variants"`
+	Images             []ProductImageInfo      `json:"images"`
+	SEO                *models.ProductSEO      `json:"seo"`
+	CreatedAt          time.Time               `json:"created_at"`
+	UpdatedAt          time.Time               `json:"updated_at"`
+	Name               string                  `json:"name"`
 }
 
 type ProductImageInfo struct {
-	ID       int64  `json:"id"`
-	FileID   int64  `json:"file_id"`
-	URL      string `json:"url"`
-	Position int    `json:"position"`
-	IsCover  bool   `json:"is_cover"`
+	ID       int64                  `json:"id"`
+	FileID   int64                  `json:"file_id"`
+	URL      string                 `json:"url"`
+	Srcset   map[string]ImageSource `json:"srcset"` // Scaled down copies by size: thumb, medium and large
+	Position int                    `json:"position"`
+	IsCover  bool                   `json:"is_cover"`
+}
+
+// ImageSource is a scaled down copy of an image, with its WebP version when one was made
+type ImageSource struct {
+	URL    string `json:"url"`
+	WebP   string `json:"webp,omitempty"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
 }
 
 type BrandInfo struct {
@@ -108,48 +130,65 @@ type AdminVariantV2 struct {
 	Width          *float64 `json:"width"`
 	Height         *float64 `json:"height"`
 	IsActive       bool     `json:"is_active"`
+	TrackingMode   string   `json:"tracking_mode"`
+
+	AttributeValues []AdminVariantAttributeValue `json:"attribute_values"`
 }
 
 type StorefrontProductItem struct {
-	ID             int64    `json:"id"`
-	NameEn         string   `json:"name_en"`
-	NameAr         string   `json:"name_ar"`
-	Slug           string   `json:"slug"`
-	BrandName      *string  `json:"brand_name"`
-	CategoryName   *string  `json:"category_name"`
-	IsFeatured     bool     `json:"is_featured"`
-	IsNew          bool     `json:"is_new"`
-	IsBestSeller   bool     `json:"is_best_seller"`
-	MinPrice       *float64 `json:"min_price"`
-	MaxPrice       *float64 `json:"max_price"`
-	CompareAtPrice *float64 `json:"compare_at_price"`
-	InStock        bool     `json:"in_stock"`
-	VariantCount   int64    `json:"variant_count"`
+	ID             int64      `json:"id"`
+	NameEn         string     `json:"name_en"`
+	NameAr         string     `json:"name_ar"`
+	Slug           string     `json:"slug"`
+	SlugEn         string     `json:"slug_en"`
+	SlugAr         string     `json:"slug_ar"`
+	BrandName      *string    `json:"brand_name"`
+	CategoryName   *string    `json:"category_name"`
+	IsFeatured     bool       `json:"is_featured"`
+	IsNew          bool       `json:"is_new"`
+	IsBestSeller   bool       `json:"is_best_seller"`
+	MinPrice       *float64   `json:"min_price"`
+	MaxPrice       *float64   `json:"max_price"`
+	CompareAtPrice *float64   `json:"compare_at_price"`
+	OnSaleUntil    *time.Time `json:"on_sale_until"` // Earliest end of an active sale price
+	InStock        bool       `json:"in_stock"`
+	AvailableOn    *time.Time `json:"available_on"`
+	VariantCount   int64      `json:"variant_count"`
 }
 
 type StorefrontProductDetail struct {
-	ID            int64               `json:"id"`
-	NameEn        string              `json:"name_en"`
-	NameAr        string              `json:"name_ar"`
-	Slug          string              `json:"slug"`
-	DescriptionEn string              `json:"description_en"`
-	DescriptionAr string              `json:"description_ar"`
-	AttributeType *string             `json:"attribute_type"`
-	IsFeatured    bool                `json:"is_featured"`
-	IsNew         bool                `json:"is_new"`
-	Brand         *BrandInfo          `json:"brand"`
-	Category      *CategoryInfo       `json:"category"`
-	SEO           *models.ProductSEO  `json:"seo"`
-	Variants      []StorefrontVariant `json:"variants"`
+	ID            int64                   `json:"id"`
+	NameEn        string                  `json:"name_en"`
+	NameAr        string                  `json:"name_ar"`
+	Slug          string                  `json:"slug"`
+	SlugEn        string                  `json:"slug_en"`
+	SlugAr        string                  `json:"slug_ar"`
+	DescriptionEn string                  `json:"description_en"`
+	DescriptionAr string                  `json:"description_ar"`
+	AttributeType *string                 `json:"attribute_type"`
+	IsFeatured    bool                    `json:"is_featured"`
+	IsNew         bool                    `json:"is_new"`
+	Brand         *BrandInfo              `json:"brand"`
+	Category      *CategoryInfo           `json:"category"`
+	SEO           *models.ProductSEO      `json:"seo"`
+	Options       []StorefrontOptionGroup `json:"options"`
+	Images        []ProductImageInfo      `json:"images"`
+	Variants      []StorefrontVariant     `json:"variants"`
+	Relations     *StorefrontRelations    `json:"relations"`
+	Rating        *RatingSummary          `json:"rating"`
 }
 
 type StorefrontVariant struct {
-	ID             int64    `json:"id"`
-	SKU            string   `json:"sku"`
-	AttributeValue string   `json:"attribute_value"`
-	Price          *float64 `json:"price"`
-	CompareAtPrice *float64 `json:"compare_at_price"`
-	InStock        bool     `json:"in_stock"`
+	ID             int64      `json:"id"`
+	SKU            string     `json:"sku"`
+	AttributeValue string     `json:"attribute_value"`
+	Price          *float64   `json:"price"`
+	CompareAtPrice *float64   `json:"compare_at_price"`
+	OnSaleUntil    *time.Time `json:"on_sale_until"` // End of the active sale setting the price
+	InStock        bool       `json:"in_stock"`
+	Availability   string     `json:"availability"`
+	AvailableOn    *time.Time `json:"available_on"`
+	OptionValueIDs []int64    `json:"option_value_ids"` // One value per option group, in group order
 }
 
 // ============== Repository Methods ==============
@@ -171,11 +210,12 @@ func (r *V2Repository) GetProductModelByID(id int64) (*models.Product, error) {
 	return &product, nil
 }
 
+// IsSlugUniqueForStore checks a slug against the English and Arabic slugs of the store's products
 func (r *V2Repository) IsSlugUniqueForStore(slug string, storeFrontID int64, excludeProductID int64) (bool, error) {
 	var count int64
 	query := r.db.Table("product_storefront ps").
 		Joins("JOIN products p ON p.id = ps.product_id").
-		Where("p.slug = ? AND ps.store_front_id = ? AND p.deleted_at IS NULL", slug, storeFrontID)
+		Where("(p.slug_en = ? OR p.slug_ar = ?) AND ps.store_front_id = ? AND p.deleted_at IS NULL", slug, slug, storeFrontID)
 	if excludeProductID > 0 {
 		query = query.Where("p.id <> ?", excludeProductID)
 	}
@@ -185,9 +225,44 @@ func (r *V2Repository) IsSlugUniqueForStore(slug string, storeFrontID int64, exc
 	return count == 0, nil
 }
 
+// IsSlugTaken reports whether any product uses the slug, whatever its stores
+func (r *V2Repository) IsSlugTaken(slug string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Product{}).Where("slug_en = ? OR slug_ar = ?", slug, slug).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindSlugRedirect returns the published product an old slug of the store redirects to,
+// with the language of the old slug
+func (r *V2Repository) FindSlugRedirect(storeFrontID int64, slug string) (*models.Product, string, error) {
+	var redirect models.ProductSlugRedirect
+	err := r.db.Table("product_slug_redirects r").
+		Joins("JOIN products p ON p.id = r.product_id").
+		Joins("JOIN product_storefront ps ON ps.product_id = p.id").
+		Where("r.slug = ? AND ps.store_front_id = ? AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", slug, storeFrontID).
+		Select("r.*").
+		Order("r.id DESC").
+		First(&redirect).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	product, err := r.GetProductModelByID(redirect.ProductID)
+	if err != nil {
+		return nil, "", err
+	}
+	return product, redirect.Language, nil
+}
+
 func (r *V2Repository) AssignProductToStores(tx *gorm.DB, productID int64, storeFrontIDs []int64) error {
-	// Remove existing assignments
-	if err := tx.Where("product_id = ?", productID).Delete(&models.ProductStorefront{}).Error; err != nil {
+	// Remove dropped assignments, kept ones hold their computed flags
+	remove := tx.Where("product_id = ?", productID)
+	if len(storeFrontIDs) > 0 {
+		remove = remove.Where("store_front_id NOT IN ?", storeFrontIDs)
+	}
+	if err := remove.Delete(&models.ProductStorefront{}).Error; err != nil {
 		return err
 	}
 	// Insert new assignments
@@ -196,7 +271,7 @@ func (r *V2Repository) AssignProductToStores(tx *gorm.DB, productID int64, store
 			ProductID:    productID,
 			StoreFrontID: sfID,
 		}
-		if err := tx.Create(&pivot).Error; err != nil {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&pivot).Error; err != nil {
 			return err
 		}
 	}
@@ -262,6 +337,8 @@ func (r *V2Repository) GetAdminProductV2ByID(productID int64) (*AdminProductV2De
 		NameEn:             product.NameEn,
 		NameAr:             product.NameAr,
 		Slug:               product.Slug,
+		SlugEn:             product.SlugEn,
+		SlugAr:             product.SlugAr,
 		DescriptionEn:      product.DescriptionEn,
 		DescriptionAr:      product.DescriptionAr,
 		Status:             product.Status,
@@ -269,8 +346,11 @@ func (r *V2Repository) GetAdminProductV2ByID(productID int64) (*AdminProductV2De
 		IsFeatured:         product.IsFeatured,
 		IsNew:              product.IsNew,
 		IsBestSeller:       product.IsBestSeller,
+		IsNewPinned:        product.IsNewPinned,
+		IsBestSellerPinned: product.IsBestSellerPinned,
 		IsInternalSupplier: product.IsInternalSupplier,
 		AttributeType:      product.AttributeType,
+		ProductType:        product.ProductType,
 		CreatedAt:          product.CreatedAt,
 		UpdatedAt:          product.UpdatedAt,
 		Name:               product.NameEn,
@@ -320,9 +400,28 @@ func (r *V2Repository) GetAdminProductV2ByID(productID int64) (*AdminProductV2De
 		detail.StoreFronts = []StoreFrontInfo{}
 	}
 
+	detail.Tags, err = productTags(r.db, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load attributes and the values variants use
+	detail.Attributes, err = adminProductAttributes(r.db, productID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Load variants
 	var variants []models.ProductVariant
 	r.db.Where("product_id = ? AND deleted_at IS NULL", productID).Order("id ASC").Find(&variants)
+	variantIDs := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+	variantValues, err := variantAttributeValues(r.db, productID, variantIDs)
+	if err != nil {
+		return nil, err
+	}
 	for _, v := range variants {
 		detail.Variants = append(detail.Variants, AdminVariantV2{
 			ID:             v.ID,
@@ -337,6 +436,9 @@ func (r *V2Repository) GetAdminProductV2ByID(productID int64) (*AdminProductV2De
 			Width:          v.Width,
 			Height:         v.Height,
 			IsActive:       v.IsActive,
+			TrackingMode:   v.TrackingMode,
+
+			AttributeValues: valuesOrEmpty(variantValues[v.ID]),
 		})
 	}
 	if detail.Variants == nil {
@@ -368,7 +470,7 @@ func (r *V2Repository) ListAdminProductsV2(filter requests.ProductFilterRequest,
 			Where("ps.store_front_id = ?", *filter.StoreFrontID)
 	}
 	if filter.CategoryID != nil {
-		query = query.Where("p.category_id = ?", *filter.CategoryID)
+		query = query.Where("p.category_id IN "+categorySubtree, []int64{*filter.CategoryID})
 	}
 	if filter.BrandID != nil {
 		query = query.Where("p.brand_id = ?", *filter.BrandID)
@@ -387,8 +489,7 @@ func (r *V2Repository) ListAdminProductsV2(filter requests.ProductFilterRequest,
 		}
 	}
 	if filter.Search != "" {
-		search := "%" + strings.ToLower(filter.Search) + "%"
-		query = query.Where("(LOWER(p.name_en) LIKE ? OR LOWER(p.name_ar) LIKE ?)", search, search)
+		query = searchProducts(query, filter.Search)
 	}
 
 	// Count total
@@ -402,8 +503,8 @@ func (r *V2Repository) ListAdminProductsV2(filter requests.ProductFilterRequest,
 	offset := (pagination.Page - 1) * pagination.Limit
 	err := query.
 		Select(`
-			p.id, p.name_en, p.name_ar, p.slug, p.status, p.is_published, p.is_featured,
-			p.attribute_type, b.name_en as brand_name, c.name_en as category_name,
+			p.id, p.name_en, p.name_ar, p.slug, p.slug_en, p.slug_ar, p.status, p.is_published, p.is_featured,
+			p.attribute_type, p.product_type, b.name_en as brand_name, c.name_en as category_name,
 			p.created_at,
 			(SELECT COUNT(*) FROM product_variants pv WHERE pv.product_id = p.id AND pv.deleted_at IS NULL) as variant_count,
 			(SELECT MIN(pv.price) FROM product_variants pv WHERE pv.product_id = p.id AND pv.deleted_at IS NULL) as min_price,
@@ -411,7 +512,7 @@ func (r *V2Repository) ListAdminProductsV2(filter requests.ProductFilterRequest,
 			(SELECT MAX(pv.price) FROM product_variants pv WHERE pv.product_id = p.id AND pv.deleted_at IS NULL) as max_price,
 			p.name_en as name
 		`).
-		Order("p.created_at DESC").
+		Clauses(listOrder(filter.Search)).
 		Offset(offset).Limit(pagination.Limit).
 		Scan(&items).Error
 	if err != nil {
@@ -423,6 +524,45 @@ func (r *V2Repository) ListAdminProductsV2(filter requests.ProductFilterRequest,
 
 // ============== Variant V2 ==============
 
+// ReplaceBundleComponents swaps the component list of a bundle variant
+func (r *V2Repository) ReplaceBundleComponents(tx *gorm.DB, bundleVariantID int64, components []models.BundleComponent) error {
+	if err := tx.Where("bundle_variant_id = ?", bundleVariantID).Delete(&models.BundleComponent{}).Error; err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return nil
+	}
+	return tx.Create(&components).Error
+}
+
+// HasVariantStock checks if any variant of a product has stock, reservations or backorders
+// in a store
+func (r *V2Repository) HasVariantStock(productID int64) (bool, error) {
+	var count int64
+	err := r.db.Table("variant_inventory vi").
+		Joins("JOIN product_variants pv ON pv.id = vi.product_variant_id").
+		Where("pv.product_id = ? AND pv.deleted_at IS NULL", productID).
+		Where("vi.quantity <> 0 OR vi.reserved_quantity <> 0 OR vi.backordered_quantity <> 0").
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// HasBundleComponents checks if any active variant of a bundle product has components
+func (r *V2Repository) HasBundleComponents(productID int64) (bool, error) {
+	var count int64
+	err := r.db.Table("bundle_components bc").
+		Joins("JOIN product_variants pv ON pv.id = bc.bundle_variant_id").
+		Where("pv.product_id = ? AND pv.is_active = ? AND pv.deleted_at IS NULL", productID, true).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (r *V2Repository) CreateVariantV2(variant *models.ProductVariant) error {
 	return r.db.Create(variant).Error
 }
@@ -473,6 +613,45 @@ func (r *V2Repository) CreateVariantInventory(inventory *models.VariantInventory
 	return r.db.Create(inventory).Error
 }
 
+// CreateMissingVariantInventory adds empty inventory rows for variants in the stores they
+// have none in, and returns the rows it added
+func (r *V2Repository) CreateMissingVariantInventory(variantIDs, storeFrontIDs []int64) ([]models.VariantInventory, error) {
+	if len(variantIDs) == 0 || len(storeFrontIDs) == 0 {
+		return nil, nil
+	}
+
+	var existing []models.VariantInventory
+	err := r.db.Select("product_variant_id, store_front_id").
+		Where("product_variant_id IN ? AND store_front_id IN ?", variantIDs, storeFrontIDs).
+		Find(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[[2]int64]bool, len(existing))
+	for _, inv := range existing {
+		have[[2]int64{inv.ProductVariantID, inv.StoreFrontID}] = true
+	}
+
+	var created []models.VariantInventory
+	for _, variantID := range variantIDs {
+		for _, sfID := range storeFrontIDs {
+			if have[[2]int64{variantID, sfID}] {
+				continue
+			}
+			inv := models.VariantInventory{
+				ProductVariantID:  variantID,
+				StoreFrontID:      sfID,
+				LowStockThreshold: 5, // Default
+			}
+			if err := r.CreateVariantInventory(&inv); err != nil {
+				return nil, err
+			}
+			created = append(created, inv)
+		}
+	}
+	return created, nil
+}
+
 func (r *V2Repository) GetProductStoreFrontIDs(productID int64) ([]int64, error) {
 	var results []struct {
 		StoreFrontID int64
@@ -497,6 +676,7 @@ func (r *V2Repository) GetProductImages(productID int64) ([]ProductImageInfo, er
 	var images []models.ProductImage
 	err := r.db.Where("product_id = ?", productID).
 		Preload("File").
+		Preload("File.Derivatives").
 		Order("position ASC, id ASC").
 		Find(&images).Error
 	if err != nil {
@@ -508,17 +688,36 @@ func (r *V2Repository) GetProductImages(productID int64) ([]ProductImageInfo, er
 		info := ProductImageInfo{
 			ID:       img.ID,
 			FileID:   img.FileID,
+			Srcset:   map[string]ImageSource{},
 			Position: img.Position,
 			IsCover:  img.IsCover,
 		}
 		if img.File != nil {
 			info.URL = img.File.FilePath
+			info.Srcset = imageSrcset(img.File.Derivatives)
 		}
 		result = append(result, info)
 	}
 	return result, nil
 }
 
+// imageSrcset groups an image's derivatives by size. Images still being processed, or
+// smaller than every size, have none.
+func imageSrcset(derivatives []models.FileDerivative) map[string]ImageSource {
+	srcset := make(map[string]ImageSource)
+	for _, d := range derivatives {
+		source := srcset[d.Size]
+		if d.Format == models.ImageFormatWebP {
+			source.WebP = d.FilePath
+		} else {
+			source.URL = d.FilePath
+		}
+		source.Width, source.Height = d.Width, d.Height
+		srcset[d.Size] = source
+	}
+	return srcset
+}
+
 func (r *V2Repository) AddProductImages(tx *gorm.DB, productID int64, fileIDs []int64) error {
 	// Get current max position
 	var maxPos int
@@ -582,23 +781,42 @@ func (r *V2Repository) SetCoverImage(tx *gorm.DB, productID, fileID int64) error
 
 // ============== Storefront Queries ==============
 
-func (r *V2Repository) ListStorefrontProducts(storeFrontID int64, filter requests.ProductFilterRequest, pagination *utils.Pagination) ([]StorefrontProductItem, int64, error) {
-	query := r.db.Table("products p").
-		Joins("JOIN product_storefront ps ON ps.product_id = p.id").
-		Joins("LEFT JOIN brands b ON b.id = p.brand_id").
-		Joins("LEFT JOIN categories c ON c.id = p.category_id").
-		Where("ps.store_front_id = ? AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", storeFrontID)
-
-	if filter.CategoryID != nil {
-		query = query.Where("p.category_id = ?", *filter.CategoryID)
-	}
-	if filter.BrandID != nil {
-		query = query.Where("p.brand_id = ?", *filter.BrandID)
-	}
-	if filter.Search != "" {
-		search := "%" + strings.ToLower(filter.Search) + "%"
-		query = query.Where("(LOWER(p.name_en) LIKE ? OR LOWER(p.name_ar) LIKE ?)", search, search)
-	}
+// storefrontVariantPrices joins variants to their price in the listing store's price list
+// for its currency (ps is the product_storefront row). Storefront reads are anonymous, so
+// only the list without a customer group applies, as in pricelists.ResolvePrices.
+const storefrontVariantPrices = `product_variants pv
+	LEFT JOIN price_list_items pli ON pli.product_variant_id = pv.id AND pli.price_list_id IN (
+		SELECT pl.id FROM price_lists pl JOIN store_fronts sf ON sf.id = pl.store_front_id AND sf.currency = pl.currency
+		WHERE pl.store_front_id = ps.store_front_id AND pl.customer_group_id IS NULL AND pl.is_active = true
+	)`
+
+// categorySubtree selects categories and every category nested under them, so listing a
+// category includes the products of its subcategories
+const categorySubtree = `(WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id IN ?
+		UNION
+		SELECT sc.id FROM categories sc JOIN subtree t ON sc.parent_id = t.id WHERE sc.deleted_at IS NULL
+	) SELECT id FROM subtree)`
+
+// activeSaleOnShownPrice matches an active price schedule (s) to the price shown by
+// storefrontVariantPrices: the list's price when listed, the variant's own otherwise
+const activeSaleOnShownPrice = `s.product_variant_id = pv.id AND s.status = 'active' AND
+	((pli.id IS NULL AND s.price_list_id IS NULL) OR s.price_list_id = pli.price_list_id)`
+
+// storefrontItemColumns selects a StorefrontProductItem of a listed product (p, ps),
+// with its brand (b) and category (c)
+const storefrontItemColumns = `
+	p.id, p.name_en, p.name_ar, p.slug, p.slug_en, p.slug_ar, p.is_featured, p.is_new, ps.is_best_seller,
+	b.name_en as brand_name, c.name_en as category_name,
+	` + storefrontMinPrice + ` as min_price,
+	(SELECT MAX(COALESCE(pli.price, pv.price)) FROM ` + storefrontVariantPrices + ` WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as max_price,
+	(SELECT MAX(CASE WHEN pli.id IS NULL THEN pv.compare_at_price ELSE pli.compare_at_price END) FROM ` + storefrontVariantPrices + ` WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as compare_at_price,
+	(SELECT MIN(s.ends_at) FROM ` + storefrontVariantPrices + ` JOIN price_schedules s ON ` + activeSaleOnShownPrice + ` WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as on_sale_until,
+	(SELECT COUNT(*) FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as variant_count
+`
+
+func (r *V2Repository) ListStorefrontProducts(scope storefrontScope, filter requests.StorefrontProductFilterRequest, attributeValues map[int64][]int64, pagination *utils.Pagination) ([]StorefrontProductItem, int64, error) {
+	query := r.storefrontListing(scope, filter, attributeValues)
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -608,30 +826,119 @@ func (r *V2Repository) ListStorefrontProducts(storeFrontID int64, filter request
 	var items []StorefrontProductItem
 	offset := (pagination.Page - 1) * pagination.Limit
 	err := query.
-		Select(`
-			p.id, p.name_en, p.name_ar, p.slug, p.is_featured, p.is_new, p.is_best_seller,
-			b.name_en as brand_name, c.name_en as category_name,
-			(SELECT MIN(pv.price) FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as min_price,
-			(SELECT MAX(pv.price) FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as max_price,
-			(SELECT MAX(pv.compare_at_price) FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as compare_at_price,
-			(SELECT COUNT(*) FROM product_variants pv WHERE pv.product_id = p.id AND pv.is_active = true AND pv.deleted_at IS NULL) as variant_count,
-			EXISTS(SELECT 1 FROM variant_inventory vi JOIN product_variants pv ON pv.id = vi.product_variant_id WHERE pv.product_id = p.id AND vi.store_front_id = ps.store_front_id AND vi.quantity > vi.reserved_quantity) as in_stock
-		`).
-		Order("p.created_at DESC").
+		Joins("LEFT JOIN brands b ON b.id = p.brand_id").
+		Joins("LEFT JOIN categories c ON c.id = p.category_id").
+		Select(storefrontItemColumns).
+		Clauses(storefrontOrder(scope, filter.Sort, filter.Search)).
 		Offset(offset).Limit(pagination.Limit).
 		Scan(&items).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
+	if err := r.applyListAvailability(scope.storeFrontID, items); err != nil {
+		return nil, 0, err
+	}
+
 	return items, total, nil
 }
 
+// applyListAvailability sets in_stock and available_on of listed products from the
+// availability projection, bundles are in stock when one can be assembled
+func (r *V2Repository) applyListAvailability(storeFrontID int64, items []StorefrontProductItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	productIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ID)
+	}
+
+	var variants []struct {
+		ID          int64
+		ProductID   int64
+		ProductType string
+	}
+	err := r.db.Table("product_variants pv").
+		Joins("JOIN products p ON p.id = pv.product_id").
+		Where("pv.product_id IN ? AND pv.is_active = true AND pv.deleted_at IS NULL", productIDs).
+		Select("pv.id, pv.product_id, p.product_type").
+		Scan(&variants).Error
+	if err != nil {
+		return err
+	}
+
+	var bundleIDs, variantIDs []int64
+	for _, v := range variants {
+		if v.ProductType == models.ProductTypeBundle {
+			bundleIDs = append(bundleIDs, v.ID)
+		} else {
+			variantIDs = append(variantIDs, v.ID)
+		}
+	}
+	components, err := r.bundleComponentsByBundle(bundleIDs)
+	if err != nil {
+		return err
+	}
+	for _, list := range components {
+		for _, c := range list {
+			variantIDs = append(variantIDs, c.ComponentVariantID)
+		}
+	}
+
+	stock, err := r.availability.Get(r.db, storeFrontID, variantIDs)
+	if err != nil {
+		return err
+	}
+
+	index := make(map[int64]int, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+	}
+	for _, v := range variants {
+		item := &items[index[v.ProductID]]
+		if v.ProductType == models.ProductTypeBundle {
+			if models.BundleAvailableQuantity(components[v.ID], stock) > 0 {
+				item.InStock = true
+			}
+			continue
+		}
+
+		inv := stock[v.ID]
+		if inv.IsSellable() {
+			item.InStock = true
+		}
+		if inv.BackorderPolicy == models.BackorderPolicyPreorder && inv.AvailableQuantity() <= 0 && inv.AvailableOn != nil {
+			if item.AvailableOn == nil || inv.AvailableOn.Before(*item.AvailableOn) {
+				item.AvailableOn = inv.AvailableOn
+			}
+		}
+	}
+	return nil
+}
+
+// bundleComponentsByBundle returns the components of bundle variants keyed by bundle variant ID
+func (r *V2Repository) bundleComponentsByBundle(bundleIDs []int64) (map[int64][]models.BundleComponent, error) {
+	byBundle := make(map[int64][]models.BundleComponent)
+	if len(bundleIDs) == 0 {
+		return byBundle, nil
+	}
+
+	var components []models.BundleComponent
+	if err := r.db.Where("bundle_variant_id IN ?", bundleIDs).Find(&components).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range components {
+		byBundle[c.BundleVariantID] = append(byBundle[c.BundleVariantID], c)
+	}
+	return byBundle, nil
+}
+
 func (r *V2Repository) GetStorefrontProduct(storeFrontID int64, slug string) (*StorefrontProductDetail, error) {
 	var product models.Product
 	err := r.db.Table("products p").
 		Joins("JOIN product_storefront ps ON ps.product_id = p.id").
-		Where("ps.store_front_id = ? AND p.slug = ? AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", storeFrontID, slug).
+		Where("ps.store_front_id = ? AND (p.slug_en = ? OR p.slug_ar = ?) AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", storeFrontID, slug, slug).
 		Select("p.*").
 		First(&product).Error
 	if err != nil {
@@ -643,6 +950,8 @@ func (r *V2Repository) GetStorefrontProduct(storeFrontID int64, slug string) (*S
 		NameEn:        product.NameEn,
 		NameAr:        product.NameAr,
 		Slug:          product.Slug,
+		SlugEn:        product.SlugEn,
+		SlugAr:        product.SlugAr,
 		DescriptionEn: product.DescriptionEn,
 		DescriptionAr: product.DescriptionAr,
 		AttributeType: product.AttributeType,
@@ -669,32 +978,180 @@ func (r *V2Repository) GetStorefrontProduct(storeFrontID int64, slug string) (*S
 	// SEO
 	detail.SEO, _ = r.GetProductSEO(product.ID)
 
+	if detail.Images, err = r.GetProductImages(product.ID); err != nil {
+		return nil, err
+	}
+	if detail.Rating, err = r.ProductRatingSummary(storeFrontID, product.ID); err != nil {
+		return nil, err
+	}
+
 	// Active variants with stock info
 	var variants []models.ProductVariant
 	r.db.Where("product_id = ? AND is_active = true AND deleted_at IS NULL", product.ID).Order("id ASC").Find(&variants)
+	variantIDs := make([]int64, 0, len(variants))
 	for _, v := range variants {
-		// Check stock for this store
-		var invCount int64
-		r.db.Table("variant_inventory").
-			Where("product_variant_id = ? AND store_front_id = ? AND quantity > reserved_quantity", v.ID, storeFrontID).
-			Count(&invCount)
+		variantIDs = append(variantIDs, v.ID)
+	}
 
-		detail.Variants = append(detail.Variants, StorefrontVariant{
+	// Option groups shoppers pick from, built from the listed variants
+	options, optionValueIDs, err := r.storefrontOptions(product.ID, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+	detail.Options = options
+
+	// Price list prices for the store's currency
+	prices, err := r.storefrontPrices(storeFrontID, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.ProductType == models.ProductTypeBundle {
+		detail.Variants, err = r.storefrontBundleVariants(storeFrontID, variants)
+		if err != nil {
+			return nil, err
+		}
+		applyVariantPrices(detail.Variants, prices)
+		if err := r.applySaleEnds(detail.Variants, prices); err != nil {
+			return nil, err
+		}
+		attachOptionValues(detail.Variants, optionValueIDs)
+		return detail, nil
+	}
+
+	// Stock and backorder policy for this store
+	stock, err := r.availability.Get(r.db, storeFrontID, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range variants {
+		inv := stock[v.ID]
+		sv := StorefrontVariant{
 			ID:             v.ID,
 			SKU:            v.SKU,
 			AttributeValue: v.AttributeValue,
 			Price:          v.Price,
 			CompareAtPrice: v.CompareAtPrice,
-			InStock:        invCount > 0,
-		})
+			InStock:        inv.IsSellable(),
+			Availability:   inv.Availability(),
+		}
+		if sv.Availability == models.AvailabilityPreorder {
+			sv.AvailableOn = inv.AvailableOn
+		}
+		detail.Variants = append(detail.Variants, sv)
 	}
 	if detail.Variants == nil {
 		detail.Variants = []StorefrontVariant{}
 	}
+	applyVariantPrices(detail.Variants, prices)
+	if err := r.applySaleEnds(detail.Variants, prices); err != nil {
+		return nil, err
+	}
+	attachOptionValues(detail.Variants, optionValueIDs)
 
 	return detail, nil
 }
 
+// storefrontPrices returns the price list prices of variants in a store's currency
+func (r *V2Repository) storefrontPrices(storeFrontID int64, variantIDs []int64) (map[int64]pricelists.VariantPrice, error) {
+	var storeFront models.StoreFront
+	if err := r.db.Select("id, currency").First(&storeFront, storeFrontID).Error; err != nil {
+		return nil, err
+	}
+	return pricelists.ResolvePrices(r.db, storeFrontID, storeFront.Currency, nil, variantIDs)
+}
+
+// applyVariantPrices replaces variant prices with their price list prices. The list's
+// compare at price replaces the variant's too, as it is in the list's currency.
+func applyVariantPrices(variants []StorefrontVariant, prices map[int64]pricelists.VariantPrice) {
+	for i := range variants {
+		listed, ok := prices[variants[i].ID]
+		if !ok {
+			continue
+		}
+		price := listed.Price
+		variants[i].Price = &price
+		variants[i].CompareAtPrice = listed.CompareAtPrice
+	}
+}
+
+// applySaleEnds sets when the active sale behind each variant's shown price ends
+func (r *V2Repository) applySaleEnds(variants []StorefrontVariant, prices map[int64]pricelists.VariantPrice) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	variantIDs := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+
+	var sales []models.PriceSchedule
+	err := r.db.Where("product_variant_id IN ? AND status = ?", variantIDs, models.PriceScheduleStatusActive).
+		Find(&sales).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range variants {
+		listed, isListed := prices[variants[i].ID]
+		for _, sale := range sales {
+			if sale.ProductVariantID != variants[i].ID {
+				continue
+			}
+			onShownPrice := sale.PriceListID == nil && !isListed ||
+				sale.PriceListID != nil && isListed && *sale.PriceListID == listed.PriceListID
+			if onShownPrice {
+				endsAt := sale.EndsAt
+				variants[i].OnSaleUntil = &endsAt
+			}
+		}
+	}
+	return nil
+}
+
+// storefrontBundleVariants derives the stock of bundle variants from their components
+func (r *V2Repository) storefrontBundleVariants(storeFrontID int64, variants []models.ProductVariant) ([]StorefrontVariant, error) {
+	bundleIDs := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		bundleIDs = append(bundleIDs, v.ID)
+	}
+
+	byBundle, err := r.bundleComponentsByBundle(bundleIDs)
+	if err != nil {
+		return nil, err
+	}
+	var componentIDs []int64
+	for _, list := range byBundle {
+		for _, c := range list {
+			componentIDs = append(componentIDs, c.ComponentVariantID)
+		}
+	}
+
+	stock, err := r.availability.Get(r.db, storeFrontID, componentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]StorefrontVariant, 0, len(variants))
+	for _, v := range variants {
+		sv := StorefrontVariant{
+			ID:             v.ID,
+			SKU:            v.SKU,
+			AttributeValue: v.AttributeValue,
+			Price:          v.Price,
+			CompareAtPrice: v.CompareAtPrice,
+			Availability:   models.AvailabilityOutOfStock,
+		}
+		if models.BundleAvailableQuantity(byBundle[v.ID], stock) > 0 {
+			sv.InStock = true
+			sv.Availability = models.AvailabilityInStock
+		}
+		result = append(result, sv)
+	}
+	return result, nil
+}
+
 // GetProductStructuredData generates JSON-LD structured data
 func (r *V2Repository) GetProductStructuredData(storeFrontID int64, slug string) (map[string]interface{}, error) {
 	detail, err := r.GetStorefrontProduct(storeFrontID, slug)
@@ -719,28 +1176,49 @@ func (r *V2Repository) GetProductStructuredData(storeFrontID int64, slug string)
 		}
 	}
 
-	// Determine availability
+	// Sale prices are valid until the first sale ends
+	var priceValidUntil *time.Time
+	for _, v := range detail.Variants {
+		if v.OnSaleUntil != nil && (priceValidUntil == nil || v.OnSaleUntil.Before(*priceValidUntil)) {
+			priceValidUntil = v.OnSaleUntil
+		}
+	}
+
+	// Determine availability, preferring physical stock over backorder/pre-order
 	availability := "https://schema.org/OutOfStock"
 	for _, v := range detail.Variants {
-		if v.InStock {
+		switch v.Availability {
+		case models.AvailabilityInStock:
 			availability = "https://schema.org/InStock"
-			break
+		case models.AvailabilityPreorder:
+			if availability == "https://schema.org/OutOfStock" {
+				availability = "https://schema.org/PreOrder"
+			}
+		case models.AvailabilityBackorder:
+			if availability != "https://schema.org/InStock" {
+				availability = "https://schema.org/BackOrder"
+			}
 		}
 	}
 
+	offers := map[string]interface{}{
+		"@type":         "AggregateOffer",
+		"lowPrice":      lowPrice,
+		"highPrice":     highPrice,
+		"priceCurrency": storeFront.Currency,
+		"availability":  availability,
+		"offerCount":    len(detail.Variants),
+	}
+	if priceValidUntil != nil {
+		offers["priceValidUntil"] = priceValidUntil.Format("2006-01-02")
+	}
+
 	jsonLD := map[string]interface{}{
 		"@context":    "https://schema.org",
 		"@type":       "Product",
 		"name":        detail.NameEn,
 		"description": detail.DescriptionEn,
-		"offers": map[string]interface{}{
-			"@type":         "AggregateOffer",
-			"lowPrice":      lowPrice,
-			"highPrice":     highPrice,
-			"priceCurrency": storeFront.Currency,
-			"availability":  availability,
-			"offerCount":    len(detail.Variants),
-		},
+		"offers":      offers,
 	}
 
 	if detail.Brand != nil {
@@ -754,6 +1232,16 @@ func (r *V2Repository) GetProductStructuredData(storeFrontID int64, slug string)
 		jsonLD["sku"] = detail.Variants[0].SKU
 	}
 
+	if detail.Rating != nil && detail.Rating.Count > 0 {
+		jsonLD["aggregateRating"] = map[string]interface{}{
+			"@type":       "AggregateRating",
+			"ratingValue": detail.Rating.Average,
+			"reviewCount": detail.Rating.Count,
+			"bestRating":  5,
+			"worstRating": 1,
+		}
+	}
+
 	// Breadcrumb
 	breadcrumbItems := []map[string]interface{}{
 		{
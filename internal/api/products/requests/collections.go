@@ -0,0 +1,36 @@
+package requests
+
+import "time"
+
+// CollectionRequest creates or updates a collection
+type CollectionRequest struct {
+	StoreFrontID  int64                   `json:"store_front_id" binding:"required"`
+	NameEn        string                  `json:"name_en" binding:"required,max=255"`
+	NameAr        string                  `json:"name_ar" binding:"required,max=255"`
+	Slug          string                  `json:"slug" binding:"omitempty,max=255"` // Defaults to name_en
+	DescriptionEn string                  `json:"description_en"`
+	DescriptionAr string                  `json:"description_ar"`
+	Type          string                  `json:"type" binding:"required,oneof=manual automatic"`
+	Rules         *CollectionRulesRequest `json:"rules"`       // Automatic collections
+	ProductIDs    []int64                 `json:"product_ids"` // Manual collections, in display order; nil keeps them on update
+	IsActive      *bool                   `json:"is_active"`
+}
+
+// CollectionRulesRequest holds the rules of an automatic collection, all set rules must match
+type CollectionRulesRequest struct {
+	MinPrice          *float64   `json:"min_price" binding:"omitempty,min=0"`
+	MaxPrice          *float64   `json:"max_price" binding:"omitempty,min=0"`
+	BrandIDs          []int64    `json:"brand_ids"`
+	CategoryIDs       []int64    `json:"category_ids"`
+	Tags              []string   `json:"tags" binding:"omitempty,dive,max=100"`
+	InStock           *bool      `json:"in_stock"`
+	CreatedAfter      *time.Time `json:"created_after"`
+	CreatedWithinDays *int       `json:"created_within_days" binding:"omitempty,min=1"`
+}
+
+// CollectionFilterRequest filters the admin collection list
+type CollectionFilterRequest struct {
+	StoreFrontID *int64 `form:"store_front_id"`
+	Type         string `form:"type" binding:"omitempty,oneof=manual automatic"`
+	Search       string `form:"search"`
+}
@@ -0,0 +1,12 @@
+package requests
+
+// SitemapRequest selects one file of a sitemap index
+type SitemapRequest struct {
+	Page int `form:"page" binding:"omitempty,min=1"`
+}
+
+// ProductFeedRequest selects the format and language of a storefront product feed
+type ProductFeedRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=xml csv"` // Defaults to xml
+	Lang   string `form:"lang" binding:"omitempty,oneof=ar en"`     // Defaults to the store's language
+}
@@ -0,0 +1,10 @@
+package requests
+
+// SetProductRelationsRequest replaces the relations of a product. Each list holds product
+// IDs in display order, a list left out clears that relation type.
+type SetProductRelationsRequest struct {
+	Related     []int64 `json:"related"`
+	CrossSells  []int64 `json:"cross_sells"`
+	Upsells     []int64 `json:"upsells"`
+	Accessories []int64 `json:"accessories"`
+}
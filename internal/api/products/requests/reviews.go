@@ -0,0 +1,22 @@
+package requests
+
+// SubmitReviewRequest is a storefront customer's review, sent as a multipart form with
+// optional "images" files
+type SubmitReviewRequest struct {
+	Rating int    `form:"rating" binding:"required,min=1,max=5"`
+	Title  string `form:"title" binding:"max=255"`
+	Body   string `form:"body" binding:"max=5000"`
+}
+
+// ReviewFilterRequest filters the admin review moderation queue
+type ReviewFilterRequest struct {
+	Status       string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	ProductID    *int64 `form:"product_id"`
+	StoreFrontID *int64 `form:"store_front_id"`
+	Rating       *int   `form:"rating" binding:"omitempty,min=1,max=5"`
+}
+
+// RejectReviewRequest rejects a review
+type RejectReviewRequest struct {
+	Reason string `json:"reason" binding:"max=1000"`
+}
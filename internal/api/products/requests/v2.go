@@ -4,16 +4,22 @@ package requests
 type CreateProductV2Request struct {
 	NameEn             string                   `json:"name_en" binding:"required"`
 	NameAr             string                   `json:"name_ar" binding:"required"`
+	SlugEn             string                   `json:"slug_en"`            // Defaults to name_en
+	SlugAr             string                   `json:"slug_ar"`            // Defaults to name_ar
+	TransliterateSlug  bool                     `json:"transliterate_slug"` // Latin Arabic slug instead of Arabic letters
 	DescriptionEn      string                   `json:"description_en"`
 	DescriptionAr      string                   `json:"description_ar"`
 	BrandID            *int64                   `json:"brand_id"`
 	CategoryID         *int64                   `json:"category_id"`
 	SupplierID         *int64                   `json:"supplier_id"`
 	IsInternalSupplier bool                     `json:"is_internal_supplier"`
-	AttributeType      *int64                   `json:"attribute_type"`
+	AttributeType      *int64                   `json:"attribute_type"` // Deprecated: single attribute, use attribute_ids
+	AttributeIDs       []int64                  `json:"attribute_ids"`  // Attributes the variants vary by, in display order
+	ProductType        string                   `json:"product_type" binding:"omitempty,oneof=simple bundle"`
 	StoreFrontIDs      []int64                  `json:"store_front_ids" binding:"required,min=1"`
 	IsFeatured         bool                     `json:"is_featured"`
 	IsNew              bool                     `json:"is_new"`
+	Tags               []string                 `json:"tags" binding:"omitempty,dive,max=100"`
 	SEO                *ProductSEORequest       `json:"seo"`
 	Variants           []CreateVariantV2Request `json:"variants"`
 }
@@ -22,17 +28,25 @@ type CreateProductV2Request struct {
 type UpdateProductV2Request struct {
 	NameEn             string                   `json:"name_en" binding:"required"`
 	NameAr             string                   `json:"name_ar" binding:"required"`
+	SlugEn             string                   `json:"slug_en"`            // Defaults to name_en
+	SlugAr             string                   `json:"slug_ar"`            // Defaults to name_ar
+	TransliterateSlug  bool                     `json:"transliterate_slug"` // Latin Arabic slug instead of Arabic letters
 	DescriptionEn      string                   `json:"description_en"`
 	DescriptionAr      string                   `json:"description_ar"`
 	BrandID            *int64                   `json:"brand_id"`
 	CategoryID         *int64                   `json:"category_id"`
 	SupplierID         *int64                   `json:"supplier_id"`
 	IsInternalSupplier bool                     `json:"is_internal_supplier"`
-	AttributeType      *int64                   `json:"attribute_type"`
+	AttributeType      *int64                   `json:"attribute_type"` // Deprecated: single attribute, use attribute_ids
+	AttributeIDs       []int64                  `json:"attribute_ids"`  // Attributes the variants vary by, in display order
+	ProductType        string                   `json:"product_type" binding:"omitempty,oneof=simple bundle"`
 	StoreFrontIDs      []int64                  `json:"store_front_ids" binding:"required,min=1"`
 	IsFeatured         bool                     `json:"is_featured"`
 	IsNew              bool                     `json:"is_new"`
 	IsBestSeller       bool                     `json:"is_best_seller"`
+	IsNewPinned        bool                     `json:"is_new_pinned"`                         // Keep is_new as set instead of expiring it
+	IsBestSellerPinned bool                     `json:"is_best_seller_pinned"`                 // Keep is_best_seller as set instead of computing it
+	Tags               []string                 `json:"tags" binding:"omitempty,dive,max=100"` // nil keeps the current tags
 	SEO                *ProductSEORequest       `json:"seo"`
 	Variants           []CreateVariantV2Request `json:"variants"`
 }
@@ -52,20 +66,60 @@ type ProductSEORequest struct {
 
 // CreateVariantV2Request is the V2 variant creation request with pricing
 type CreateVariantV2Request struct {
-	ID             *int64   `json:"id"` // Optional: for updates
-	SKU            string   `json:"sku"`
-	AttributeValue string   `json:"attribute_value"`
-	Price          *float64 `json:"price" binding:"required"`
-	CompareAtPrice *float64 `json:"compare_at_price"`
-	CostPrice      *float64 `json:"cost_price"`
-	Barcode        string   `json:"barcode"`
-	Weight         *float64 `json:"weight"`
-	Length         *float64 `json:"length"`
-	Width          *float64 `json:"width"`
-	Height         *float64 `json:"height"`
-	IsActive       bool     `json:"is_active"`
-	ImageFileIDs   []int64  `json:"image_file_ids"`
-	Stock          *int     `json:"stock"`
+	ID                *int64   `json:"id"` // Optional: for updates
+	SKU               string   `json:"sku"`
+	AttributeValue    string   `json:"attribute_value"`     // Deprecated: free-text value of single attribute products
+	AttributeValueIDs []int64  `json:"attribute_value_ids"` // One value per product attribute
+	Price             *float64 `json:"price" binding:"required"`
+	CompareAtPrice    *float64 `json:"compare_at_price"`
+	CostPrice         *float64 `json:"cost_price"`
+	Barcode           string   `json:"barcode"`
+	Weight            *float64 `json:"weight"`
+	Length            *float64 `json:"length"`
+	Width             *float64 `json:"width"`
+	Height            *float64 `json:"height"`
+	IsActive          bool     `json:"is_active"`
+	ImageFileIDs      []int64  `json:"image_file_ids"`
+	Stock             *int     `json:"stock"`
+	TrackingMode      string   `json:"tracking_mode" binding:"omitempty,oneof=none serial lot"`
+}
+
+// BundleComponentRequest is one component variant of a bundle and the units it takes
+type BundleComponentRequest struct {
+	ProductVariantID int64 `json:"product_variant_id" binding:"required"`
+	Quantity         int   `json:"quantity" binding:"required,min=1"`
+}
+
+// SetBundleComponentsRequest replaces the components of a bundle variant
+type SetBundleComponentsRequest struct {
+	Components []BundleComponentRequest `json:"components" binding:"required,min=1,dive"`
+}
+
+// VariantMatrixAttributeRequest is one attribute of a variant matrix and the values to combine
+type VariantMatrixAttributeRequest struct {
+	AttributeID int64   `json:"attribute_id" binding:"required"`
+	ValueIDs    []int64 `json:"value_ids" binding:"required,min=1"`
+}
+
+// VariantStockRequest is the initial stock of a variant in one store
+type VariantStockRequest struct {
+	StoreFrontID int64 `json:"store_front_id" binding:"required"`
+	Quantity     int   `json:"quantity" binding:"min=0"`
+}
+
+// GenerateVariantsRequest creates a variant for every combination of the selected attribute values.
+// SKUTemplate may use {slug}, {product_id}, {n} and the lowercased English attribute names, e.g.
+// "{slug}-{size}-{color}"; by default the slug is followed by every value.
+type GenerateVariantsRequest struct {
+	Attributes     []VariantMatrixAttributeRequest `json:"attributes" binding:"required,min=1,dive"`
+	SKUTemplate    string                          `json:"sku_template"`
+	Price          *float64                        `json:"price" binding:"required"`
+	CompareAtPrice *float64                        `json:"compare_at_price"`
+	CostPrice      *float64                        `json:"cost_price"`
+	Weight         *float64                        `json:"weight"`
+	IsActive       *bool                           `json:"is_active"` // Defaults to true
+	TrackingMode   string                          `json:"tracking_mode" binding:"omitempty,oneof=none serial lot"`
+	Stock          []VariantStockRequest           `json:"stock" binding:"dive"` // Stores left out start at zero
 }
 
 // UpdateProductStatusRequest is the status change request
@@ -87,6 +141,17 @@ type ProductFilterRequest struct {
 	Search        string  `form:"search"`
 }
 
+// StorefrontProductFilterRequest filters and sorts a storefront product listing. Selected
+// values of one attribute match any of them, values of different attributes all match.
+type StorefrontProductFilterRequest struct {
+	ProductFilterRequest
+	MinPrice          *float64 `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice          *float64 `form:"max_price" binding:"omitempty,min=0"`
+	AttributeValueIDs []int64  `form:"attribute_value_ids"`
+	InStock           *bool    `form:"in_stock"`
+	Sort              string   `form:"sort" binding:"omitempty,oneof=relevance newest price_asc price_desc best_selling featured"`
+}
+
 // AddProductImagesRequest is the request to add images to a product
 type AddProductImagesRequest struct {
 	FileIDs []int64 `json:"file_ids" binding:"required,min=1"`
@@ -96,3 +161,23 @@ type AddProductImagesRequest struct {
 type SetCoverImageRequest struct {
 	FileID int64 `json:"file_id" binding:"required"`
 }
+
+// CatalogImportRequest holds the options of a catalog import upload
+type CatalogImportRequest struct {
+	DryRun *bool `form:"dry_run"` // Defaults to true
+}
+
+// DuplicateProductRequest copies a product into a new draft. Names default to the source's
+// with a " (Copy)" suffix. SKUPattern may use {sku} (the source variant's SKU), {slug} (the
+// copy's slug) and {n} (the variant's position); it defaults to "{slug}-{n}".
+type DuplicateProductRequest struct {
+	NameEn     string `json:"name_en"`
+	NameAr     string `json:"name_ar"`
+	SKUPattern string `json:"sku_pattern"`
+}
+
+// SearchSuggestRequest holds the autocomplete query
+type SearchSuggestRequest struct {
+	Q     string `form:"q"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=20"`
+}
@@ -0,0 +1,419 @@
+package products
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// maxReviewImages caps the photos of one review
+const maxReviewImages = 5
+
+// RatingSummary is the approved rating of a product in a store
+type RatingSummary struct {
+	Average float64       `json:"average"` // Rounded to one decimal, 0 without reviews
+	Count   int64         `json:"count"`
+	Stars   map[int]int64 `json:"stars"` // Approved reviews per star rating
+}
+
+// ReviewImageInfo is a photo of a review
+type ReviewImageInfo struct {
+	FileID int64  `json:"file_id"`
+	URL    string `json:"url"`
+}
+
+// StorefrontReview is an approved review shown on the storefront
+type StorefrontReview struct {
+	ID           int64             `json:"id"`
+	Rating       int               `json:"rating"`
+	Title        string            `json:"title"`
+	Body         string            `json:"body"`
+	CustomerName string            `json:"customer_name"` // First name and last initial
+	Images       []ReviewImageInfo `json:"images"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// AdminReview is a review in the moderation queue
+type AdminReview struct {
+	ID              int64             `json:"id"`
+	ProductID       int64             `json:"product_id"`
+	ProductName     string            `json:"product_name"`
+	StoreFrontID    int64             `json:"store_front_id"`
+	CustomerID      int64             `json:"customer_id"`
+	CustomerName    string            `json:"customer_name"`
+	CustomerEmail   string            `json:"customer_email"`
+	OrderID         int64             `json:"order_id"`
+	Rating          int               `json:"rating"`
+	Title           string            `json:"title"`
+	Body            string            `json:"body"`
+	Status          string            `json:"status"`
+	RejectionReason string            `json:"rejection_reason"`
+	ModeratedBy     *int64            `json:"moderated_by"`
+	ModeratedAt     *time.Time        `json:"moderated_at"`
+	Images          []ReviewImageInfo `json:"images"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// SubmitReview records a customer's review of a product bought in a completed order of
+// the store. The review waits for moderation, a rejected review can be submitted again.
+func (s *ServiceV2) SubmitReview(storeFrontID, userID int64, slug string, req requests.SubmitReviewRequest, images []*multipart.FileHeader) utils.IResource {
+	if len(images) > maxReviewImages {
+		return utils.NewBadRequestResource(fmt.Sprintf("A review can have at most %d images", maxReviewImages), nil)
+	}
+
+	productID, err := s.repo.StorefrontProductIDBySlug(storeFrontID, slug)
+	if err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	customer, err := s.repo.GetStoreCustomerByUser(storeFrontID, userID)
+	if err != nil {
+		return utils.NewForbiddenResource("Only customers who bought this product can review it", nil)
+	}
+	orderID, err := s.repo.CompletedPurchaseOrderID(storeFrontID, customer.ID, productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.NewForbiddenResource("Only customers who bought this product can review it", nil)
+	}
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to verify purchase", err)
+	}
+
+	review, err := s.repo.GetCustomerReview(productID, customer.ID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		review = &models.ProductReview{ProductID: productID, StoreFrontID: storeFrontID, CustomerID: customer.ID}
+	case err != nil:
+		return utils.NewInternalErrorResource("Failed to submit review", err)
+	case review.Status != models.ReviewStatusRejected:
+		return utils.NewBadRequestResource("You have already reviewed this product", nil)
+	}
+
+	// Photos are uploaded before the transaction, which stays short while files are written
+	imageConfig := s.files.GetDefaultConfig()
+	imageConfig.AllowedTypes = map[string][]string{"image": imageConfig.AllowedTypes["image"]}
+	fileIDs := make([]int64, 0, len(images))
+	for _, header := range images {
+		file, err := s.files.UploadCustomerFile(header, customer.ID, imageConfig)
+		if err != nil {
+			return utils.NewBadRequestResource(fmt.Sprintf("Image %s: %v", header.Filename, err), nil)
+		}
+		fileIDs = append(fileIDs, file.ID)
+	}
+
+	review.OrderID = orderID
+	review.Rating = req.Rating
+	review.Title = strings.TrimSpace(req.Title)
+	review.Body = strings.TrimSpace(req.Body)
+	review.Status = models.ReviewStatusPending
+	review.RejectionReason = ""
+	review.ModeratedBy = nil
+	review.ModeratedAt = nil
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(review).Error; err != nil {
+			return err
+		}
+		return replaceReviewImages(tx, review.ID, fileIDs)
+	})
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to submit review", err)
+	}
+
+	return utils.NewCreatedResource("Review submitted for moderation", review)
+}
+
+// StorefrontListReviews lists the approved reviews of a product, newest first, with its rating
+func (s *ServiceV2) StorefrontListReviews(storeFrontID int64, slug string, pagination *utils.Pagination) utils.IResource {
+	productID, err := s.repo.StorefrontProductIDBySlug(storeFrontID, slug)
+	if err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	reviews, total, err := s.repo.ListApprovedReviews(storeFrontID, productID, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve reviews", err)
+	}
+	rating, err := s.repo.ProductRatingSummary(storeFrontID, productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve rating", err)
+	}
+
+	pagination.SetTotal(total)
+	meta := pagination.GetMeta()
+	meta["rating"] = rating
+	return utils.NewPaginatedOKResource("Reviews retrieved successfully", reviews, meta)
+}
+
+// ListReviews lists reviews for moderation, oldest first so the queue is worked in order
+func (s *ServiceV2) ListReviews(filter requests.ReviewFilterRequest, pagination *utils.Pagination) utils.IResource {
+	reviews, total, err := s.repo.ListReviews(filter, pagination)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve reviews", err)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Reviews retrieved successfully", reviews, pagination.GetMeta())
+}
+
+// ApproveReview shows a review on the storefront and counts it in the product's rating
+func (s *ServiceV2) ApproveReview(id, adminID int64) utils.IResource {
+	return s.moderateReview(id, adminID, models.ReviewStatusApproved, "")
+}
+
+// RejectReview hides a review, the customer may submit it again
+func (s *ServiceV2) RejectReview(id, adminID int64, req requests.RejectReviewRequest) utils.IResource {
+	return s.moderateReview(id, adminID, models.ReviewStatusRejected, strings.TrimSpace(req.Reason))
+}
+
+func (s *ServiceV2) moderateReview(id, adminID int64, status, reason string) utils.IResource {
+	var review models.ProductReview
+	if err := s.db.First(&review, id).Error; err != nil {
+		return utils.NewNotFoundResource("Review not found", nil)
+	}
+
+	now := time.Now()
+	review.Status = status
+	review.RejectionReason = reason
+	review.ModeratedBy = &adminID
+	review.ModeratedAt = &now
+	if err := s.db.Save(&review).Error; err != nil {
+		return utils.NewInternalErrorResource("Failed to moderate review", err)
+	}
+
+	return utils.NewOKResource(fmt.Sprintf("Review %s successfully", status), review)
+}
+
+func replaceReviewImages(tx *gorm.DB, reviewID int64, fileIDs []int64) error {
+	if err := tx.Where("review_id = ?", reviewID).Delete(&models.ProductReviewImage{}).Error; err != nil {
+		return err
+	}
+	for i, fileID := range fileIDs {
+		if err := tx.Create(&models.ProductReviewImage{ReviewID: reviewID, FileID: fileID, Position: i}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reviewerName shortens a customer's name to the first name and last initial
+func reviewerName(firstName, lastName string) string {
+	name := strings.TrimSpace(firstName)
+	if last := []rune(strings.TrimSpace(lastName)); len(last) > 0 {
+		name = strings.TrimSpace(name + " " + string(last[0]) + ".")
+	}
+	return name
+}
+
+// StorefrontProductIDBySlug returns the ID of a product the store lists under slug
+func (r *V2Repository) StorefrontProductIDBySlug(storeFrontID int64, slug string) (int64, error) {
+	var ids []int64
+	err := r.db.Table("products p").
+		Joins("JOIN product_storefront ps ON ps.product_id = p.id").
+		Where("ps.store_front_id = ? AND (p.slug_en = ? OR p.slug_ar = ?) AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", storeFrontID, slug, slug).
+		Limit(1).
+		Pluck("p.id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return ids[0], nil
+}
+
+// GetStoreCustomerByUser returns the store's customer record of a signed in user
+func (r *V2Repository) GetStoreCustomerByUser(storeFrontID, userID int64) (*models.Customer, error) {
+	var customer models.Customer
+	if err := r.db.Where("store_front_id = ? AND user_id = ?", storeFrontID, userID).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// CompletedPurchaseOrderID returns the latest completed order of the store in which the
+// customer bought the product
+func (r *V2Repository) CompletedPurchaseOrderID(storeFrontID, customerID, productID int64) (int64, error) {
+	var ids []int64
+	err := r.db.Table("order_items oi").
+		Joins("JOIN orders o ON o.id = oi.order_id AND o.deleted_at IS NULL").
+		Joins("JOIN order_statuses os ON os.id = o.order_status_id AND os.slug = 'completed'").
+		Where("oi.product_id = ? AND o.customer_id = ? AND o.store_front_id = ?", productID, customerID, storeFrontID).
+		Order("o.created_at DESC").
+		Limit(1).
+		Pluck("o.id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return ids[0], nil
+}
+
+func (r *V2Repository) GetCustomerReview(productID, customerID int64) (*models.ProductReview, error) {
+	var review models.ProductReview
+	if err := r.db.Where("product_id = ? AND customer_id = ?", productID, customerID).First(&review).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ProductRatingSummary aggregates the approved reviews of a product in a store
+func (r *V2Repository) ProductRatingSummary(storeFrontID, productID int64) (*RatingSummary, error) {
+	var rows []struct {
+		Rating int
+		Count  int64
+	}
+	err := r.db.Model(&models.ProductReview{}).
+		Select("rating, COUNT(*) AS count").
+		Where("store_front_id = ? AND product_id = ? AND status = ?", storeFrontID, productID, models.ReviewStatusApproved).
+		Group("rating").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &RatingSummary{Stars: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	var total int64
+	for _, row := range rows {
+		summary.Stars[row.Rating] = row.Count
+		summary.Count += row.Count
+		total += int64(row.Rating) * row.Count
+	}
+	if summary.Count > 0 {
+		summary.Average = math.Round(float64(total)/float64(summary.Count)*10) / 10
+	}
+	return summary, nil
+}
+
+func (r *V2Repository) ListApprovedReviews(storeFrontID, productID int64, pagination *utils.Pagination) ([]StorefrontReview, int64, error) {
+	query := r.db.Model(&models.ProductReview{}).
+		Where("store_front_id = ? AND product_id = ? AND status = ?", storeFrontID, productID, models.ReviewStatusApproved)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.ProductReview
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Preload("Customer").Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Preload("Images.File").
+		Order("created_at DESC").Offset(offset).Limit(pagination.Limit).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]StorefrontReview, 0, len(reviews))
+	for _, review := range reviews {
+		item := StorefrontReview{
+			ID:        review.ID,
+			Rating:    review.Rating,
+			Title:     review.Title,
+			Body:      review.Body,
+			Images:    reviewImageInfos(review.Images),
+			CreatedAt: review.CreatedAt,
+		}
+		if review.Customer != nil {
+			item.CustomerName = reviewerName(review.Customer.FirstName, review.Customer.LastName)
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+func (r *V2Repository) ListReviews(filter requests.ReviewFilterRequest, pagination *utils.Pagination) ([]AdminReview, int64, error) {
+	query := r.db.Model(&models.ProductReview{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.ProductID != nil {
+		query = query.Where("product_id = ?", *filter.ProductID)
+	}
+	if filter.StoreFrontID != nil {
+		query = query.Where("store_front_id = ?", *filter.StoreFrontID)
+	}
+	if filter.Rating != nil {
+		query = query.Where("rating = ?", *filter.Rating)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []models.ProductReview
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Preload("Customer").Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Preload("Images.File").
+		Order("created_at ASC").Offset(offset).Limit(pagination.Limit).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	productIDs := make([]int64, 0, len(reviews))
+	for _, review := range reviews {
+		productIDs = append(productIDs, review.ProductID)
+	}
+	var products []models.Product
+	if len(productIDs) > 0 {
+		if err := r.db.Unscoped().Select("id, name_en").Where("id IN ?", uniqueInt64s(productIDs)).Find(&products).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+	names := make(map[int64]string, len(products))
+	for _, p := range products {
+		names[p.ID] = p.NameEn
+	}
+
+	items := make([]AdminReview, 0, len(reviews))
+	for _, review := range reviews {
+		item := AdminReview{
+			ID:              review.ID,
+			ProductID:       review.ProductID,
+			ProductName:     names[review.ProductID],
+			StoreFrontID:    review.StoreFrontID,
+			CustomerID:      review.CustomerID,
+			OrderID:         review.OrderID,
+			Rating:          review.Rating,
+			Title:           review.Title,
+			Body:            review.Body,
+			Status:          review.Status,
+			RejectionReason: review.RejectionReason,
+			ModeratedBy:     review.ModeratedBy,
+			ModeratedAt:     review.ModeratedAt,
+			Images:          reviewImageInfos(review.Images),
+			CreatedAt:       review.CreatedAt,
+		}
+		if review.Customer != nil {
+			item.CustomerName = strings.TrimSpace(review.Customer.FirstName + " " + review.Customer.LastName)
+			item.CustomerEmail = review.Customer.Email
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+func reviewImageInfos(images []models.ProductReviewImage) []ReviewImageInfo {
+	infos := make([]ReviewImageInfo, 0, len(images))
+	for _, img := range images {
+		info := ReviewImageInfo{FileID: img.FileID}
+		if img.File != nil {
+			info.URL = img.File.FilePath
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
@@ -0,0 +1,497 @@
+package products
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/api/inventory"
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ============== Snapshots ==============
+
+// ProductSnapshot is the state of a product stored with each revision
+type ProductSnapshot struct {
+	Product       ProductSnapshotFields       `json:"product"`
+	SEO           *requests.ProductSEORequest `json:"seo"`
+	StoreFrontIDs []int64                     `json:"store_front_ids"`
+	Tags          []string                    `json:"tags"` // nil in revisions recorded before tags
+	Variants      []VariantSnapshot           `json:"variants"`
+}
+
+type ProductSnapshotFields struct {
+	NameEn             string  `json:"name_en"`
+	NameAr             string  `json:"name_ar"`
+	Slug               string  `json:"slug"`
+	SlugAr             string  `json:"slug_ar"`
+	DescriptionEn      string  `json:"description_en"`
+	DescriptionAr      string  `json:"description_ar"`
+	BrandID            *int64  `json:"brand_id"`
+	CategoryID         *int64  `json:"category_id"`
+	SupplierID         *int64  `json:"supplier_id"`
+	IsInternalSupplier bool    `json:"is_internal_supplier"`
+	Status             string  `json:"status"`
+	IsPublished        bool    `json:"is_published"`
+	IsFeatured         bool    `json:"is_featured"`
+	IsNew              bool    `json:"is_new"`
+	IsBestSeller       bool    `json:"is_best_seller"`
+	IsNewPinned        bool    `json:"is_new_pinned"`
+	IsBestSellerPinned bool    `json:"is_best_seller_pinned"`
+	ProductType        string  `json:"product_type"`
+	AttributeType      *string `json:"attribute_type"`
+}
+
+type VariantSnapshot struct {
+	ID             int64    `json:"id"`
+	SKU            string   `json:"sku"`
+	AttributeValue string   `json:"attribute_value"`
+	Price          *float64 `json:"price"`
+	CompareAtPrice *float64 `json:"compare_at_price"`
+	CostPrice      *float64 `json:"cost_price"`
+	Barcode        *string  `json:"barcode"`
+	Weight         *float64 `json:"weight"`
+	Length         *float64 `json:"length"`
+	Width          *float64 `json:"width"`
+	Height         *float64 `json:"height"`
+	IsActive       bool     `json:"is_active"`
+	TrackingMode   string   `json:"tracking_mode"`
+}
+
+// RevisionChange is one field that differs from the previous revision
+type RevisionChange struct {
+	Field string      `json:"field"` // e.g. product.name_en, variants[12].price, seo.meta_title_en
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type ProductRevisionInfo struct {
+	ID             int64            `json:"id"`
+	ProductID      int64            `json:"product_id"`
+	Action         string           `json:"action"`
+	AdminID        *int64           `json:"admin_id"`
+	AdminName      *string          `json:"admin_name"`
+	RestoredFromID *int64           `json:"restored_from_id"`
+	CreatedAt      time.Time        `json:"created_at"`
+	Changes        []RevisionChange `json:"changes"`
+	Snapshot       *ProductSnapshot `json:"snapshot,omitempty"`
+}
+
+// buildProductSnapshot captures the current state of a product
+func buildProductSnapshot(db *gorm.DB, productID int64) (*ProductSnapshot, error) {
+	var product models.Product
+	if err := db.First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+
+	snap := &ProductSnapshot{
+		Product: ProductSnapshotFields{
+			NameEn:             product.NameEn,
+			NameAr:             product.NameAr,
+			Slug:               product.SlugEn,
+			SlugAr:             product.SlugAr,
+			DescriptionEn:      product.DescriptionEn,
+			DescriptionAr:      product.DescriptionAr,
+			BrandID:            product.BrandID,
+			CategoryID:         product.CategoryID,
+			SupplierID:         product.SupplierID,
+			IsInternalSupplier: product.IsInternalSupplier,
+			Status:             product.Status,
+			IsPublished:        product.IsPublished,
+			IsFeatured:         product.IsFeatured,
+			IsNew:              product.IsNew,
+			IsBestSeller:       product.IsBestSeller,
+			IsNewPinned:        product.IsNewPinned,
+			IsBestSellerPinned: product.IsBestSellerPinned,
+			ProductType:        product.ProductType,
+			AttributeType:      product.AttributeType,
+		},
+		StoreFrontIDs: []int64{},
+		Variants:      []VariantSnapshot{},
+	}
+
+	var seo models.ProductSEO
+	err := db.Where("product_id = ?", productID).First(&seo).Error
+	switch {
+	case err == nil:
+		snap.SEO = &requests.ProductSEORequest{
+			MetaTitleEn:       seo.MetaTitleEn,
+			MetaTitleAr:       seo.MetaTitleAr,
+			MetaDescriptionEn: seo.MetaDescriptionEn,
+			MetaDescriptionAr: seo.MetaDescriptionAr,
+			MetaKeywords:      seo.MetaKeywords,
+			CanonicalURL:      seo.CanonicalURL,
+			OgTitle:           seo.OgTitle,
+			OgDescription:     seo.OgDescription,
+			OgImage:           seo.OgImage,
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	err = db.Model(&models.ProductStorefront{}).Where("product_id = ?", productID).
+		Order("store_front_id ASC").Pluck("store_front_id", &snap.StoreFrontIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if snap.Tags, err = productTags(db, productID); err != nil {
+		return nil, err
+	}
+
+	var variants []models.ProductVariant
+	if err := db.Where("product_id = ?", productID).Order("id ASC").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		snap.Variants = append(snap.Variants, VariantSnapshot{
+			ID:             v.ID,
+			SKU:            v.SKU,
+			AttributeValue: v.AttributeValue,
+			Price:          v.Price,
+			CompareAtPrice: v.CompareAtPrice,
+			CostPrice:      v.CostPrice,
+			Barcode:        v.Barcode,
+			Weight:         v.Weight,
+			Length:         v.Length,
+			Width:          v.Width,
+			Height:         v.Height,
+			IsActive:       v.IsActive,
+			TrackingMode:   v.TrackingMode,
+		})
+	}
+
+	return snap, nil
+}
+
+// flattenSnapshot maps every field of a snapshot to its JSON value by path
+func flattenSnapshot(snap *ProductSnapshot) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if snap == nil {
+		return fields
+	}
+
+	addJSONFields(fields, "product", snap.Product)
+	if snap.SEO != nil {
+		addJSONFields(fields, "seo", snap.SEO)
+	}
+	for _, v := range snap.Variants {
+		addJSONFields(fields, fmt.Sprintf("variants[%d]", v.ID), v)
+		delete(fields, fmt.Sprintf("variants[%d].id", v.ID))
+	}
+
+	var storeFronts interface{}
+	b, _ := json.Marshal(snap.StoreFrontIDs)
+	_ = json.Unmarshal(b, &storeFronts)
+	fields["store_front_ids"] = storeFronts
+	if len(snap.Tags) > 0 {
+		var tags interface{}
+		b, _ = json.Marshal(snap.Tags)
+		_ = json.Unmarshal(b, &tags)
+		fields["tags"] = tags
+	}
+	return fields
+}
+
+func addJSONFields(fields map[string]interface{}, prefix string, value interface{}) {
+	b, _ := json.Marshal(value)
+	var m map[string]interface{}
+	_ = json.Unmarshal(b, &m)
+	for k, v := range m {
+		fields[prefix+"."+k] = v
+	}
+}
+
+// diffSnapshots lists the fields that differ between two snapshots, sorted by field
+func diffSnapshots(prev, next *ProductSnapshot) []RevisionChange {
+	before, after := flattenSnapshot(prev), flattenSnapshot(next)
+
+	changes := []RevisionChange{}
+	for field, to := range after {
+		from, existed := before[field]
+		if !existed || !reflect.DeepEqual(from, to) {
+			changes = append(changes, RevisionChange{Field: field, From: from, To: to})
+		}
+	}
+	for field, from := range before {
+		if _, exists := after[field]; !exists {
+			changes = append(changes, RevisionChange{Field: field, From: from, To: nil})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// ============== Recording ==============
+
+// recordRevision snapshots a product after a write. Writes that changed nothing since
+// the previous revision are not recorded.
+func recordRevision(tx *gorm.DB, productID int64, action string, adminID int64, restoredFromID *int64) error {
+	snap, err := buildProductSnapshot(tx, productID)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot product %d: %w", productID, err)
+	}
+
+	var prevSnap *ProductSnapshot
+	var prev models.ProductRevision
+	err = tx.Where("product_id = ?", productID).Order("id DESC").First(&prev).Error
+	switch {
+	case err == nil:
+		prevSnap = &ProductSnapshot{}
+		if err := json.Unmarshal([]byte(prev.Snapshot), prevSnap); err != nil {
+			return fmt.Errorf("failed to read revision %d: %w", prev.ID, err)
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	changes := diffSnapshots(prevSnap, snap)
+	if prevSnap != nil && len(changes) == 0 {
+		return nil
+	}
+
+	snapJSON, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	diffJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	revision := models.ProductRevision{
+		ProductID:      productID,
+		Action:         action,
+		RestoredFromID: restoredFromID,
+		Snapshot:       string(snapJSON),
+		Diff:           string(diffJSON),
+	}
+	if adminID > 0 {
+		revision.AdminID = &adminID
+	}
+	return tx.Create(&revision).Error
+}
+
+// RecordPriceScheduleRevision records a revision of a product whose variant prices a
+// price schedule started or ended, without an admin
+func RecordPriceScheduleRevision(tx *gorm.DB, productID int64) error {
+	return recordRevision(tx, productID, models.ProductRevisionPriceSchedule, 0, nil)
+}
+
+// toRevisionInfo decodes a stored revision, with its snapshot when asked
+func toRevisionInfo(rev models.ProductRevision, withSnapshot bool) (ProductRevisionInfo, error) {
+	info := ProductRevisionInfo{
+		ID:             rev.ID,
+		ProductID:      rev.ProductID,
+		Action:         rev.Action,
+		AdminID:        rev.AdminID,
+		RestoredFromID: rev.RestoredFromID,
+		CreatedAt:      rev.CreatedAt,
+		Changes:        []RevisionChange{},
+	}
+	if rev.Admin != nil {
+		name := rev.Admin.FirstName + " " + rev.Admin.LastName
+		info.AdminName = &name
+	}
+	if rev.Diff != "" {
+		if err := json.Unmarshal([]byte(rev.Diff), &info.Changes); err != nil {
+			return info, err
+		}
+	}
+	if withSnapshot {
+		info.Snapshot = &ProductSnapshot{}
+		if err := json.Unmarshal([]byte(rev.Snapshot), info.Snapshot); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
+
+// ============== Service ==============
+
+// ListProductRevisions lists a product's revisions, newest first
+func (s *ServiceV2) ListProductRevisions(productID int64, pagination *utils.Pagination) utils.IResource {
+	if _, err := s.repo.GetProductModelByID(productID); err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	query := s.db.Model(&models.ProductRevision{}).Where("product_id = ?", productID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve revisions", err)
+	}
+
+	var revisions []models.ProductRevision
+	offset := (pagination.Page - 1) * pagination.Limit
+	err := query.Preload("Admin").Order("id DESC").Offset(offset).Limit(pagination.Limit).Find(&revisions).Error
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve revisions", err)
+	}
+
+	items := make([]ProductRevisionInfo, 0, len(revisions))
+	for _, rev := range revisions {
+		info, err := toRevisionInfo(rev, false)
+		if err != nil {
+			return utils.NewInternalErrorResource("Failed to read revision", err)
+		}
+		items = append(items, info)
+	}
+
+	pagination.SetTotal(total)
+	return utils.NewPaginatedOKResource("Revisions retrieved successfully", items, pagination.GetMeta())
+}
+
+// GetProductRevision returns a revision with its full snapshot
+func (s *ServiceV2) GetProductRevision(productID, revisionID int64) utils.IResource {
+	var rev models.ProductRevision
+	err := s.db.Preload("Admin").Where("id = ? AND product_id = ?", revisionID, productID).First(&rev).Error
+	if err != nil {
+		return utils.NewNotFoundResource("Revision not found", nil)
+	}
+
+	info, err := toRevisionInfo(rev, true)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to read revision", err)
+	}
+	return utils.NewOKResource("Revision retrieved successfully", info)
+}
+
+// RestoreProductRevision brings a product back to a revision's content: names,
+// descriptions, flags, SEO, storefront assignments and variant pricing and details.
+// Status, product type, attributes and tracking modes have their own rules and are
+// left as they are; variants created after the revision are deactivated.
+func (s *ServiceV2) RestoreProductRevision(productID, revisionID, adminID int64) utils.IResource {
+	product, err := s.repo.GetProductModelByID(productID)
+	if err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	var rev models.ProductRevision
+	if err := s.db.Where("id = ? AND product_id = ?", revisionID, productID).First(&rev).Error; err != nil {
+		return utils.NewNotFoundResource("Revision not found", nil)
+	}
+	var snap ProductSnapshot
+	if err := json.Unmarshal([]byte(rev.Snapshot), &snap); err != nil {
+		return utils.NewInternalErrorResource("Failed to read revision", err)
+	}
+
+	if snap.Product.Slug != product.SlugEn || snap.Product.SlugAr != product.SlugAr {
+		if res := s.validateProductSlugs(snap.StoreFrontIDs, productID, snap.Product.Slug, snap.Product.SlugAr); res != nil {
+			return res
+		}
+	}
+	oldSlugEn, oldSlugAr := product.SlugEn, product.SlugAr
+	for _, v := range snap.Variants {
+		unique, err := s.repo.IsSKUUnique(v.SKU, v.ID)
+		if err != nil {
+			return utils.NewInternalErrorResource("Failed to validate SKU uniqueness", err)
+		}
+		if !unique {
+			return utils.NewBadRequestResource(fmt.Sprintf("SKU '%s' is now used by another variant", v.SKU), nil)
+		}
+	}
+
+	err = s.invRepo.Transaction(s.db, func(tx *gorm.DB) error {
+		product.NameEn = snap.Product.NameEn
+		product.NameAr = snap.Product.NameAr
+		product.Name = snap.Product.NameEn
+		product.Slug = snap.Product.Slug
+		product.SlugEn = snap.Product.Slug
+		product.SlugAr = snap.Product.SlugAr
+		product.DescriptionEn = snap.Product.DescriptionEn
+		product.DescriptionAr = snap.Product.DescriptionAr
+		product.Description = snap.Product.DescriptionEn
+		product.BrandID = snap.Product.BrandID
+		product.CategoryID = snap.Product.CategoryID
+		product.SupplierID = snap.Product.SupplierID
+		product.IsInternalSupplier = snap.Product.IsInternalSupplier
+		product.IsFeatured = snap.Product.IsFeatured
+		// is_new and is_best_seller follow the catalog today, they and their pins are not restored
+		if err := tx.Save(product).Error; err != nil {
+			return err
+		}
+		if err := recordSlugRedirects(tx, product, snap.StoreFrontIDs, oldSlugEn, oldSlugAr); err != nil {
+			return err
+		}
+
+		repoTx := &V2Repository{db: tx}
+		if err := repoTx.AssignProductToStores(tx, productID, snap.StoreFrontIDs); err != nil {
+			return err
+		}
+		if snap.Tags != nil {
+			if err := setProductTags(tx, productID, snap.Tags); err != nil {
+				return err
+			}
+		}
+
+		if snap.SEO != nil {
+			if err := repoTx.UpsertProductSEO(tx, productID, *snap.SEO); err != nil {
+				return err
+			}
+		} else if err := tx.Where("product_id = ?", productID).Delete(&models.ProductSEO{}).Error; err != nil {
+			return err
+		}
+
+		restored := make([]int64, 0, len(snap.Variants))
+		for _, v := range snap.Variants {
+			result := tx.Model(&models.ProductVariant{}).Where("id = ? AND product_id = ?", v.ID, productID).
+				Updates(map[string]interface{}{
+					"sku":              v.SKU,
+					"price":            v.Price,
+					"compare_at_price": v.CompareAtPrice,
+					"cost_price":       v.CostPrice,
+					"barcode":          v.Barcode,
+					"weight":           v.Weight,
+					"length":           v.Length,
+					"width":            v.Width,
+					"height":           v.Height,
+					"is_active":        v.IsActive,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			restored = append(restored, v.ID)
+		}
+
+		newer := tx.Model(&models.ProductVariant{}).Where("product_id = ?", productID)
+		if len(restored) > 0 {
+			newer = newer.Where("id NOT IN ?", restored)
+		}
+		if err := newer.Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		// Stores the restore adds back start with empty stock, bundles get no rows as in createVariantInventory
+		if product.ProductType != models.ProductTypeBundle {
+			created, err := repoTx.CreateMissingVariantInventory(restored, snap.StoreFrontIDs)
+			if err != nil {
+				return err
+			}
+			for _, inv := range created {
+				if err := s.invRepo.InvalidateAvailability(tx, inv.StoreFrontID, inv.ProductVariantID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := inventory.RefreshProductStock(tx, productID); err != nil {
+			return err
+		}
+		return recordRevision(tx, productID, models.ProductRevisionRestore, adminID, &revisionID)
+	})
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to restore revision", err)
+	}
+
+	detail, err := s.repo.GetAdminProductV2ByID(productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Revision restored but failed to retrieve product", err)
+	}
+	return utils.NewOKResource("Revision restored successfully", detail)
+}
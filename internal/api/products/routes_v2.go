@@ -16,6 +16,11 @@ func RegisterV2Routes(router *gin.RouterGroup, controller *ControllerV2) {
 		adminRoutes.GET("/:id", middleware.RequirePermission("products.view"), controller.AdminGetProductV2)
 		adminRoutes.POST("", middleware.RequirePermission("products.create"), controller.AdminCreateProductV2)
 		adminRoutes.PUT("/:id", middleware.RequirePermission("products.update"), controller.AdminUpdateProductV2)
+		adminRoutes.POST("/:id/duplicate", middleware.RequirePermission("products.create"), controller.AdminDuplicateProduct)
+
+		// Catalog import
+		adminRoutes.POST("/import", middleware.RequirePermission("products.create"), controller.AdminImportCatalog)
+		adminRoutes.GET("/import/:jobId", middleware.RequirePermission("products.view"), controller.AdminGetCatalogImport)
 
 		// Status management
 		adminRoutes.PATCH("/:id/status", middleware.RequirePermission("products.update"), controller.AdminUpdateProductStatus)
@@ -26,14 +31,49 @@ func RegisterV2Routes(router *gin.RouterGroup, controller *ControllerV2) {
 
 		// V2 Variants (with pricing)
 		adminRoutes.POST("/:id/variants", middleware.RequirePermission("products.update"), controller.AdminCreateVariantV2)
+		adminRoutes.POST("/:id/variants/generate", middleware.RequirePermission("products.update"), controller.AdminGenerateVariants)
 		adminRoutes.PUT("/:id/variants/:variantId", middleware.RequirePermission("products.update"), controller.AdminUpdateVariantV2)
 
+		// Bundle components
+		adminRoutes.GET("/:id/variants/:variantId/components", middleware.RequirePermission("products.view"), controller.AdminGetBundleComponents)
+		adminRoutes.PUT("/:id/variants/:variantId/components", middleware.RequirePermission("products.update"), controller.AdminSetBundleComponents)
+
+		// Related products, cross-sells, upsells and accessories
+		adminRoutes.GET("/:id/relations", middleware.RequirePermission("products.view"), controller.AdminGetProductRelations)
+		adminRoutes.PUT("/:id/relations", middleware.RequirePermission("products.update"), controller.AdminSetProductRelations)
+
+		// Revision history
+		adminRoutes.GET("/:id/revisions", middleware.RequirePermission("products.view"), controller.AdminListProductRevisions)
+		adminRoutes.GET("/:id/revisions/:revisionId", middleware.RequirePermission("products.view"), controller.AdminGetProductRevision)
+		adminRoutes.POST("/:id/revisions/:revisionId/restore", middleware.RequirePermission("products.update"), controller.AdminRestoreProductRevision)
+
 		// Product images
 		adminRoutes.POST("/:id/images", middleware.RequirePermission("products.update"), controller.AdminAddProductImages)
 		adminRoutes.DELETE("/:id/images/:imageId", middleware.RequirePermission("products.update"), controller.AdminRemoveProductImage)
 		adminRoutes.PUT("/:id/images/cover", middleware.RequirePermission("products.update"), controller.AdminSetCoverImage)
 	}
 
+	// Collections
+	collectionRoutes := router.Group("/admin/collections")
+	collectionRoutes.Use(middleware.AuthMiddleware(), middleware.AdminAuthMiddleware())
+	{
+		collectionRoutes.GET("", middleware.RequirePermission("collections.view"), controller.AdminListCollections)
+		collectionRoutes.GET("/:id", middleware.RequirePermission("collections.view"), controller.AdminGetCollection)
+		collectionRoutes.GET("/:id/products", middleware.RequirePermission("collections.view"), controller.AdminListCollectionProducts)
+		collectionRoutes.POST("", middleware.RequirePermission("collections.manage"), controller.AdminCreateCollection)
+		collectionRoutes.PUT("/:id", middleware.RequirePermission("collections.manage"), controller.AdminUpdateCollection)
+		collectionRoutes.DELETE("/:id", middleware.RequirePermission("collections.manage"), controller.AdminDeleteCollection)
+	}
+
+	// Review moderation
+	reviewRoutes := router.Group("/admin/reviews")
+	reviewRoutes.Use(middleware.AuthMiddleware(), middleware.AdminAuthMiddleware())
+	{
+		reviewRoutes.GET("", middleware.RequirePermission("reviews.view"), controller.AdminListReviews)
+		reviewRoutes.POST("/:id/approve", middleware.RequirePermission("reviews.moderate"), controller.AdminApproveReview)
+		reviewRoutes.POST("/:id/reject", middleware.RequirePermission("reviews.moderate"), controller.AdminRejectReview)
+	}
+
 	// Storefront public routes (domain-resolved)
 	sfRoutes := router.Group("/storefront")
 	sfRoutes.Use(middleware.StoreFrontResolver())
@@ -41,5 +81,11 @@ func RegisterV2Routes(router *gin.RouterGroup, controller *ControllerV2) {
 		sfRoutes.GET("/products", controller.StorefrontListProducts)
 		sfRoutes.GET("/products/:slug", controller.StorefrontGetProduct)
 		sfRoutes.GET("/products/:slug/structured-data", controller.StorefrontGetStructuredData)
+		sfRoutes.GET("/products/:slug/reviews", controller.StorefrontListReviews)
+		sfRoutes.POST("/products/:slug/reviews", middleware.AuthMiddleware(), middleware.UserAuthMiddleware(), controller.StorefrontSubmitReview)
+		sfRoutes.GET("/search/suggest", controller.StorefrontSearchSuggest)
+		sfRoutes.GET("/collections/:slug", controller.StorefrontGetCollection)
+		sfRoutes.GET("/sitemap.xml", controller.StorefrontSitemap)
+		sfRoutes.GET("/feeds/products", controller.StorefrontProductFeed)
 	}
 }
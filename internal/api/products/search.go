@@ -0,0 +1,140 @@
+package products
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Product search runs on product_search_documents, which triggers keep current with the
+// product's names, SKUs, brand, category and descriptions. Terms are normalized like the
+// documents (see normalize_search_text), match on full-text or, for typos, on trigrams.
+const (
+	searchTSQuery = `(websearch_to_tsquery('english', normalize_search_text(?)) || websearch_to_tsquery('simple', normalize_search_text(?)))`
+	searchMatch   = `(psd.search_vector @@ ` + searchTSQuery + ` OR normalize_search_text(?) <% psd.document)`
+	searchRank    = `ts_rank_cd(psd.search_vector, ` + searchTSQuery + `) + word_similarity(normalize_search_text(?), psd.document)`
+)
+
+const (
+	minSuggestLength    = 2
+	defaultSuggestLimit = 8
+)
+
+// searchProducts narrows a products query (aliased p) to a search term
+func searchProducts(query *gorm.DB, term string) *gorm.DB {
+	return query.Joins("JOIN product_search_documents psd ON psd.product_id = p.id").
+		Where(searchMatch, term, term, term)
+}
+
+// searchRankOrder orders searched products best match first, newest first on ties
+func searchRankOrder(term string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                searchRank + ` DESC, p.created_at DESC`,
+		Vars:               []interface{}{term, term, term},
+		WithoutParentheses: true,
+	}}
+}
+
+// listOrder orders a product list by search rank when searching, newest first otherwise
+func listOrder(term string) clause.OrderBy {
+	if term != "" {
+		return searchRankOrder(term)
+	}
+	return clause.OrderBy{Columns: []clause.OrderByColumn{{Column: clause.Column{Name: "p.created_at", Raw: true}, Desc: true}}}
+}
+
+// escapeLike escapes the LIKE wildcards of a term
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// ============== Suggestions ==============
+
+type SearchSuggestion struct {
+	Products   []ProductSuggestion `json:"products"`
+	Brands     []NameSuggestion    `json:"brands"`
+	Categories []NameSuggestion    `json:"categories"`
+}
+
+type ProductSuggestion struct {
+	ID     int64  `json:"id"`
+	NameEn string `json:"name_en"`
+	NameAr string `json:"name_ar"`
+	SlugEn string `json:"slug_en"`
+	SlugAr string `json:"slug_ar"`
+}
+
+type NameSuggestion struct {
+	ID     int64  `json:"id"`
+	NameEn string `json:"name_en"`
+	NameAr string `json:"name_ar"`
+}
+
+// SearchSuggestions returns the published products whose names start with or nearly match
+// the term, with the matching brands and categories of the store
+func (r *V2Repository) SearchSuggestions(storeFrontID int64, term string, limit int) (*SearchSuggestion, error) {
+	result := &SearchSuggestion{Products: []ProductSuggestion{}, Brands: []NameSuggestion{}, Categories: []NameSuggestion{}}
+	prefix := escapeLike(term) + "%"
+	wordPrefix := "% " + prefix
+
+	err := r.db.Table("products p").
+		Joins("JOIN product_storefront ps ON ps.product_id = p.id").
+		Joins("JOIN product_search_documents psd ON psd.product_id = p.id").
+		Where("ps.store_front_id = ? AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", storeFrontID).
+		Where("(psd.name_document LIKE normalize_search_text(?) OR psd.name_document LIKE normalize_search_text(?) OR normalize_search_text(?) <% psd.name_document)", prefix, wordPrefix, term).
+		Select("p.id, p.name_en, p.name_ar, p.slug_en, p.slug_ar").
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "word_similarity(normalize_search_text(?), psd.name_document) DESC, p.name_en ASC",
+			Vars:               []interface{}{term},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Scan(&result.Products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := func(table, pivot, column string, dest *[]NameSuggestion) error {
+		return r.db.Table(table+" t").
+			Joins("JOIN "+pivot+" x ON x."+column+" = t.id").
+			Where("x.store_front_id = ? AND t.deleted_at IS NULL", storeFrontID).
+			Where("(normalize_search_text(t.name_en) LIKE normalize_search_text(?) OR normalize_search_text(t.name_ar) LIKE normalize_search_text(?))", prefix, prefix).
+			Select("t.id, t.name_en, t.name_ar").
+			Order("t.name_en ASC").
+			Limit(limit).
+			Scan(dest).Error
+	}
+	if err := names("brands", "brand_storefront", "brand_id", &result.Brands); err != nil {
+		return nil, err
+	}
+	if err := names("categories", "category_storefront", "category_id", &result.Categories); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SearchSuggest returns autocomplete suggestions, terms shorter than two letters suggest nothing
+func (s *ServiceV2) SearchSuggest(storeFrontID int64, req requests.SearchSuggestRequest) utils.IResource {
+	term := strings.TrimSpace(req.Q)
+	if utf8.RuneCountInString(term) < minSuggestLength {
+		return utils.NewOKResource("Suggestions retrieved successfully", SearchSuggestion{
+			Products: []ProductSuggestion{}, Brands: []NameSuggestion{}, Categories: []NameSuggestion{},
+		})
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultSuggestLimit
+	}
+
+	suggestions, err := s.repo.SearchSuggestions(storeFrontID, term, limit)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve suggestions", err)
+	}
+	return utils.NewOKResource("Suggestions retrieved successfully", suggestions)
+}
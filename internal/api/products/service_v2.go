@@ -2,11 +2,13 @@ package products
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/onas/ecommerce-api/internal/api/inventory"
 	"github.com/onas/ecommerce-api/internal/api/products/requests"
 	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/services"
 	"github.com/onas/ecommerce-api/internal/utils"
 	"gorm.io/gorm"
 )
@@ -16,62 +18,147 @@ type ServiceV2 struct {
 	db      *gorm.DB
 	repo    *V2Repository
 	invRepo *inventory.Repository
+	files   *services.FileService
 }
 
-func NewServiceV2(db *gorm.DB, repo *V2Repository, invRepo *inventory.Repository) *ServiceV2 {
-	return &ServiceV2{db: db, repo: repo, invRepo: invRepo}
+func NewServiceV2(db *gorm.DB, repo *V2Repository, invRepo *inventory.Repository, files *services.FileService) *ServiceV2 {
+	return &ServiceV2{db: db, repo: repo, invRepo: invRepo, files: files}
 }
 
-// validateVariantAttributeRule checks that variant matches the product attribute type
-func validateVariantAttributeRule(product *models.Product, attributeValue string) error {
-	if product.AttributeType == nil {
-		if attributeValue != "" {
-			return fmt.Errorf("simple product cannot have attribute values")
+// trackingModeOrDefault returns the requested tracking mode, none when unset
+func trackingModeOrDefault(mode string) string {
+	if mode == "" {
+		return models.TrackingModeNone
+	}
+	return mode
+}
+
+// validateTrackedStock rejects initial stock on serial or lot tracked variants,
+// their stock is received through inventory adjustments with serials or a lot
+func validateTrackedStock(req requests.CreateVariantV2Request) error {
+	mode := trackingModeOrDefault(req.TrackingMode)
+	if mode != models.TrackingModeNone && req.Stock != nil && *req.Stock > 0 {
+		return fmt.Errorf("variant %s is %s-tracked, receive its stock through an inventory adjustment", req.SKU, mode)
+	}
+	return nil
+}
+
+// applyTrackingMode switches the tracking mode of a variant, allowed only while it holds no stock.
+// Bundle components stay untracked, bundle lines carry no serials or lots.
+func (s *ServiceV2) applyTrackingMode(tx *gorm.DB, variant *models.ProductVariant, mode string) error {
+	if mode == "" || mode == variant.TrackingMode {
+		return nil
+	}
+
+	if mode != models.TrackingModeNone {
+		var bundles int64
+		err := tx.Model(&models.BundleComponent{}).
+			Where("component_variant_id = ?", variant.ID).
+			Count(&bundles).Error
+		if err != nil {
+			return err
 		}
+		if bundles > 0 {
+			return fmt.Errorf("cannot track %s, it is a component of %d bundle(s)", variant.SKU, bundles)
+		}
+	}
+
+	var onHand int64
+	err := tx.Model(&models.VariantInventory{}).
+		Where("product_variant_id = ?", variant.ID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&onHand).Error
+	if err != nil {
+		return err
+	}
+	if onHand > 0 {
+		return fmt.Errorf("cannot change tracking mode of %s while it has stock", variant.SKU)
+	}
+
+	variant.TrackingMode = mode
+	return nil
+}
+
+// createVariantInventory gives a new variant an inventory row in every store with the ledger
+// entry of its opening stock. Rows start empty with the default threshold, opening sets a
+// store's stock and settings. Bundles hold no stock of their own, it comes from their
+// components, so bundle variants get no rows.
+func (s *ServiceV2) createVariantInventory(tx *gorm.DB, product *models.Product, variant *models.ProductVariant, storeFrontIDs []int64, opening func(inv *models.VariantInventory)) error {
+	if product.ProductType == models.ProductTypeBundle {
 		return nil
 	}
-	if attributeValue == "" {
-		return fmt.Errorf("variant must specify attribute_value for %s product", *product.AttributeType)
+
+	repoTx := &V2Repository{db: tx}
+	for _, sfID := range storeFrontIDs {
+		inv := models.VariantInventory{
+			ProductVariantID:  variant.ID,
+			StoreFrontID:      sfID,
+			LowStockThreshold: 5,
+		}
+		if opening != nil {
+			opening(&inv)
+		}
+		if err := repoTx.CreateVariantInventory(&inv); err != nil {
+			return fmt.Errorf("failed to create inventory for variant %s: %w", variant.SKU, err)
+		}
+		if err := s.recordInitialStock(tx, product.ID, &inv); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// resolveAttributeType fetches attribute name by ID
-func (s *ServiceV2) resolveAttributeType(id *int64) (*string, error) {
-	if id == nil {
-		return nil, nil
+// openingStock sets the same opening quantity in every store, none when stock is unset
+func openingStock(stock *int) func(inv *models.VariantInventory) {
+	return func(inv *models.VariantInventory) {
+		if stock != nil {
+			inv.Quantity = *stock
+		}
+	}
+}
+
+// recordInitialStock writes the ledger entry for stock set when an inventory row is created
+func (s *ServiceV2) recordInitialStock(tx *gorm.DB, productID int64, inv *models.VariantInventory) error {
+	if err := s.invRepo.InvalidateAvailability(tx, inv.StoreFrontID, inv.ProductVariantID); err != nil {
+		return err
 	}
-	var attr models.Attribute
-	if err := s.db.First(&attr, *id).Error; err != nil {
-		return nil, fmt.Errorf("invalid attribute_type id: %d", *id)
+	if inv.Quantity == 0 && inv.ReservedQuantity == 0 {
+		return nil
+	}
+	empty := *inv
+	empty.Quantity = 0
+	empty.ReservedQuantity = 0
+	movement := inventory.NewMovement(&empty, inv.Quantity, inv.ReservedQuantity, models.MovementTypeInitialStock, inventory.MovementSource{
+		Type: models.MovementSourceProduct,
+		ID:   &productID,
+	})
+	if err := s.invRepo.CreateMovement(tx, movement); err != nil {
+		return fmt.Errorf("failed to record initial stock for variant %d: %w", inv.ProductVariantID, err)
 	}
-	// Use NameEn as the key for now
-	name := strings.ToLower(attr.NameEn)
-	return &name, nil
+	return nil
 }
 
-func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.IResource {
-	// Resolve attribute type ID to string
-	attrType, err := s.resolveAttributeType(req.AttributeType)
+func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request, adminID int64) utils.IResource {
+	attrs, err := s.resolveProductAttributes(req.AttributeIDs, req.AttributeType)
 	if err != nil {
 		return utils.NewBadRequestResource(err.Error(), nil)
 	}
+	attrType := attributeTypeOf(attrs)
 
-	// Generate slug
-	slug := utils.Slugify(req.NameEn)
-	if slug == "" {
+	// Generate slugs
+	slugEn, slugAr := productSlugs(req.NameEn, req.NameAr, req.SlugEn, req.SlugAr, req.TransliterateSlug)
+	if slugEn == "" {
 		return utils.NewBadRequestResource("Could not generate slug from name_en", nil)
 	}
 
 	// Validate slug uniqueness per each store
-	for _, sfID := range req.StoreFrontIDs {
-		unique, err := s.repo.IsSlugUniqueForStore(slug, sfID, 0)
-		if err != nil {
-			return utils.NewInternalErrorResource("Failed to validate slug", err)
-		}
-		if !unique {
-			return utils.NewBadRequestResource(fmt.Sprintf("Slug '%s' already exists in store %d", slug, sfID), nil)
-		}
+	if res := s.validateProductSlugs(req.StoreFrontIDs, 0, slugEn, slugAr); res != nil {
+		return res
+	}
+
+	productType := req.ProductType
+	if productType == "" {
+		productType = models.ProductTypeSimple
 	}
 
 	// Validate variants if any
@@ -88,6 +175,10 @@ func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.I
 			}
 			skuMap[vReq.SKU] = true
 
+			if err := validateTrackedStock(vReq); err != nil {
+				return utils.NewBadRequestResource(err.Error(), nil)
+			}
+
 			// 2. Check duplicates in DB
 			unique, err := s.repo.IsSKUUnique(vReq.SKU, 0)
 			if err != nil {
@@ -101,12 +192,14 @@ func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.I
 
 	// Create product in transaction
 	var productID int64
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.invRepo.Transaction(s.db, func(tx *gorm.DB) error {
 		product := &models.Product{
 			NameEn:             req.NameEn,
 			NameAr:             req.NameAr,
 			Name:               req.NameEn, // backward compat
-			Slug:               slug,
+			Slug:               slugEn,
+			SlugEn:             slugEn,
+			SlugAr:             slugAr,
 			DescriptionEn:      req.DescriptionEn,
 			DescriptionAr:      req.DescriptionAr,
 			Description:        req.DescriptionEn, // backward compat
@@ -115,6 +208,7 @@ func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.I
 			SupplierID:         req.SupplierID,
 			IsInternalSupplier: req.IsInternalSupplier,
 			AttributeType:      attrType,
+			ProductType:        productType,
 			Status:             models.ProductStatusDraft,
 			IsPublished:        false,
 			IsFeatured:         req.IsFeatured,
@@ -127,11 +221,18 @@ func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.I
 		}
 		productID = product.ID
 
+		if err := setProductAttributes(tx, productID, attrs); err != nil {
+			return err
+		}
+
 		// Assign to stores
 		repoTx := &V2Repository{db: tx}
 		if err := repoTx.AssignProductToStores(tx, productID, req.StoreFrontIDs); err != nil {
 			return err
 		}
+		if err := setProductTags(tx, productID, req.Tags); err != nil {
+			return err
+		}
 
 		// Upsert SEO if provided
 		if req.SEO != nil {
@@ -143,13 +244,9 @@ func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.I
 		// Create Variants
 		if len(req.Variants) > 0 {
 			for _, vReq := range req.Variants {
-				if err := validateVariantAttributeRule(product, vReq.AttributeValue); err != nil {
-					return fmt.Errorf("variant validation failed: %w", err)
-				}
 				variant := &models.ProductVariant{
 					ProductID:      productID,
 					SKU:            vReq.SKU,
-					AttributeValue: vReq.AttributeValue,
 					Price:          vReq.Price,
 					CompareAtPrice: vReq.CompareAtPrice,
 					CostPrice:      vReq.CostPrice,
@@ -158,35 +255,29 @@ func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.I
 					Width:          vReq.Width,
 					Height:         vReq.Height,
 					IsActive:       vReq.IsActive,
+					TrackingMode:   trackingModeOrDefault(vReq.TrackingMode),
 				}
 				if vReq.Barcode != "" {
 					variant.Barcode = &vReq.Barcode
 				}
+				values, err := assignVariantValues(tx, attrs, variant, vReq.AttributeValueIDs, vReq.AttributeValue)
+				if err != nil {
+					return fmt.Errorf("variant validation failed: %w", err)
+				}
 
 				if err := repoTx.CreateVariantV2(variant); err != nil {
 					return fmt.Errorf("failed to create variant %s: %w", vReq.SKU, err)
 				}
-
-				// Create inventory for all storefronts
-				initialStock := 0
-				if vReq.Stock != nil {
-					initialStock = *vReq.Stock
+				if err := linkVariantValues(tx, productID, variant.ID, values); err != nil {
+					return err
 				}
-				for _, sfID := range req.StoreFrontIDs {
-					inv := models.VariantInventory{
-						ProductVariantID:  variant.ID,
-						StoreFrontID:      sfID,
-						Quantity:          initialStock,
-						LowStockThreshold: 5,
-					}
-					if err := repoTx.CreateVariantInventory(&inv); err != nil {
-						return fmt.Errorf("failed to create inventory for variant %s: %w", vReq.SKU, err)
-					}
+				if err := s.createVariantInventory(tx, product, variant, req.StoreFrontIDs, openingStock(vReq.Stock)); err != nil {
+					return err
 				}
 			}
 		}
 
-		return nil
+		return recordRevision(tx, productID, models.ProductRevisionCreate, adminID, nil)
 	})
 
 	if err != nil {
@@ -201,30 +292,67 @@ func (s *ServiceV2) CreateProductV2(req requests.CreateProductV2Request) utils.I
 	return utils.NewCreatedResource("Product created successfully", detail)
 }
 
-func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Request) utils.IResource {
+func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Request, adminID int64) utils.IResource {
 	product, err := s.repo.GetProductModelByID(id)
 	if err != nil {
 		return utils.NewNotFoundResource("Product not found", nil)
 	}
 
-	attrType, err := s.resolveAttributeType(req.AttributeType)
+	attrs, err := s.resolveProductAttributes(req.AttributeIDs, req.AttributeType)
 	if err != nil {
 		return utils.NewBadRequestResource(err.Error(), nil)
 	}
+	attrType := attributeTypeOf(attrs)
 
-	// Re-generate slug if name changed
-	slug := utils.Slugify(req.NameEn)
-	if slug != product.Slug {
-		for _, sfID := range req.StoreFrontIDs {
-			unique, err := s.repo.IsSlugUniqueForStore(slug, sfID, id)
+	// Variants are defined by the product's attributes, which only change while it has none
+	currentAttrs, err := productAttributes(s.db, id)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product attributes", err)
+	}
+	if !sameAttributeSet(currentAttrs, attrs) {
+		var variantCount int64
+		if err := s.db.Model(&models.ProductVariant{}).Where("product_id = ?", id).Count(&variantCount).Error; err != nil {
+			return utils.NewInternalErrorResource("Failed to check product variants", err)
+		}
+		if variantCount > 0 {
+			return utils.NewBadRequestResource("Cannot change the attributes of a product that has variants", nil)
+		}
+	}
+
+	if req.ProductType != "" && req.ProductType != product.ProductType {
+		if product.ProductType == models.ProductTypeBundle {
+			hasComponents, err := s.repo.HasBundleComponents(id)
 			if err != nil {
-				return utils.NewInternalErrorResource("Failed to validate slug", err)
+				return utils.NewInternalErrorResource("Failed to check bundle components", err)
 			}
-			if !unique {
-				return utils.NewBadRequestResource(fmt.Sprintf("Slug '%s' already exists in store %d", slug, sfID), nil)
+			if hasComponents {
+				return utils.NewBadRequestResource("Cannot change product type: remove the bundle components first", nil)
 			}
 		}
+		// Bundles take their stock from components, their own would go unseen
+		if req.ProductType == models.ProductTypeBundle {
+			hasStock, err := s.repo.HasVariantStock(id)
+			if err != nil {
+				return utils.NewInternalErrorResource("Failed to check variant stock", err)
+			}
+			if hasStock {
+				return utils.NewBadRequestResource("Cannot change product type: variants still have stock, reservations or backorders", nil)
+			}
+		}
+		product.ProductType = req.ProductType
+	}
+
+	// Re-generate slugs if names changed, the old ones redirect
+	slugEn, slugAr := productSlugs(req.NameEn, req.NameAr, req.SlugEn, req.SlugAr, req.TransliterateSlug)
+	if slugEn == "" {
+		return utils.NewBadRequestResource("Could not generate slug from name_en", nil)
+	}
+	if slugEn != product.SlugEn || slugAr != product.SlugAr {
+		if res := s.validateProductSlugs(req.StoreFrontIDs, id, slugEn, slugAr); res != nil {
+			return res
+		}
 	}
+	oldSlugEn, oldSlugAr := product.SlugEn, product.SlugAr
 
 	// Validate variants if any
 	if len(req.Variants) > 0 {
@@ -240,6 +368,10 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 			}
 			skuMap[vReq.SKU] = true
 
+			if err := validateTrackedStock(vReq); err != nil {
+				return utils.NewBadRequestResource(err.Error(), nil)
+			}
+
 			// 2. Check duplicates in DB (exclude current variant ID if updating)
 			excludeID := int64(0)
 			if vReq.ID != nil {
@@ -256,11 +388,13 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 		}
 	}
 
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.invRepo.Transaction(s.db, func(tx *gorm.DB) error {
 		product.NameEn = req.NameEn
 		product.NameAr = req.NameAr
 		product.Name = req.NameEn
-		product.Slug = slug
+		product.Slug = slugEn
+		product.SlugEn = slugEn
+		product.SlugAr = slugAr
 		product.DescriptionEn = req.DescriptionEn
 		product.DescriptionAr = req.DescriptionAr
 		product.Description = req.DescriptionEn
@@ -272,15 +406,34 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 		product.IsFeatured = req.IsFeatured
 		product.IsNew = req.IsNew
 		product.IsBestSeller = req.IsBestSeller
+		product.IsNewPinned = req.IsNewPinned
+		product.IsBestSellerPinned = req.IsBestSellerPinned
 
 		if err := tx.Save(product).Error; err != nil {
 			return err
 		}
+		if err := recordSlugRedirects(tx, product, req.StoreFrontIDs, oldSlugEn, oldSlugAr); err != nil {
+			return err
+		}
+
+		if err := setProductAttributes(tx, id, attrs); err != nil {
+			return err
+		}
 
 		repoTx := &V2Repository{db: tx}
 		if err := repoTx.AssignProductToStores(tx, id, req.StoreFrontIDs); err != nil {
 			return err
 		}
+		if product.IsBestSellerPinned {
+			if err := pinStoreBestSeller(tx, id, product.IsBestSeller); err != nil {
+				return err
+			}
+		}
+		if req.Tags != nil {
+			if err := setProductTags(tx, id, req.Tags); err != nil {
+				return err
+			}
+		}
 
 		if req.SEO != nil {
 			if err := repoTx.UpsertProductSEO(tx, id, *req.SEO); err != nil {
@@ -297,12 +450,17 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 					return fmt.Errorf("variant %d not found for product", *vReq.ID)
 				}
 
-				if err := validateVariantAttributeRule(product, vReq.AttributeValue); err != nil {
-					return fmt.Errorf("variant validation failed: %w", err)
+				// Older clients resend the label, which keeps the current values
+				changed := len(vReq.AttributeValueIDs) > 0 || vReq.AttributeValue != existing.AttributeValue
+				var values []models.AttributeValue
+				if changed {
+					var err error
+					if values, err = assignVariantValues(tx, attrs, &existing, vReq.AttributeValueIDs, vReq.AttributeValue); err != nil {
+						return fmt.Errorf("variant validation failed: %w", err)
+					}
 				}
 
 				existing.SKU = vReq.SKU
-				existing.AttributeValue = vReq.AttributeValue
 				existing.Price = vReq.Price
 				existing.CompareAtPrice = vReq.CompareAtPrice
 				existing.CostPrice = vReq.CostPrice
@@ -311,6 +469,9 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 				existing.Width = vReq.Width
 				existing.Height = vReq.Height
 				existing.IsActive = vReq.IsActive
+				if err := s.applyTrackingMode(tx, &existing, vReq.TrackingMode); err != nil {
+					return err
+				}
 				if vReq.Barcode != "" {
 					existing.Barcode = &vReq.Barcode
 				}
@@ -318,16 +479,16 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 				if err := repoTx.UpdateVariantV2(&existing); err != nil {
 					return fmt.Errorf("failed to update variant %s: %w", vReq.SKU, err)
 				}
+				if changed {
+					if err := linkVariantValues(tx, id, existing.ID, values); err != nil {
+						return err
+					}
+				}
 			} else {
 				// Create new
-				if err := validateVariantAttributeRule(product, vReq.AttributeValue); err != nil {
-					return fmt.Errorf("variant validation failed: %w", err)
-				}
-
 				variant := &models.ProductVariant{
 					ProductID:      id,
 					SKU:            vReq.SKU,
-					AttributeValue: vReq.AttributeValue,
 					Price:          vReq.Price,
 					CompareAtPrice: vReq.CompareAtPrice,
 					CostPrice:      vReq.CostPrice,
@@ -336,34 +497,34 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 					Width:          vReq.Width,
 					Height:         vReq.Height,
 					IsActive:       vReq.IsActive,
+					TrackingMode:   trackingModeOrDefault(vReq.TrackingMode),
 				}
 				if vReq.Barcode != "" {
 					variant.Barcode = &vReq.Barcode
 				}
 
+				values, err := assignVariantValues(tx, attrs, variant, vReq.AttributeValueIDs, vReq.AttributeValue)
+				if err != nil {
+					return fmt.Errorf("variant validation failed: %w", err)
+				}
+
 				if err := repoTx.CreateVariantV2(variant); err != nil {
 					return fmt.Errorf("failed to create new variant %s: %w", vReq.SKU, err)
 				}
-
-				// Create inventory for new variant
-				initialStock := 0
-				if vReq.Stock != nil {
-					initialStock = *vReq.Stock
+				if err := linkVariantValues(tx, id, variant.ID, values); err != nil {
+					return err
 				}
-				for _, sfID := range req.StoreFrontIDs {
-					inv := models.VariantInventory{
-						ProductVariantID:  variant.ID,
-						StoreFrontID:      sfID,
-						Quantity:          initialStock,
-						LowStockThreshold: 5,
-					}
-					// Ignore error if exists (unlikely for new variant)
-					_ = repoTx.CreateVariantInventory(&inv)
+				if err := s.createVariantInventory(tx, product, variant, req.StoreFrontIDs, openingStock(vReq.Stock)); err != nil {
+					return err
 				}
 			}
 		}
 
-		return nil
+		// Stores and active variants may have changed without any stock moving
+		if err := inventory.RefreshProductStock(tx, id); err != nil {
+			return err
+		}
+		return recordRevision(tx, id, models.ProductRevisionUpdate, adminID, nil)
 	})
 
 	if err != nil {
@@ -378,7 +539,7 @@ func (s *ServiceV2) UpdateProductV2(id int64, req requests.UpdateProductV2Reques
 	return utils.NewOKResource("Product updated successfully", detail)
 }
 
-func (s *ServiceV2) UpdateProductStatus(id int64, req requests.UpdateProductStatusRequest) utils.IResource {
+func (s *ServiceV2) UpdateProductStatus(id int64, req requests.UpdateProductStatusRequest, adminID int64) utils.IResource {
 	product, err := s.repo.GetProductModelByID(id)
 	if err != nil {
 		return utils.NewNotFoundResource("Product not found", nil)
@@ -398,13 +559,23 @@ func (s *ServiceV2) UpdateProductStatus(id int64, req requests.UpdateProductStat
 			return utils.NewBadRequestResource("Cannot activate product: requires at least 1 active variant", nil)
 		}
 
-		// Must have inventory
-		hasInv, err := s.invRepo.HasInventoryForProduct(id)
-		if err != nil {
-			return utils.NewInternalErrorResource("Failed to check inventory", err)
-		}
-		if !hasInv {
-			return utils.NewBadRequestResource("Cannot activate product: requires inventory for at least 1 variant", nil)
+		// Bundles must be made of something, other products must have inventory
+		if product.ProductType == models.ProductTypeBundle {
+			hasComponents, err := s.repo.HasBundleComponents(id)
+			if err != nil {
+				return utils.NewInternalErrorResource("Failed to check bundle components", err)
+			}
+			if !hasComponents {
+				return utils.NewBadRequestResource("Cannot activate bundle: requires components for at least 1 variant", nil)
+			}
+		} else {
+			hasInv, err := s.invRepo.HasInventoryForProduct(id)
+			if err != nil {
+				return utils.NewInternalErrorResource("Failed to check inventory", err)
+			}
+			if !hasInv {
+				return utils.NewBadRequestResource("Cannot activate product: requires inventory for at least 1 variant", nil)
+			}
 		}
 
 		// Activate and publish
@@ -440,6 +611,10 @@ func (s *ServiceV2) UpdateProductStatus(id int64, req requests.UpdateProductStat
 		return utils.NewBadRequestResource("Invalid status", nil)
 	}
 
+	if err := recordRevision(s.db, id, models.ProductRevisionStatus, adminID, nil); err != nil {
+		return utils.NewInternalErrorResource("Status updated but failed to record revision", err)
+	}
+
 	detail, err := s.repo.GetAdminProductV2ByID(id)
 	if err != nil {
 		return utils.NewInternalErrorResource("Status updated but failed to retrieve product", err)
@@ -448,7 +623,7 @@ func (s *ServiceV2) UpdateProductStatus(id int64, req requests.UpdateProductStat
 	return utils.NewOKResource("Product status updated successfully", detail)
 }
 
-func (s *ServiceV2) UpdateProductSEO(id int64, req requests.ProductSEORequest) utils.IResource {
+func (s *ServiceV2) UpdateProductSEO(id int64, req requests.ProductSEORequest, adminID int64) utils.IResource {
 	_, err := s.repo.GetProductModelByID(id)
 	if err != nil {
 		return utils.NewNotFoundResource("Product not found", nil)
@@ -463,7 +638,13 @@ func (s *ServiceV2) UpdateProductSEO(id int64, req requests.ProductSEORequest) u
 		warnings = append(warnings, "meta_description_en recommended length: 140-160 chars")
 	}
 
-	if err := s.repo.UpsertProductSEO(s.db, id, req); err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.UpsertProductSEO(tx, id, req); err != nil {
+			return err
+		}
+		return recordRevision(tx, id, models.ProductRevisionSEO, adminID, nil)
+	})
+	if err != nil {
 		return utils.NewInternalErrorResource("Failed to update SEO", err)
 	}
 
@@ -539,20 +720,23 @@ func (s *ServiceV2) GetProductV2ByID(id int64) utils.IResource {
 }
 
 // CreateVariantV2 creates a new variant with pricing
-func (s *ServiceV2) CreateVariantV2(productID int64, req requests.CreateVariantV2Request) utils.IResource {
+func (s *ServiceV2) CreateVariantV2(productID int64, req requests.CreateVariantV2Request, adminID int64) utils.IResource {
 	product, err := s.repo.GetProductModelByID(productID)
 	if err != nil {
 		return utils.NewNotFoundResource("Product not found", nil)
 	}
 
-	// Validate attribute rule
-	if err := validateVariantAttributeRule(product, req.AttributeValue); err != nil {
-		return utils.NewBadRequestResource(err.Error(), nil)
+	attrs, err := productAttributes(s.db, productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product attributes", err)
 	}
 
 	if req.SKU == "" {
 		req.SKU = utils.GenerateRandomSKU()
 	}
+	if err := validateTrackedStock(req); err != nil {
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
 
 	// Validate SKU uniqueness
 	unique, err := s.repo.IsSKUUnique(req.SKU, 0)
@@ -566,59 +750,69 @@ func (s *ServiceV2) CreateVariantV2(productID int64, req requests.CreateVariantV
 	variant := &models.ProductVariant{
 		ProductID:      productID,
 		SKU:            req.SKU,
-		AttributeValue: req.AttributeValue,
 		Price:          req.Price,
 		CompareAtPrice: req.CompareAtPrice,
 		CostPrice:      req.CostPrice,
 		Weight:         req.Weight,
 		IsActive:       req.IsActive,
+		TrackingMode:   trackingModeOrDefault(req.TrackingMode),
 	}
 	if req.Barcode != "" {
 		variant.Barcode = &req.Barcode
 	}
+	// Values are checked in the transaction that stores them
+	var values []models.AttributeValue
+	var invalid error
+	err = s.invRepo.Transaction(s.db, func(tx *gorm.DB) error {
+		if values, invalid = assignVariantValues(tx, attrs, variant, req.AttributeValueIDs, req.AttributeValue); invalid != nil {
+			return invalid
+		}
 
-	if err := s.repo.CreateVariantV2(variant); err != nil {
-		return utils.NewInternalErrorResource("Failed to create variant", err)
-	}
+		repoTx := &V2Repository{db: tx}
+		if err := repoTx.CreateVariantV2(variant); err != nil {
+			return err
+		}
+		if err := linkVariantValues(tx, productID, variant.ID, values); err != nil {
+			return err
+		}
 
-	// Create inventory for all storefronts
-	sfIDs, err := s.repo.GetProductStoreFrontIDs(productID)
-	if err == nil && len(sfIDs) > 0 {
-		initialStock := 0
-		if req.Stock != nil {
-			initialStock = *req.Stock
+		sfIDs, err := repoTx.GetProductStoreFrontIDs(productID)
+		if err != nil {
+			return err
 		}
-		for _, sfID := range sfIDs {
-			inv := models.VariantInventory{
-				ProductVariantID:  variant.ID,
-				StoreFrontID:      sfID,
-				Quantity:          initialStock,
-				LowStockThreshold: 5, // Default
-			}
-			_ = s.repo.CreateVariantInventory(&inv)
+		if err := s.createVariantInventory(tx, product, variant, sfIDs, openingStock(req.Stock)); err != nil {
+			return err
 		}
+		return recordRevision(tx, productID, models.ProductRevisionVariantCreate, adminID, nil)
+	})
+	if invalid != nil {
+		return utils.NewBadRequestResource(invalid.Error(), nil)
+	}
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to create variant", err)
 	}
 
 	return utils.NewCreatedResource("Variant created successfully", AdminVariantV2{
-		ID:             variant.ID,
-		SKU:            variant.SKU,
-		AttributeValue: variant.AttributeValue,
-		Price:          variant.Price,
-		CompareAtPrice: variant.CompareAtPrice,
-		CostPrice:      variant.CostPrice,
-		Barcode:        variant.Barcode,
-		Weight:         variant.Weight,
-		Length:         variant.Length,
-		Width:          variant.Width,
-		Height:         variant.Height,
-		IsActive:       variant.IsActive,
+		ID:              variant.ID,
+		SKU:             variant.SKU,
+		AttributeValue:  variant.AttributeValue,
+		Price:           variant.Price,
+		CompareAtPrice:  variant.CompareAtPrice,
+		CostPrice:       variant.CostPrice,
+		Barcode:         variant.Barcode,
+		Weight:          variant.Weight,
+		Length:          variant.Length,
+		Width:           variant.Width,
+		Height:          variant.Height,
+		IsActive:        variant.IsActive,
+		TrackingMode:    variant.TrackingMode,
+		AttributeValues: adminVariantValues(attrs, values),
 	})
 }
 
 // UpdateVariantV2 updates a variant with pricing
-func (s *ServiceV2) UpdateVariantV2(productID, variantID int64, req requests.CreateVariantV2Request) utils.IResource {
-	product, err := s.repo.GetProductModelByID(productID)
-	if err != nil {
+func (s *ServiceV2) UpdateVariantV2(productID, variantID int64, req requests.CreateVariantV2Request, adminID int64) utils.IResource {
+	if _, err := s.repo.GetProductModelByID(productID); err != nil {
 		return utils.NewNotFoundResource("Product not found", nil)
 	}
 
@@ -630,10 +824,14 @@ func (s *ServiceV2) UpdateVariantV2(productID, variantID int64, req requests.Cre
 		return utils.NewNotFoundResource("Variant does not belong to this product", nil)
 	}
 
-	if err := validateVariantAttributeRule(product, req.AttributeValue); err != nil {
-		return utils.NewBadRequestResource(err.Error(), nil)
+	attrs, err := productAttributes(s.db, productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product attributes", err)
 	}
 
+	// Older clients resend the label, which keeps the current values
+	changed := len(req.AttributeValueIDs) > 0 || req.AttributeValue != variant.AttributeValue
+
 	if req.SKU == "" {
 		req.SKU = utils.GenerateRandomSKU()
 	}
@@ -648,30 +846,64 @@ func (s *ServiceV2) UpdateVariantV2(productID, variantID int64, req requests.Cre
 	}
 
 	variant.SKU = req.SKU
-	variant.AttributeValue = req.AttributeValue
 	variant.Price = req.Price
 	variant.CompareAtPrice = req.CompareAtPrice
 	variant.CostPrice = req.CostPrice
 	variant.Weight = req.Weight
 	variant.IsActive = req.IsActive
+	if err := s.applyTrackingMode(s.db, variant, req.TrackingMode); err != nil {
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
 	if req.Barcode != "" {
 		variant.Barcode = &req.Barcode
 	}
 
-	if err := s.repo.UpdateVariantV2(variant); err != nil {
+	var invalid error
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var values []models.AttributeValue
+		if changed {
+			if values, invalid = assignVariantValues(tx, attrs, variant, req.AttributeValueIDs, req.AttributeValue); invalid != nil {
+				return invalid
+			}
+		}
+
+		if err := (&V2Repository{db: tx}).UpdateVariantV2(variant); err != nil {
+			return err
+		}
+		if changed {
+			if err := linkVariantValues(tx, productID, variant.ID, values); err != nil {
+				return err
+			}
+		}
+		if err := inventory.RefreshProductStock(tx, productID); err != nil {
+			return err
+		}
+		return recordRevision(tx, productID, models.ProductRevisionVariantUpdate, adminID, nil)
+	})
+	if invalid != nil {
+		return utils.NewBadRequestResource(invalid.Error(), nil)
+	}
+	if err != nil {
 		return utils.NewInternalErrorResource("Failed to update variant", err)
 	}
 
+	linked, err := variantAttributeValues(s.db, productID, []int64{variant.ID})
+	if err != nil {
+		return utils.NewInternalErrorResource("Variant updated but failed to retrieve", err)
+	}
+
 	return utils.NewOKResource("Variant updated successfully", AdminVariantV2{
-		ID:             variant.ID,
-		SKU:            variant.SKU,
-		AttributeValue: variant.AttributeValue,
-		Price:          variant.Price,
-		CompareAtPrice: variant.CompareAtPrice,
-		CostPrice:      variant.CostPrice,
-		Barcode:        variant.Barcode,
-		Weight:         variant.Weight,
-		IsActive:       variant.IsActive,
+		ID:              variant.ID,
+		SKU:             variant.SKU,
+		AttributeValue:  variant.AttributeValue,
+		Price:           variant.Price,
+		CompareAtPrice:  variant.CompareAtPrice,
+		CostPrice:       variant.CostPrice,
+		Barcode:         variant.Barcode,
+		Weight:          variant.Weight,
+		IsActive:        variant.IsActive,
+		TrackingMode:    variant.TrackingMode,
+		AttributeValues: valuesOrEmpty(linked[variant.ID]),
 	})
 }
 
@@ -739,29 +971,81 @@ func (s *ServiceV2) SetCoverImage(productID int64, req requests.SetCoverImageReq
 }
 
 // Storefront public APIs
-func (s *ServiceV2) StorefrontListProducts(storeFrontID int64, filter requests.ProductFilterRequest, pagination *utils.Pagination) utils.IResource {
-	items, total, err := s.repo.ListStorefrontProducts(storeFrontID, filter, pagination)
+func (s *ServiceV2) StorefrontListProducts(storeFrontID int64, filter requests.StorefrontProductFilterRequest, pagination *utils.Pagination) utils.IResource {
+	items, meta, res := s.listStorefront(storefrontScope{storeFrontID: storeFrontID}, filter, pagination)
+	if res != nil {
+		return res
+	}
+	return utils.NewPaginatedOKResource("Products retrieved successfully", items, meta)
+}
+
+// listStorefront lists a page of a scope's products, returning the pagination meta with
+// the facet counts of the listing
+func (s *ServiceV2) listStorefront(scope storefrontScope, filter requests.StorefrontProductFilterRequest, pagination *utils.Pagination) ([]StorefrontProductItem, map[string]interface{}, utils.IResource) {
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, nil, utils.NewBadRequestResource("min_price must not be greater than max_price", nil)
+	}
+
+	attributeValues, unknown, err := s.repo.groupAttributeValues(filter.AttributeValueIDs)
 	if err != nil {
-		return utils.NewInternalErrorResource("Failed to retrieve products", err)
+		return nil, nil, utils.NewInternalErrorResource("Failed to retrieve products", err)
+	}
+	if len(unknown) > 0 {
+		return nil, nil, utils.NewBadRequestResource(fmt.Sprintf("Unknown attribute values: %v", unknown), nil)
+	}
+
+	items, total, err := s.repo.ListStorefrontProducts(scope, filter, attributeValues, pagination)
+	if err != nil {
+		return nil, nil, utils.NewInternalErrorResource("Failed to retrieve products", err)
+	}
+
+	facets, err := s.repo.StorefrontFacets(scope, filter, attributeValues)
+	if err != nil {
+		return nil, nil, utils.NewInternalErrorResource("Failed to retrieve product facets", err)
 	}
 
 	pagination.SetTotal(total)
-	return utils.NewPaginatedOKResource("Products retrieved successfully", items, pagination.GetMeta())
+	meta := pagination.GetMeta()
+	meta["facets"] = facets
+	return items, meta, nil
 }
 
+// StorefrontGetProduct resolves a product by its English or Arabic slug. An old slug of a
+// renamed product answers with a permanent redirect to the current slug of its language.
 func (s *ServiceV2) StorefrontGetProduct(storeFrontID int64, slug string) utils.IResource {
 	detail, err := s.repo.GetStorefrontProduct(storeFrontID, slug)
 	if err != nil {
-		return utils.NewNotFoundResource("Product not found", nil)
+		product, language, redirectErr := s.repo.FindSlugRedirect(storeFrontID, slug)
+		if redirectErr != nil {
+			return utils.NewNotFoundResource("Product not found", nil)
+		}
+		current := localizedSlug(product, language)
+		return utils.NewMovedPermanentlyResource("Product has moved", url.PathEscape(current), map[string]interface{}{
+			"slug":     current,
+			"language": language,
+		})
 	}
 
+	relations, err := s.storefrontRelations(storeFrontID, detail.ID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to retrieve related products", err)
+	}
+	detail.Relations = relations
+
 	return utils.NewOKResource("Product retrieved successfully", detail)
 }
 
 func (s *ServiceV2) GetProductStructuredData(storeFrontID int64, slug string) utils.IResource {
 	jsonLD, err := s.repo.GetProductStructuredData(storeFrontID, slug)
 	if err != nil {
-		return utils.NewNotFoundResource("Product not found", nil)
+		// Old slugs of renamed products describe the product they redirect to
+		product, language, redirectErr := s.repo.FindSlugRedirect(storeFrontID, slug)
+		if redirectErr != nil {
+			return utils.NewNotFoundResource("Product not found", nil)
+		}
+		if jsonLD, err = s.repo.GetProductStructuredData(storeFrontID, localizedSlug(product, language)); err != nil {
+			return utils.NewNotFoundResource("Product not found", nil)
+		}
 	}
 
 	return utils.NewOKResource("Structured data generated", jsonLD)
@@ -0,0 +1,209 @@
+package products
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// sitemapPageSize is the pages per sitemap file, each page is listed once per language
+// so a file stays under the 50,000 URL limit
+const sitemapPageSize = 20000
+
+// sitemapLanguages are the languages every storefront page is served in
+var sitemapLanguages = []string{"ar", "en"}
+
+// sitemapPage is a storefront page with its path per language
+type sitemapPage struct {
+	Paths   map[string]string // Path under the language prefix, by language
+	LastMod time.Time
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Xhtml   string       `xml:"xmlns:xhtml,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string             `xml:"loc"`
+	LastMod    string             `xml:"lastmod,omitempty"`
+	Alternates []sitemapAlternate `xml:"xhtml:link"`
+}
+
+// sitemapAlternate is an hreflang link to the page in another language
+type sitemapAlternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	Xmlns    string         `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// storefrontPageURL is the absolute URL of a storefront page in a language
+func storefrontPageURL(domain, lang, path string) string {
+	return fmt.Sprintf("https://%s/%s%s", domain, lang, path)
+}
+
+// StorefrontSitemap renders the store's sitemap of its home page, categories, collections
+// and active published products. A store with more pages than fit one file gets a sitemap
+// index, page selects one of its files. Page 0 is the sitemap or index itself.
+func (s *ServiceV2) StorefrontSitemap(storeFront models.StoreFront, page int) ([]byte, error) {
+	pages, err := s.repo.SitemapPages(storeFront.ID)
+	if err != nil {
+		return nil, err
+	}
+	pages = append([]sitemapPage{{Paths: map[string]string{"ar": "", "en": ""}}}, pages...)
+
+	files := (len(pages) + sitemapPageSize - 1) / sitemapPageSize
+	switch {
+	case page < 0 || page > files:
+		return nil, gorm.ErrRecordNotFound
+	case page == 0 && files > 1:
+		return renderSitemapIndex(storeFront, pages, files)
+	case page == 0:
+		return renderSitemap(storeFront, pages)
+	}
+
+	end := page * sitemapPageSize
+	if end > len(pages) {
+		end = len(pages)
+	}
+	return renderSitemap(storeFront, pages[(page-1)*sitemapPageSize:end])
+}
+
+func renderSitemap(storeFront models.StoreFront, pages []sitemapPage) ([]byte, error) {
+	set := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		Xhtml: "http://www.w3.org/1999/xhtml",
+		URLs:  make([]sitemapURL, 0, len(pages)*len(sitemapLanguages)),
+	}
+	for _, p := range pages {
+		var alternates []sitemapAlternate
+		for _, lang := range sitemapLanguages {
+			alternates = append(alternates, sitemapAlternate{Rel: "alternate", Hreflang: lang, Href: storefrontPageURL(storeFront.Domain, lang, p.Paths[lang])})
+		}
+		defaultLang := storeFront.DefaultLanguage
+		if _, ok := p.Paths[defaultLang]; !ok {
+			defaultLang = sitemapLanguages[0]
+		}
+		alternates = append(alternates, sitemapAlternate{Rel: "alternate", Hreflang: "x-default", Href: storefrontPageURL(storeFront.Domain, defaultLang, p.Paths[defaultLang])})
+
+		for _, lang := range sitemapLanguages {
+			u := sitemapURL{Loc: storefrontPageURL(storeFront.Domain, lang, p.Paths[lang]), Alternates: alternates}
+			if !p.LastMod.IsZero() {
+				u.LastMod = p.LastMod.UTC().Format("2006-01-02")
+			}
+			set.URLs = append(set.URLs, u)
+		}
+	}
+	return marshalXML(set)
+}
+
+func renderSitemapIndex(storeFront models.StoreFront, pages []sitemapPage, files int) ([]byte, error) {
+	index := sitemapIndex{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for i := 1; i <= files; i++ {
+		end := i * sitemapPageSize
+		if end > len(pages) {
+			end = len(pages)
+		}
+		var lastMod time.Time
+		for _, p := range pages[(i-1)*sitemapPageSize : end] {
+			if p.LastMod.After(lastMod) {
+				lastMod = p.LastMod
+			}
+		}
+
+		entry := sitemapEntry{Loc: fmt.Sprintf("https://%s/api/storefront/sitemap.xml?page=%d", storeFront.Domain, i)}
+		if !lastMod.IsZero() {
+			entry.LastMod = lastMod.UTC().Format("2006-01-02")
+		}
+		index.Sitemaps = append(index.Sitemaps, entry)
+	}
+	return marshalXML(index)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// SitemapPages returns the store's categories, active collections and active published
+// products with their paths per language
+func (r *V2Repository) SitemapPages(storeFrontID int64) ([]sitemapPage, error) {
+	type row struct {
+		SlugEn    string
+		SlugAr    string
+		UpdatedAt time.Time
+	}
+	var pages []sitemapPage
+	add := func(prefix string, rows []row) {
+		for _, row := range rows {
+			slugAr := row.SlugAr
+			if slugAr == "" {
+				slugAr = row.SlugEn
+			}
+			pages = append(pages, sitemapPage{
+				Paths: map[string]string{
+					"en": prefix + url.PathEscape(row.SlugEn),
+					"ar": prefix + url.PathEscape(slugAr),
+				},
+				LastMod: row.UpdatedAt,
+			})
+		}
+	}
+
+	var categories []row
+	err := r.db.Table("categories c").
+		Joins("JOIN category_storefront cs ON cs.category_id = c.id AND cs.store_front_id = ?", storeFrontID).
+		Where("c.deleted_at IS NULL AND c.slug_en <> ''").
+		Select("c.slug_en, c.slug_ar, c.updated_at").
+		Order("c.id").
+		Scan(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	add("/categories/", categories)
+
+	var collections []row
+	err = r.db.Model(&models.Collection{}).
+		Where("store_front_id = ? AND is_active = true", storeFrontID).
+		Select("slug AS slug_en, slug AS slug_ar, updated_at").
+		Order("id").
+		Scan(&collections).Error
+	if err != nil {
+		return nil, err
+	}
+	add("/collections/", collections)
+
+	var products []row
+	err = r.db.Table("products p").
+		Joins("JOIN product_storefront ps ON ps.product_id = p.id").
+		Where("ps.store_front_id = ? AND p.status = 'active' AND p.is_published = true AND p.deleted_at IS NULL", storeFrontID).
+		Select("p.slug_en, p.slug_ar, p.updated_at").
+		Order("p.id").
+		Scan(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	add("/products/", products)
+
+	return pages, nil
+}
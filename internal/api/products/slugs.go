@@ -0,0 +1,89 @@
+package products
+
+import (
+	"fmt"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// productSlugs builds the English and Arabic slugs of a product. Explicit slugs win over
+// names. The Arabic slug keeps Arabic letters unless transliterate asks for a Latin one,
+// and an English name without Latin letters is transliterated.
+func productSlugs(nameEn, nameAr, slugEn, slugAr string, transliterate bool) (string, string) {
+	sourceEn := slugEn
+	if sourceEn == "" {
+		sourceEn = nameEn
+	}
+	en := utils.Slugify(sourceEn)
+	if en == "" {
+		en = utils.Slugify(utils.TransliterateArabic(sourceEn))
+	}
+
+	sourceAr := slugAr
+	if sourceAr == "" {
+		sourceAr = nameAr
+	}
+	if transliterate {
+		return en, utils.Slugify(utils.TransliterateArabic(sourceAr))
+	}
+	return en, utils.SlugifyArabic(sourceAr)
+}
+
+// validateProductSlugs checks that the slugs are free in every store of the product
+func (s *ServiceV2) validateProductSlugs(storeFrontIDs []int64, excludeProductID int64, slugs ...string) utils.IResource {
+	for _, slug := range slugs {
+		if slug == "" {
+			continue
+		}
+		for _, sfID := range storeFrontIDs {
+			unique, err := s.repo.IsSlugUniqueForStore(slug, sfID, excludeProductID)
+			if err != nil {
+				return utils.NewInternalErrorResource("Failed to validate slug", err)
+			}
+			if !unique {
+				return utils.NewBadRequestResource(fmt.Sprintf("Slug '%s' already exists in store %d", slug, sfID), nil)
+			}
+		}
+	}
+	return nil
+}
+
+// recordSlugRedirects keeps the slugs a product had before a rename resolving to it.
+// Redirects on the product's current slugs are dropped in the stores it is sold in, a live
+// slug wins over a redirect. Slugs are unique per store, other stores keep their redirects.
+func recordSlugRedirects(tx *gorm.DB, product *models.Product, storeFrontIDs []int64, oldSlugEn, oldSlugAr string) error {
+	old := []models.ProductSlugRedirect{}
+	if oldSlugEn != "" && oldSlugEn != product.SlugEn {
+		old = append(old, models.ProductSlugRedirect{ProductID: product.ID, Language: models.SlugLanguageEn, Slug: oldSlugEn})
+	}
+	if oldSlugAr != "" && oldSlugAr != product.SlugAr {
+		old = append(old, models.ProductSlugRedirect{ProductID: product.ID, Language: models.SlugLanguageAr, Slug: oldSlugAr})
+	}
+	if len(old) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&old).Error; err != nil {
+			return err
+		}
+	}
+	if len(storeFrontIDs) == 0 {
+		return nil
+	}
+
+	current := []string{product.SlugEn}
+	if product.SlugAr != "" {
+		current = append(current, product.SlugAr)
+	}
+	sameStores := tx.Model(&models.ProductStorefront{}).Select("product_id").Where("store_front_id IN ?", storeFrontIDs)
+	return tx.Where("slug IN ? AND (product_id = ? OR product_id IN (?))", current, product.ID, sameStores).
+		Delete(&models.ProductSlugRedirect{}).Error
+}
+
+// localizedSlug returns the product's slug in a language, falling back to English
+func localizedSlug(product *models.Product, language string) string {
+	if language == models.SlugLanguageAr && product.SlugAr != "" {
+		return product.SlugAr
+	}
+	return product.SlugEn
+}
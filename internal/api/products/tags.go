@@ -0,0 +1,49 @@
+package products
+
+import (
+	"strings"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// normalizeTags lowercases and trims tags, dropping empty and repeated ones
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// setProductTags replaces the tags of a product
+func setProductTags(tx *gorm.DB, productID int64, tags []string) error {
+	if err := tx.Where("product_id = ?", productID).Delete(&models.ProductTag{}).Error; err != nil {
+		return err
+	}
+	for _, tag := range normalizeTags(tags) {
+		if err := tx.Create(&models.ProductTag{ProductID: productID, Tag: tag}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// productTags returns the tags of a product in alphabetical order
+func productTags(db *gorm.DB, productID int64) ([]string, error) {
+	tags := []string{}
+	err := db.Model(&models.ProductTag{}).
+		Where("product_id = ?", productID).
+		Order("tag ASC").
+		Pluck("tag", &tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
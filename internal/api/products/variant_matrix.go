@@ -0,0 +1,343 @@
+package products
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onas/ecommerce-api/internal/api/products/requests"
+	"github.com/onas/ecommerce-api/internal/models"
+	"github.com/onas/ecommerce-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// maxGeneratedVariants caps the combinations a single generate request may produce
+const maxGeneratedVariants = 200
+
+// GeneratedVariant is a variant created by the matrix generator
+type GeneratedVariant struct {
+	ID             int64  `json:"id"`
+	SKU            string `json:"sku"`
+	AttributeValue string `json:"attribute_value"`
+}
+
+// SkippedVariant is a combination the matrix generator did not create
+type SkippedVariant struct {
+	SKU            string `json:"sku"`
+	AttributeValue string `json:"attribute_value"`
+	Reason         string `json:"reason"`
+}
+
+// GenerateVariantsResult reports the outcome of a matrix generation
+type GenerateVariantsResult struct {
+	Created []GeneratedVariant `json:"created"`
+	Skipped []SkippedVariant   `json:"skipped"`
+}
+
+// matrixAttribute is a selected attribute with its values in request order
+type matrixAttribute struct {
+	attribute models.Attribute
+	values    []models.AttributeValue
+}
+
+// variantCombinations returns the cartesian product of the attribute values,
+// varying the last attribute fastest
+func variantCombinations(attrs []matrixAttribute) [][]models.AttributeValue {
+	combos := [][]models.AttributeValue{{}}
+	for _, attr := range attrs {
+		next := make([][]models.AttributeValue, 0, len(combos)*len(attr.values))
+		for _, combo := range combos {
+			for _, v := range attr.values {
+				c := make([]models.AttributeValue, len(combo), len(combo)+1)
+				copy(c, combo)
+				next = append(next, append(c, v))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// combinationKey identifies a set of attribute values regardless of order
+func combinationKey(valueIDs []int64) string {
+	sorted := append([]int64(nil), valueIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// combinationLabel is the display value stored on the variant, e.g. "XL / Red"
+func combinationLabel(combo []models.AttributeValue) string {
+	parts := make([]string, len(combo))
+	for i, v := range combo {
+		parts[i] = v.ValueEn
+	}
+	return strings.Join(parts, " / ")
+}
+
+// skuToken turns a value into an SKU segment, falling back to its ID when it has no latin characters
+func skuToken(v models.AttributeValue) string {
+	if token := strings.ToUpper(utils.Slugify(v.ValueEn)); token != "" {
+		return token
+	}
+	return strconv.FormatInt(v.ID, 10)
+}
+
+// renderVariantSKU fills an SKU template for one combination
+func renderVariantSKU(template string, product *models.Product, n int, attrs []matrixAttribute, combo []models.AttributeValue) string {
+	base := strings.ToUpper(product.Slug)
+	if base == "" {
+		base = strconv.FormatInt(product.ID, 10)
+	}
+
+	if template == "" {
+		parts := []string{base}
+		for _, v := range combo {
+			parts = append(parts, skuToken(v))
+		}
+		return strings.Join(parts, "-")
+	}
+
+	pairs := []string{
+		"{slug}", base,
+		"{product_id}", strconv.FormatInt(product.ID, 10),
+		"{n}", strconv.Itoa(n),
+	}
+	for i, attr := range attrs {
+		pairs = append(pairs, "{"+strings.ToLower(attr.attribute.NameEn)+"}", skuToken(combo[i]))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// orderMatrixAttributes sorts selected attributes like the product's attributes
+func orderMatrixAttributes(attrs []matrixAttribute, productAttrs []models.Attribute) []matrixAttribute {
+	byID := make(map[int64]matrixAttribute, len(attrs))
+	for _, a := range attrs {
+		byID[a.attribute.ID] = a
+	}
+	ordered := make([]matrixAttribute, 0, len(productAttrs))
+	for _, pa := range productAttrs {
+		ordered = append(ordered, byID[pa.ID])
+	}
+	return ordered
+}
+
+// loadMatrixAttributes validates the requested attributes and values
+func (s *ServiceV2) loadMatrixAttributes(req []requests.VariantMatrixAttributeRequest) ([]matrixAttribute, error) {
+	attrIDs := make([]int64, 0, len(req))
+	var valueIDs []int64
+	seenAttr := make(map[int64]bool)
+	for _, a := range req {
+		if seenAttr[a.AttributeID] {
+			return nil, fmt.Errorf("attribute %d is listed more than once", a.AttributeID)
+		}
+		seenAttr[a.AttributeID] = true
+		attrIDs = append(attrIDs, a.AttributeID)
+		valueIDs = append(valueIDs, a.ValueIDs...)
+	}
+
+	var attributes []models.Attribute
+	if err := s.db.Where("id IN ?", attrIDs).Find(&attributes).Error; err != nil {
+		return nil, err
+	}
+	attrByID := make(map[int64]models.Attribute, len(attributes))
+	for _, a := range attributes {
+		attrByID[a.ID] = a
+	}
+
+	var values []models.AttributeValue
+	if err := s.db.Where("id IN ? AND is_active = ?", valueIDs, true).Find(&values).Error; err != nil {
+		return nil, err
+	}
+	valueByID := make(map[int64]models.AttributeValue, len(values))
+	for _, v := range values {
+		valueByID[v.ID] = v
+	}
+
+	attrs := make([]matrixAttribute, 0, len(req))
+	for _, a := range req {
+		attr, ok := attrByID[a.AttributeID]
+		if !ok {
+			return nil, fmt.Errorf("attribute %d not found", a.AttributeID)
+		}
+		m := matrixAttribute{attribute: attr}
+		seenValue := make(map[int64]bool)
+		for _, id := range a.ValueIDs {
+			v, ok := valueByID[id]
+			if !ok || v.AttributeID != attr.ID {
+				return nil, fmt.Errorf("value %d is not an active value of attribute %s", id, attr.NameEn)
+			}
+			if !seenValue[id] {
+				seenValue[id] = true
+				m.values = append(m.values, v)
+			}
+		}
+		attrs = append(attrs, m)
+	}
+	return attrs, nil
+}
+
+// GenerateVariants creates a variant for every combination of the selected attribute values
+// that the product does not have yet
+func (s *ServiceV2) GenerateVariants(productID int64, req requests.GenerateVariantsRequest, adminID int64) utils.IResource {
+	product, err := s.repo.GetProductModelByID(productID)
+	if err != nil {
+		return utils.NewNotFoundResource("Product not found", nil)
+	}
+
+	attrs, err := s.loadMatrixAttributes(req.Attributes)
+	if err != nil {
+		return utils.NewBadRequestResource(err.Error(), nil)
+	}
+
+	// Combinations follow the product's attributes; a product without any takes the selected ones
+	productAttrs, err := productAttributes(s.db, productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product attributes", err)
+	}
+	selected := make([]models.Attribute, len(attrs))
+	for i, a := range attrs {
+		selected[i] = a.attribute
+	}
+	if len(productAttrs) > 0 {
+		if !sameAttributeSet(productAttrs, selected) {
+			names := make([]string, len(productAttrs))
+			for i, a := range productAttrs {
+				names[i] = a.NameEn
+			}
+			return utils.NewBadRequestResource(fmt.Sprintf("Product varies by %s, select values for exactly those attributes", strings.Join(names, ", ")), nil)
+		}
+		attrs = orderMatrixAttributes(attrs, productAttrs)
+	} else {
+		var variantCount int64
+		if err := s.db.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&variantCount).Error; err != nil {
+			return utils.NewInternalErrorResource("Failed to check product variants", err)
+		}
+		if variantCount > 0 {
+			return utils.NewBadRequestResource("Product has variants without attribute values, remove them before generating variants", nil)
+		}
+	}
+
+	combos := variantCombinations(attrs)
+	if len(combos) > maxGeneratedVariants {
+		return utils.NewBadRequestResource(fmt.Sprintf("Selection makes %d variants, the limit is %d", len(combos), maxGeneratedVariants), nil)
+	}
+
+	// Stock applies to the stores the product is listed in
+	sfIDs, err := s.repo.GetProductStoreFrontIDs(productID)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load product store fronts", err)
+	}
+	stock := make(map[int64]int, len(sfIDs))
+	for _, id := range sfIDs {
+		stock[id] = 0
+	}
+	trackingMode := trackingModeOrDefault(req.TrackingMode)
+	for _, st := range req.Stock {
+		if _, ok := stock[st.StoreFrontID]; !ok {
+			return utils.NewBadRequestResource(fmt.Sprintf("Product is not listed in store front %d", st.StoreFrontID), nil)
+		}
+		if st.Quantity > 0 && trackingMode != models.TrackingModeNone {
+			return utils.NewBadRequestResource(fmt.Sprintf("Variants are %s-tracked, receive their stock through an inventory adjustment", trackingMode), nil)
+		}
+		stock[st.StoreFrontID] = st.Quantity
+	}
+
+	existingKeys, err := combinationKeys(s.db, productID, 0)
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to load existing variants", err)
+	}
+
+	result := GenerateVariantsResult{Created: []GeneratedVariant{}, Skipped: []SkippedVariant{}}
+	var pending []*models.ProductVariant
+	pendingCombos := make(map[*models.ProductVariant][]models.AttributeValue)
+	batchSKUs := make(map[string]bool)
+
+	for i, combo := range combos {
+		valueIDs := make([]int64, len(combo))
+		for j, v := range combo {
+			valueIDs[j] = v.ID
+		}
+		label := combinationLabel(combo)
+		sku := renderVariantSKU(req.SKUTemplate, product, i+1, attrs, combo)
+
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, SkippedVariant{SKU: sku, AttributeValue: label, Reason: reason})
+		}
+		if _, exists := existingKeys[combinationKey(valueIDs)]; exists {
+			skip("variant already exists")
+			continue
+		}
+		if sku == "" || batchSKUs[sku] {
+			skip("sku template does not give a unique sku")
+			continue
+		}
+		unique, err := s.repo.IsSKUUnique(sku, 0)
+		if err != nil {
+			return utils.NewInternalErrorResource("Failed to validate SKU", err)
+		}
+		if !unique {
+			skip("sku already exists")
+			continue
+		}
+		batchSKUs[sku] = true
+
+		variant := &models.ProductVariant{
+			ProductID:      productID,
+			SKU:            sku,
+			AttributeValue: label,
+			Price:          req.Price,
+			CompareAtPrice: req.CompareAtPrice,
+			CostPrice:      req.CostPrice,
+			Weight:         req.Weight,
+			IsActive:       req.IsActive == nil || *req.IsActive,
+			TrackingMode:   trackingMode,
+		}
+		pending = append(pending, variant)
+		pendingCombos[variant] = combo
+	}
+
+	if len(pending) == 0 {
+		return utils.NewOKResource("No new variants to generate", result)
+	}
+
+	err = s.invRepo.Transaction(s.db, func(tx *gorm.DB) error {
+		repoTx := &V2Repository{db: tx}
+
+		if len(productAttrs) == 0 {
+			if err := setProductAttributes(tx, productID, selected); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", productID).Update("attribute_type", attributeTypeOf(selected)).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, variant := range pending {
+			if err := repoTx.CreateVariantV2(variant); err != nil {
+				return fmt.Errorf("failed to create variant %s: %w", variant.SKU, err)
+			}
+			if err := linkVariantValues(tx, productID, variant.ID, pendingCombos[variant]); err != nil {
+				return err
+			}
+			err := s.createVariantInventory(tx, product, variant, sfIDs, func(inv *models.VariantInventory) {
+				inv.Quantity = stock[inv.StoreFrontID]
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Created = append(result.Created, GeneratedVariant{ID: variant.ID, SKU: variant.SKU, AttributeValue: variant.AttributeValue})
+		}
+		return recordRevision(tx, productID, models.ProductRevisionVariantCreate, adminID, nil)
+	})
+	if err != nil {
+		return utils.NewInternalErrorResource("Failed to generate variants", err)
+	}
+
+	return utils.NewCreatedResource(fmt.Sprintf("%d variants generated", len(result.Created)), result)
+}
@@ -0,0 +1,467 @@
+package products
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// StorefrontOptionGroup is an attribute shoppers pick a value of, e.g. size, with the values on sale
+type StorefrontOptionGroup struct {
+	AttributeID int64                   `json:"attribute_id"`
+	NameEn      string                  `json:"name_en"`
+	NameAr      string                  `json:"name_ar"`
+	Values      []StorefrontOptionValue `json:"values"`
+}
+
+// StorefrontOptionValue is one selectable value of an option group
+type StorefrontOptionValue struct {
+	ID      int64  `json:"id"`
+	ValueEn string `json:"value_en"`
+	ValueAr string `json:"value_ar"`
+}
+
+// productAttributes returns the attributes a product's variants vary by, in display order
+func productAttributes(db *gorm.DB, productID int64) ([]models.Attribute, error) {
+	var attrs []models.Attribute
+	err := db.Table("attributes a").
+		Joins("JOIN product_attributes pa ON pa.attribute_id = a.id").
+		Where("pa.product_id = ? AND a.deleted_at IS NULL", productID).
+		Order("pa.sort_order ASC, pa.id ASC").
+		Select("a.*").
+		Find(&attrs).Error
+	return attrs, err
+}
+
+// resolveProductAttributes loads the attributes selected for a product in request order.
+// The single attribute_type of older clients is used when attribute_ids is empty.
+func (s *ServiceV2) resolveProductAttributes(ids []int64, legacyType *int64) ([]models.Attribute, error) {
+	if len(ids) == 0 && legacyType != nil {
+		ids = []int64{*legacyType}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var found []models.Attribute
+	if err := s.db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.Attribute, len(found))
+	for _, a := range found {
+		byID[a.ID] = a
+	}
+
+	attrs := make([]models.Attribute, 0, len(ids))
+	seen := make(map[int64]bool)
+	for _, id := range ids {
+		a, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("invalid attribute id: %d", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("attribute %d is listed more than once", id)
+		}
+		seen[id] = true
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
+
+// attributeTypeOf is the legacy attribute_type column: the first attribute's lowercased
+// English name, kept so list filters and older clients keep working
+func attributeTypeOf(attrs []models.Attribute) *string {
+	if len(attrs) == 0 {
+		return nil
+	}
+	name := strings.ToLower(attrs[0].NameEn)
+	return &name
+}
+
+// sameAttributeSet reports whether two attribute lists hold the same attributes in any order
+func sameAttributeSet(a, b []models.Attribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := make(map[int64]bool, len(a))
+	for _, attr := range a {
+		ids[attr.ID] = true
+	}
+	for _, attr := range b {
+		if !ids[attr.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// setProductAttributes replaces the attributes of a product and drops allowed values of removed ones
+func setProductAttributes(tx *gorm.DB, productID int64, attrs []models.Attribute) error {
+	if err := tx.Where("product_id = ?", productID).Delete(&models.ProductAttribute{}).Error; err != nil {
+		return err
+	}
+	attrIDs := make([]int64, 0, len(attrs))
+	for i, a := range attrs {
+		pa := models.ProductAttribute{ProductID: productID, AttributeID: a.ID, SortOrder: i}
+		if err := tx.Create(&pa).Error; err != nil {
+			return err
+		}
+		attrIDs = append(attrIDs, a.ID)
+	}
+
+	stale := tx.Where("product_id = ?", productID)
+	if len(attrIDs) > 0 {
+		stale = stale.Where("attribute_value_id NOT IN (?)", tx.Unscoped().Model(&models.AttributeValue{}).Select("id").Where("attribute_id IN ?", attrIDs))
+	}
+	return stale.Delete(&models.ProductAttributeValue{}).Error
+}
+
+// resolveVariantValues returns the values of a variant ordered like the product's attributes.
+// Variants name one value per attribute by ID. The free-text attribute_value of older clients
+// and imports, "S / Red" for several attributes, is matched to or added as attribute values.
+func resolveVariantValues(tx *gorm.DB, attrs []models.Attribute, valueIDs []int64, label string) ([]models.AttributeValue, error) {
+	if len(attrs) == 0 {
+		if len(valueIDs) > 0 || label != "" {
+			return nil, fmt.Errorf("simple product cannot have attribute values")
+		}
+		return nil, nil
+	}
+
+	if len(valueIDs) == 0 {
+		parts := []string{label}
+		if len(attrs) > 1 {
+			parts = strings.Split(label, " / ")
+		}
+		if label == "" || len(parts) != len(attrs) {
+			names := make([]string, len(attrs))
+			for i, a := range attrs {
+				names[i] = a.NameEn
+			}
+			return nil, fmt.Errorf("variant must specify attribute_value_ids for %s", strings.Join(names, ", "))
+		}
+
+		values := make([]models.AttributeValue, 0, len(attrs))
+		for i, a := range attrs {
+			value, err := findOrCreateAttributeValue(tx, a.ID, strings.TrimSpace(parts[i]))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, *value)
+		}
+		return values, nil
+	}
+
+	var found []models.AttributeValue
+	if err := tx.Where("id IN ?", valueIDs).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	if len(found) != len(valueIDs) {
+		return nil, fmt.Errorf("one or more attribute values are invalid")
+	}
+	byAttr := make(map[int64]models.AttributeValue, len(found))
+	for _, v := range found {
+		if _, dup := byAttr[v.AttributeID]; dup {
+			return nil, fmt.Errorf("duplicate attribute in variant definition")
+		}
+		byAttr[v.AttributeID] = v
+	}
+
+	values := make([]models.AttributeValue, 0, len(attrs))
+	for _, a := range attrs {
+		v, ok := byAttr[a.ID]
+		if !ok {
+			return nil, fmt.Errorf("variant must specify a %s value", a.NameEn)
+		}
+		values = append(values, v)
+	}
+	if len(values) != len(found) {
+		return nil, fmt.Errorf("one or more attribute values are not allowed for this product")
+	}
+	return values, nil
+}
+
+// findOrCreateAttributeValue matches a free-text value to an attribute value by name
+func findOrCreateAttributeValue(tx *gorm.DB, attributeID int64, label string) (*models.AttributeValue, error) {
+	var value models.AttributeValue
+	err := tx.Where("attribute_id = ? AND (LOWER(value_en) = LOWER(?) OR value_ar = ?)", attributeID, label, label).
+		Order("id ASC").
+		First(&value).Error
+	if err == nil {
+		return &value, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	value = models.AttributeValue{AttributeID: attributeID, ValueEn: label, ValueAr: label, IsActive: true}
+	if err := tx.Create(&value).Error; err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// combinationKeys maps the attribute value set of each variant of a product to the variant ID
+func combinationKeys(db *gorm.DB, productID, excludeVariantID int64) (map[string]int64, error) {
+	var links []models.ProductVariantAttributeValue
+	query := db.Table("product_variant_attribute_values pvav").
+		Joins("JOIN product_variants pv ON pv.id = pvav.product_variant_id").
+		Where("pv.product_id = ? AND pv.deleted_at IS NULL", productID)
+	if excludeVariantID > 0 {
+		query = query.Where("pv.id <> ?", excludeVariantID)
+	}
+	if err := query.Select("pvav.*").Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	byVariant := make(map[int64][]int64)
+	for _, l := range links {
+		byVariant[l.ProductVariantID] = append(byVariant[l.ProductVariantID], l.AttributeValueID)
+	}
+	keys := make(map[string]int64, len(byVariant))
+	for variantID, valueIDs := range byVariant {
+		keys[combinationKey(valueIDs)] = variantID
+	}
+	return keys, nil
+}
+
+// ensureUniqueCombination rejects a value combination another variant of the product already has
+func ensureUniqueCombination(tx *gorm.DB, productID, excludeVariantID int64, values []models.AttributeValue) error {
+	if len(values) == 0 {
+		return nil
+	}
+	keys, err := combinationKeys(tx, productID, excludeVariantID)
+	if err != nil {
+		return err
+	}
+	valueIDs := make([]int64, len(values))
+	for i, v := range values {
+		valueIDs[i] = v.ID
+	}
+	if _, exists := keys[combinationKey(valueIDs)]; exists {
+		return fmt.Errorf("a variant with %s already exists", combinationLabel(values))
+	}
+	return nil
+}
+
+// linkVariantValues replaces the values of a variant and allows them on its product
+func linkVariantValues(tx *gorm.DB, productID, variantID int64, values []models.AttributeValue) error {
+	if err := tx.Where("product_variant_id = ?", variantID).Delete(&models.ProductVariantAttributeValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	var allowed []int64
+	if err := tx.Model(&models.ProductAttributeValue{}).Where("product_id = ?", productID).Pluck("attribute_value_id", &allowed).Error; err != nil {
+		return err
+	}
+	isAllowed := make(map[int64]bool, len(allowed))
+	for _, id := range allowed {
+		isAllowed[id] = true
+	}
+
+	for _, v := range values {
+		if err := tx.Create(&models.ProductVariantAttributeValue{ProductVariantID: variantID, AttributeValueID: v.ID}).Error; err != nil {
+			return err
+		}
+		if !isAllowed[v.ID] {
+			if err := tx.Create(&models.ProductAttributeValue{ProductID: productID, AttributeValueID: v.ID}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// assignVariantValues resolves, checks and stores the attribute values of a variant. The
+// variant's attribute_value label is set from them, so callers save the variant afterwards.
+func assignVariantValues(tx *gorm.DB, attrs []models.Attribute, variant *models.ProductVariant, valueIDs []int64, label string) ([]models.AttributeValue, error) {
+	values, err := resolveVariantValues(tx, attrs, valueIDs, label)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureUniqueCombination(tx, variant.ProductID, variant.ID, values); err != nil {
+		return nil, err
+	}
+	variant.AttributeValue = combinationLabel(values)
+	return values, nil
+}
+
+// variantAttributeValues returns the values of variants keyed by variant ID in attribute order
+func variantAttributeValues(db *gorm.DB, productID int64, variantIDs []int64) (map[int64][]AdminVariantAttributeValue, error) {
+	result := make(map[int64][]AdminVariantAttributeValue, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		ProductVariantID int64
+		AttributeID      int64
+		AttributeName    string
+		ValueID          int64
+		Value            string
+	}
+	err := db.Table("product_variant_attribute_values pvav").
+		Joins("JOIN attribute_values av ON av.id = pvav.attribute_value_id").
+		Joins("JOIN attributes a ON a.id = av.attribute_id").
+		Joins("LEFT JOIN product_attributes pa ON pa.attribute_id = a.id AND pa.product_id = ?", productID).
+		Where("pvav.product_variant_id IN ?", variantIDs).
+		Order("pa.sort_order ASC, a.id ASC").
+		Select("pvav.product_variant_id, a.id AS attribute_id, a.name_en AS attribute_name, av.id AS value_id, av.value_en AS value").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		result[r.ProductVariantID] = append(result[r.ProductVariantID], AdminVariantAttributeValue{
+			AttributeID:   r.AttributeID,
+			AttributeName: r.AttributeName,
+			ValueID:       r.ValueID,
+			Value:         r.Value,
+		})
+	}
+	return result, nil
+}
+
+// storefrontOptions builds the option groups of a product from the values its listed
+// variants use, and returns each variant's value IDs in option order
+func (r *V2Repository) storefrontOptions(productID int64, variantIDs []int64) ([]StorefrontOptionGroup, map[int64][]int64, error) {
+	groups := []StorefrontOptionGroup{}
+	byVariant := make(map[int64][]int64, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return groups, byVariant, nil
+	}
+
+	attrs, err := productAttributes(r.db, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var links []models.ProductVariantAttributeValue
+	if err := r.db.Where("product_variant_id IN ?", variantIDs).Find(&links).Error; err != nil {
+		return nil, nil, err
+	}
+	valueIDs := make([]int64, 0, len(links))
+	for _, l := range links {
+		valueIDs = append(valueIDs, l.AttributeValueID)
+	}
+	var values []models.AttributeValue
+	if len(valueIDs) > 0 {
+		if err := r.db.Where("id IN ?", valueIDs).Order("sort_order ASC, id ASC").Find(&values).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	valueByID := make(map[int64]models.AttributeValue, len(values))
+	for _, v := range values {
+		valueByID[v.ID] = v
+	}
+
+	position := make(map[int64]int, len(attrs))
+	for i, a := range attrs {
+		position[a.ID] = i
+		groups = append(groups, StorefrontOptionGroup{AttributeID: a.ID, NameEn: a.NameEn, NameAr: a.NameAr, Values: []StorefrontOptionValue{}})
+	}
+	for _, v := range values {
+		if i, ok := position[v.AttributeID]; ok {
+			groups[i].Values = append(groups[i].Values, StorefrontOptionValue{ID: v.ID, ValueEn: v.ValueEn, ValueAr: v.ValueAr})
+		}
+	}
+
+	for _, l := range links {
+		byVariant[l.ProductVariantID] = append(byVariant[l.ProductVariantID], l.AttributeValueID)
+	}
+	for id, ids := range byVariant {
+		sort.Slice(ids, func(i, j int) bool {
+			return position[valueByID[ids[i]].AttributeID] < position[valueByID[ids[j]].AttributeID]
+		})
+		byVariant[id] = ids
+	}
+	return groups, byVariant, nil
+}
+
+// adminVariantValues pairs values resolved for a variant with the product attributes they belong to
+func adminVariantValues(attrs []models.Attribute, values []models.AttributeValue) []AdminVariantAttributeValue {
+	names := make(map[int64]string, len(attrs))
+	for _, a := range attrs {
+		names[a.ID] = a.NameEn
+	}
+	result := make([]AdminVariantAttributeValue, 0, len(values))
+	for _, v := range values {
+		result = append(result, AdminVariantAttributeValue{
+			AttributeID:   v.AttributeID,
+			AttributeName: names[v.AttributeID],
+			ValueID:       v.ID,
+			Value:         v.ValueEn,
+		})
+	}
+	return result
+}
+
+func valuesOrEmpty(values []AdminVariantAttributeValue) []AdminVariantAttributeValue {
+	if values == nil {
+		return []AdminVariantAttributeValue{}
+	}
+	return values
+}
+
+// attachOptionValues sets the option value IDs of storefront variants
+func attachOptionValues(variants []StorefrontVariant, byVariant map[int64][]int64) {
+	for i := range variants {
+		variants[i].OptionValueIDs = byVariant[variants[i].ID]
+		if variants[i].OptionValueIDs == nil {
+			variants[i].OptionValueIDs = []int64{}
+		}
+	}
+}
+
+// adminProductAttributes returns a product's attributes with the values allowed for its variants
+func adminProductAttributes(db *gorm.DB, productID int64) ([]AdminProductAttribute, error) {
+	attrs, err := productAttributes(db, productID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]AdminProductAttribute, 0, len(attrs))
+	if len(attrs) == 0 {
+		return result, nil
+	}
+
+	var values []models.AttributeValue
+	err = db.Table("attribute_values av").
+		Joins("JOIN product_attribute_values pav ON pav.attribute_value_id = av.id").
+		Where("pav.product_id = ?", productID).
+		Order("av.sort_order ASC, av.id ASC").
+		Select("av.*").
+		Find(&values).Error
+	if err != nil {
+		return nil, err
+	}
+
+	position := make(map[int64]int, len(attrs))
+	for i, a := range attrs {
+		position[a.ID] = i
+		result = append(result, AdminProductAttribute{
+			AttributeID:   a.ID,
+			Name:          a.NameEn,
+			SortOrder:     i,
+			AllowedValues: []AdminAttributeValue{},
+		})
+	}
+	for _, v := range values {
+		i, ok := position[v.AttributeID]
+		if !ok {
+			continue
+		}
+		result[i].AllowedValues = append(result[i].AllowedValues, AdminAttributeValue{
+			ID:            v.ID,
+			AttributeID:   v.AttributeID,
+			AttributeName: attrs[i].NameEn,
+			Value:         v.ValueEn,
+			SortOrder:     v.SortOrder,
+		})
+	}
+	return result, nil
+}
@@ -19,6 +19,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.User{},
 		&models.Admin{},
 		&models.File{},
+		&models.FileDerivative{},
 		&models.Section{},
 		&models.Category{},
 		&models.Customer{},
@@ -28,8 +29,29 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.ProductImage{},
 		&models.VariantInventory{},
 		&models.InventoryAdjustment{},
+		&models.InventoryMovement{},
+		&models.BundleComponent{},
+		&models.SerialNumber{},
+		&models.StockLot{},
+		&models.StockHold{},
+		&models.StockHoldItem{},
+		&models.StockHoldItemComponent{},
+		&models.CatalogImportJob{},
+		&models.CustomerGroup{},
+		&models.PriceList{},
+		&models.PriceListItem{},
+		&models.PriceSchedule{},
+		&models.ProductRevision{},
+		&models.ProductSlugRedirect{},
+		&models.ProductTag{},
+		&models.Collection{},
+		&models.CollectionProduct{},
+		&models.ProductRelation{},
+		&models.ProductReview{},
+		&models.ProductReviewImage{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderItemComponent{},
 		&models.OrderStatus{},
 		&models.PaymentStatus{},
 		&models.FulfillmentStatus{},
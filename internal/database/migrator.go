@@ -214,20 +214,34 @@ func (m *Migrator) isEmptyMigration(sql string) bool {
 	return true
 }
 
-// splitSQL splits SQL content into individual statements
+// splitSQL splits SQL content into individual statements. Semicolons inside
+// dollar-quoted bodies ($$ ... $$) of functions and triggers do not split.
 func (m *Migrator) splitSQL(sql string) []string {
-	// Simple split by semicolon - this might need to be more sophisticated
-	// for complex SQL with semicolons in strings, etc.
-	statements := strings.Split(sql, ";")
-	
 	var result []string
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt != "" {
+	var current strings.Builder
+	inDollarQuote := false
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
 			result = append(result, stmt)
 		}
+		current.Reset()
 	}
-	
+
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case strings.HasPrefix(sql[i:], "$$"):
+			inDollarQuote = !inDollarQuote
+			current.WriteString("$$")
+			i++
+		case sql[i] == ';' && !inDollarQuote:
+			flush()
+		default:
+			current.WriteByte(sql[i])
+		}
+	}
+	flush()
+
 	return result
 }
 
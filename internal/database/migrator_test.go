@@ -0,0 +1,36 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSQL_KeepsDollarQuotedBodies(t *testing.T) {
+	sql := `CREATE TABLE a (id INT);
+
+CREATE OR REPLACE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_touch BEFORE UPDATE ON a FOR EACH ROW EXECUTE FUNCTION touch();
+`
+	got := (&Migrator{}).splitSQL(sql)
+	want := []string{
+		"CREATE TABLE a (id INT)",
+		"CREATE OR REPLACE FUNCTION touch() RETURNS trigger AS $$\nBEGIN\n    NEW.updated_at = NOW();\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql",
+		"CREATE TRIGGER trg_touch BEFORE UPDATE ON a FOR EACH ROW EXECUTE FUNCTION touch()",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected statements:\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSplitSQL_SkipsEmptyStatements(t *testing.T) {
+	got := (&Migrator{}).splitSQL("SELECT 1;;\n  ;\nSELECT 2")
+	if want := []string{"SELECT 1", "SELECT 2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AttributeValue is one selectable value of a global attribute, such as "XL" for size
+type AttributeValue struct {
+	ID          int64          `gorm:"primaryKey" json:"id"`
+	AttributeID int64          `gorm:"type:bigint;not null" json:"attribute_id"`
+	ValueAr     string         `gorm:"type:varchar(100);not null" json:"value_ar"`
+	ValueEn     string         `gorm:"type:varchar(100);not null" json:"value_en"`
+	SortOrder   int            `gorm:"not null;default:0" json:"sort_order"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Attribute *Attribute `gorm:"foreignKey:AttributeID" json:"-"`
+}
+
+func (AttributeValue) TableName() string { return "attribute_values" }
+
+// ProductAttribute lists an attribute a product's variants vary by
+type ProductAttribute struct {
+	ID          int64     `gorm:"primaryKey" json:"id"`
+	ProductID   int64     `gorm:"type:bigint;not null" json:"product_id"`
+	AttributeID int64     `gorm:"type:bigint;not null" json:"attribute_id"`
+	SortOrder   int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (ProductAttribute) TableName() string { return "product_attributes" }
+
+// ProductAttributeValue allows an attribute value for a product's variants
+type ProductAttributeValue struct {
+	ID               int64     `gorm:"primaryKey" json:"id"`
+	ProductID        int64     `gorm:"type:bigint;not null" json:"product_id"`
+	AttributeValueID int64     `gorm:"type:bigint;not null" json:"attribute_value_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (ProductAttributeValue) TableName() string { return "product_attribute_values" }
+
+// ProductVariantAttributeValue links a variant to one value of its attribute combination
+type ProductVariantAttributeValue struct {
+	ID               int64     `gorm:"primaryKey" json:"id"`
+	ProductVariantID int64     `gorm:"type:bigint;not null" json:"product_variant_id"`
+	AttributeValueID int64     `gorm:"type:bigint;not null" json:"attribute_value_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (ProductVariantAttributeValue) TableName() string { return "product_variant_attribute_values" }
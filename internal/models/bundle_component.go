@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// BundleComponent links a bundle variant to one of the variants it is made of
+type BundleComponent struct {
+	ID                 int64     `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	BundleVariantID    int64     `gorm:"type:bigint;not null;index" json:"bundle_variant_id"`
+	ComponentVariantID int64     `gorm:"type:bigint;not null;index" json:"component_variant_id"`
+	Quantity           int       `gorm:"not null;default:1" json:"quantity"`
+	CreatedAt          time.Time `json:"created_at"`
+
+	// Relations
+	ComponentVariant *ProductVariant `gorm:"foreignKey:ComponentVariantID" json:"component_variant,omitempty"`
+}
+
+func (BundleComponent) TableName() string { return "bundle_components" }
+
+// BundleAvailableQuantity returns how many bundles can be assembled from the
+// available stock of their components, keyed by component variant ID
+func BundleAvailableQuantity(components []BundleComponent, stock map[int64]VariantInventory) int {
+	if len(components) == 0 {
+		return 0
+	}
+
+	available := -1
+	for _, c := range components {
+		if c.Quantity <= 0 {
+			continue
+		}
+		inv := stock[c.ComponentVariantID]
+		n := max(inv.AvailableQuantity(), 0) / c.Quantity
+		if available < 0 || n < available {
+			available = n
+		}
+	}
+	return max(available, 0)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Catalog import job statuses
+const (
+	CatalogImportPending   = "pending"
+	CatalogImportRunning   = "running"
+	CatalogImportCompleted = "completed"
+	CatalogImportFailed    = "failed"
+)
+
+// CatalogImportJob tracks a background product catalog import and its per-row report
+type CatalogImportJob struct {
+	ID              int64      `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	FileName        string     `gorm:"type:varchar(255);not null" json:"file_name"`
+	DryRun          bool       `gorm:"not null;default:true" json:"dry_run"`
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	TotalRows       int        `gorm:"not null;default:0" json:"total_rows"`
+	ProcessedRows   int        `gorm:"not null;default:0" json:"processed_rows"`
+	CreatedProducts int        `gorm:"not null;default:0" json:"created_products"`
+	UpdatedProducts int        `gorm:"not null;default:0" json:"updated_products"`
+	CreatedVariants int        `gorm:"not null;default:0" json:"created_variants"`
+	UpdatedVariants int        `gorm:"not null;default:0" json:"updated_variants"`
+	ErrorRows       int        `gorm:"not null;default:0" json:"error_rows"`
+	Report          string     `gorm:"type:text" json:"-"` // JSON encoded row results
+	Error           string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedBy       int64      `gorm:"type:bigint;not null" json:"created_by"`
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (CatalogImportJob) TableName() string { return "catalog_import_jobs" }
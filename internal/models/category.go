@@ -6,19 +6,28 @@ import (
 	"gorm.io/gorm"
 )
 
-// Category represents a product category in the system
+// Category represents a product category in the system. Categories nest under a parent
+// within their section and are shown in the stores listed in category_storefront.
 type Category struct {
-	ID        int64          `json:"id"`
-	SectionID int64          `json:"section_id"`
-	NameAr    string         `json:"name_ar"`
-	NameEn    string         `json:"name_en"`
-	IconID    int64          `json:"icon_id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty"`
+	ID                int64          `json:"id"`
+	SectionID         int64          `json:"section_id"`
+	ParentID          *int64         `gorm:"index" json:"parent_id"`
+	Position          int            `gorm:"not null;default:0" json:"position"`
+	NameAr            string         `json:"name_ar"`
+	NameEn            string         `json:"name_en"`
+	SlugEn            string         `gorm:"type:varchar(255);not null;default:''" json:"slug_en"`
+	SlugAr            string         `gorm:"type:varchar(255);not null;default:''" json:"slug_ar"`
+	MetaTitleEn       string         `gorm:"type:varchar(255);not null;default:''" json:"meta_title_en"`
+	MetaTitleAr       string         `gorm:"type:varchar(255);not null;default:''" json:"meta_title_ar"`
+	MetaDescriptionEn string         `gorm:"type:text;not null;default:''" json:"meta_description_en"`
+	MetaDescriptionAr string         `gorm:"type:text;not null;default:''" json:"meta_description_ar"`
+	IconID            int64          `json:"icon_id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty"`
 }
 
 // TableName specifies the table name for Category
 func (Category) TableName() string {
 	return "categories"
-}
\ No newline at end of file
+}
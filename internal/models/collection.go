@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Collection types
+const (
+	CollectionTypeManual    = "manual"    // Hand-picked products in a set order
+	CollectionTypeAutomatic = "automatic" // Products matching the collection's rules
+)
+
+// Collection groups a store's products for merchandising, such as "Ramadan offers".
+// Rules of automatic collections are evaluated on every read, so products join and
+// leave the collection as their prices, stock and details change.
+type Collection struct {
+	ID            int64          `gorm:"primaryKey" json:"id"`
+	StoreFrontID  int64          `gorm:"type:bigint;not null;uniqueIndex:idx_collections_store_slug,where:deleted_at IS NULL" json:"store_front_id"`
+	NameEn        string         `gorm:"type:varchar(255);not null" json:"name_en"`
+	NameAr        string         `gorm:"type:varchar(255);not null" json:"name_ar"`
+	Slug          string         `gorm:"type:varchar(255);not null;uniqueIndex:idx_collections_store_slug,where:deleted_at IS NULL" json:"slug"`
+	DescriptionEn string         `gorm:"type:text" json:"description_en"`
+	DescriptionAr string         `gorm:"type:text" json:"description_ar"`
+	Type          string         `gorm:"type:varchar(20);not null;default:'manual'" json:"type"`
+	Rules         string         `gorm:"type:text" json:"-"` // JSON CollectionRules, automatic collections only
+	IsActive      bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Collection) TableName() string { return "collections" }
+
+// CollectionRules select the products of an automatic collection. Every set rule must
+// match; a product matches a list rule when it matches any entry of the list.
+type CollectionRules struct {
+	MinPrice          *float64   `json:"min_price,omitempty"`
+	MaxPrice          *float64   `json:"max_price,omitempty"`
+	BrandIDs          []int64    `json:"brand_ids,omitempty"`
+	CategoryIDs       []int64    `json:"category_ids,omitempty"` // Subcategories included
+	Tags              []string   `json:"tags,omitempty"`
+	InStock           *bool      `json:"in_stock,omitempty"`
+	CreatedAfter      *time.Time `json:"created_after,omitempty"`
+	CreatedWithinDays *int       `json:"created_within_days,omitempty"`
+}
+
+// CollectionProduct places a product in a manual collection
+type CollectionProduct struct {
+	ID           int64     `gorm:"primaryKey" json:"id"`
+	CollectionID int64     `gorm:"type:bigint;not null;uniqueIndex:idx_collection_products_collection_product" json:"collection_id"`
+	ProductID    int64     `gorm:"type:bigint;not null;uniqueIndex:idx_collection_products_collection_product;index" json:"product_id"`
+	Position     int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (CollectionProduct) TableName() string { return "collection_products" }
@@ -19,6 +19,9 @@ type Customer struct {
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
+	// Pricing
+	CustomerGroupID *int64 `json:"customer_group_id,omitempty" gorm:"index"` // Nullable, selects the group's price lists
+
 	// Relations
 	Orders []Order `json:"orders,omitempty" gorm:"foreignKey:CustomerID"`
 }
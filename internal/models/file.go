@@ -16,9 +16,12 @@ type File struct {
 	MimeType     string         `json:"mime_type" gorm:"type:varchar(100);not null"`
 	FileType     string         `json:"file_type" gorm:"type:varchar(50);not null"` // document, spreadsheet, image
 	Extension    string         `json:"extension" gorm:"type:varchar(10);not null"`
-	UploadedBy   int64          `json:"uploaded_by" gorm:"type:bigint;not null"`
+	UploadedBy   *int64         `json:"uploaded_by" gorm:"type:bigint"` // Admin, nil for storefront uploads
+	UploadedByCustomerID *int64 `json:"uploaded_by_customer_id,omitempty" gorm:"type:bigint;index"`
 	UploadedByAdmin *Admin      `json:"uploaded_by_admin,omitempty" gorm:"foreignKey:UploadedBy;references:ID"`
 	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	Derivatives  []FileDerivative `json:"derivatives,omitempty" gorm:"foreignKey:FileID"`
+	DerivativesGeneratedAt *time.Time `json:"derivatives_generated_at,omitempty"` // Set once an image was scaled, even to no derivatives
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Image derivative sizes, by the width they are scaled down to
+const (
+	ImageSizeThumb  = "thumb"
+	ImageSizeMedium = "medium"
+	ImageSizeLarge  = "large"
+)
+
+// Image derivative formats
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+	ImageFormatWebP = "webp"
+)
+
+// FileDerivative is a scaled down copy of an image file, stored next to the original
+type FileDerivative struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	FileID    int64     `gorm:"type:bigint;not null;uniqueIndex:idx_file_derivatives_unique" json:"file_id"`
+	Size      string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_file_derivatives_unique" json:"size"`
+	Format    string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_file_derivatives_unique" json:"format"`
+	FilePath  string    `gorm:"type:varchar(500);not null" json:"file_path"`
+	MimeType  string    `gorm:"type:varchar(100);not null" json:"mime_type"`
+	Width     int       `gorm:"not null" json:"width"`
+	Height    int       `gorm:"not null" json:"height"`
+	FileSize  int64     `gorm:"not null" json:"file_size"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Movement type constants
+const (
+	MovementTypeOpeningBalance = "opening_balance"
+	MovementTypeInitialStock   = "initial_stock"
+	MovementTypeAdjustment     = "adjustment"
+	MovementTypeReserve        = "reserve"
+	MovementTypeRelease        = "release"
+	MovementTypeSale           = "sale"
+	MovementTypeRebuild        = "rebuild"
+)
+
+// Movement source document constants
+const (
+	MovementSourceOrder         = "order"
+	MovementSourceTransfer      = "transfer"
+	MovementSourcePurchaseOrder = "purchase_order"
+	MovementSourceStockCount    = "stock_count"
+	MovementSourceAdjustment    = "adjustment"
+	MovementSourceProduct       = "product"
+	MovementSourceSystem        = "system"
+	MovementSourceHold          = "hold"
+)
+
+// InventoryMovement is an append-only ledger entry for every change to
+// quantity or reserved_quantity of a VariantInventory row
+type InventoryMovement struct {
+	ID                 int64     `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	VariantInventoryID int64     `gorm:"type:bigint;not null;index" json:"variant_inventory_id"`
+	ProductVariantID   int64     `gorm:"type:bigint;not null;index" json:"product_variant_id"`
+	StoreFrontID       int64     `gorm:"type:bigint;not null;index" json:"store_front_id"`
+	MovementType       string    `gorm:"type:varchar(30);not null" json:"movement_type"`
+	SourceType         string    `gorm:"type:varchar(30);not null" json:"source_type"`
+	SourceID           *int64    `gorm:"type:bigint" json:"source_id"`
+	QuantityBefore     int       `gorm:"not null" json:"quantity_before"`
+	QuantityAfter      int       `gorm:"not null" json:"quantity_after"`
+	QuantityChange     int       `gorm:"not null" json:"quantity_change"`
+	ReservedBefore     int       `gorm:"not null" json:"reserved_before"`
+	ReservedAfter      int       `gorm:"not null" json:"reserved_after"`
+	ReservedChange     int       `gorm:"not null" json:"reserved_change"`
+	PerformedBy        *int64    `gorm:"type:bigint" json:"performed_by"`
+	Notes              string    `gorm:"type:text" json:"notes"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+func (InventoryMovement) TableName() string { return "inventory_movements" }
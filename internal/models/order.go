@@ -92,9 +92,24 @@ type OrderItem struct {
 	UnitPrice             float64 `json:"unit_price" gorm:"not null"`
 	CostPrice             float64 `json:"cost_price" gorm:"not null;default:0"`
 	Quantity              int     `json:"quantity" gorm:"not null"`
+	BackorderedQuantity   int     `json:"backordered_quantity" gorm:"not null;default:0"` // Units waiting for stock
 	TotalPrice            float64 `json:"total_price" gorm:"not null"`
+	StockLotID            *int64  `json:"stock_lot_id" gorm:"index"` // Lot shipped, for lot-tracked variants
+	LotNumber             *string `json:"lot_number" gorm:"size:100"`
 
 	// Associations
-	Product        *Product        `json:"product,omitempty" gorm:"foreignKey:ProductID"`
-	ProductVariant *ProductVariant `json:"product_variant,omitempty" gorm:"foreignKey:ProductVariantID"`
+	Product        *Product             `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	ProductVariant *ProductVariant      `json:"product_variant,omitempty" gorm:"foreignKey:ProductVariantID"`
+	Components     []OrderItemComponent `json:"components,omitempty" gorm:"foreignKey:OrderItemID"`
+	Serials        []SerialNumber       `json:"serials,omitempty" gorm:"foreignKey:OrderItemID"`
+}
+
+// OrderItemComponent snapshots the component variants consumed by a bundle order item
+type OrderItemComponent struct {
+	ID                int64  `json:"id" gorm:"primaryKey"`
+	OrderItemID       int64  `json:"order_item_id" gorm:"index;not null"`
+	ProductVariantID  int64  `json:"product_variant_id" gorm:"index;not null"`
+	SKU               string `json:"sku" gorm:"size:64;not null"`
+	QuantityPerBundle int    `json:"quantity_per_bundle" gorm:"not null"`
+	Quantity          int    `json:"quantity" gorm:"not null"` // QuantityPerBundle x item quantity
 }
@@ -4,6 +4,8 @@ package models
 type ProductStorefront struct {
 	ProductID    int64 `gorm:"primaryKey" json:"product_id"`
 	StoreFrontID int64 `gorm:"primaryKey" json:"store_front_id"`
+	IsBestSeller bool  `gorm:"not null;default:false" json:"is_best_seller"` // Set by the merchandising job
+	InStock      bool  `gorm:"not null;default:false" json:"in_stock"`       // Kept current with the stock by the availability projection
 }
 
 func (ProductStorefront) TableName() string { return "product_storefront" }
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// CustomerGroup segments customers for group-specific pricing, such as wholesale buyers
+type CustomerGroup struct {
+	ID          int64     `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
+	Slug        string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (CustomerGroup) TableName() string { return "customer_groups" }
+
+// PriceList overrides variant prices for one store and currency. A list with a
+// customer group only applies to that group's customers and wins over the
+// store's list without a group.
+type PriceList struct {
+	ID              int64     `gorm:"primaryKey" json:"id"`
+	Name            string    `gorm:"type:varchar(255);not null" json:"name"`
+	StoreFrontID    int64     `gorm:"type:bigint;not null;index" json:"store_front_id"`
+	Currency        string    `gorm:"type:varchar(10);not null" json:"currency"`
+	CustomerGroupID *int64    `gorm:"type:bigint;index" json:"customer_group_id"`
+	IsActive        bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Relations
+	StoreFront    *StoreFront     `gorm:"foreignKey:StoreFrontID" json:"store_front,omitempty"`
+	CustomerGroup *CustomerGroup  `gorm:"foreignKey:CustomerGroupID" json:"customer_group,omitempty"`
+	Items         []PriceListItem `gorm:"foreignKey:PriceListID" json:"items,omitempty"`
+}
+
+func (PriceList) TableName() string { return "price_lists" }
+
+// PriceListItem is a variant's price in a price list
+type PriceListItem struct {
+	ID               int64     `gorm:"primaryKey" json:"id"`
+	PriceListID      int64     `gorm:"type:bigint;not null;uniqueIndex:idx_price_list_items_variant" json:"price_list_id"`
+	ProductVariantID int64     `gorm:"type:bigint;not null;uniqueIndex:idx_price_list_items_variant" json:"product_variant_id"`
+	Price            float64   `gorm:"type:numeric(12,2);not null" json:"price"`
+	CompareAtPrice   *float64  `gorm:"type:numeric(12,2)" json:"compare_at_price"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (PriceListItem) TableName() string { return "price_list_items" }
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Price schedule statuses
+const (
+	PriceScheduleStatusScheduled  = "scheduled"
+	PriceScheduleStatusActive     = "active" // Sale price applied
+	PriceScheduleStatusEnded      = "ended"  // Original price restored
+	PriceScheduleStatusCancelled  = "cancelled"
+	PriceScheduleStatusOverridden = "overridden" // Price changed during the sale, left as it was
+)
+
+// PriceSchedule sets a sale price on a variant for a time window. Without a price
+// list it changes the variant's own price, otherwise the variant's price in the list.
+// The scheduler keeps the replaced prices to restore them when the sale ends.
+type PriceSchedule struct {
+	ID               int64     `gorm:"primaryKey" json:"id"`
+	Name             string    `gorm:"type:varchar(255);not null" json:"name"`
+	ProductVariantID int64     `gorm:"type:bigint;not null;index" json:"product_variant_id"`
+	PriceListID      *int64    `gorm:"type:bigint;index" json:"price_list_id"`
+	SalePrice        float64   `gorm:"type:numeric(12,2);not null" json:"sale_price"`
+	CompareAtPrice   *float64  `gorm:"type:numeric(12,2)" json:"compare_at_price"`
+	StartsAt         time.Time `gorm:"not null;index" json:"starts_at"`
+	EndsAt           time.Time `gorm:"not null;index" json:"ends_at"`
+	Status           string    `gorm:"type:varchar(20);not null;default:'scheduled';index" json:"status"`
+
+	// Prices replaced on activation, restored when the sale ends
+	OriginalPrice          *float64 `gorm:"type:numeric(12,2)" json:"original_price"`
+	OriginalCompareAtPrice *float64 `gorm:"type:numeric(12,2)" json:"original_compare_at_price"`
+	OriginalListed         bool     `gorm:"not null;default:false" json:"original_listed"` // The list priced the variant before the sale
+
+	ActivatedAt *time.Time `json:"activated_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+	CreatedBy   *int64     `gorm:"type:bigint" json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (PriceSchedule) TableName() string { return "price_schedules" }
+
+// IsOpen reports whether the schedule still holds its time window
+func (p *PriceSchedule) IsOpen() bool {
+	return p.Status == PriceScheduleStatusScheduled || p.Status == PriceScheduleStatusActive
+}
@@ -14,6 +14,12 @@ const (
 	ProductStatusArchived = "archived"
 )
 
+// Product type constants
+const (
+	ProductTypeSimple = "simple"
+	ProductTypeBundle = "bundle"
+)
+
 // Attribute type constants
 const (
 	AttributeTypeSize  = "size"
@@ -25,7 +31,9 @@ type Product struct {
 	Name               string         `gorm:"type:varchar(255)" json:"name"`
 	NameEn             string         `gorm:"type:varchar(255)" json:"name_en"`
 	NameAr             string         `gorm:"type:varchar(255)" json:"name_ar"`
-	Slug               string         `gorm:"type:varchar(255)" json:"slug"`
+	Slug               string         `gorm:"type:varchar(255)" json:"slug"` // backward compat, same as SlugEn
+	SlugEn             string         `gorm:"type:varchar(255);not null;default:'';index" json:"slug_en"`
+	SlugAr             string         `gorm:"type:varchar(255);not null;default:'';index" json:"slug_ar"`
 	Description        string         `gorm:"type:text" json:"description"`
 	DescriptionEn      string         `gorm:"type:text" json:"description_en"`
 	DescriptionAr      string         `gorm:"type:text" json:"description_ar"`
@@ -40,7 +48,10 @@ type Product struct {
 	IsFeatured         bool           `gorm:"default:false" json:"is_featured"`
 	IsNew              bool           `gorm:"default:false" json:"is_new"`
 	IsBestSeller       bool           `gorm:"default:false" json:"is_best_seller"`
+	IsNewPinned        bool           `gorm:"not null;default:false" json:"is_new_pinned"`         // The merchandising job leaves is_new alone
+	IsBestSellerPinned bool           `gorm:"not null;default:false" json:"is_best_seller_pinned"` // The merchandising job leaves is_best_seller alone
 	AttributeType      *string        `gorm:"type:varchar(20)" json:"attribute_type"`
+	ProductType        string         `gorm:"type:varchar(20);not null;default:'simple'" json:"product_type"`
 	CreatedAt          time.Time      `json:"created_at"`
 	UpdatedAt          time.Time      `json:"updated_at"`
 	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
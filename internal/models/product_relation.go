@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Product relation types
+const (
+	ProductRelationRelated   = "related"    // Similar products
+	ProductRelationCrossSell = "cross_sell" // Products bought alongside
+	ProductRelationUpsell    = "upsell"     // Pricier alternatives
+	ProductRelationAccessory = "accessory"  // Products that complete it
+)
+
+// ProductRelation links a product to another shown with it, ordered by position
+// within its type
+type ProductRelation struct {
+	ID               int64     `gorm:"primaryKey" json:"id"`
+	ProductID        int64     `gorm:"type:bigint;not null;uniqueIndex:idx_product_relations_unique" json:"product_id"`
+	RelatedProductID int64     `gorm:"type:bigint;not null;uniqueIndex:idx_product_relations_unique;index" json:"related_product_id"`
+	Type             string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_product_relations_unique" json:"type"`
+	Position         int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt        time.Time `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Product review statuses
+const (
+	ReviewStatusPending  = "pending"  // Waiting for moderation
+	ReviewStatusApproved = "approved" // Shown on the storefront
+	ReviewStatusRejected = "rejected"
+)
+
+// ProductReview is a customer's rating and review of a product they bought. Only
+// approved reviews are shown and counted in the product's rating.
+type ProductReview struct {
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	ProductID       int64      `gorm:"type:bigint;not null;uniqueIndex:idx_product_reviews_customer;index:idx_product_reviews_status" json:"product_id"`
+	StoreFrontID    int64      `gorm:"type:bigint;not null;index" json:"store_front_id"`
+	CustomerID      int64      `gorm:"type:bigint;not null;uniqueIndex:idx_product_reviews_customer" json:"customer_id"`
+	OrderID         int64      `gorm:"type:bigint;not null" json:"order_id"` // Completed order the product was bought in
+	Rating          int        `gorm:"not null" json:"rating"`               // 1 to 5 stars
+	Title           string     `gorm:"type:varchar(255)" json:"title"`
+	Body            string     `gorm:"type:text" json:"body"`
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_product_reviews_status" json:"status"`
+	RejectionReason string     `gorm:"type:text" json:"rejection_reason,omitempty"`
+	ModeratedBy     *int64     `gorm:"type:bigint" json:"moderated_by"`
+	ModeratedAt     *time.Time `json:"moderated_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	Customer *Customer            `gorm:"foreignKey:CustomerID" json:"-"`
+	Images   []ProductReviewImage `gorm:"foreignKey:ReviewID" json:"images,omitempty"`
+}
+
+// ProductReviewImage is a photo attached to a review
+type ProductReviewImage struct {
+	ID       int64 `gorm:"primaryKey" json:"id"`
+	ReviewID int64 `gorm:"type:bigint;not null;index" json:"review_id"`
+	FileID   int64 `gorm:"type:bigint;not null" json:"file_id"`
+	Position int   `gorm:"not null;default:0" json:"position"`
+
+	File *File `gorm:"foreignKey:FileID" json:"file,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Product revision actions
+const (
+	ProductRevisionCreate        = "create"
+	ProductRevisionUpdate        = "update"
+	ProductRevisionStatus        = "status"
+	ProductRevisionSEO           = "seo"
+	ProductRevisionVariantCreate = "variant_create"
+	ProductRevisionVariantUpdate = "variant_update"
+	ProductRevisionImport        = "import"
+	ProductRevisionRestore       = "restore"
+	ProductRevisionPriceSchedule = "price_schedule" // Recorded by the scheduler, without an admin
+	ProductRevisionMerchandising = "merchandising"  // Computed flags changed, without an admin
+)
+
+// ProductRevision is a snapshot of a product, its SEO, variants and storefront
+// assignments taken after a write, with the changes from the previous revision
+type ProductRevision struct {
+	ID             int64     `gorm:"primaryKey" json:"id"`
+	ProductID      int64     `gorm:"type:bigint;not null;index" json:"product_id"`
+	Action         string    `gorm:"type:varchar(30);not null" json:"action"`
+	AdminID        *int64    `gorm:"type:bigint" json:"admin_id"`
+	RestoredFromID *int64    `gorm:"type:bigint" json:"restored_from_id"` // Revision a restore went back to
+	Snapshot       string    `gorm:"type:text;not null" json:"-"`         // JSON
+	Diff           string    `gorm:"type:text" json:"-"`                  // JSON
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+
+	// Relations
+	Admin *Admin `gorm:"foreignKey:AdminID" json:"admin,omitempty"`
+}
+
+func (ProductRevision) TableName() string { return "product_revisions" }
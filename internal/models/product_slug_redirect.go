@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Slug languages
+const (
+	SlugLanguageEn = "en"
+	SlugLanguageAr = "ar"
+)
+
+// ProductSlugRedirect keeps a product's old slug resolving after the product is renamed
+type ProductSlugRedirect struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	ProductID int64     `gorm:"type:bigint;not null;uniqueIndex:idx_product_slug_redirects_product_slug" json:"product_id"`
+	Language  string    `gorm:"type:varchar(2);not null;uniqueIndex:idx_product_slug_redirects_product_slug" json:"language"`
+	Slug      string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_product_slug_redirects_product_slug;index" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ProductSlugRedirect) TableName() string { return "product_slug_redirects" }
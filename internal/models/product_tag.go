@@ -0,0 +1,10 @@
+package models
+
+// ProductTag is a free-form merchandising label on a product, stored lowercase
+type ProductTag struct {
+	ID        int64  `gorm:"primaryKey" json:"id"`
+	ProductID int64  `gorm:"type:bigint;not null;uniqueIndex:idx_product_tags_product_tag" json:"product_id"`
+	Tag       string `gorm:"type:varchar(100);not null;uniqueIndex:idx_product_tags_product_tag;index" json:"tag"`
+}
+
+func (ProductTag) TableName() string { return "product_tags" }
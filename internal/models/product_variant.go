@@ -20,6 +20,7 @@ type ProductVariant struct {
 	Width          *float64       `gorm:"type:numeric(10,3)" json:"width"`
 	Height         *float64       `gorm:"type:numeric(10,3)" json:"height"`
 	IsActive       bool           `gorm:"default:true" json:"is_active"`
+	TrackingMode   string         `gorm:"type:varchar(10);not null;default:'none'" json:"tracking_mode"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Stock hold statuses
+const (
+	StockHoldStatusActive    = "active"
+	StockHoldStatusConverted = "converted" // Sold through the channel
+	StockHoldStatusReleased  = "released"
+	StockHoldStatusExpired   = "expired"
+)
+
+// StockHold reserves stock for an external channel (POS, marketplace) until it is
+// converted to a sale, released or its TTL runs out
+type StockHold struct {
+	ID           int64      `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	StoreFrontID int64      `gorm:"type:bigint;not null;index" json:"store_front_id"`
+	Reference    string     `gorm:"type:varchar(100);not null" json:"reference"` // Client key, unique per store
+	Channel      string     `gorm:"type:varchar(50);not null" json:"channel"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expires_at"`
+	ConvertedAt  *time.Time `json:"converted_at"`
+	ReleasedAt   *time.Time `json:"released_at"`
+	CreatedBy    *int64     `gorm:"type:bigint" json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	Items []StockHoldItem `gorm:"foreignKey:StockHoldID" json:"items"`
+}
+
+func (StockHold) TableName() string { return "stock_holds" }
+
+// IsActive reports whether the hold still reserves stock
+func (h *StockHold) IsActive() bool {
+	return h.Status == StockHoldStatusActive
+}
+
+// StockHoldItem is the quantity of one variant reserved by a hold
+type StockHoldItem struct {
+	ID               int64  `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	StockHoldID      int64  `gorm:"type:bigint;not null;index" json:"stock_hold_id"`
+	ProductVariantID int64  `gorm:"type:bigint;not null" json:"product_variant_id"`
+	SKU              string `gorm:"type:varchar(100);not null" json:"sku"`
+	Quantity         int    `gorm:"not null" json:"quantity"`
+
+	// Relations
+	Components []StockHoldItemComponent `gorm:"foreignKey:StockHoldItemID" json:"components,omitempty"`
+}
+
+func (StockHoldItem) TableName() string { return "stock_hold_items" }
+
+// StockHoldItemComponent snapshots the component variants reserved for a bundle hold item
+type StockHoldItemComponent struct {
+	ID                int64  `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	StockHoldItemID   int64  `gorm:"type:bigint;not null;index" json:"stock_hold_item_id"`
+	ProductVariantID  int64  `gorm:"type:bigint;not null" json:"product_variant_id"`
+	SKU               string `gorm:"type:varchar(100);not null" json:"sku"`
+	QuantityPerBundle int    `gorm:"not null" json:"quantity_per_bundle"`
+	Quantity          int    `gorm:"not null" json:"quantity"` // QuantityPerBundle x item quantity
+}
+
+func (StockHoldItemComponent) TableName() string { return "stock_hold_item_components" }
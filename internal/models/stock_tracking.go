@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Variant tracking modes
+const (
+	TrackingModeNone   = "none"
+	TrackingModeSerial = "serial"
+	TrackingModeLot    = "lot"
+)
+
+// Serial number statuses
+const (
+	SerialStatusInStock    = "in_stock"
+	SerialStatusAllocated  = "allocated" // Assigned to an order item, not yet delivered
+	SerialStatusSold       = "sold"
+	SerialStatusWrittenOff = "written_off"
+)
+
+// SerialNumber is a single tracked unit of a serial-tracked variant
+type SerialNumber struct {
+	ID               int64      `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	ProductVariantID int64      `gorm:"type:bigint;not null;index" json:"product_variant_id"`
+	StoreFrontID     int64      `gorm:"type:bigint;not null;index" json:"store_front_id"`
+	Serial           string     `gorm:"type:varchar(100);not null" json:"serial"`
+	Status           string     `gorm:"type:varchar(20);not null;default:'in_stock'" json:"status"`
+	OrderItemID      *int64     `gorm:"type:bigint;index" json:"order_item_id"`
+	ReceivedAt       time.Time  `json:"received_at"`
+	SoldAt           *time.Time `json:"sold_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (SerialNumber) TableName() string { return "serial_numbers" }
+
+// StockLot is the stock of one batch/lot of a lot-tracked variant in a store
+type StockLot struct {
+	ID                int64      `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	ProductVariantID  int64      `gorm:"type:bigint;not null;index" json:"product_variant_id"`
+	StoreFrontID      int64      `gorm:"type:bigint;not null;index" json:"store_front_id"`
+	LotNumber         string     `gorm:"type:varchar(100);not null" json:"lot_number"`
+	Quantity          int        `gorm:"not null;default:0" json:"quantity"`           // On hand
+	AllocatedQuantity int        `gorm:"not null;default:0" json:"allocated_quantity"` // Assigned to order items
+	ExpiresAt         *time.Time `json:"expires_at"`
+	ReceivedAt        time.Time  `json:"received_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+func (StockLot) TableName() string { return "stock_lots" }
+
+// AvailableQuantity returns the units of the lot not yet assigned to an order
+func (l *StockLot) AvailableQuantity() int {
+	return l.Quantity - l.AllocatedQuantity
+}
@@ -2,15 +2,36 @@ package models
 
 import "time"
 
+// Backorder policy constants
+const (
+	BackorderPolicyDeny     = "deny"
+	BackorderPolicyAllow    = "allow_backorder"
+	BackorderPolicyPreorder = "preorder"
+)
+
+// Storefront availability constants
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityBackorder  = "backorder"
+	AvailabilityPreorder   = "preorder"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
 type VariantInventory struct {
-	ID                int64     `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
-	ProductVariantID  int64     `gorm:"type:bigint;not null" json:"product_variant_id"`
-	StoreFrontID      int64     `gorm:"type:bigint;not null" json:"store_front_id"`
-	Quantity          int       `gorm:"not null;default:0" json:"quantity"`
-	ReservedQuantity  int       `gorm:"not null;default:0" json:"reserved_quantity"`
-	LowStockThreshold int       `gorm:"not null;default:5" json:"low_stock_threshold"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                int64 `gorm:"type:bigint;primary_key;autoIncrement" json:"id"`
+	ProductVariantID  int64 `gorm:"type:bigint;not null" json:"product_variant_id"`
+	StoreFrontID      int64 `gorm:"type:bigint;not null" json:"store_front_id"`
+	Quantity          int   `gorm:"not null;default:0" json:"quantity"`
+	ReservedQuantity  int   `gorm:"not null;default:0" json:"reserved_quantity"`
+	LowStockThreshold int   `gorm:"not null;default:5" json:"low_stock_threshold"`
+	// Selling beyond available stock
+	BackorderPolicy     string     `gorm:"type:varchar(20);not null;default:'deny'" json:"backorder_policy"`
+	BackorderLimit      *int       `json:"backorder_limit"` // nil = no cap
+	BackorderedQuantity int        `gorm:"not null;default:0" json:"backordered_quantity"`
+	AvailableOn         *time.Time `json:"available_on"`
+	LowStockNotifiedAt  *time.Time `json:"low_stock_notified_at"` // Set while a low-stock alert is outstanding
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 
 	// Relations
 	StoreFront     StoreFront     `gorm:"foreignKey:StoreFrontID" json:"store_front,omitempty"`
@@ -28,3 +49,41 @@ func (v *VariantInventory) AvailableQuantity() int {
 func (v *VariantInventory) IsLowStock() bool {
 	return v.AvailableQuantity() <= v.LowStockThreshold
 }
+
+// AllowsBackorder returns true if the policy lets orders exceed available stock
+func (v *VariantInventory) AllowsBackorder() bool {
+	return v.BackorderPolicy == BackorderPolicyAllow || v.BackorderPolicy == BackorderPolicyPreorder
+}
+
+// BackorderCapacity returns how many more units can be backordered, or -1 when uncapped
+func (v *VariantInventory) BackorderCapacity() int {
+	if !v.AllowsBackorder() {
+		return 0
+	}
+	if v.BackorderLimit == nil {
+		return -1
+	}
+	if remaining := *v.BackorderLimit - v.BackorderedQuantity; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// IsSellable returns true if the variant can currently be ordered, from stock or on backorder
+func (v *VariantInventory) IsSellable() bool {
+	return v.AvailableQuantity() > 0 || v.BackorderCapacity() != 0
+}
+
+// Availability returns the storefront availability state of the variant
+func (v *VariantInventory) Availability() string {
+	switch {
+	case v.AvailableQuantity() > 0:
+		return AvailabilityInStock
+	case v.BackorderCapacity() == 0:
+		return AvailabilityOutOfStock
+	case v.BackorderPolicy == BackorderPolicyPreorder:
+		return AvailabilityPreorder
+	default:
+		return AvailabilityBackorder
+	}
+}
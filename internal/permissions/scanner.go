@@ -95,6 +95,19 @@ func (s *Scanner) addPredefinedPermissions() {
 
 		// Phase 2: SEO permissions
 		{Name: "seo.manage", Description: "Manage product SEO metadata", Module: "seo", Action: "manage"},
+
+		// Pricing permissions
+		{Name: "pricing.view", Description: "View price lists and customer groups", Module: "pricing", Action: "view"},
+		{Name: "pricing.manage", Description: "Manage price lists and customer groups", Module: "pricing", Action: "manage"},
+
+		// Collection permissions
+		{Name: "collections.view", Description: "View and list product collections", Module: "collections", Action: "view"},
+		{Name: "collections.manage", Description: "Manage product collections", Module: "collections", Action: "manage"},
+
+		// Review permissions
+		{Name: "reviews.view", Description: "View and list product reviews", Module: "reviews", Action: "view"},
+		{Name: "reviews.moderate", Description: "Approve and reject product reviews", Module: "reviews", Action: "moderate"},
+
 		// Order permissions
 		{Name: "orders.view", Description: "View and list orders", Module: "orders", Action: "view"},
 		{Name: "orders.create", Description: "Create new orders", Module: "orders", Action: "create"},
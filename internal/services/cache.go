@@ -0,0 +1,299 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onas/ecommerce-api/config"
+)
+
+// Cache is a key/value store for derived data that can be rebuilt from the database
+type Cache interface {
+	// GetMany returns the values found for keys, missing or expired keys are left out
+	GetMany(ctx context.Context, keys []string) (map[string][]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// NewCache builds the cache selected by the configured driver
+func NewCache(cfg config.CacheConfig) Cache {
+	switch cfg.Driver {
+	case "redis":
+		return NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	default:
+		return NewMemoryCache()
+	}
+}
+
+// MemoryCache keeps values in process memory, for single instance deployments and tests
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // zero for no expiry
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryEntry)}
+}
+
+func (c *MemoryCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	now := time.Now()
+	found := make(map[string][]byte, len(keys))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, key := range keys {
+		entry, ok := c.entries[key]
+		if !ok || (!entry.expiresAt.IsZero() && now.After(entry.expiresAt)) {
+			continue
+		}
+		found[key] = entry.value
+	}
+	return found, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	c.evictExpired()
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// evictExpired drops expired entries once the map has grown, callers hold the write lock
+func (c *MemoryCache) evictExpired() {
+	if len(c.entries)%1024 != 0 {
+		return
+	}
+	now := time.Now()
+	for key, entry := range c.entries {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// RedisCache talks to a Redis compatible server over RESP with a small connection pool
+type RedisCache struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	pool     chan *redisConn
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewRedisCache(addr, password string, db int) *RedisCache {
+	return &RedisCache{
+		addr:     addr,
+		password: password,
+		db:       db,
+		timeout:  2 * time.Second,
+		pool:     make(chan *redisConn, 8),
+	}
+}
+
+func (c *RedisCache) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	found := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	args := append([]string{"MGET"}, keys...)
+	reply, err := c.do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != len(keys) {
+		return nil, fmt.Errorf("redis: unexpected MGET reply")
+	}
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			found[keys[i]] = b
+		}
+	}
+	return found, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// do sends one command and reads its reply, broken connections are not returned to the pool
+func (c *RedisCache) do(ctx context.Context, args ...string) (interface{}, error) {
+	rc, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	rc.conn.SetDeadline(deadline)
+
+	reply, err := rc.command(args...)
+	if err != nil {
+		if _, isReplyErr := err.(redisError); !isReplyErr {
+			rc.conn.Close()
+			return nil, err
+		}
+	}
+	c.put(rc)
+	return reply, err
+}
+
+func (c *RedisCache) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case rc := <-c.pool:
+		return rc, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	rc := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+
+	if c.password != "" {
+		if _, err := rc.command("AUTH", c.password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := rc.command("SELECT", strconv.Itoa(c.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+func (c *RedisCache) put(rc *redisConn) {
+	select {
+	case c.pool <- rc:
+	default:
+		rc.conn.Close()
+	}
+}
+
+// redisError is an error reply sent by the server, the connection stays usable
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (rc *redisConn) command(args ...string) (interface{}, error) {
+	if _, err := rc.conn.Write(encodeRESPCommand(args)); err != nil {
+		return nil, err
+	}
+	return readRESPReply(rc.r)
+}
+
+// encodeRESPCommand encodes a command as a RESP array of bulk strings
+func encodeRESPCommand(args []string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return []byte(sb.String())
+}
+
+// readRESPReply reads one reply: simple strings and integers as string/int64,
+// bulk strings as []byte (nil when missing) and arrays as []interface{}
+func readRESPReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESPReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+	}
+}
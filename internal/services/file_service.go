@@ -1,10 +1,16 @@
 package services
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/onas/ecommerce-api/internal/models"
@@ -16,6 +22,11 @@ import (
 type FileService struct {
 	db       *gorm.DB
 	fileUtil *utils.FileUtil
+
+	// Background image derivatives, see image_derivatives.go
+	derivativeQueue   chan models.File
+	derivativesQueued sync.Map // File IDs waiting or being scaled
+	startDerivatives  sync.Once
 }
 
 // FileUploadConfig holds configuration for file uploads
@@ -66,6 +77,16 @@ func (s *FileService) GetDefaultConfig() *FileUploadConfig {
 
 // UploadFile handles file upload with validation
 func (s *FileService) UploadFile(fileHeader *multipart.FileHeader, uploadedBy int64, config *FileUploadConfig) (*models.File, error) {
+	return s.upload(fileHeader, &models.File{UploadedBy: &uploadedBy}, config)
+}
+
+// UploadCustomerFile handles a storefront customer's file upload with validation
+func (s *FileService) UploadCustomerFile(fileHeader *multipart.FileHeader, customerID int64, config *FileUploadConfig) (*models.File, error) {
+	return s.upload(fileHeader, &models.File{UploadedByCustomerID: &customerID}, config)
+}
+
+// upload validates and stores an uploaded file, saving its details on fileRecord
+func (s *FileService) upload(fileHeader *multipart.FileHeader, fileRecord *models.File, config *FileUploadConfig) (*models.File, error) {
 	if config == nil {
 		config = s.GetDefaultConfig()
 	}
@@ -116,25 +137,94 @@ func (s *FileService) UploadFile(fileHeader *multipart.FileHeader, uploadedBy in
 		return nil, fmt.Errorf("failed to save file: %v", err)
 	}
 
-	// Create file record
+	// Fill file record
+	fileRecord.OriginalName = fileHeader.Filename
+	fileRecord.FileName = uniqueFileName
+	fileRecord.FilePath = relativePath
+	fileRecord.FileSize = fileHeader.Size
+	fileRecord.MimeType = mimeType
+	fileRecord.FileType = fileType
+	fileRecord.Extension = extension
+	fileRecord.IsActive = true
+
+	// Save to database
+	if err := s.db.Create(fileRecord).Error; err != nil {
+		// Clean up file if database save fails
+		s.fileUtil.DeleteFile(relativePath)
+		return nil, fmt.Errorf("failed to save file record: %v", err)
+	}
+	s.generateDerivativesAsync(*fileRecord)
+
+	return fileRecord, nil
+}
+
+// ImportFromURL downloads a remote file and stores it like an upload
+func (s *FileService) ImportFromURL(rawURL string, uploadedBy int64, config *FileUploadConfig) (*models.File, error) {
+	if config == nil {
+		config = s.GetDefaultConfig()
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid file url %q", rawURL)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(parsed.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	// Read one byte past the limit to detect oversized files
+	data, err := io.ReadAll(io.LimitReader(resp.Body, config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %v", err)
+	}
+	if int64(len(data)) > config.MaxFileSize {
+		return nil, fmt.Errorf("file exceeds maximum allowed size of %d bytes", config.MaxFileSize)
+	}
+
+	mimeType := http.DetectContentType(data)
+	fileType, allowed := s.validateFileType(mimeType, config.AllowedTypes)
+	if !allowed {
+		return nil, fmt.Errorf("file type %s is not allowed", mimeType)
+	}
+
+	originalName := path.Base(parsed.Path)
+	if originalName == "." || originalName == "/" {
+		originalName = "download"
+	}
+	extension := strings.ToLower(filepath.Ext(originalName))
+	if extension == "" {
+		extension = s.getExtensionFromMimeType(mimeType)
+	}
+	uniqueFileName := s.generateUniqueFileName(originalName, extension)
+
+	relativePath, err := s.fileUtil.SaveFileFromReader(fmt.Sprintf("%s/%s", fileType, uniqueFileName), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save file: %v", err)
+	}
+
 	fileRecord := &models.File{
-		OriginalName: fileHeader.Filename,
+		OriginalName: originalName,
 		FileName:     uniqueFileName,
 		FilePath:     relativePath,
-		FileSize:     fileHeader.Size,
+		FileSize:     int64(len(data)),
 		MimeType:     mimeType,
 		FileType:     fileType,
 		Extension:    extension,
-		UploadedBy:   uploadedBy,
+		UploadedBy:   &uploadedBy,
 		IsActive:     true,
 	}
-
-	// Save to database
 	if err := s.db.Create(fileRecord).Error; err != nil {
-		// Clean up file if database save fails
 		s.fileUtil.DeleteFile(relativePath)
 		return nil, fmt.Errorf("failed to save file record: %v", err)
 	}
+	s.generateDerivativesAsync(*fileRecord)
 
 	return fileRecord, nil
 }
@@ -198,7 +288,10 @@ func (s *FileService) DeleteFile(id int64, deletedBy int64) error {
 		return err
 	}
 
-	// Remove from storage
+	// Remove from storage, with its image derivatives
+	if err := s.deleteDerivatives(file.ID); err != nil {
+		fmt.Printf("Warning: failed to delete image derivatives: %v\n", err)
+	}
 	if err := s.fileUtil.DeleteFile(file.FilePath); err != nil {
 		// Log error but don't fail the operation
 		// The file record is already deleted from DB
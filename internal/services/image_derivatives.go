@@ -0,0 +1,377 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // Registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onas/ecommerce-api/config"
+	"github.com/onas/ecommerce-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImageSize is a derivative size and the width images are scaled down to
+type ImageSize struct {
+	Name  string
+	Width int
+}
+
+// ImageSizes are the derivatives made of every image, smallest first
+var ImageSizes = []ImageSize{
+	{Name: models.ImageSizeThumb, Width: 200},
+	{Name: models.ImageSizeMedium, Width: 600},
+	{Name: models.ImageSizeLarge, Width: 1200},
+}
+
+// defaultMaxPixels caps the images scaled when no limit is configured, a decoded image
+// takes four bytes per pixel and is copied once more while scaling
+const defaultMaxPixels = 40_000_000
+
+// derivativeQueueSize is how many uploads wait for a background worker, further ones are
+// left for their first request or the images command
+const derivativeQueueSize = 100
+
+// derivableMimeTypes are the image types the standard library decodes
+var derivableMimeTypes = []string{"image/jpeg", "image/jpg", "image/png", "image/gif"}
+
+// HasDerivatives reports whether scaled down copies are made of a file
+func HasDerivatives(file *models.File) bool {
+	if file.FileType != "image" {
+		return false
+	}
+	for _, mimeType := range derivableMimeTypes {
+		if file.MimeType == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// derivativeFormat is the format an image's derivatives keep, JPEG photos stay JPEG and
+// everything else becomes PNG to keep transparency
+func derivativeFormat(file *models.File) (format, mimeType, extension string) {
+	if file.MimeType == "image/jpeg" || file.MimeType == "image/jpg" {
+		return models.ImageFormatJPEG, "image/jpeg", ".jpg"
+	}
+	return models.ImageFormatPNG, "image/png", ".png"
+}
+
+func imageConfig() config.ImageConfig {
+	if config.AppConfig != nil {
+		return config.AppConfig.Images
+	}
+	return config.ImageConfig{JPEGQuality: jpeg.DefaultQuality, DerivativeWorkers: 1, MaxPixels: defaultMaxPixels}
+}
+
+// GenerateDerivatives scales an image down to every size narrower than it, saving each
+// next to the original as name_size.ext, plus a WebP copy when the encoder is installed.
+// Images are never scaled up, so a small image has fewer or no derivatives. The file is
+// marked generated afterwards, also when it cannot be decoded or has more pixels than
+// the configured maximum; such images are only served as uploaded.
+func (s *FileService) GenerateDerivatives(file *models.File) ([]models.FileDerivative, error) {
+	if !HasDerivatives(file) {
+		return nil, nil
+	}
+
+	data, err := s.fileUtil.ReadFile(file.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := imageConfig()
+	maxPixels := cfg.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = defaultMaxPixels
+	}
+	// The header gives the dimensions without decoding, so oversized images never are
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == nil && int64(header.Width)*int64(header.Height) > int64(maxPixels) {
+		err = fmt.Errorf("%dx%d pixels exceeds the maximum of %d", header.Width, header.Height, maxPixels)
+	}
+	var src image.Image
+	if err == nil {
+		src, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		if markErr := s.markDerivativesGenerated(file); markErr != nil {
+			log.Printf("⚠️  Failed to mark the derivatives of file %d: %v", file.ID, markErr)
+		}
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	jpegQuality := cfg.JPEGQuality
+	if jpegQuality < 1 || jpegQuality > 100 {
+		jpegQuality = jpeg.DefaultQuality
+	}
+	webpEncoder := ""
+	if cfg.WebPEncoder != "" {
+		if path, err := exec.LookPath(cfg.WebPEncoder); err == nil {
+			webpEncoder = path
+		}
+	}
+
+	format, mimeType, extension := derivativeFormat(file)
+	base := strings.TrimSuffix(file.FilePath, filepath.Ext(file.FilePath))
+
+	var derivatives []models.FileDerivative
+	for _, size := range ImageSizes {
+		if src.Bounds().Dx() <= size.Width {
+			continue
+		}
+		scaled := scaleToWidth(src, size.Width)
+
+		var buf bytes.Buffer
+		if format == models.ImageFormatJPEG {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			err = png.Encode(&buf, scaled)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s image: %v", size.Name, err)
+		}
+
+		derivativePath, err := s.fileUtil.SaveFile(fmt.Sprintf("%s_%s%s", base, size.Name, extension), buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		derivative := models.FileDerivative{
+			FileID:   file.ID,
+			Size:     size.Name,
+			Format:   format,
+			FilePath: derivativePath,
+			MimeType: mimeType,
+			Width:    scaled.Bounds().Dx(),
+			Height:   scaled.Bounds().Dy(),
+			FileSize: int64(buf.Len()),
+		}
+		derivatives = append(derivatives, derivative)
+
+		if webpEncoder == "" {
+			continue
+		}
+		webpPath := fmt.Sprintf("%s_%s.webp", base, size.Name)
+		webpSize, err := s.encodeWebP(webpEncoder, cfg.WebPQuality, derivativePath, webpPath)
+		if err != nil {
+			// The scaled copy is still usable without its WebP version
+			log.Printf("⚠️  WebP %s image of file %d failed: %v", size.Name, file.ID, err)
+			continue
+		}
+		derivative.Format = models.ImageFormatWebP
+		derivative.FilePath = webpPath
+		derivative.MimeType = "image/webp"
+		derivative.FileSize = webpSize
+		derivatives = append(derivatives, derivative)
+	}
+
+	if len(derivatives) > 0 {
+		err = s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "file_id"}, {Name: "size"}, {Name: "format"}},
+			DoUpdates: clause.AssignmentColumns([]string{"file_path", "mime_type", "width", "height", "file_size", "updated_at"}),
+		}).Create(&derivatives).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to save image derivatives: %v", err)
+		}
+	}
+	if err := s.markDerivativesGenerated(file); err != nil {
+		return nil, fmt.Errorf("failed to mark image derivatives: %v", err)
+	}
+	return derivatives, nil
+}
+
+// markDerivativesGenerated records that an image was processed, so requests for sizes
+// it has no derivative of stop trying to make one
+func (s *FileService) markDerivativesGenerated(file *models.File) error {
+	now := time.Now()
+	if err := s.db.Model(&models.File{}).Where("id = ?", file.ID).UpdateColumn("derivatives_generated_at", now).Error; err != nil {
+		return err
+	}
+	file.DerivativesGeneratedAt = &now
+	return nil
+}
+
+// encodeWebP converts a stored image to WebP with the cwebp encoder, returning its size
+func (s *FileService) encodeWebP(encoder string, quality int, sourcePath, targetPath string) (int64, error) {
+	if quality < 1 || quality > 100 {
+		quality = 80
+	}
+	cmd := exec.Command(encoder, "-quiet", "-q", strconv.Itoa(quality), s.fileUtil.GetFullPath(sourcePath), "-o", s.fileUtil.GetFullPath(targetPath))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return 0, fmt.Errorf("%v: %s", err, bytes.TrimSpace(output))
+	}
+	info, err := s.fileUtil.GetFileInfo(targetPath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// generateDerivativesAsync queues the derivatives of an image for the background workers.
+// An image already queued is not queued twice, and one that finds the queue full is made
+// on a later request for a derivative or by the images command.
+func (s *FileService) generateDerivativesAsync(file models.File) {
+	if !HasDerivatives(&file) {
+		return
+	}
+	s.startDerivatives.Do(s.startDerivativeWorkers)
+
+	if _, queued := s.derivativesQueued.LoadOrStore(file.ID, true); queued {
+		return
+	}
+	select {
+	case s.derivativeQueue <- file:
+	default:
+		s.derivativesQueued.Delete(file.ID)
+		log.Printf("⚠️  Image derivative queue is full, file %d is left for later", file.ID)
+	}
+}
+
+// startDerivativeWorkers starts the fixed number of workers that scale queued images
+func (s *FileService) startDerivativeWorkers() {
+	workers := imageConfig().DerivativeWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	s.derivativeQueue = make(chan models.File, derivativeQueueSize)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for file := range s.derivativeQueue {
+				if _, err := s.GenerateDerivatives(&file); err != nil {
+					log.Printf("⚠️  Image derivatives of file %d failed: %v", file.ID, err)
+				}
+				s.derivativesQueued.Delete(file.ID)
+			}
+		}()
+	}
+}
+
+// GetDerivativeByPath returns a derivative of the image stored at filePath. An image not
+// processed yet is queued and reported not found, so it is served as uploaded until its
+// derivatives exist. An empty format is the image's own derivative format.
+func (s *FileService) GetDerivativeByPath(filePath, size, format string) (*models.FileDerivative, error) {
+	var file models.File
+	if err := s.db.Where("file_path = ? AND is_active = ?", strings.TrimPrefix(filePath, "/"), true).First(&file).Error; err != nil {
+		return nil, err
+	}
+	if !HasDerivatives(&file) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if format == "" {
+		format, _, _ = derivativeFormat(&file)
+	}
+
+	var derivative models.FileDerivative
+	err := s.db.Where("file_id = ? AND size = ? AND format = ?", file.ID, size, format).First(&derivative).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) && file.DerivativesGeneratedAt == nil {
+		s.generateDerivativesAsync(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &derivative, nil
+}
+
+// RegenerateDerivatives remakes the derivatives of every stored image, or only of images
+// not processed yet when missingOnly is set. It returns how many images were processed;
+// an image that fails is logged and skipped.
+func (s *FileService) RegenerateDerivatives(missingOnly bool) (int, error) {
+	processed := 0
+	var lastID int64
+	for {
+		query := s.db.Where("file_type = ? AND mime_type IN ? AND is_active = ? AND id > ?", "image", derivableMimeTypes, true, lastID)
+		if missingOnly {
+			query = query.Where("derivatives_generated_at IS NULL")
+		}
+
+		var files []models.File
+		if err := query.Order("id ASC").Limit(100).Find(&files).Error; err != nil {
+			return processed, err
+		}
+		if len(files) == 0 {
+			return processed, nil
+		}
+
+		for i := range files {
+			lastID = files[i].ID
+			if _, err := s.GenerateDerivatives(&files[i]); err != nil {
+				log.Printf("⚠️  Image derivatives of file %d failed: %v", files[i].ID, err)
+				continue
+			}
+			processed++
+		}
+	}
+}
+
+// deleteDerivatives removes the derivatives of a file from storage and the database
+func (s *FileService) deleteDerivatives(fileID int64) error {
+	var derivatives []models.FileDerivative
+	if err := s.db.Where("file_id = ?", fileID).Find(&derivatives).Error; err != nil {
+		return err
+	}
+	for _, derivative := range derivatives {
+		if err := s.fileUtil.DeleteFile(derivative.FilePath); err != nil {
+			fmt.Printf("Warning: failed to delete file from storage: %v\n", err)
+		}
+	}
+	return s.db.Where("file_id = ?", fileID).Delete(&models.FileDerivative{}).Error
+}
+
+// scaleToWidth scales an image down to width, keeping its aspect ratio. Each target
+// pixel averages the source pixels it covers.
+func scaleToWidth(src image.Image, width int) *image.RGBA {
+	bounds := src.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	// Reading pixels through src.At is an interface call and a color conversion per
+	// pixel, the pixels are read from an RGBA copy instead
+	pixels, ok := src.(*image.RGBA)
+	if !ok {
+		pixels = image.NewRGBA(bounds)
+		draw.Draw(pixels, bounds, src, bounds.Min, draw.Src)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := pixels.Pix[pixels.PixOffset(x0, sy):pixels.PixOffset(x1, sy)]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/onas/ecommerce-api/config"
+)
+
+// Notification is a message sent to admins through a Notifier
+type Notification struct {
+	Event      string      `json:"event"`
+	Subject    string      `json:"subject"`
+	Body       string      `json:"body"`
+	Recipients []string    `json:"recipients"`
+	Payload    interface{} `json:"payload,omitempty"`
+}
+
+// Notifier delivers notifications to admins
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NewNotifier builds the notifier selected by the configured channel
+func NewNotifier(cfg config.NotificationConfig) Notifier {
+	switch cfg.Channel {
+	case "email":
+		return &EmailNotifier{
+			addr:     cfg.SMTPHost + ":" + cfg.SMTPPort,
+			host:     cfg.SMTPHost,
+			username: cfg.SMTPUser,
+			password: cfg.SMTPPass,
+			from:     cfg.MailFrom,
+		}
+	case "webhook":
+		return &WebhookNotifier{
+			url:    cfg.WebhookURL,
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		return &LogNotifier{}
+	}
+}
+
+// LogNotifier writes notifications to the application log, for local development
+type LogNotifier struct{}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Notification) error {
+	log.Printf("📣 [%s] %s -> %s\n%s", msg.Event, msg.Subject, strings.Join(msg.Recipients, ", "), msg.Body)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Notification) error {
+	if n.url == "" {
+		return fmt.Errorf("webhook url is not configured")
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier sends notifications as plain text emails over SMTP
+type EmailNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, msg Notification) error {
+	if n.host == "" || n.from == "" {
+		return fmt.Errorf("smtp host and sender are not configured")
+	}
+	if len(msg.Recipients) == 0 {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", n.from)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(msg.Recipients, ", "))
+	fmt.Fprintf(&sb, "Subject: %s\r\n", msg.Subject)
+	sb.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	sb.WriteString(msg.Body)
+
+	return smtp.SendMail(n.addr, auth, n.from, msg.Recipients, []byte(sb.String()))
+}
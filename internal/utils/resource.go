@@ -21,6 +21,7 @@ type baseResource struct {
 	data       interface{}
 	errors     interface{}
 	meta       interface{}
+	location   string
 }
 
 func (r *baseResource) GetStatusCode() int     { return r.statusCode }
@@ -60,6 +61,17 @@ func NewPaginatedOKResource(message string, data interface{}, meta interface{})
 	}
 }
 
+// NewMovedPermanentlyResource answers with a 301 and a Location header. A relative location
+// resolves against the request path, e.g. "new-slug" replaces the last path segment.
+func NewMovedPermanentlyResource(message, location string, data interface{}) IResource {
+	return &baseResource{
+		statusCode: http.StatusMovedPermanently,
+		message:    message,
+		data:       data,
+		location:   location,
+	}
+}
+
 func NewBadRequestResource(message string, errors interface{}) IResource {
 	return &baseResource{
 		statusCode: http.StatusBadRequest,
@@ -117,6 +129,14 @@ func WriteResource(c *gin.Context, res IResource) {
 		return
 	}
 
+	if statusCode == http.StatusMovedPermanently {
+		if r, ok := res.(*baseResource); ok && r.location != "" {
+			c.Header("Location", r.location)
+		}
+		SuccessResponse(c, statusCode, res.GetMessage(), res.GetData())
+		return
+	}
+
 	if statusCode >= 200 && statusCode < 300 {
 		if res.GetMeta() != nil {
 			SuccessResponseWithMeta(c, statusCode, res.GetMessage(), res.GetData(), res.GetMeta())
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 var (
@@ -20,3 +22,80 @@ func Slugify(input string) string {
 	s = strings.Trim(s, "-")
 	return s
 }
+
+// UniqueSlug returns base when it is free, otherwise base followed by the first free
+// number from 2, e.g. "blue-sofa-2". taken reports whether a slug is already used.
+func UniqueSlug(base string, taken func(slug string) (bool, error)) (string, error) {
+	slug := base
+	for n := 2; ; n++ {
+		used, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// arabicTransliteration maps Arabic letters to Latin for transliterated slugs. Alef forms
+// are folded into plain alef by normalizeArabic first.
+var arabicTransliteration = map[rune]string{
+	'ا': "a", 'ء': "", 'ؤ': "o", 'ئ': "e",
+	'ب': "b", 'ت': "t", 'ث': "th", 'ج': "j", 'ح': "h", 'خ': "kh",
+	'د': "d", 'ذ': "dh", 'ر': "r", 'ز': "z", 'س': "s", 'ش': "sh",
+	'ص': "s", 'ض': "d", 'ط': "t", 'ظ': "z", 'ع': "a", 'غ': "gh",
+	'ف': "f", 'ق': "q", 'ك': "k", 'ل': "l", 'م': "m", 'ن': "n",
+	'ه': "h", 'و': "w", 'ي': "y", 'ى': "a", 'ة': "a",
+}
+
+// normalizeArabic strips diacritics and tatweel, unifies alef forms and turns
+// Arabic-Indic digits into ASCII digits
+func normalizeArabic(input string) string {
+	var b strings.Builder
+	for _, r := range input {
+		switch {
+		case r == 'ـ', r >= 'ً' && r <= 'ٟ', r == 'ٰ', r >= 'ۖ' && r <= 'ۭ':
+			continue
+		case r == 'أ', r == 'إ', r == 'آ':
+			r = 'ا'
+		case r >= '٠' && r <= '٩':
+			r = '0' + (r - '٠')
+		case r >= '۰' && r <= '۹':
+			r = '0' + (r - '۰')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SlugifyArabic converts a string to a slug that keeps Arabic and other Unicode
+// letters, e.g. "كنبة زرقاء" becomes "كنبة-زرقاء"
+func SlugifyArabic(input string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(normalizeArabic(strings.TrimSpace(input))) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			b.WriteRune('-')
+		}
+	}
+	s := slugMultiDash.ReplaceAllString(b.String(), "-")
+	return strings.Trim(s, "-")
+}
+
+// TransliterateArabic spells Arabic letters in Latin, leaving other characters as they are.
+// Slugify the result for a Latin slug, e.g. "كنبة زرقاء" becomes "knba-zrqa".
+func TransliterateArabic(input string) string {
+	var b strings.Builder
+	for _, r := range normalizeArabic(input) {
+		if latin, ok := arabicTransliteration[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
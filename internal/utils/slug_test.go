@@ -30,3 +30,43 @@ func TestSlugify(t *testing.T) {
 		})
 	}
 }
+
+func TestUniqueSlug(t *testing.T) {
+	used := map[string]bool{"blue-sofa": true, "blue-sofa-2": true}
+	taken := func(slug string) (bool, error) { return used[slug], nil }
+
+	if got, _ := UniqueSlug("red-sofa", taken); got != "red-sofa" {
+		t.Fatalf("UniqueSlug(red-sofa) = %q, want red-sofa", got)
+	}
+	if got, _ := UniqueSlug("blue-sofa", taken); got != "blue-sofa-3" {
+		t.Fatalf("UniqueSlug(blue-sofa) = %q, want blue-sofa-3", got)
+	}
+}
+
+func TestSlugifyArabic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"كنبة زرقاء", "كنبة-زرقاء"},
+		{"  كَنَبَةٌ  ", "كنبة"},
+		{"أريكة إيطالية", "اريكة-ايطالية"},
+		{"طاولة ٣ أرجل!", "طاولة-3-ارجل"},
+		{"Sofa كنبة", "sofa-كنبة"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if result := SlugifyArabic(tt.input); result != tt.expected {
+				t.Fatalf("SlugifyArabic(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTransliterateArabic(t *testing.T) {
+	if result := Slugify(TransliterateArabic("كنبة زرقاء")); result != "knba-zrqa" {
+		t.Fatalf("transliterated slug = %q, want knba-zrqa", result)
+	}
+}
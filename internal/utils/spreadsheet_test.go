@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSpreadsheet_RoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"sku", "barcode", "quantity", "notes"},
+		{"SKU-001", "0012345", "10", "Fish & <Chips>"},
+		{"SKU-002", "", "-3", "منتج"},
+	}
+
+	for _, format := range []string{SpreadsheetCSV, SpreadsheetXLSX} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteSpreadsheet(&buf, format, rows); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+
+			got, err := ReadSpreadsheet(format, buf.Bytes())
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, rows) {
+				t.Fatalf("round trip mismatch:\n got %q\nwant %q", got, rows)
+			}
+		})
+	}
+}
+
+func TestSpreadsheetFormatFromName(t *testing.T) {
+	if f, err := SpreadsheetFormatFromName("stock.XLSX"); err != nil || f != SpreadsheetXLSX {
+		t.Fatalf("expected xlsx, got %q (%v)", f, err)
+	}
+	if f, err := SpreadsheetFormatFromName("csv"); err != nil || f != SpreadsheetCSV {
+		t.Fatalf("expected csv, got %q (%v)", f, err)
+	}
+	if _, err := SpreadsheetFormatFromName("stock.ods"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
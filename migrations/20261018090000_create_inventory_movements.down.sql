@@ -0,0 +1,3 @@
+-- Down migration: create_inventory_movements
+
+DROP TABLE IF EXISTS inventory_movements;
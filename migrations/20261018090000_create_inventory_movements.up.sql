@@ -0,0 +1,46 @@
+-- Migration: create_inventory_movements
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- INVENTORY MOVEMENTS (append-only stock ledger)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id                   BIGSERIAL PRIMARY KEY,
+    variant_inventory_id BIGINT      NOT NULL REFERENCES variant_inventory(id) ON DELETE CASCADE,
+    product_variant_id   BIGINT      NOT NULL,
+    store_front_id       BIGINT      NOT NULL,
+    movement_type        VARCHAR(30) NOT NULL,
+    source_type          VARCHAR(30) NOT NULL,
+    source_id            BIGINT,
+    quantity_before      INT         NOT NULL,
+    quantity_after       INT         NOT NULL,
+    quantity_change      INT         NOT NULL,
+    reserved_before      INT         NOT NULL,
+    reserved_after       INT         NOT NULL,
+    reserved_change      INT         NOT NULL,
+    performed_by         BIGINT,
+    notes                TEXT,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_inventory ON inventory_movements (variant_inventory_id, id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_variant   ON inventory_movements (product_variant_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_store     ON inventory_movements (store_front_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_source    ON inventory_movements (source_type, source_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_date      ON inventory_movements (created_at);
+
+-- Opening balance so the ledger replays to the current stock level
+INSERT INTO inventory_movements (
+    variant_inventory_id, product_variant_id, store_front_id,
+    movement_type, source_type,
+    quantity_before, quantity_after, quantity_change,
+    reserved_before, reserved_after, reserved_change,
+    notes
+)
+SELECT
+    vi.id, vi.product_variant_id, vi.store_front_id,
+    'opening_balance', 'system',
+    0, vi.quantity, vi.quantity,
+    0, vi.reserved_quantity, vi.reserved_quantity,
+    'Opening balance created by ledger migration'
+FROM variant_inventory vi;
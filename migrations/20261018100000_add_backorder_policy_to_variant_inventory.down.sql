@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_variant_inventory_backordered;
+
+ALTER TABLE variant_inventory
+    DROP CONSTRAINT IF EXISTS chk_variant_inventory_backorder_policy;
+
+ALTER TABLE variant_inventory
+    DROP COLUMN IF EXISTS backorder_policy,
+    DROP COLUMN IF EXISTS backorder_limit,
+    DROP COLUMN IF EXISTS backordered_quantity,
+    DROP COLUMN IF EXISTS available_on;
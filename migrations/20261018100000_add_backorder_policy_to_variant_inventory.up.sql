@@ -0,0 +1,20 @@
+-- Migration: add_backorder_policy_to_variant_inventory
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- BACKORDER / PRE-ORDER POLICY (per variant, per storefront)
+-- ============================================================
+ALTER TABLE variant_inventory
+    ADD COLUMN IF NOT EXISTS backorder_policy     VARCHAR(20) NOT NULL DEFAULT 'deny',
+    ADD COLUMN IF NOT EXISTS backorder_limit      INT,
+    ADD COLUMN IF NOT EXISTS backordered_quantity INT         NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS available_on         TIMESTAMPTZ;
+
+ALTER TABLE variant_inventory
+    DROP CONSTRAINT IF EXISTS chk_variant_inventory_backorder_policy;
+ALTER TABLE variant_inventory
+    ADD CONSTRAINT chk_variant_inventory_backorder_policy
+    CHECK (backorder_policy IN ('deny', 'allow_backorder', 'preorder'));
+
+CREATE INDEX IF NOT EXISTS idx_variant_inventory_backordered
+    ON variant_inventory (store_front_id) WHERE backordered_quantity > 0;
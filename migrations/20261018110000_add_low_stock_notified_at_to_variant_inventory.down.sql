@@ -0,0 +1,2 @@
+ALTER TABLE variant_inventory
+    DROP COLUMN IF EXISTS low_stock_notified_at;
@@ -0,0 +1,10 @@
+-- Migration: add_low_stock_notified_at_to_variant_inventory
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- LOW-STOCK ALERT TRACKING
+-- ============================================================
+-- Set when admins are notified that a row crossed its threshold,
+-- cleared once stock recovers so the next crossing alerts again
+ALTER TABLE variant_inventory
+    ADD COLUMN IF NOT EXISTS low_stock_notified_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS bundle_components;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS product_type;
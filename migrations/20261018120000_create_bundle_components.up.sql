@@ -0,0 +1,24 @@
+-- Migration: create_bundle_components
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PRODUCT TYPE
+-- ============================================================
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS product_type VARCHAR(20) NOT NULL DEFAULT 'simple';
+
+-- ============================================================
+-- BUNDLE COMPONENTS (bundle variant -> component variants)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS bundle_components (
+    id                   BIGSERIAL PRIMARY KEY,
+    bundle_variant_id    BIGINT      NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    component_variant_id BIGINT      NOT NULL REFERENCES product_variants(id) ON DELETE RESTRICT,
+    quantity             INT         NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_bundle_component UNIQUE (bundle_variant_id, component_variant_id),
+    CONSTRAINT chk_bundle_not_self CHECK (bundle_variant_id <> component_variant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundle_components_bundle    ON bundle_components (bundle_variant_id);
+CREATE INDEX IF NOT EXISTS idx_bundle_components_component ON bundle_components (component_variant_id);
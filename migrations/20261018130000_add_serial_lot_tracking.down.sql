@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS stock_lots;
+DROP TABLE IF EXISTS serial_numbers;
+
+ALTER TABLE product_variants
+    DROP COLUMN IF EXISTS tracking_mode;
@@ -0,0 +1,48 @@
+-- Migration: add_serial_lot_tracking
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- VARIANT TRACKING MODE (none, serial, lot)
+-- ============================================================
+ALTER TABLE product_variants
+    ADD COLUMN IF NOT EXISTS tracking_mode VARCHAR(10) NOT NULL DEFAULT 'none';
+
+-- ============================================================
+-- SERIAL NUMBERS (one row per tracked unit)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS serial_numbers (
+    id                 BIGSERIAL PRIMARY KEY,
+    product_variant_id BIGINT       NOT NULL REFERENCES product_variants(id) ON DELETE RESTRICT,
+    store_front_id     BIGINT       NOT NULL REFERENCES store_fronts(id) ON DELETE RESTRICT,
+    serial             VARCHAR(100) NOT NULL,
+    status             VARCHAR(20)  NOT NULL DEFAULT 'in_stock',
+    order_item_id      BIGINT,
+    received_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    sold_at            TIMESTAMPTZ,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_serial_numbers_variant_serial UNIQUE (product_variant_id, serial)
+);
+
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_serial     ON serial_numbers (serial);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_stock      ON serial_numbers (product_variant_id, store_front_id, status);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_order_item ON serial_numbers (order_item_id);
+
+-- ============================================================
+-- STOCK LOTS (batch/lot stock per store)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS stock_lots (
+    id                 BIGSERIAL PRIMARY KEY,
+    product_variant_id BIGINT       NOT NULL REFERENCES product_variants(id) ON DELETE RESTRICT,
+    store_front_id     BIGINT       NOT NULL REFERENCES store_fronts(id) ON DELETE RESTRICT,
+    lot_number         VARCHAR(100) NOT NULL,
+    quantity           INT          NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    allocated_quantity INT          NOT NULL DEFAULT 0 CHECK (allocated_quantity >= 0),
+    expires_at         TIMESTAMPTZ,
+    received_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_stock_lots_variant_store_lot UNIQUE (product_variant_id, store_front_id, lot_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_lots_lot_number ON stock_lots (lot_number);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stock_hold_items;
+DROP TABLE IF EXISTS stock_holds;
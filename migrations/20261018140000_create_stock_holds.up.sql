@@ -0,0 +1,35 @@
+-- Migration: create_stock_holds
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- STOCK HOLDS (reservations made by external channels)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS stock_holds (
+    id             BIGSERIAL PRIMARY KEY,
+    store_front_id BIGINT       NOT NULL REFERENCES store_fronts(id) ON DELETE RESTRICT,
+    reference      VARCHAR(100) NOT NULL,
+    channel        VARCHAR(50)  NOT NULL,
+    status         VARCHAR(20)  NOT NULL DEFAULT 'active',
+    expires_at     TIMESTAMPTZ  NOT NULL,
+    converted_at   TIMESTAMPTZ,
+    released_at    TIMESTAMPTZ,
+    created_by     BIGINT,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_stock_holds_store_reference UNIQUE (store_front_id, reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_holds_active_expiry ON stock_holds (expires_at) WHERE status = 'active';
+
+-- ============================================================
+-- STOCK HOLD ITEMS (variants and quantities held)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS stock_hold_items (
+    id                 BIGSERIAL PRIMARY KEY,
+    stock_hold_id      BIGINT       NOT NULL REFERENCES stock_holds(id) ON DELETE CASCADE,
+    product_variant_id BIGINT       NOT NULL REFERENCES product_variants(id) ON DELETE RESTRICT,
+    sku                VARCHAR(100) NOT NULL,
+    quantity           INT          NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_hold_items_hold ON stock_hold_items (stock_hold_id);
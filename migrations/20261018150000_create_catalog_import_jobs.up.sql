@@ -0,0 +1,28 @@
+-- Migration: create_catalog_import_jobs
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- CATALOG IMPORT JOBS (background product imports and reports)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS catalog_import_jobs (
+    id               BIGSERIAL PRIMARY KEY,
+    file_name        VARCHAR(255) NOT NULL,
+    dry_run          BOOLEAN      NOT NULL DEFAULT TRUE,
+    status           VARCHAR(20)  NOT NULL DEFAULT 'pending',
+    total_rows       INT          NOT NULL DEFAULT 0,
+    processed_rows   INT          NOT NULL DEFAULT 0,
+    created_products INT          NOT NULL DEFAULT 0,
+    updated_products INT          NOT NULL DEFAULT 0,
+    created_variants INT          NOT NULL DEFAULT 0,
+    updated_variants INT          NOT NULL DEFAULT 0,
+    error_rows       INT          NOT NULL DEFAULT 0,
+    report           TEXT,
+    error            TEXT,
+    created_by       BIGINT       NOT NULL,
+    started_at       TIMESTAMPTZ,
+    finished_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_catalog_import_jobs_created_by ON catalog_import_jobs (created_by);
@@ -0,0 +1,5 @@
+-- Migration: link_variant_attribute_values
+-- Created at: 2026-10-18
+
+-- Data backfill only: the links stay valid alongside products.attribute_type and
+-- product_variants.attribute_value, which are still maintained, so nothing is undone.
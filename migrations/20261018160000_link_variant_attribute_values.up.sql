@@ -0,0 +1,62 @@
+-- Migration: link_variant_attribute_values
+-- Created at: 2026-10-18
+
+-- Single attribute V2 products kept their attribute as products.attribute_type (the
+-- lowercased English attribute name) and each variant's value as free text in
+-- product_variants.attribute_value. Variants now link to attribute_values rows, so the
+-- existing data is backfilled into product_attributes, attribute_values,
+-- product_attribute_values and product_variant_attribute_values.
+
+-- ============================================================
+-- PRODUCT ATTRIBUTES (from products.attribute_type)
+-- ============================================================
+INSERT INTO product_attributes (product_id, attribute_id, sort_order)
+SELECT p.id, a.id, 0
+FROM products p
+JOIN attributes a ON LOWER(a.name_en) = p.attribute_type AND a.deleted_at IS NULL
+WHERE p.attribute_type IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM product_attributes pa WHERE pa.product_id = p.id);
+
+-- ============================================================
+-- ATTRIBUTE VALUES (free-text variant values not yet defined)
+-- ============================================================
+INSERT INTO attribute_values (attribute_id, value_ar, value_en, sort_order, is_active)
+SELECT DISTINCT ON (pa.attribute_id, LOWER(pv.attribute_value))
+       pa.attribute_id, pv.attribute_value, pv.attribute_value, 0, TRUE
+FROM product_variants pv
+JOIN product_attributes pa ON pa.product_id = pv.product_id
+WHERE pv.attribute_value IS NOT NULL AND pv.attribute_value <> ''
+  AND (SELECT COUNT(*) FROM product_attributes x WHERE x.product_id = pv.product_id) = 1
+  AND NOT EXISTS (SELECT 1 FROM product_variant_attribute_values l WHERE l.product_variant_id = pv.id)
+  AND NOT EXISTS (
+      SELECT 1 FROM attribute_values av
+      WHERE av.attribute_id = pa.attribute_id
+        AND (LOWER(av.value_en) = LOWER(pv.attribute_value) OR av.value_ar = pv.attribute_value)
+  )
+ORDER BY pa.attribute_id, LOWER(pv.attribute_value), pv.id;
+
+-- ============================================================
+-- VARIANT VALUES (link each unlinked variant to its value)
+-- ============================================================
+INSERT INTO product_variant_attribute_values (product_variant_id, attribute_value_id)
+SELECT pv.id, (
+    SELECT av.id FROM attribute_values av
+    WHERE av.attribute_id = pa.attribute_id
+      AND (LOWER(av.value_en) = LOWER(pv.attribute_value) OR av.value_ar = pv.attribute_value)
+    ORDER BY av.id
+    LIMIT 1
+)
+FROM product_variants pv
+JOIN product_attributes pa ON pa.product_id = pv.product_id
+WHERE pv.attribute_value IS NOT NULL AND pv.attribute_value <> ''
+  AND (SELECT COUNT(*) FROM product_attributes x WHERE x.product_id = pv.product_id) = 1
+  AND NOT EXISTS (SELECT 1 FROM product_variant_attribute_values l WHERE l.product_variant_id = pv.id);
+
+-- ============================================================
+-- ALLOWED PRODUCT VALUES (every value a variant uses)
+-- ============================================================
+INSERT INTO product_attribute_values (product_id, attribute_value_id)
+SELECT DISTINCT pv.product_id, l.attribute_value_id
+FROM product_variant_attribute_values l
+JOIN product_variants pv ON pv.id = l.product_variant_id
+ON CONFLICT (product_id, attribute_value_id) DO NOTHING;
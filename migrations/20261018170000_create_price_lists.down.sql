@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS price_list_items;
+DROP TABLE IF EXISTS price_lists;
+DROP INDEX IF EXISTS idx_customers_customer_group_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS customer_group_id;
+DROP TABLE IF EXISTS customer_groups;
@@ -0,0 +1,48 @@
+-- Migration: create_price_lists
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- CUSTOMER GROUPS (segments for group-specific pricing)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS customer_groups (
+    id          BIGSERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    slug        VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS customer_group_id BIGINT REFERENCES customer_groups(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_customers_customer_group_id ON customers (customer_group_id);
+
+-- ============================================================
+-- PRICE LISTS (per store, currency and optional customer group)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS price_lists (
+    id                BIGSERIAL PRIMARY KEY,
+    name              VARCHAR(255) NOT NULL,
+    store_front_id    BIGINT       NOT NULL REFERENCES store_fronts(id) ON DELETE CASCADE,
+    currency          VARCHAR(10)  NOT NULL,
+    customer_group_id BIGINT       REFERENCES customer_groups(id) ON DELETE CASCADE,
+    is_active         BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- One list per store, currency and group (NULL group = the store's default list)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_lists_scope
+    ON price_lists (store_front_id, currency, COALESCE(customer_group_id, 0));
+
+CREATE TABLE IF NOT EXISTS price_list_items (
+    id                 BIGSERIAL PRIMARY KEY,
+    price_list_id      BIGINT         NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    product_variant_id BIGINT         NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    price              NUMERIC(12,2)  NOT NULL CHECK (price >= 0),
+    compare_at_price   NUMERIC(12,2),
+    created_at         TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_list_items_variant ON price_list_items (price_list_id, product_variant_id);
+CREATE INDEX IF NOT EXISTS idx_price_list_items_product_variant_id ON price_list_items (product_variant_id);
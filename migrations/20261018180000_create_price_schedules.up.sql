@@ -0,0 +1,32 @@
+-- Migration: create_price_schedules
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PRICE SCHEDULES (timed sale prices on variants or price lists)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS price_schedules (
+    id                        BIGSERIAL PRIMARY KEY,
+    name                      VARCHAR(255)  NOT NULL,
+    product_variant_id        BIGINT        NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    price_list_id             BIGINT        REFERENCES price_lists(id) ON DELETE CASCADE,
+    sale_price                NUMERIC(12,2) NOT NULL CHECK (sale_price >= 0),
+    compare_at_price          NUMERIC(12,2),
+    starts_at                 TIMESTAMPTZ   NOT NULL,
+    ends_at                   TIMESTAMPTZ   NOT NULL,
+    status                    VARCHAR(20)   NOT NULL DEFAULT 'scheduled',
+    original_price            NUMERIC(12,2),
+    original_compare_at_price NUMERIC(12,2),
+    original_listed           BOOLEAN       NOT NULL DEFAULT FALSE,
+    activated_at              TIMESTAMPTZ,
+    ended_at                  TIMESTAMPTZ,
+    created_by                BIGINT,
+    created_at                TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at                TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_price_schedules_window CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_schedules_product_variant_id ON price_schedules (product_variant_id);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_price_list_id ON price_schedules (price_list_id);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_status ON price_schedules (status);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_starts_at ON price_schedules (starts_at);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_ends_at ON price_schedules (ends_at);
@@ -0,0 +1,19 @@
+-- Migration: create_product_revisions
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PRODUCT REVISIONS (snapshots of product writes for history and rollback)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_revisions (
+    id               BIGSERIAL PRIMARY KEY,
+    product_id       BIGINT      NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    action           VARCHAR(30) NOT NULL,
+    admin_id         BIGINT,
+    restored_from_id BIGINT,
+    snapshot         TEXT        NOT NULL,
+    diff             TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_revisions_product_id ON product_revisions (product_id);
+CREATE INDEX IF NOT EXISTS idx_product_revisions_created_at ON product_revisions (created_at);
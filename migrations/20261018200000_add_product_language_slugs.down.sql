@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS product_slug_redirects;
+DROP INDEX IF EXISTS idx_products_slug_ar;
+DROP INDEX IF EXISTS idx_products_slug_en;
+ALTER TABLE products DROP COLUMN IF EXISTS slug_ar;
+ALTER TABLE products DROP COLUMN IF EXISTS slug_en;
@@ -0,0 +1,27 @@
+-- Migration: add_product_language_slugs
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PRODUCT SLUGS PER LANGUAGE (slug stays as the English slug)
+-- ============================================================
+ALTER TABLE products ADD COLUMN IF NOT EXISTS slug_en VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS slug_ar VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE products SET slug_en = COALESCE(slug, '') WHERE slug_en = '';
+
+CREATE INDEX IF NOT EXISTS idx_products_slug_en ON products (slug_en);
+CREATE INDEX IF NOT EXISTS idx_products_slug_ar ON products (slug_ar);
+
+-- ============================================================
+-- PRODUCT SLUG REDIRECTS (old slugs kept working after renames)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_slug_redirects (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id BIGINT       NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    language   VARCHAR(2)   NOT NULL CHECK (language IN ('en', 'ar')),
+    slug       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_slug_redirects_product_slug ON product_slug_redirects (product_id, language, slug);
+CREATE INDEX IF NOT EXISTS idx_product_slug_redirects_slug ON product_slug_redirects (slug);
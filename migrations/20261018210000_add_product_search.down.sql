@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS trg_categories_search_refresh ON categories;
+DROP TRIGGER IF EXISTS trg_brands_search_refresh ON brands;
+DROP TRIGGER IF EXISTS trg_product_variants_search_refresh ON product_variants;
+DROP TRIGGER IF EXISTS trg_products_search_refresh ON products;
+DROP FUNCTION IF EXISTS categories_search_refresh();
+DROP FUNCTION IF EXISTS brands_search_refresh();
+DROP FUNCTION IF EXISTS product_variants_search_refresh();
+DROP FUNCTION IF EXISTS products_search_refresh();
+DROP FUNCTION IF EXISTS refresh_product_search_document(BIGINT);
+DROP TABLE IF EXISTS product_search_documents;
+DROP FUNCTION IF EXISTS normalize_search_text(TEXT);
@@ -0,0 +1,115 @@
+-- Migration: add_product_search
+-- Created at: 2026-10-18
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- ============================================================
+-- SEARCH NORMALIZATION (lowercase, one form for alef/hamza/yaa/taa marbuta, no tashkeel or tatweel)
+-- ============================================================
+CREATE OR REPLACE FUNCTION normalize_search_text(input TEXT) RETURNS TEXT AS $$
+    SELECT translate(lower(COALESCE(input, '')), 'أإآٱىةؤئًٌٍَُِّْٰـ', 'اااايهوي')
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- ============================================================
+-- PRODUCT SEARCH DOCUMENTS (names, SKUs, brand, category and descriptions per product)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_search_documents (
+    product_id    BIGINT      PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    name_document TEXT        NOT NULL DEFAULT '', -- Normalized names, for suggestions
+    document      TEXT        NOT NULL DEFAULT '', -- Every searched field, normalized, for typo matching
+    search_vector TSVECTOR    NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_search_documents_vector ON product_search_documents USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_product_search_documents_name_trgm ON product_search_documents USING GIN (name_document gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_product_search_documents_trgm ON product_search_documents USING GIN (document gin_trgm_ops);
+
+-- Names and SKUs rank above brand and category, which rank above descriptions
+CREATE OR REPLACE FUNCTION refresh_product_search_document(pid BIGINT) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO product_search_documents (product_id, name_document, document, search_vector, updated_at)
+    SELECT p.id,
+           normalize_search_text(concat_ws(' ', p.name_en, p.name_ar)),
+           normalize_search_text(concat_ws(' ', p.name_en, p.name_ar, skus.list, b.name_en, b.name_ar, c.name_en, c.name_ar, p.description_en, p.description_ar)),
+           setweight(to_tsvector('english', normalize_search_text(p.name_en)), 'A') ||
+           setweight(to_tsvector('simple', normalize_search_text(concat_ws(' ', p.name_ar, skus.list))), 'A') ||
+           setweight(to_tsvector('english', normalize_search_text(concat_ws(' ', b.name_en, c.name_en))), 'B') ||
+           setweight(to_tsvector('simple', normalize_search_text(concat_ws(' ', b.name_ar, c.name_ar))), 'B') ||
+           setweight(to_tsvector('english', normalize_search_text(p.description_en)), 'C') ||
+           setweight(to_tsvector('simple', normalize_search_text(p.description_ar)), 'C'),
+           NOW()
+    FROM products p
+    LEFT JOIN brands b ON b.id = p.brand_id
+    LEFT JOIN categories c ON c.id = p.category_id
+    LEFT JOIN LATERAL (
+        SELECT string_agg(pv.sku, ' ') AS list
+        FROM product_variants pv
+        WHERE pv.product_id = p.id AND pv.deleted_at IS NULL
+    ) skus ON true
+    WHERE p.id = pid
+    ON CONFLICT (product_id) DO UPDATE SET
+        name_document = EXCLUDED.name_document,
+        document      = EXCLUDED.document,
+        search_vector = EXCLUDED.search_vector,
+        updated_at    = EXCLUDED.updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================
+-- TRIGGERS (documents follow product, variant, brand and category writes)
+-- ============================================================
+CREATE OR REPLACE FUNCTION products_search_refresh() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_product_search_document(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_search_refresh ON products;
+CREATE TRIGGER trg_products_search_refresh
+    AFTER INSERT OR UPDATE OF name_en, name_ar, description_en, description_ar, brand_id, category_id ON products
+    FOR EACH ROW EXECUTE FUNCTION products_search_refresh();
+
+CREATE OR REPLACE FUNCTION product_variants_search_refresh() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_product_search_document(OLD.product_id);
+    ELSE
+        PERFORM refresh_product_search_document(NEW.product_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_product_variants_search_refresh ON product_variants;
+CREATE TRIGGER trg_product_variants_search_refresh
+    AFTER INSERT OR DELETE OR UPDATE OF sku, deleted_at ON product_variants
+    FOR EACH ROW EXECUTE FUNCTION product_variants_search_refresh();
+
+CREATE OR REPLACE FUNCTION brands_search_refresh() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_product_search_document(p.id) FROM products p WHERE p.brand_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_brands_search_refresh ON brands;
+CREATE TRIGGER trg_brands_search_refresh
+    AFTER UPDATE OF name_en, name_ar ON brands
+    FOR EACH ROW EXECUTE FUNCTION brands_search_refresh();
+
+CREATE OR REPLACE FUNCTION categories_search_refresh() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_product_search_document(p.id) FROM products p WHERE p.category_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_categories_search_refresh ON categories;
+CREATE TRIGGER trg_categories_search_refresh
+    AFTER UPDATE OF name_en, name_ar ON categories
+    FOR EACH ROW EXECUTE FUNCTION categories_search_refresh();
+
+-- Backfill existing products
+SELECT refresh_product_search_document(id) FROM products;
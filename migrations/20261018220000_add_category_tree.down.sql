@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_categories_slug_ar;
+DROP INDEX IF EXISTS idx_categories_slug_en;
+DROP INDEX IF EXISTS idx_categories_section_position;
+DROP INDEX IF EXISTS idx_categories_parent;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS meta_description_ar;
+ALTER TABLE categories DROP COLUMN IF EXISTS meta_description_en;
+ALTER TABLE categories DROP COLUMN IF EXISTS meta_title_ar;
+ALTER TABLE categories DROP COLUMN IF EXISTS meta_title_en;
+ALTER TABLE categories DROP COLUMN IF EXISTS slug_ar;
+ALTER TABLE categories DROP COLUMN IF EXISTS slug_en;
+ALTER TABLE categories DROP COLUMN IF EXISTS position;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,35 @@
+-- Migration: add_category_tree
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- CATEGORY TREE (nested categories ordered within their parent)
+-- ============================================================
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS position  INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories (parent_id);
+CREATE INDEX IF NOT EXISTS idx_categories_section_position ON categories (section_id, position);
+
+-- ============================================================
+-- CATEGORY SLUGS AND SEO
+-- ============================================================
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS slug_en             VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS slug_ar             VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS meta_title_en       VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS meta_title_ar       VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS meta_description_en TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS meta_description_ar TEXT NOT NULL DEFAULT '';
+
+-- Existing categories get an English slug from their name, suffixed by id when taken
+UPDATE categories
+SET slug_en = TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(name_en), '[^a-z0-9]+', '-', 'g'))
+WHERE slug_en = '';
+
+UPDATE categories c
+SET slug_en = CASE WHEN c.slug_en = '' THEN 'category-' || c.id ELSE c.slug_en || '-' || c.id END
+WHERE c.slug_en = '' OR EXISTS (
+    SELECT 1 FROM categories o WHERE o.slug_en = c.slug_en AND o.id < c.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug_en ON categories (slug_en) WHERE deleted_at IS NULL AND slug_en <> '';
+CREATE INDEX IF NOT EXISTS idx_categories_slug_ar ON categories (slug_ar);
@@ -0,0 +1,47 @@
+-- Migration: add_product_collections
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PRODUCT TAGS (merchandising labels, stored lowercase)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_tags (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id BIGINT       NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    tag        VARCHAR(100) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_tags_product_tag ON product_tags (product_id, tag);
+CREATE INDEX IF NOT EXISTS idx_product_tags_tag ON product_tags (tag);
+
+-- ============================================================
+-- COLLECTIONS (manual product lists or rule-based groupings per store)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS collections (
+    id             BIGSERIAL PRIMARY KEY,
+    store_front_id BIGINT       NOT NULL REFERENCES store_fronts(id) ON DELETE CASCADE,
+    name_en        VARCHAR(255) NOT NULL,
+    name_ar        VARCHAR(255) NOT NULL,
+    slug           VARCHAR(255) NOT NULL,
+    description_en TEXT,
+    description_ar TEXT,
+    type           VARCHAR(20)  NOT NULL DEFAULT 'manual' CHECK (type IN ('manual', 'automatic')),
+    rules          TEXT,
+    is_active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    deleted_at     TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collections_store_slug ON collections (store_front_id, slug) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_collections_deleted_at ON collections (deleted_at);
+
+CREATE TABLE IF NOT EXISTS collection_products (
+    id            BIGSERIAL PRIMARY KEY,
+    collection_id BIGINT      NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    product_id    BIGINT      NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    position      INT         NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collection_products_collection_product ON collection_products (collection_id, product_id);
+CREATE INDEX IF NOT EXISTS idx_collection_products_product_id ON collection_products (product_id);
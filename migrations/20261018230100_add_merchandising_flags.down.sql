@@ -0,0 +1,3 @@
+ALTER TABLE product_storefront DROP COLUMN IF EXISTS is_best_seller;
+ALTER TABLE products DROP COLUMN IF EXISTS is_best_seller_pinned;
+ALTER TABLE products DROP COLUMN IF EXISTS is_new_pinned;
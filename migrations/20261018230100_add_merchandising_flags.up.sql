@@ -0,0 +1,18 @@
+-- Migration: add_merchandising_flags
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PINNED FLAGS (admin set values the merchandising job leaves alone)
+-- ============================================================
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_new_pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_best_seller_pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ============================================================
+-- PER STORE BEST SELLERS (computed from completed orders)
+-- ============================================================
+ALTER TABLE product_storefront ADD COLUMN IF NOT EXISTS is_best_seller BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE product_storefront ps
+SET is_best_seller = p.is_best_seller
+FROM products p
+WHERE p.id = ps.product_id AND p.is_best_seller;
@@ -0,0 +1,19 @@
+-- Migration: add_product_relations
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PRODUCT RELATIONS (related, cross-sell, upsell and accessory links)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_relations (
+    id                 BIGSERIAL PRIMARY KEY,
+    product_id         BIGINT      NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    related_product_id BIGINT      NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    type               VARCHAR(20) NOT NULL,
+    position           INT         NOT NULL DEFAULT 0,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_product_relations_type CHECK (type IN ('related', 'cross_sell', 'upsell', 'accessory')),
+    CONSTRAINT chk_product_relations_self CHECK (product_id <> related_product_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_relations_unique ON product_relations (product_id, related_product_id, type);
+CREATE INDEX IF NOT EXISTS idx_product_relations_related_product_id ON product_relations (related_product_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS product_review_images;
+DROP TABLE IF EXISTS product_reviews;
+DROP INDEX IF EXISTS idx_files_uploaded_by_customer_id;
+ALTER TABLE files DROP COLUMN IF EXISTS uploaded_by_customer_id;
@@ -0,0 +1,45 @@
+-- Migration: add_product_reviews
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- CUSTOMER UPLOADS (files uploaded from the storefront have no admin)
+-- ============================================================
+ALTER TABLE files ALTER COLUMN uploaded_by DROP NOT NULL;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS uploaded_by_customer_id BIGINT REFERENCES customers(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_files_uploaded_by_customer_id ON files (uploaded_by_customer_id);
+
+-- ============================================================
+-- PRODUCT REVIEWS (verified purchase ratings, moderated before shown)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_reviews (
+    id               BIGSERIAL PRIMARY KEY,
+    product_id       BIGINT       NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    store_front_id   BIGINT       NOT NULL REFERENCES store_fronts(id) ON DELETE CASCADE,
+    customer_id      BIGINT       NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    order_id         BIGINT       NOT NULL REFERENCES orders(id),
+    rating           INT          NOT NULL,
+    title            VARCHAR(255),
+    body             TEXT,
+    status           VARCHAR(20)  NOT NULL DEFAULT 'pending',
+    rejection_reason TEXT,
+    moderated_by     BIGINT,
+    moderated_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_product_reviews_rating CHECK (rating BETWEEN 1 AND 5),
+    CONSTRAINT chk_product_reviews_status CHECK (status IN ('pending', 'approved', 'rejected'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_reviews_customer ON product_reviews (product_id, customer_id);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_status ON product_reviews (product_id, status);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_store_front_id ON product_reviews (store_front_id);
+
+CREATE TABLE IF NOT EXISTS product_review_images (
+    id        BIGSERIAL PRIMARY KEY,
+    review_id BIGINT NOT NULL REFERENCES product_reviews(id) ON DELETE CASCADE,
+    file_id   BIGINT NOT NULL REFERENCES files(id),
+    position  INT    NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_review_images_review_id ON product_review_images (review_id);
@@ -0,0 +1,23 @@
+-- Migration: add_file_derivatives
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- FILE DERIVATIVES (thumb, medium and large copies of images, also as WebP)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS file_derivatives (
+    id         BIGSERIAL PRIMARY KEY,
+    file_id    BIGINT       NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    size       VARCHAR(20)  NOT NULL,
+    format     VARCHAR(10)  NOT NULL,
+    file_path  VARCHAR(500) NOT NULL,
+    mime_type  VARCHAR(100) NOT NULL,
+    width      INT          NOT NULL,
+    height     INT          NOT NULL,
+    file_size  BIGINT       NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_file_derivatives_size CHECK (size IN ('thumb', 'medium', 'large')),
+    CONSTRAINT chk_file_derivatives_format CHECK (format IN ('jpeg', 'png', 'webp'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_file_derivatives_unique ON file_derivatives (file_id, size, format);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS stock_hold_item_components;
@@ -0,0 +1,24 @@
+-- Migration: add_stock_hold_item_components
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- STOCK HOLD ITEM COMPONENTS (bundle components reserved by a hold item)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS stock_hold_item_components (
+    id                  BIGSERIAL PRIMARY KEY,
+    stock_hold_item_id  BIGINT       NOT NULL REFERENCES stock_hold_items(id) ON DELETE CASCADE,
+    product_variant_id  BIGINT       NOT NULL REFERENCES product_variants(id) ON DELETE RESTRICT,
+    sku                 VARCHAR(100) NOT NULL,
+    quantity_per_bundle INT          NOT NULL CHECK (quantity_per_bundle > 0),
+    quantity            INT          NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_hold_item_components_item ON stock_hold_item_components (stock_hold_item_id);
+
+-- Snapshot the current components of bundles held by active holds
+INSERT INTO stock_hold_item_components (stock_hold_item_id, product_variant_id, sku, quantity_per_bundle, quantity)
+SELECT shi.id, bc.component_variant_id, pv.sku, bc.quantity, bc.quantity * shi.quantity
+FROM stock_hold_items shi
+JOIN stock_holds sh ON sh.id = shi.stock_hold_id AND sh.status = 'active'
+JOIN bundle_components bc ON bc.bundle_variant_id = shi.product_variant_id
+JOIN product_variants pv ON pv.id = bc.component_variant_id;
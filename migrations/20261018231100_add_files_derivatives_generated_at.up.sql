@@ -0,0 +1,13 @@
+-- Migration: add_files_derivatives_generated_at
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- FILES: when an image's derivatives were made (NULL = not processed yet)
+-- ============================================================
+ALTER TABLE files
+    ADD COLUMN IF NOT EXISTS derivatives_generated_at TIMESTAMPTZ;
+
+-- Images that already have derivatives were processed
+UPDATE files SET derivatives_generated_at = NOW()
+WHERE derivatives_generated_at IS NULL
+  AND EXISTS (SELECT 1 FROM file_derivatives fd WHERE fd.file_id = files.id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_product_storefront_in_stock;
+
+ALTER TABLE product_storefront
+    DROP COLUMN IF EXISTS in_stock;
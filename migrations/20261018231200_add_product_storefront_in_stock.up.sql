@@ -0,0 +1,30 @@
+-- Migration: add_product_storefront_in_stock
+-- Created at: 2026-10-18
+
+-- ============================================================
+-- PER STORE STOCK FLAG (kept current by inventory writes, read by storefront listings)
+-- ============================================================
+ALTER TABLE product_storefront ADD COLUMN IF NOT EXISTS in_stock BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_product_storefront_in_stock
+    ON product_storefront (store_front_id, in_stock);
+
+-- A variant with stock or open backorders, or a bundle whose components make up at least one
+UPDATE product_storefront
+SET in_stock = EXISTS (
+        SELECT 1 FROM product_variants pv
+        JOIN products p ON p.id = pv.product_id AND p.product_type <> 'bundle'
+        JOIN variant_inventory vi ON vi.product_variant_id = pv.id AND vi.store_front_id = product_storefront.store_front_id
+        WHERE pv.product_id = product_storefront.product_id AND pv.is_active = true AND pv.deleted_at IS NULL
+        AND (vi.quantity - vi.reserved_quantity > 0 OR (vi.backorder_policy IN ('allow_backorder', 'preorder') AND (vi.backorder_limit IS NULL OR vi.backorder_limit > vi.backordered_quantity)))
+    ) OR EXISTS (
+        SELECT 1 FROM product_variants pv
+        JOIN products p ON p.id = pv.product_id AND p.product_type = 'bundle'
+        WHERE pv.product_id = product_storefront.product_id AND pv.is_active = true AND pv.deleted_at IS NULL
+        AND EXISTS (SELECT 1 FROM bundle_components bc WHERE bc.bundle_variant_id = pv.id AND bc.quantity > 0)
+        AND NOT EXISTS (
+            SELECT 1 FROM bundle_components bc
+            LEFT JOIN variant_inventory vi ON vi.product_variant_id = bc.component_variant_id AND vi.store_front_id = product_storefront.store_front_id
+            WHERE bc.bundle_variant_id = pv.id AND bc.quantity > 0 AND COALESCE(vi.quantity - vi.reserved_quantity, 0) < bc.quantity
+        )
+    );